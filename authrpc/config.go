@@ -0,0 +1,100 @@
+package authrpc
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Config is a config of the authenticated RPC endpoint serving the privileged namespaces
+type Config struct {
+	// Host is the listening interface of the endpoint, empty host disables the endpoint
+	Host string
+	// Port is the listening port of the endpoint
+	Port int
+	// VirtualHosts are the hostnames from which the requests are accepted
+	VirtualHosts []string
+	// JWTSecret is a path to the file with the hex-encoded 32-byte JWT secret, relative to the datadir.
+	// A new secret is generated if the file doesn't exist.
+	JWTSecret string
+	// Modules are the namespaces served by the endpoint
+	Modules []string
+}
+
+// DefaultModules are the namespaces of the privileged APIs.
+// The public methods of the mixed namespaces, e.g. debug_traceTransaction, are still served by the public endpoints.
+func DefaultModules() []string {
+	return []string{"admin", "debug", "emitter", "personal"}
+}
+
+// DefaultConfig returns the default config, the endpoint is disabled
+func DefaultConfig() Config {
+	return Config{
+		Host:         "",
+		Port:         18551,
+		VirtualHosts: []string{"localhost"},
+		JWTSecret:    "jwtsecret",
+		Modules:      DefaultModules(),
+	}
+}
+
+// Enabled tells whether the endpoint is opened
+func (c Config) Enabled() bool {
+	return c.Host != ""
+}
+
+// Validate checks the authenticated RPC endpoint config
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return errors.New("authenticated RPC: invalid port")
+	}
+	if c.JWTSecret == "" {
+		return errors.New("authenticated RPC: JWT secret file isn't set")
+	}
+	if len(c.Modules) == 0 {
+		return errors.New("authenticated RPC: no modules")
+	}
+	return nil
+}
+
+// PublicModules returns the modules excluding the ones served by the authenticated endpoint,
+// so that the privileged namespaces aren't exposed by the unauthenticated endpoints.
+func (c Config) PublicModules(modules []string) (public []string, removed []string) {
+	if !c.Enabled() {
+		return modules, nil
+	}
+	private := make(map[string]bool, len(c.Modules))
+	for _, module := range c.Modules {
+		private[module] = true
+	}
+	public = make([]string, 0, len(modules))
+	for _, module := range modules {
+		if private[module] {
+			removed = append(removed, module)
+			continue
+		}
+		public = append(public, module)
+	}
+	return public, removed
+}
+
+// SharedModules returns the removed namespaces which have public APIs, e.g. debug.
+// The unauthenticated endpoints keep serving the methods of their public APIs only.
+func SharedModules(removed []string, apis []rpc.API) []string {
+	public := make(map[string]bool)
+	for _, api := range apis {
+		if api.Public {
+			public[api.Namespace] = true
+		}
+	}
+	var shared []string
+	for _, module := range removed {
+		if public[module] {
+			shared = append(shared, module)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,163 @@
+package authrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxRequestContentLength is the max size of the HTTP-RPC request, the same as in the RPC server
+	maxRequestContentLength = 1024 * 1024 * 5
+
+	// errcodeMethodNotFound is the JSON-RPC error code of the unavailable methods
+	errcodeMethodNotFound = -32601
+)
+
+var (
+	authorizedMeter   = metrics.GetOrRegisterMeter("rpc/auth/authorized", nil)
+	unauthorizedMeter = metrics.GetOrRegisterMeter("rpc/auth/unauthorized", nil)
+)
+
+type jsonrpcCall struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *jsonrpcError   `json:"error"`
+}
+
+// Handler is an HTTP-RPC middleware which serves only the requests with a valid JWT token
+// and only the calls of the configured modules.
+type Handler struct {
+	secret  []byte
+	modules map[string]bool
+	next    http.Handler
+
+	now func() time.Time
+}
+
+// NewHandler creates the authenticating middleware over the RPC server
+func NewHandler(secret []byte, modules []string, next http.Handler) *Handler {
+	h := &Handler{
+		secret:  secret,
+		modules: make(map[string]bool, len(modules)),
+		next:    next,
+		now:     time.Now,
+	}
+	for _, module := range modules {
+		h.modules[module] = true
+	}
+	return h
+}
+
+// available tells whether the method namespace is served
+func (h *Handler) available(method string) bool {
+	namespace := method
+	if i := strings.IndexByte(method, '_'); i >= 0 {
+		namespace = method[:i]
+	}
+	// rpc_modules is served by every RPC server
+	return namespace == "rpc" || h.modules[namespace]
+}
+
+// authorize checks the bearer token of the request
+func (h *Handler) authorize(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return errMissingToken
+	}
+	return verifyToken(h.secret, strings.TrimSpace(auth[len("Bearer "):]), h.now())
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authorize(r); err != nil {
+		unauthorizedMeter.Mark(1)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	authorizedMeter.Mark(1)
+	if r.Method != http.MethodPost {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestContentLength+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestContentLength {
+		http.Error(w, fmt.Sprintf("content length too large (>%d)", maxRequestContentLength), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if calls, batch, ok := parseCalls(body); ok {
+		for _, call := range calls {
+			if call.Method != "" && !h.available(call.Method) {
+				// the requests with the unavailable methods are rejected as a whole
+				reject(w, calls, batch, fmt.Sprintf("the method %s does not exist/is not available", call.Method))
+				return
+			}
+		}
+	}
+	// invalid requests are left for the RPC server to answer
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	h.next.ServeHTTP(w, r)
+}
+
+// parseCalls decodes the single call or the batch of calls, ok is false if the body isn't valid JSON
+func parseCalls(body []byte) (calls []jsonrpcCall, batch bool, ok bool) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, true, false
+		}
+		calls = make([]jsonrpcCall, len(raws))
+		for i, raw := range raws {
+			_ = json.Unmarshal(raw, &calls[i])
+		}
+		return calls, true, true
+	}
+	var call jsonrpcCall
+	if err := json.Unmarshal(trimmed, &call); err != nil {
+		return nil, false, false
+	}
+	return []jsonrpcCall{call}, false, true
+}
+
+// reject answers all the calls with the method not found error
+func reject(w http.ResponseWriter, calls []jsonrpcCall, batch bool, message string) {
+	responses := make([]*jsonrpcResponse, len(calls))
+	for i, call := range calls {
+		id := call.ID
+		if len(id) == 0 {
+			id = json.RawMessage("null")
+		}
+		responses[i] = &jsonrpcResponse{
+			Version: "2.0",
+			ID:      id,
+			Error:   &jsonrpcError{Code: errcodeMethodNotFound, Message: message},
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		_ = json.NewEncoder(w).Encode(responses)
+	} else {
+		_ = json.NewEncoder(w).Encode(responses[0])
+	}
+}
@@ -0,0 +1,146 @@
+package authrpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type testService struct{}
+
+func (testService) BlockNumber() uint64 { return 1 }
+
+func newToken(secret []byte, header string, issuedAt int64) string {
+	h := base64.RawURLEncoding.EncodeToString([]byte(header))
+	c := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, issuedAt)))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(h + "." + c))
+	return h + "." + c + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyToken(t *testing.T) {
+	require := require.New(t)
+
+	secret := make([]byte, secretLen)
+	now := time.Unix(1000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+
+	require.NoError(verifyToken(secret, newToken(secret, hs256, 1000), now))
+	require.NoError(verifyToken(secret, newToken(secret, hs256, 1060), now))
+	require.NoError(verifyToken(secret, newToken(secret, hs256, 940), now))
+	require.Equal(errStaleToken, verifyToken(secret, newToken(secret, hs256, 1061), now))
+	require.Equal(errStaleToken, verifyToken(secret, newToken(secret, hs256, 939), now))
+
+	other := make([]byte, secretLen)
+	other[0] = 1
+	require.Equal(errInvalidSignature, verifyToken(secret, newToken(other, hs256, 1000), now))
+	require.Equal(errUnsupportedAlg, verifyToken(secret, newToken(secret, `{"alg":"none"}`, 1000), now))
+	require.Equal(errMalformedToken, verifyToken(secret, "abc", now))
+}
+
+func TestObtainSecret(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "node", "jwtsecret")
+	secret, err := ObtainSecret(path)
+	require.NoError(err)
+	require.Len(secret, secretLen)
+
+	again, err := ObtainSecret(path)
+	require.NoError(err)
+	require.Equal(secret, again)
+
+	require.NoError(os.WriteFile(path, []byte("0x1234"), 0600))
+	_, err = ObtainSecret(path)
+	require.Error(err)
+}
+
+func TestHandler(t *testing.T) {
+	require := require.New(t)
+
+	srv := rpc.NewServer()
+	require.NoError(srv.RegisterName("eth", testService{}))
+	require.NoError(srv.RegisterName("admin", testService{}))
+	t.Cleanup(srv.Stop)
+
+	secret := make([]byte, secretLen)
+	h := NewHandler(secret, []string{"admin"}, srv)
+	h.now = func() time.Time {
+		return time.Unix(1000, 0)
+	}
+	call := func(body string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	token := newToken(secret, `{"alg":"HS256","typ":"JWT"}`, 1000)
+
+	w := call(`{"jsonrpc":"2.0","id":1,"method":"admin_blockNumber","params":[]}`, "")
+	require.Equal(http.StatusUnauthorized, w.Code)
+	w = call(`{"jsonrpc":"2.0","id":1,"method":"admin_blockNumber","params":[]}`, newToken(secret, `{"alg":"HS256"}`, 0))
+	require.Equal(http.StatusUnauthorized, w.Code)
+
+	w = call(`{"jsonrpc":"2.0","id":1,"method":"admin_blockNumber","params":[]}`, token)
+	require.Equal(http.StatusOK, w.Code)
+	require.Contains(w.Body.String(), `"result":1`)
+
+	// the public namespaces aren't served
+	w = call(`{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber","params":[]}`, token)
+	require.Equal(http.StatusOK, w.Code)
+	var resp jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(json.RawMessage("2"), resp.ID)
+	require.Equal(errcodeMethodNotFound, resp.Error.Code)
+
+	w = call(`[{"jsonrpc":"2.0","id":3,"method":"admin_blockNumber","params":[]},{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber","params":[]}]`, token)
+	var batch []jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(batch, 2)
+	require.Equal(errcodeMethodNotFound, batch[0].Error.Code)
+	require.Equal(errcodeMethodNotFound, batch[1].Error.Code)
+}
+
+func TestPublicModules(t *testing.T) {
+	require := require.New(t)
+
+	cfg := DefaultConfig()
+	modules := []string{"eth", "debug", "personal", "web3"}
+	public, removed := cfg.PublicModules(modules)
+	require.Equal(modules, public)
+	require.Empty(removed)
+
+	cfg.Host = "localhost"
+	public, removed = cfg.PublicModules(modules)
+	require.Equal([]string{"eth", "web3"}, public)
+	require.Equal([]string{"debug", "personal"}, removed)
+}
+
+func TestSharedModules(t *testing.T) {
+	require := require.New(t)
+
+	apis := []rpc.API{
+		{Namespace: "eth", Public: true},
+		{Namespace: "debug", Public: true},
+		{Namespace: "debug", Public: false},
+		{Namespace: "personal", Public: false},
+	}
+	require.Equal([]string{"debug"}, SharedModules([]string{"debug", "personal"}, apis))
+	require.Empty(SharedModules(nil, apis))
+}
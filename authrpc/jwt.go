@@ -0,0 +1,120 @@
+package authrpc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// secretLen is a length of the JWT secret
+	secretLen = 32
+	// maxIssuedAtDrift is the max difference between the token issuance time and the local time
+	maxIssuedAtDrift = 60 * time.Second
+)
+
+var (
+	errMissingToken     = errors.New("missing token")
+	errMalformedToken   = errors.New("malformed token")
+	errUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	errInvalidSignature = errors.New("invalid token signature")
+	errMissingIssuedAt  = errors.New("missing issued-at claim")
+	errStaleToken       = errors.New("stale token")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	IssuedAt *float64 `json:"iat"`
+}
+
+// verifyToken checks the HS256 signature of the token and its issued-at claim
+func verifyToken(secret []byte, token string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedToken
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return errUnsupportedAlg
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errMalformedToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errInvalidSignature
+	}
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.IssuedAt == nil {
+		return errMissingIssuedAt
+	}
+	issuedAt := time.Unix(int64(math.Floor(*claims.IssuedAt)), 0)
+	if drift := now.Sub(issuedAt); drift > maxIssuedAtDrift || drift < -maxIssuedAtDrift {
+		return errStaleToken
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errMalformedToken
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errMalformedToken
+	}
+	return nil
+}
+
+// ObtainSecret reads the hex-encoded JWT secret from the file, or generates a new secret if the file doesn't exist
+func ObtainSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret in %s: %v", path, err)
+		}
+		if len(secret) != secretLen {
+			return nil, fmt.Errorf("invalid JWT secret in %s: expected %d bytes, got %d", path, secretLen, len(secret))
+		}
+		return secret, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	secret := make([]byte, secretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
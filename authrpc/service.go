@@ -0,0 +1,85 @@
+package authrpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Service is the authenticated HTTP-RPC endpoint, served on a separate listener.
+// The endpoint serves the privileged namespaces of the node APIs to the clients holding the JWT secret.
+type Service struct {
+	cfg      Config
+	handler  http.Handler
+	timeouts rpc.HTTPTimeouts
+
+	server   *http.Server
+	listener net.Listener
+}
+
+// Register creates the authenticated endpoint and registers it as a lifecycle of the node
+func Register(stack *node.Node, cfg Config, timeouts rpc.HTTPTimeouts) (*Service, error) {
+	path := cfg.JWTSecret
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(stack.DataDir(), path)
+	}
+	secret, err := ObtainSecret(path)
+	if err != nil {
+		return nil, err
+	}
+	srv, err := stack.RPCHandler()
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{
+		cfg:      cfg,
+		handler:  node.NewHTTPHandlerStack(NewHandler(secret, cfg.Modules, srv), nil, cfg.VirtualHosts),
+		timeouts: timeouts,
+	}
+	stack.RegisterLifecycle(s)
+	return s, nil
+}
+
+// Start opens the listener of the endpoint
+func (s *Service) Start() error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to open the authenticated RPC endpoint: %w", err)
+	}
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:      s.handler,
+		ReadTimeout:  s.timeouts.ReadTimeout,
+		WriteTimeout: s.timeouts.WriteTimeout,
+		IdleTimeout:  s.timeouts.IdleTimeout,
+	}
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	log.Info("Authenticated HTTP-RPC endpoint opened", "url", s.Endpoint(), "modules", s.cfg.Modules)
+	return nil
+}
+
+// Stop closes the listener of the endpoint
+func (s *Service) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	log.Info("Authenticated HTTP-RPC endpoint closed", "url", s.Endpoint())
+	return err
+}
+
+// Endpoint returns the URL of the endpoint, empty string if the endpoint isn't started
+func (s *Service) Endpoint() string {
+	if s.listener == nil {
+		return ""
+	}
+	return "http://" + s.listener.Addr().String()
+}
@@ -88,6 +88,10 @@ func initFlags() {
 		flags.TxPoolAccountQueueFlag,
 		flags.TxPoolGlobalQueueFlag,
 		flags.TxPoolLifetimeFlag,
+		flags.TxPoolEvictionFlag,
+		flags.TxPoolSenderQuotaFlag,
+		flags.TxPoolSpamScoreFlag,
+		flags.TxPoolSpamHalfLifeFlag,
 	}
 	operaFlags = []cli.Flag{
 		flags.IdentityFlag,
@@ -103,7 +107,23 @@ func initFlags() {
 		flags.ValidatorIDFlag,
 		flags.ValidatorPubkeyFlag,
 		flags.ValidatorPasswordFlag,
+		flags.ValidatorSignerFlag,
+		flags.ValidatorSignerCertFlag,
+		flags.ValidatorSignerKeyFlag,
+		flags.ValidatorSignerCAFlag,
+		flags.ValidatorSignerTimeoutFlag,
+		flags.ValidatorProtectionFlag,
+		flags.ValidatorLeaseFlag,
+		flags.ValidatorLeaseTTLFlag,
+		flags.ValidatorLeaseHolderFlag,
 		flags.ModeFlag,
+		flags.HistoryBlocksFlag,
+		flags.HistoryEpochsFlag,
+		flags.SnapshotServeFlag,
+		flags.SnapshotSyncFlag,
+		flags.SnapshotIntervalFlag,
+		flags.PrivateTxPeersFlag,
+		flags.PrivateTxBlocksFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -114,6 +134,9 @@ func initFlags() {
 		flags.HTTPVirtualHostsFlag,
 		flags.HTTPApiFlag,
 		flags.HTTPPathPrefixFlag,
+		flags.GraphQLEnabledFlag,
+		flags.GraphQLCORSDomainFlag,
+		flags.GraphQLVirtualHostsFlag,
 		flags.WSEnabledFlag,
 		flags.WSListenAddrFlag,
 		flags.WSPortFlag,
@@ -126,6 +149,18 @@ func initFlags() {
 		flags.RPCGlobalEVMTimeoutFlag,
 		flags.RPCGlobalTxFeeCapFlag,
 		flags.RPCGlobalTimeoutFlag,
+		flags.RPCLogsPageSizeFlag,
+		flags.RPCRateLimitFlag,
+		flags.RPCRateLimitBurstFlag,
+		flags.RPCRateLimitProxyFlag,
+		flags.AuthRPCEnabledFlag,
+		flags.AuthRPCListenAddrFlag,
+		flags.AuthRPCPortFlag,
+		flags.AuthRPCVirtualHostsFlag,
+		flags.AuthRPCJWTSecretFlag,
+		flags.AuthRPCApiFlag,
+		flags.TraceIndexFlag,
+		flags.AddressIndexFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/mrmikeo/Xpense/lease"
+	"github.com/mrmikeo/Xpense/version"
+)
+
+var (
+	ListenAddrFlag = cli.StringFlag{
+		Name:  "addr",
+		Usage: "Listening address of the lease service",
+		Value: "127.0.0.1:18570",
+	}
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "xpenselease"
+	app.Usage = "the stand-in lease service of the active/passive validator nodes"
+	app.Version = version.AsString()
+	app.Flags = []cli.Flag{
+		ListenAddrFlag,
+	}
+	app.Action = run
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+
+	server := &http.Server{
+		Addr:    ctx.String(ListenAddrFlag.Name),
+		Handler: lease.NewServer(),
+	}
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		log.Info("Shutting down the lease service")
+		_ = server.Close()
+	}()
+	log.Info("Lease service started", "addr", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
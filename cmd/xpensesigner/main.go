@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
+	"github.com/mrmikeo/Xpense/valkeystore/remote"
+	"github.com/mrmikeo/Xpense/version"
+)
+
+var (
+	ListenAddrFlag = cli.StringFlag{
+		Name:  "addr",
+		Usage: "Listening address of the signer",
+		Value: "127.0.0.1:18560",
+	}
+	KeystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Directory of the validator keystore",
+	}
+	PubkeyFlag = cli.StringSliceFlag{
+		Name:  "pubkey",
+		Usage: "Public key of a validator key to unlock, may be repeated",
+	}
+	PasswordFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "File with the passwords of the validator keys, one per line in the order of --pubkey",
+	}
+	TLSCertFlag = cli.StringFlag{
+		Name:  "tls.cert",
+		Usage: "TLS certificate of the signer",
+	}
+	TLSKeyFlag = cli.StringFlag{
+		Name:  "tls.key",
+		Usage: "TLS private key of the signer",
+	}
+	TLSClientCAFlag = cli.StringFlag{
+		Name:  "tls.clientca",
+		Usage: "CA certificate which the client certificates are verified against",
+	}
+	ProtectionFlag = cli.StringFlag{
+		Name:  "protection",
+		Usage: "Directory of the slashing protection database",
+		Value: "slashing-protection",
+	}
+	AuditFlag = cli.StringFlag{
+		Name:  "audit",
+		Usage: "File which the audit log of the sign requests is appended to, '-' for stdout",
+		Value: "-",
+	}
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "xpensesigner"
+	app.Usage = "the stand-in remote signer of the validator keys"
+	app.Version = version.AsString()
+	app.Flags = []cli.Flag{
+		ListenAddrFlag,
+		KeystoreFlag,
+		PubkeyFlag,
+		PasswordFlag,
+		TLSCertFlag,
+		TLSKeyFlag,
+		TLSClientCAFlag,
+		ProtectionFlag,
+		AuditFlag,
+	}
+	app.Action = run
+	app.Commands = []cli.Command{
+		{
+			Name:      "export",
+			Usage:     "Export the slashing protection history into an interchange file",
+			ArgsUsage: "<filename>",
+			Action:    exportProtection,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import the slashing protection history from an interchange file",
+			ArgsUsage: "<filename>",
+			Action:    importProtection,
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+
+	keystore, err := unlockKeys(ctx)
+	if err != nil {
+		return err
+	}
+	db, err := protection.Open(ctx.String(ProtectionFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var audit io.Writer = os.Stdout
+	if path := ctx.String(AuditFlag.Name); path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		defer f.Close()
+		audit = f
+	}
+	tlsConfig, err := remote.ServerTLSConfig(ctx.String(TLSCertFlag.Name), ctx.String(TLSKeyFlag.Name), ctx.String(TLSClientCAFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS config: %w", err)
+	}
+
+	server := &http.Server{
+		Addr:      ctx.String(ListenAddrFlag.Name),
+		Handler:   remote.NewServer(valkeystore.NewSigner(keystore), db, audit),
+		TLSConfig: tlsConfig,
+	}
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		log.Info("Shutting down the signer")
+		_ = server.Close()
+	}()
+	log.Info("Signer started", "addr", server.Addr)
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// unlockKeys unlocks the validator keys in the keystore
+func unlockKeys(ctx *cli.Context) (valkeystore.KeystoreI, error) {
+	if !ctx.IsSet(KeystoreFlag.Name) {
+		return nil, fmt.Errorf("--%s is required", KeystoreFlag.Name)
+	}
+	pubkeys := ctx.StringSlice(PubkeyFlag.Name)
+	if len(pubkeys) == 0 {
+		return nil, fmt.Errorf("--%s is required", PubkeyFlag.Name)
+	}
+	var passwords []string
+	if path := ctx.String(PasswordFlag.Name); path != "" {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the password file: %w", err)
+		}
+		passwords = strings.Split(strings.ReplaceAll(string(text), "\r", ""), "\n")
+	}
+	keystore := valkeystore.NewDefaultFileKeystore(ctx.String(KeystoreFlag.Name))
+	for i, s := range pubkeys {
+		pubkey, err := validatorpk.FromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator public key %s: %w", s, err)
+		}
+		if i >= len(passwords) {
+			return nil, fmt.Errorf("no password for the validator key %s", s)
+		}
+		if err := keystore.Unlock(pubkey, passwords[i]); err != nil {
+			return nil, fmt.Errorf("failed to unlock the validator key %s: %w", s, err)
+		}
+		log.Info("Unlocked validator key", "pubkey", pubkey.String())
+	}
+	return keystore, nil
+}
+
+// exportProtection writes the slashing protection history into the interchange file
+func exportProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		return errors.New("the interchange file is required")
+	}
+	db, err := protection.Open(ctx.GlobalString(ProtectionFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	f, err := os.OpenFile(ctx.Args().First(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := db.Export(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// importProtection merges the interchange file into the slashing protection history
+func importProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		return errors.New("the interchange file is required")
+	}
+	db, err := protection.Open(ctx.GlobalString(ProtectionFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.Import(f)
+}
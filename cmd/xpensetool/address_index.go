@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/mrmikeo/Xpense/gossip"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func backfillAddressIndex(ctx *cli.Context) error {
+	return backfillIndex(ctx, func(cfg *gossip.StoreConfig) {
+		cfg.EVM.EnableAddressIndexing = true
+	}, func(ctx context.Context, gdb *gossip.Store, from, to idx.Block) error {
+		log.Info("Backfilling address index", "from", from, "to", to)
+		err := gdb.BackfillAddressIndex(ctx, from, to, func(n idx.Block) {
+			if n%1000 == 0 {
+				log.Info("Block txs indexed by addresses", "block", n)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		first, last, _ := gdb.EvmStore().GetAddressIndexedRange()
+		log.Info("Address index backfilled", "first", first, "last", last)
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/mrmikeo/Xpense/config/flags"
+	"github.com/mrmikeo/Xpense/gossip"
+	"github.com/mrmikeo/Xpense/integration"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"gopkg.in/urfave/cli.v1"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// backfillIndex opens the store with the index enabled by configure and runs backfill
+// for the block range given by the optional command arguments.
+func backfillIndex(ctx *cli.Context, configure func(cfg *gossip.StoreConfig), backfill func(ctx context.Context, gdb *gossip.Store, from, to idx.Block) error) error {
+	dataDir := ctx.GlobalString(flags.DataDirFlag.Name)
+	if dataDir == "" {
+		return fmt.Errorf("--%s need to be set", flags.DataDirFlag.Name)
+	}
+	cacheRatio, err := cacheScaler(ctx)
+	if err != nil {
+		return err
+	}
+
+	cancelCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	chaindataDir := filepath.Join(dataDir, "chaindata")
+	dbs, err := integration.GetDbProducer(chaindataDir, integration.DBCacheConfig{
+		Cache:   cacheRatio.U64(480 * opt.MiB),
+		Fdlimit: 100,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to make DB producer: %v", err)
+	}
+	defer dbs.Close()
+
+	gdbConfig := gossip.DefaultStoreConfig(cacheRatio)
+	gdbConfig.EVM.StateDb.Directory = filepath.Join(dataDir, "carmen")
+	configure(&gdbConfig)
+	gdb, err := gossip.NewStore(dbs, gdbConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create gossip store: %w", err)
+	}
+	defer gdb.Close()
+	if err := gdb.EvmStore().Open(); err != nil {
+		return fmt.Errorf("failed to open EvmStore: %v", err)
+	}
+
+	from := idx.Block(1)
+	to := gdb.GetLatestBlockIndex()
+	if len(ctx.Args()) > 0 {
+		n, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid first block: %w", err)
+		}
+		from = idx.Block(n)
+	}
+	if len(ctx.Args()) > 1 {
+		n, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid last block: %w", err)
+		}
+		if idx.Block(n) < to {
+			to = idx.Block(n)
+		}
+	}
+	if from > to {
+		return fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+
+	return backfill(cancelCtx, gdb, from, to)
+}
@@ -126,6 +126,48 @@ The archive state is used for RPC - allows to handle state-related RPC queries.
 			},
 		},
 
+		{
+			Name:  "trace",
+			Usage: "Manage the transaction trace index",
+			Subcommands: []cli.Command{
+				{
+					Name:      "backfill",
+					Usage:     "Fill the trace index from the archive state",
+					ArgsUsage: "[<blockFrom> <blockTo>]",
+					Action:    backfillTraceIndex,
+					Description: `
+    sonictool --datadir=<datadir> trace backfill [<blockFrom> <blockTo>]
+
+Replays the blocks using the archive state and stores traces of their transactions
+into the trace index used by trace_filter. All the blocks are replayed by default.
+The index covers a continuous range of blocks - to make the backfilled blocks usable,
+the range has to reach the blocks already indexed by the node (started with --trace.index).
+`,
+				},
+			},
+		},
+
+		{
+			Name:  "address",
+			Usage: "Manage the index of transactions by addresses",
+			Subcommands: []cli.Command{
+				{
+					Name:      "backfill",
+					Usage:     "Fill the address index from the archive state",
+					ArgsUsage: "[<blockFrom> <blockTo>]",
+					Action:    backfillAddressIndex,
+					Description: `
+    sonictool --datadir=<datadir> address backfill [<blockFrom> <blockTo>]
+
+Replays the blocks using the archive state and indexes their transactions by the addresses
+they touch, used by ftm_getTransactionsByAddress. All the blocks are replayed by default.
+The index covers a continuous range of blocks - to make the backfilled blocks usable,
+the range has to reach the blocks already indexed by the node (started with --address.index).
+`,
+				},
+			},
+		},
+
 		{
 			Name:        "heal",
 			Usage:       "Fix database in dirty state",
@@ -390,6 +432,67 @@ password to file or expose in any other way.
 					ArgsUsage: "<account address> <validator pubkey>",
 					Description: `
 Converts an account private key to a validator private key and saves in the validator keystore.
+`,
+				},
+				{
+					Name:  "protection",
+					Usage: "Manage the slashing protection history",
+					Description: `
+The slashing protection database records every event, block vote and epoch vote
+signed by the validator keys of the node, and refuses to sign the conflicting ones.
+
+When moving a validator to another host, stop the old node, export its history
+and import it on the new host before starting the validator there.
+`,
+					Subcommands: []cli.Command{
+						{
+							Name:      "export",
+							Usage:     "Export the slashing protection history into an interchange file",
+							ArgsUsage: "<filename>",
+							Action:    validatorProtectionExport,
+							Flags: []cli.Flag{
+								flags.DataDirFlag,
+							},
+							Description: `
+    sonictool --datadir=<datadir> validator protection export <filename>
+
+Writes the signed events and votes of all the validator keys into the interchange file.
+The node must be stopped.
+`,
+						},
+						{
+							Name:      "import",
+							Usage:     "Import the slashing protection history from an interchange file",
+							ArgsUsage: "<filename>",
+							Action:    validatorProtectionImport,
+							Flags: []cli.Flag{
+								flags.DataDirFlag,
+							},
+							Description: `
+    sonictool --datadir=<datadir> validator protection import <filename>
+
+Merges the interchange file into the slashing protection history.
+Nothing is imported if the file conflicts with the existing history.
+The node must be stopped.
+`,
+						},
+					},
+				},
+				{
+					Name:      "report",
+					Usage:     "Print the uptime and performance history of a validator",
+					ArgsUsage: "<validator ID> [<epochFrom> <epochTo>]",
+					Action:    validatorReport,
+					Flags: []cli.Flag{
+						flags.DataDirFlag,
+						reportJSONFlag,
+					},
+					Description: `
+    sonictool --datadir=<datadir> validator report <validator ID> [<epochFrom> <epochTo>]
+
+Prints the metrics of the validator in the sealed epochs: uptime, missed blocks, offline time,
+confirmed events, gas power used and originated fee. The metrics are recorded only for the epochs
+processed by the node. The node must be stopped.
 `,
 				},
 			},
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/mrmikeo/Xpense/gossip"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func backfillTraceIndex(ctx *cli.Context) error {
+	return backfillIndex(ctx, func(cfg *gossip.StoreConfig) {
+		cfg.EVM.EnableTraceIndexing = true
+	}, func(ctx context.Context, gdb *gossip.Store, from, to idx.Block) error {
+		log.Info("Backfilling trace index", "from", from, "to", to)
+		err := gdb.BackfillTraceIndex(ctx, from, to, func(n idx.Block) {
+			if n%1000 == 0 {
+				log.Info("Block traces indexed", "block", n)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		first, last, _ := gdb.EvmStore().GetTracedBlocksRange()
+		log.Info("Trace index backfilled", "first", first, "last", last)
+		return nil
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mrmikeo/Xpense/inter/validatorpk"
 	"github.com/mrmikeo/Xpense/valkeystore"
 	"github.com/mrmikeo/Xpense/valkeystore/encryption"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"gopkg.in/urfave/cli.v1"
@@ -137,3 +138,60 @@ func findAccountKeypath(addr common.Address, keydir string) (keypath string, err
 	}
 	return keypath, nil
 }
+
+// validatorProtectionExport writes the slashing protection history into the interchange file.
+func validatorProtectionExport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		return fmt.Errorf("this command requires an argument - the interchange file")
+	}
+	db, err := openProtectionDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.OpenFile(ctx.Args().First(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := db.Export(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to export the slashing protection history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("Slashing protection history is exported to " + ctx.Args().First())
+	return nil
+}
+
+// validatorProtectionImport merges the interchange file into the slashing protection history.
+func validatorProtectionImport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		return fmt.Errorf("this command requires an argument - the interchange file")
+	}
+	db, err := openProtectionDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := db.Import(f); err != nil {
+		return fmt.Errorf("failed to import the slashing protection history: %w", err)
+	}
+	fmt.Println("Slashing protection history is imported from " + ctx.Args().First())
+	return nil
+}
+
+func openProtectionDB(ctx *cli.Context) (*protection.DB, error) {
+	cfg, err := config.MakeAllConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return protection.Open(cfg.Emitter.SlashingProtectionDB)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/mrmikeo/Xpense/cmd/xpensetool/db"
+	"github.com/mrmikeo/Xpense/config/flags"
+	"github.com/mrmikeo/Xpense/gossip"
+)
+
+var reportJSONFlag = cli.BoolFlag{
+	Name:  "json",
+	Usage: "Print the report in JSON format",
+}
+
+// validatorReportRow is the metrics of a validator in a sealed epoch
+type validatorReportRow struct {
+	Epoch         idx.Epoch     `json:"epoch"`
+	Duration      time.Duration `json:"duration"` // zero if unknown
+	Uptime        time.Duration `json:"uptime"`
+	MissedBlocks  idx.Block     `json:"missedBlocks"`
+	OfflineTime   time.Duration `json:"offlineTime"`
+	Events        idx.Event     `json:"events"`
+	GasPowerUsed  uint64        `json:"gasPowerUsed"`
+	OriginatedFee *big.Int      `json:"originatedFee"`
+}
+
+// validatorReport prints the metrics of a validator in the sealed epochs of the range.
+func validatorReport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		return fmt.Errorf("this command requires an argument - the validator ID")
+	}
+	vid, err := strconv.ParseUint(ctx.Args().Get(0), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid validator ID: %w", err)
+	}
+	dataDir := ctx.GlobalString(flags.DataDirFlag.Name)
+	if dataDir == "" {
+		return fmt.Errorf("--%s need to be set", flags.DataDirFlag.Name)
+	}
+	cacheRatio, err := cacheScaler(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbs, err := db.MakeDbProducer(filepath.Join(dataDir, "chaindata"), cacheRatio)
+	if err != nil {
+		return err
+	}
+	defer dbs.Close()
+	gdb, err := db.MakeGossipDb(dbs, dataDir, false, cacheRatio)
+	if err != nil {
+		return err
+	}
+	defer gdb.Close()
+
+	if gdb.GetEpoch() <= 1 {
+		return fmt.Errorf("no sealed epochs")
+	}
+	last := gdb.GetEpoch() - 1
+	from, to := idx.Epoch(1), last
+	if len(ctx.Args()) > 1 {
+		n, err := strconv.ParseUint(ctx.Args().Get(1), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid first epoch: %w", err)
+		}
+		from = idx.Epoch(n)
+	}
+	if len(ctx.Args()) > 2 {
+		n, err := strconv.ParseUint(ctx.Args().Get(2), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid last epoch: %w", err)
+		}
+		if idx.Epoch(n) < to {
+			to = idx.Epoch(n)
+		}
+	}
+	if from > to {
+		return fmt.Errorf("invalid epoch range %d-%d, the last sealed epoch is %d", from, to, last)
+	}
+
+	rows := make([]validatorReportRow, 0)
+	for e := from; e <= to; e++ {
+		m := gdb.GetValidatorEpochMetrics(e, idx.ValidatorID(vid))
+		if m == nil {
+			continue
+		}
+		rows = append(rows, validatorReportRow{
+			Epoch:         e,
+			Duration:      epochDuration(gdb, e),
+			Uptime:        time.Duration(m.Uptime),
+			MissedBlocks:  m.MissedBlocks,
+			OfflineTime:   time.Duration(m.OfflineTime),
+			Events:        m.Events,
+			GasPowerUsed:  m.GasPowerUsed,
+			OriginatedFee: m.OriginatedFee,
+		})
+	}
+
+	if ctx.Bool(reportJSONFlag.Name) {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Printf("No metrics of validator %d are recorded in epochs %d-%d\n", vid, from, to)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "epoch\tduration\tuptime\tuptime%\tmissed blocks\toffline\tevents\tgas power used\toriginated fee\t")
+	var (
+		totalDuration, totalUptime time.Duration
+		totalEvents                idx.Event
+		totalMissed                idx.Block
+	)
+	for _, r := range rows {
+		uptime := r.Uptime
+		ratio := "-"
+		if r.Duration > 0 {
+			ratio = fmt.Sprintf("%.2f", 100*float64(uptime)/float64(r.Duration))
+			totalDuration += r.Duration
+			totalUptime += uptime
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%d\t%d\t%s\t\n", r.Epoch, r.Duration.Round(time.Second), uptime.Round(time.Second), ratio,
+			r.MissedBlocks, r.OfflineTime.Round(time.Second), r.Events, r.GasPowerUsed, r.OriginatedFee)
+		totalEvents += r.Events
+		totalMissed += r.MissedBlocks
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nValidator %d: %d epochs, %d events, %d missed blocks", vid, len(rows), totalEvents, totalMissed)
+	if totalDuration > 0 {
+		fmt.Printf(", uptime %.2f%%", 100*float64(totalUptime)/float64(totalDuration))
+	}
+	fmt.Println()
+	return nil
+}
+
+// epochDuration returns the duration of a sealed epoch, zero if the epoch states aren't available
+func epochDuration(gdb *gossip.Store, epoch idx.Epoch) time.Duration {
+	es := gdb.GetHistoryEpochState(epoch)
+	next := gdb.GetHistoryEpochState(epoch + 1)
+	if es == nil || next == nil || next.EpochStart < es.EpochStart {
+		return 0
+	}
+	return time.Duration(next.EpochStart - es.EpochStart)
+}
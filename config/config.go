@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/Fantom-foundation/lachesis-base/abft"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -156,6 +157,21 @@ func setTxPool(ctx *cli.Context, cfg *evmcore.TxPoolConfig) error {
 	if ctx.GlobalIsSet(flags.TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(flags.TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(flags.TxPoolEvictionFlag.Name) {
+		cfg.Eviction = ctx.GlobalString(flags.TxPoolEvictionFlag.Name)
+		if cfg.Eviction != evmcore.EvictByFeeCap && cfg.Eviction != evmcore.EvictByEffectiveTip {
+			return fmt.Errorf("invalid --%s: %s", flags.TxPoolEvictionFlag.Name, cfg.Eviction)
+		}
+	}
+	if ctx.GlobalIsSet(flags.TxPoolSenderQuotaFlag.Name) {
+		cfg.SenderQuota = ctx.GlobalUint64(flags.TxPoolSenderQuotaFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.TxPoolSpamScoreFlag.Name) {
+		cfg.SpamScoreLimit = ctx.GlobalUint64(flags.TxPoolSpamScoreFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.TxPoolSpamHalfLifeFlag.Name) {
+		cfg.SpamScoreHalfLife = ctx.GlobalDuration(flags.TxPoolSpamHalfLifeFlag.Name)
+	}
 	return nil
 }
 
@@ -174,10 +190,92 @@ func gossipConfigWithFlags(ctx *cli.Context, src gossip.Config) gossip.Config {
 	if ctx.GlobalIsSet(flags.RPCGlobalTimeoutFlag.Name) {
 		cfg.RPCTimeout = ctx.GlobalDuration(flags.RPCGlobalTimeoutFlag.Name)
 	}
+	if ctx.GlobalIsSet(flags.RPCLogsPageSizeFlag.Name) {
+		cfg.FilterAPI.MaxLogsPageSize = ctx.GlobalInt(flags.RPCLogsPageSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.RPCRateLimitFlag.Name) {
+		cfg.RPCLimits.Quota.Rate = ctx.GlobalFloat64(flags.RPCRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.RPCRateLimitBurstFlag.Name) {
+		cfg.RPCLimits.Quota.Burst = ctx.GlobalFloat64(flags.RPCRateLimitBurstFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.RPCRateLimitProxyFlag.Name) {
+		cfg.RPCLimits.TrustedProxy = ctx.GlobalBool(flags.RPCRateLimitProxyFlag.Name)
+	}
+	if ctx.GlobalBool(flags.AuthRPCEnabledFlag.Name) && cfg.AuthRPC.Host == "" {
+		cfg.AuthRPC.Host = ctx.GlobalString(flags.AuthRPCListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.AuthRPCPortFlag.Name) {
+		cfg.AuthRPC.Port = ctx.GlobalInt(flags.AuthRPCPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.AuthRPCVirtualHostsFlag.Name) {
+		cfg.AuthRPC.VirtualHosts = splitAndTrim(ctx.GlobalString(flags.AuthRPCVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(flags.AuthRPCJWTSecretFlag.Name) {
+		cfg.AuthRPC.JWTSecret = ctx.GlobalString(flags.AuthRPCJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.AuthRPCApiFlag.Name) {
+		cfg.AuthRPC.Modules = splitAndTrim(ctx.GlobalString(flags.AuthRPCApiFlag.Name))
+	}
+
+	return cfg
+}
+
+func pruningConfigWithFlags(ctx *cli.Context, src gossip.PruningConfig) gossip.PruningConfig {
+	cfg := src
+
+	if ctx.GlobalIsSet(flags.HistoryBlocksFlag.Name) {
+		cfg.HistoryBlocks = idx.Block(ctx.GlobalUint64(flags.HistoryBlocksFlag.Name))
+	}
+	if ctx.GlobalIsSet(flags.HistoryEpochsFlag.Name) {
+		cfg.HistoryEpochs = idx.Epoch(ctx.GlobalUint64(flags.HistoryEpochsFlag.Name))
+	}
+
+	return cfg
+}
+
+func snapshotsConfigWithFlags(ctx *cli.Context, datadir string, src gossip.SnapshotsConfig) gossip.SnapshotsConfig {
+	cfg := src
+	if len(cfg.Dir) == 0 {
+		cfg.Dir = filepath.Join(datadir, "snapshots")
+	}
+
+	if ctx.GlobalIsSet(flags.SnapshotServeFlag.Name) {
+		cfg.Serve = ctx.GlobalBool(flags.SnapshotServeFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.SnapshotSyncFlag.Name) {
+		cfg.Sync = ctx.GlobalBool(flags.SnapshotSyncFlag.Name)
+	}
+	if ctx.GlobalIsSet(flags.SnapshotIntervalFlag.Name) {
+		cfg.EpochsInterval = idx.Epoch(ctx.GlobalUint64(flags.SnapshotIntervalFlag.Name))
+	}
 
 	return cfg
 }
 
+func privateTxsConfigWithFlags(ctx *cli.Context, src gossip.PrivateTxsConfig) (gossip.PrivateTxsConfig, error) {
+	cfg := src
+
+	if ctx.GlobalIsSet(flags.PrivateTxPeersFlag.Name) {
+		cfg.TrustedPeers = []*enode.Node{}
+		for _, url := range strings.Split(ctx.GlobalString(flags.PrivateTxPeersFlag.Name), ",") {
+			if url = strings.TrimSpace(url); url == "" {
+				continue
+			}
+			node, err := enode.Parse(enode.ValidSchemes, url)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid enode URL in --%s: %v", flags.PrivateTxPeersFlag.Name, err)
+			}
+			cfg.TrustedPeers = append(cfg.TrustedPeers, node)
+		}
+	}
+	if ctx.GlobalIsSet(flags.PrivateTxBlocksFlag.Name) {
+		cfg.DefaultBlocks = idx.Block(ctx.GlobalUint64(flags.PrivateTxBlocksFlag.Name))
+	}
+
+	return cfg, nil
+}
+
 func setEvmStore(ctx *cli.Context, datadir string, src  evmstore.StoreConfig) (evmstore.StoreConfig, error) {
 	cfg := src
 	cfg.StateDb.Directory = filepath.Join(datadir, "carmen")
@@ -198,6 +296,12 @@ func setEvmStore(ctx *cli.Context, datadir string, src  evmstore.StoreConfig) (e
 			cfg.DisableTxHashesIndexing = true
 		}
 	}
+	if ctx.GlobalIsSet(flags.TraceIndexFlag.Name) || ctx.IsSet(flags.TraceIndexFlag.Name) {
+		cfg.EnableTraceIndexing = true
+	}
+	if ctx.GlobalIsSet(flags.AddressIndexFlag.Name) || ctx.IsSet(flags.AddressIndexFlag.Name) {
+		cfg.EnableAddressIndexing = true
+	}
 	return cfg, nil
 }
 
@@ -308,6 +412,15 @@ func MakeAllConfigsFromFile(ctx *cli.Context, configFile string) (*Config, error
 	if err != nil {
 		return nil, err
 	}
+	cfg.OperaStore.Pruning = pruningConfigWithFlags(ctx, cfg.OperaStore.Pruning)
+	cfg.Opera.Snapshots = snapshotsConfigWithFlags(ctx, cfg.Node.DataDir, cfg.Opera.Snapshots)
+	if cfg.Opera.Snapshots.Serve && cfg.OperaStore.EVM.StateDb.Archive != carmen.S5Archive {
+		return nil, fmt.Errorf("--%s requires the archive state", flags.SnapshotServeFlag.Name)
+	}
+	cfg.Opera.PrivateTxs, err = privateTxsConfigWithFlags(ctx, cfg.Opera.PrivateTxs)
+	if err != nil {
+		return nil, err
+	}
 
 	err = setValidator(ctx, &cfg.Emitter)
 	if err != nil {
@@ -316,6 +429,15 @@ func MakeAllConfigsFromFile(ctx *cli.Context, configFile string) (*Config, error
 	if cfg.Emitter.Validator.ID != 0 && len(cfg.Emitter.PrevEmittedEventFile.Path) == 0 {
 		cfg.Emitter.PrevEmittedEventFile.Path = path.Join(cfg.Node.DataDir, "emitter", fmt.Sprintf("last-%d", cfg.Emitter.Validator.ID))
 	}
+	if ctx.GlobalIsSet(flags.ValidatorProtectionFlag.Name) {
+		cfg.Emitter.SlashingProtectionDB = ctx.GlobalString(flags.ValidatorProtectionFlag.Name)
+	}
+	if len(cfg.Emitter.SlashingProtectionDB) == 0 {
+		cfg.Emitter.SlashingProtectionDB = path.Join(cfg.Node.DataDir, "emitter", "protection")
+	}
+	if err := checkLeaseProtection(ctx, cfg.Emitter.SlashingProtectionDB, cfg.Node.DataDir); err != nil {
+		return nil, err
+	}
 	if err := setTxPool(ctx, &cfg.TxPool); err != nil {
 		return nil, err
 	}
@@ -329,6 +451,9 @@ func MakeAllConfigsFromFile(ctx *cli.Context, configFile string) (*Config, error
 	if err := cfg.Opera.Validate(); err != nil {
 		return nil, err
 	}
+	if err := cfg.OperaStore.Pruning.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
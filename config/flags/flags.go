@@ -17,11 +17,14 @@
 package flags
 
 import (
+	"github.com/mrmikeo/Xpense/authrpc"
+	"github.com/mrmikeo/Xpense/evmcore"
 	"github.com/mrmikeo/Xpense/gossip"
 	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	"gopkg.in/urfave/cli.v1"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
@@ -120,6 +123,26 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ethconfig.Defaults.TxPool.Lifetime,
 	}
+	TxPoolEvictionFlag = cli.StringFlag{
+		Name:  "txpool.eviction",
+		Usage: "Eviction order of remote transactions when the pool is full: 'price' (lowest fee cap first) or 'tip' (lowest effective tip first, senders above the quota and spammers go before others)",
+		Value: evmcore.DefaultTxPoolConfig.Eviction,
+	}
+	TxPoolSenderQuotaFlag = cli.Uint64Flag{
+		Name:  "txpool.senderquota",
+		Usage: "Maximum percentage of the full pool slots a single remote sender may hold (0 = unlimited)",
+		Value: evmcore.DefaultTxPoolConfig.SenderQuota,
+	}
+	TxPoolSpamScoreFlag = cli.Uint64Flag{
+		Name:  "txpool.spamscore",
+		Usage: "Number of recently dropped transactions after which new transactions of a remote sender are rejected (0 = disabled)",
+		Value: evmcore.DefaultTxPoolConfig.SpamScoreLimit,
+	}
+	TxPoolSpamHalfLifeFlag = cli.DurationFlag{
+		Name:  "txpool.spamhalflife",
+		Usage: "Time interval for the spam score of a sender to decay twice",
+		Value: evmcore.DefaultTxPoolConfig.SpamScoreHalfLife,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -183,6 +206,20 @@ var (
 		Usage: "HTTP path path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphql.corsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
+		Value: "",
+	}
+	GraphQLVirtualHostsFlag = cli.StringFlag{
+		Name:  "graphql.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -213,6 +250,37 @@ var (
 		Value: "",
 	}
 
+	// Authenticated RPC settings
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the JWT-authenticated HTTP-RPC server serving the privileged APIs, which are removed from the HTTP-RPC and WS-RPC servers except their public methods (e.g. debug_trace*)",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Authenticated HTTP-RPC server listening interface",
+		Value: node.DefaultHTTPHost,
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Authenticated HTTP-RPC server listening port",
+		Value: authrpc.DefaultConfig().Port,
+	}
+	AuthRPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept authenticated requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(authrpc.DefaultConfig().VirtualHosts, ","),
+	}
+	AuthRPCJWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to a hex-encoded 32-byte JWT secret used to authenticate the requests, generated if missing",
+		Value: authrpc.DefaultConfig().JWTSecret,
+	}
+	AuthRPCApiFlag = cli.StringFlag{
+		Name:  "authrpc.api",
+		Usage: "API's offered over the authenticated HTTP-RPC interface",
+		Value: strings.Join(authrpc.DefaultModules(), ","),
+	}
+
 	// Network Settings
 	MaxPeersFlag = cli.IntFlag{
 		Name:  "maxpeers",
@@ -296,6 +364,25 @@ var (
 		Usage: "Time limit for RPC calls execution",
 		Value: gossip.DefaultConfig(cachescale.Identity).RPCTimeout,
 	}
+	RPCLogsPageSizeFlag = cli.IntFlag{
+		Name:  "rpc.logspagesize",
+		Usage: "Max number of logs in a page returned by ftm_getLogsPage",
+		Value: gossip.DefaultConfig(cachescale.Identity).FilterAPI.MaxLogsPageSize,
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit",
+		Usage: "Number of cost units per second refilled into the RPC quota of each client IP (0 = no limits), shared by HTTP-RPC, WebSocket-RPC and GraphQL",
+		Value: gossip.DefaultConfig(cachescale.Identity).RPCLimits.Quota.Rate,
+	}
+	RPCRateLimitBurstFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit.burst",
+		Usage: "Max number of cost units accumulated in the RPC quota of each client IP",
+		Value: gossip.DefaultConfig(cachescale.Identity).RPCLimits.Quota.Burst,
+	}
+	RPCRateLimitProxyFlag = cli.BoolFlag{
+		Name:  "rpc.ratelimit.proxy",
+		Usage: "Identifies the RPC clients by the X-Forwarded-For header set by a trusted reverse proxy",
+	}
 	BatchRequestLimitFlag = cli.IntFlag{
 		Name:  "rpc.batchrequestlimit",
 		Usage: "BatchRequestLimit is maximum number of requests in batch",
@@ -311,6 +398,42 @@ var (
 		Usage: "Limit maximum size in some RPC calls execution",
 		Value: gossip.DefaultConfig(cachescale.Identity).MaxResponseSize,
 	}
+	TraceIndexFlag = cli.BoolFlag{
+		Name:  "trace.index",
+		Usage: "Enables storing of transaction traces indexed by addresses, used by trace_filter",
+	}
+	AddressIndexFlag = cli.BoolFlag{
+		Name:  "address.index",
+		Usage: "Enables indexing of transactions by the addresses they touch, used by ftm_getTransactionsByAddress",
+	}
+	HistoryBlocksFlag = cli.Uint64Flag{
+		Name:  "history.blocks",
+		Usage: "Number of the last blocks to keep receipts, txs, txs positions, logs, traces and address index of (0 = all)",
+	}
+	HistoryEpochsFlag = cli.Uint64Flag{
+		Name:  "history.epochs",
+		Usage: "Number of the sealed epochs to keep receipts, txs, txs positions, logs, traces and address index of (0 = all)",
+	}
+	SnapshotServeFlag = cli.BoolFlag{
+		Name:  "snapshot.serve",
+		Usage: "Enables creation of the state snapshots of LLR-finalized epochs and serving them to the peers (requires archive mode)",
+	}
+	SnapshotSyncFlag = cli.BoolFlag{
+		Name:  "snapshot.sync",
+		Usage: "Enables bootstrapping from a state snapshot of a recent LLR-finalized epoch fetched from the peers",
+	}
+	SnapshotIntervalFlag = cli.Uint64Flag{
+		Name:  "snapshot.interval",
+		Usage: "Number of epochs between the served state snapshots",
+	}
+	PrivateTxPeersFlag = cli.StringFlag{
+		Name:  "privatetx.peers",
+		Usage: "Comma separated enode URLs of the trusted peers which private transactions are forwarded to and accepted from",
+	}
+	PrivateTxBlocksFlag = cli.Uint64Flag{
+		Name:  "privatetx.blocks",
+		Usage: "Number of blocks a private transaction is kept for if no max block number is specified",
+	}
 	ModeFlag = cli.StringFlag{
 		Name:  "mode",
 		Usage: `Mode of the node ("rpc" or "validator")`,
@@ -341,4 +464,49 @@ var (
 		Usage: "Password to unlock validator private key",
 		Value: "",
 	}
+	ValidatorSignerFlag = cli.StringFlag{
+		Name:  "validator.signer",
+		Usage: "HTTPS URL of a remote signer holding the validator private key, the key isn't unlocked locally",
+		Value: "",
+	}
+	ValidatorSignerCertFlag = cli.StringFlag{
+		Name:  "validator.signer.cert",
+		Usage: "Client TLS certificate presented to the remote signer",
+		Value: "",
+	}
+	ValidatorSignerKeyFlag = cli.StringFlag{
+		Name:  "validator.signer.key",
+		Usage: "Client TLS private key presented to the remote signer",
+		Value: "",
+	}
+	ValidatorSignerCAFlag = cli.StringFlag{
+		Name:  "validator.signer.ca",
+		Usage: "CA certificate which the remote signer TLS certificate is verified against",
+		Value: "",
+	}
+	ValidatorSignerTimeoutFlag = cli.DurationFlag{
+		Name:  "validator.signer.timeout",
+		Usage: "Timeout of a remote signer request",
+		Value: 2 * time.Second,
+	}
+	ValidatorProtectionFlag = cli.StringFlag{
+		Name:  "validator.protection",
+		Usage: "Directory of the slashing protection database (default = inside the datadir), must be on the shared storage in the active/passive mode unless a remote signer is used",
+		Value: "",
+	}
+	ValidatorLeaseFlag = cli.StringFlag{
+		Name:  "validator.lease",
+		Usage: "Enables the active/passive mode of the nodes sharing the validator identity, only the holder of the lease emits events. Either a path of the lease file on the shared storage or an HTTP URL of the lease service",
+		Value: "",
+	}
+	ValidatorLeaseTTLFlag = cli.DurationFlag{
+		Name:  "validator.lease.ttl",
+		Usage: "Time to live of the validator lease, the standby takes over in this time after the active node fails",
+		Value: 10 * time.Second,
+	}
+	ValidatorLeaseHolderFlag = cli.StringFlag{
+		Name:  "validator.lease.holder",
+		Usage: "Name of this node in the validator lease, must be unique among the nodes sharing the lease (default = hostname with a random suffix per process)",
+		Value: "",
+	}
 )
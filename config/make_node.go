@@ -2,11 +2,15 @@ package config
 
 import (
 	"fmt"
+	"github.com/mrmikeo/Xpense/authrpc"
 	"github.com/mrmikeo/Xpense/config/flags"
 	"github.com/mrmikeo/Xpense/evmcore"
 	"github.com/mrmikeo/Xpense/gossip"
 	"github.com/mrmikeo/Xpense/gossip/emitter"
+	"github.com/mrmikeo/Xpense/graphql"
 	"github.com/mrmikeo/Xpense/integration"
+	"github.com/mrmikeo/Xpense/lease"
+	"github.com/mrmikeo/Xpense/rpclimit"
 	"github.com/mrmikeo/Xpense/utils/errlock"
 	"github.com/mrmikeo/Xpense/valkeystore"
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
@@ -65,6 +69,19 @@ func MakeNode(ctx *cli.Context, cfg *Config) (*node.Node, *gossip.Service, func(
 		setBootnodes(ctx, bootnodes, &cfg.Node)
 	}
 
+	// the privileged namespaces are served only by the authenticated endpoint
+	var removedHTTP, removedWS []string
+	if cfg.Opera.AuthRPC.Enabled() {
+		cfg.Node.HTTPModules, removedHTTP = cfg.Opera.AuthRPC.PublicModules(cfg.Node.HTTPModules)
+		cfg.Node.WSModules, removedWS = cfg.Opera.AuthRPC.PublicModules(cfg.Node.WSModules)
+	}
+	// the public RPC endpoints are served by the rate-limited handlers instead of the node,
+	// they also serve the public methods of the privileged namespaces
+	publicRPC := cfg.Opera.RPCLimits.Enabled() || cfg.Opera.AuthRPC.Enabled()
+	var ws rpclimit.WSConfig
+	if publicRPC {
+		ws = rpclimit.TakeWS(&cfg.Node)
+	}
 	stack, err := makeNetworkStack(ctx, &cfg.Node)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to unlock validator key: %w", err)
@@ -89,14 +106,17 @@ func MakeNode(ctx *cli.Context, cfg *Config) (*node.Node, *gossip.Service, func(
 		log.Info("Unlocked fake validator account", "address", coinbase.Address.Hex())
 	}
 
-	// unlock validator key
-	if !valPubkey.Empty() {
-		err := unlockValidatorKey(ctx, valPubkey, valKeystore)
+	signer, err := makeValidatorSigner(ctx, valPubkey, valKeystore)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var leaseKeeper *lease.Keeper
+	if cfg.Emitter.Validator.ID != 0 {
+		leaseKeeper, err = makeValidatorLease(ctx, cfg.Emitter.Validator.ID)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to unlock validator key: %w", err)
+			return nil, nil, nil, err
 		}
 	}
-	signer := valkeystore.NewSigner(valKeystore)
 
 	// Create and register a gossip network service.
 	newTxPool := func(reader evmcore.StateReader) gossip.TxPool {
@@ -137,10 +157,53 @@ func MakeNode(ctx *cli.Context, cfg *Config) (*node.Node, *gossip.Service, func(
 	}
 
 	if cfg.Emitter.Validator.ID != 0 {
-		svc.RegisterEmitter(emitter.NewEmitter(cfg.Emitter, svc.EmitterWorld(signer)))
+		world := svc.EmitterWorld(signer)
+		if leaseKeeper != nil {
+			world.Lease = leaseKeeper
+			// registered before the service to be stopped after the emitter
+			stack.RegisterLifecycle(leaseKeeper)
+		}
+		svc.RegisterEmitter(emitter.NewEmitter(cfg.Emitter, world))
 	}
 
-	stack.RegisterAPIs(svc.APIs())
+	apis := svc.APIs()
+	stack.RegisterAPIs(apis)
+	var limiter *rpclimit.Handler
+	if publicRPC {
+		publicCfg := cfg.Node
+		sharedHTTP := authrpc.SharedModules(removedHTTP, apis)
+		sharedWS := authrpc.SharedModules(removedWS, apis)
+		publicCfg.HTTPModules = append(append([]string{}, publicCfg.HTTPModules...), sharedHTTP...)
+		ws.Modules = append(append([]string{}, ws.Modules...), sharedWS...)
+		if len(removedHTTP) != 0 || len(removedWS) != 0 {
+			// e.g. debug_traceTransaction stays public, debug_setHead is served only by the authenticated endpoint
+			log.Warn("Privileged APIs are served only by the authenticated RPC endpoint, the public methods of the shared namespaces are kept",
+				"http", removedHTTP, "ws", removedWS, "httpShared", sharedHTTP, "wsShared", sharedWS)
+		}
+		var restricted []string
+		if cfg.Opera.AuthRPC.Enabled() {
+			restricted = cfg.Opera.AuthRPC.Modules
+		}
+		limiter, err = rpclimit.Register(stack, &publicCfg, ws, cfg.Opera.RPCLimits, apis, restricted)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to register the rate-limited RPC handler: %w", err)
+		}
+	}
+	if cfg.Opera.AuthRPC.Enabled() {
+		_, err = authrpc.Register(stack, cfg.Opera.AuthRPC, cfg.Node.HTTPTimeouts)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to register the authenticated RPC endpoint: %w", err)
+		}
+	}
+	if ctx.GlobalBool(flags.GraphQLEnabledFlag.Name) {
+		if cfg.Node.HTTPHost == "" {
+			return nil, nil, nil, fmt.Errorf("--%s requires --%s", flags.GraphQLEnabledFlag.Name, flags.HTTPEnabledFlag.Name)
+		}
+		err = graphql.New(stack, svc.EthAPI, cfg.Opera.FilterAPI, cfg.Node.GraphQLCors, cfg.Node.GraphQLVirtualHosts, limiter)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to register the GraphQL service: %w", err)
+		}
+	}
 	stack.RegisterProtocols(svc.Protocols())
 	stack.RegisterLifecycle(svc)
 
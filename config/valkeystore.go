@@ -2,17 +2,23 @@ package config
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/mrmikeo/Xpense/config/flags"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/lease"
 	"github.com/mrmikeo/Xpense/valkeystore"
+	"github.com/mrmikeo/Xpense/valkeystore/remote"
 )
 
 func addFakeValidatorKey(ctx *cli.Context, key *ecdsa.PrivateKey, pubkey validatorpk.PubKey, valKeystore valkeystore.RawKeystoreI) error {
@@ -46,6 +52,94 @@ func makeValidatorPasswordList(ctx *cli.Context) ([]string, error) {
 	return nil, nil
 }
 
+// makeValidatorSigner creates the remote signer if it's configured, otherwise unlocks the validator key in the local keystore
+func makeValidatorSigner(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore valkeystore.KeystoreI) (valkeystore.SignerI, error) {
+	if url := ctx.GlobalString(flags.ValidatorSignerFlag.Name); url != "" {
+		signer, err := remote.NewSigner(remote.Config{
+			URL:      url,
+			CertFile: ctx.GlobalString(flags.ValidatorSignerCertFlag.Name),
+			KeyFile:  ctx.GlobalString(flags.ValidatorSignerKeyFlag.Name),
+			CAFile:   ctx.GlobalString(flags.ValidatorSignerCAFlag.Name),
+			Timeout:  ctx.GlobalDuration(flags.ValidatorSignerTimeoutFlag.Name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote signer: %w", err)
+		}
+		if err := signer.Status(); err != nil {
+			log.Warn("Remote signer is unavailable", "url", url, "err", err)
+		}
+		log.Info("Using remote validator signer", "url", url, "pubkey", pubKey.String())
+		return signer, nil
+	}
+	if !pubKey.Empty() {
+		if err := unlockValidatorKey(ctx, pubKey, valKeystore); err != nil {
+			return nil, fmt.Errorf("failed to unlock validator key: %w", err)
+		}
+	}
+	return valkeystore.NewSigner(valKeystore), nil
+}
+
+// makeValidatorLease creates the keeper of the validator lease if the active/passive mode is enabled
+func makeValidatorLease(ctx *cli.Context, id idx.ValidatorID) (*lease.Keeper, error) {
+	location := ctx.GlobalString(flags.ValidatorLeaseFlag.Name)
+	if location == "" {
+		return nil, nil
+	}
+	ttl := ctx.GlobalDuration(flags.ValidatorLeaseTTLFlag.Name)
+	if ttl <= 0 {
+		return nil, fmt.Errorf("--%s must be positive", flags.ValidatorLeaseTTLFlag.Name)
+	}
+	holder := ctx.GlobalString(flags.ValidatorLeaseHolderFlag.Name)
+	if holder == "" {
+		var err error
+		holder, err = defaultLeaseHolder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the lease holder name: %w", err)
+		}
+	}
+	backend, err := lease.NewBackend(location, fmt.Sprintf("validator-%d", id), ttl/4)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Validator runs in the active/passive mode", "lease", location, "holder", holder)
+	return lease.NewKeeper(backend, holder, ttl), nil
+}
+
+// defaultLeaseHolder names the holder after the hostname with a random suffix,
+// so that the nodes with the same hostname (e.g. containers) never share the lease
+func defaultLeaseHolder() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return hostname + "-" + hex.EncodeToString(suffix), nil
+}
+
+// checkLeaseProtection checks that the slashing protection is shared by the nodes in the active/passive mode.
+// The protection inside the datadir is local to the node, so it's allowed only if a remote signer protects the key.
+func checkLeaseProtection(ctx *cli.Context, protection, datadir string) error {
+	if ctx.GlobalString(flags.ValidatorLeaseFlag.Name) == "" || ctx.GlobalString(flags.ValidatorSignerFlag.Name) != "" {
+		return nil
+	}
+	absProtection, err := filepath.Abs(protection)
+	if err != nil {
+		return err
+	}
+	absDatadir, err := filepath.Abs(datadir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absDatadir, absProtection); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("--%s requires --%s on the shared storage outside the datadir or --%s",
+			flags.ValidatorLeaseFlag.Name, flags.ValidatorProtectionFlag.Name, flags.ValidatorSignerFlag.Name)
+	}
+	return nil
+}
+
 func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore valkeystore.KeystoreI) error {
 	if !valKeystore.Has(pubKey) {
 		return valkeystore.ErrNotFound
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/mrmikeo/Xpense/config/flags"
+)
+
+func TestCheckLeaseProtection(t *testing.T) {
+	require := require.New(t)
+
+	newCtx := func(args ...string) *cli.Context {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String(flags.ValidatorLeaseFlag.Name, "", "")
+		set.String(flags.ValidatorSignerFlag.Name, "", "")
+		require.NoError(set.Parse(args))
+		return cli.NewContext(nil, set, nil)
+	}
+	lease := "--" + flags.ValidatorLeaseFlag.Name + "=/shared/lease"
+
+	// the protection isn't shared without the lease
+	require.NoError(checkLeaseProtection(newCtx(), "/data/emitter/protection", "/data"))
+
+	require.Error(checkLeaseProtection(newCtx(lease), "/data/emitter/protection", "/data"))
+	require.Error(checkLeaseProtection(newCtx(lease), "/data", "/data"))
+	require.NoError(checkLeaseProtection(newCtx(lease), "/shared/protection", "/data"))
+	require.NoError(checkLeaseProtection(newCtx(lease), "/data2/protection", "/data"))
+
+	// the remote signer protects the key
+	signer := "--" + flags.ValidatorSignerFlag.Name + "=https://signer"
+	require.NoError(checkLeaseProtection(newCtx(lease, signer), "/data/emitter/protection", "/data"))
+}
+
+func TestDefaultLeaseHolder(t *testing.T) {
+	require := require.New(t)
+
+	hostname, err := os.Hostname()
+	require.NoError(err)
+
+	a, err := defaultLeaseHolder()
+	require.NoError(err)
+	b, err := defaultLeaseHolder()
+	require.NoError(err)
+	require.True(strings.HasPrefix(a, hostname+"-"))
+	require.True(strings.HasPrefix(b, hostname+"-"))
+	// the processes on the hosts with the same name hold the lease under the different names
+	require.NotEqual(a, b)
+}
@@ -2,10 +2,14 @@ package ethapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/inter/iblockproc"
 )
 
 // PublicAbftAPI provides an API to access consensus related information.
@@ -73,3 +77,81 @@ func (s *PublicAbftAPI) GetOriginatedEpochFee(ctx context.Context, validatorID h
 	}
 	return (*hexutil.Big)(v), nil
 }
+
+// maxEpochMetricsRange is the maximum number of epochs served by a single GetValidatorEpochMetrics call
+const maxEpochMetricsRange = 1024
+
+// sealedEpoch converts the epoch number into a sealed epoch, the latest epoch is the last sealed one
+func (s *PublicAbftAPI) sealedEpoch(ctx context.Context, epoch rpc.BlockNumber) (idx.Epoch, error) {
+	current := s.b.CurrentEpoch(ctx)
+	if epoch == rpc.LatestBlockNumber || epoch == rpc.PendingBlockNumber {
+		if current == 0 {
+			return 0, nil
+		}
+		return current - 1, nil
+	}
+	if epoch < 0 {
+		return 0, errors.New("invalid epoch")
+	}
+	return idx.Epoch(epoch), nil
+}
+
+func rpcMarshalValidatorEpochMetrics(epoch idx.Epoch, m iblockproc.ValidatorEpochMetrics) map[string]interface{} {
+	return map[string]interface{}{
+		"epoch":         hexutil.Uint64(epoch),
+		"missedBlocks":  hexutil.Uint64(m.MissedBlocks),
+		"offlineTime":   hexutil.Uint64(m.OfflineTime),
+		"uptime":        hexutil.Uint64(m.Uptime),
+		"originatedFee": (*hexutil.Big)(m.OriginatedFee),
+		"events":        hexutil.Uint64(m.Events),
+		"gasPowerUsed":  hexutil.Uint64(m.GasPowerUsed),
+	}
+}
+
+// GetEpochMetrics returns the metrics of the validators in a sealed epoch: missed blocks, offline time and uptime in nanoseconds,
+// originated fee, confirmed events and their gas power used. Returns nil if the metrics aren't recorded by the node.
+func (s *PublicAbftAPI) GetEpochMetrics(ctx context.Context, epoch rpc.BlockNumber) (map[hexutil.Uint64]interface{}, error) {
+	e, err := s.sealedEpoch(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.b.GetEpochMetrics(ctx, e)
+	if err != nil || metrics == nil {
+		return nil, err
+	}
+	res := make(map[hexutil.Uint64]interface{}, len(metrics))
+	for vid, m := range metrics {
+		res[hexutil.Uint64(vid)] = rpcMarshalValidatorEpochMetrics(e, m)
+	}
+	return res, nil
+}
+
+// GetValidatorEpochMetrics returns the metrics of the validator in the sealed epochs of the range, see GetEpochMetrics.
+// The epochs for which the metrics aren't recorded are omitted.
+func (s *PublicAbftAPI) GetValidatorEpochMetrics(ctx context.Context, validatorID hexutil.Uint, fromEpoch, toEpoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	from, err := s.sealedEpoch(ctx, fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.sealedEpoch(ctx, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("fromEpoch is greater than toEpoch")
+	}
+	if to-from >= maxEpochMetricsRange {
+		return nil, fmt.Errorf("too many epochs requested, the limit is %d", maxEpochMetricsRange)
+	}
+	res := make([]map[string]interface{}, 0, to-from+1)
+	for e := from; e <= to; e++ {
+		m, err := s.b.GetValidatorEpochMetrics(ctx, e, idx.ValidatorID(validatorID))
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			res = append(res, rpcMarshalValidatorEpochMetrics(e, *m))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,114 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+// maxAddressTxsPageSize is a max number of transactions returned by ftm_getTransactionsByAddress at once
+const maxAddressTxsPageSize = 1000
+
+// PublicAddressAPI provides an API to access transactions by the addresses they touch.
+type PublicAddressAPI struct {
+	b Backend
+}
+
+// NewPublicAddressAPI creates a new address API.
+func NewPublicAddressAPI(b Backend) *PublicAddressAPI {
+	return &PublicAddressAPI{b}
+}
+
+// AddressTxsCursor is a position of the transaction to continue the search from
+type AddressTxsCursor struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+}
+
+// AddressTxsPage is a page of the transactions touching an address
+type AddressTxsPage struct {
+	Transactions []*RPCTransaction `json:"transactions"`
+	// Next is the position of the first transaction of the next page, nil if there are no more transactions
+	Next *AddressTxsCursor `json:"next"`
+}
+
+// GetTransactionsByAddress returns the transactions within the blocks range which touch the address
+// as a sender, a recipient, a created contract or a participant of an internal call.
+// The transactions are ordered by their positions, the search is continued from the cursor if it's provided.
+func (s *PublicAddressAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, fromBlock, toBlock *rpc.BlockNumber, cursor *AddressTxsCursor) (*AddressTxsPage, error) {
+	head := rpc.BlockNumber(s.b.CurrentBlock().NumberU64())
+	from, to := rpc.BlockNumber(1), head
+	if fromBlock != nil && *fromBlock != rpc.EarliestBlockNumber {
+		from = *fromBlock
+		if from == rpc.LatestBlockNumber || from == rpc.PendingBlockNumber {
+			from = head
+		}
+	}
+	if toBlock != nil {
+		to = *toBlock
+		if to == rpc.LatestBlockNumber || to == rpc.PendingBlockNumber {
+			to = head
+		}
+	}
+	if from < 1 {
+		// genesis block has no transactions
+		from = 1
+	}
+	if from > to {
+		return nil, errors.New("invalid blocks range")
+	}
+
+	first, last, ok := s.b.GetAddressIndexedRange(ctx)
+	if !ok {
+		return nil, errors.New("transactions are not indexed by addresses")
+	}
+	if idx.Block(from) < first || idx.Block(to) > last {
+		return nil, fmt.Errorf("only blocks %d-%d are indexed", first, last)
+	}
+
+	start, startIndex := idx.Block(from), uint32(0)
+	if cursor != nil && idx.Block(cursor.BlockNumber) >= start {
+		start, startIndex = idx.Block(cursor.BlockNumber), uint32(cursor.TransactionIndex)
+	}
+
+	positions, err := s.b.GetAddressTxs(ctx, address, start, startIndex, idx.Block(to), maxAddressTxsPageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AddressTxsPage{
+		Transactions: make([]*RPCTransaction, 0, len(positions)),
+	}
+	var block *evmcore.EvmBlock
+	for i, pos := range positions {
+		if i == maxAddressTxsPageSize {
+			page.Next = &AddressTxsCursor{
+				BlockNumber:      hexutil.Uint64(pos.Block),
+				TransactionIndex: hexutil.Uint(pos.Index),
+			}
+			break
+		}
+		if block == nil || block.NumberU64() != uint64(pos.Block) {
+			block, err = s.b.BlockByNumber(ctx, rpc.BlockNumber(pos.Block))
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, fmt.Errorf("block %d not found", pos.Block)
+			}
+		}
+		tx := newRPCTransactionFromBlockIndex(block, uint64(pos.Index))
+		if tx == nil || tx.Hash != pos.Hash {
+			return nil, fmt.Errorf("transaction %s not found in block %d", pos.Hash.String(), pos.Block)
+		}
+		page.Transactions = append(page.Transactions, tx)
+	}
+	return page, nil
+}
@@ -47,12 +47,14 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/tyler-smith/go-bip39"
 
 	"github.com/mrmikeo/Xpense/evmcore"
 	"github.com/mrmikeo/Xpense/gossip/gasprice"
 	"github.com/mrmikeo/Xpense/inter/state"
 	"github.com/mrmikeo/Xpense/opera"
+	"github.com/mrmikeo/Xpense/txtrace"
 	"github.com/mrmikeo/Xpense/utils/signers/gsignercache"
 	"github.com/mrmikeo/Xpense/utils/signers/internaltx"
 )
@@ -70,12 +72,17 @@ var (
 // PublicEthereumAPI provides an API to access Ethereum related information.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicEthereumAPI struct {
-	b Backend
+	b          Backend
+	feeHistory *lru.Cache
 }
 
 // NewPublicEthereumAPI creates a new Ethereum protocol API.
 func NewPublicEthereumAPI(b Backend) *PublicEthereumAPI {
-	return &PublicEthereumAPI{b}
+	feeHistory, _ := lru.New(feeHistoryCacheSize)
+	return &PublicEthereumAPI{
+		b:          b,
+		feeHistory: feeHistory,
+	}
 }
 
 // GasPrice returns a suggestion for a gas price for legacy transactions.
@@ -100,10 +107,10 @@ type feeHistoryResult struct {
 
 var errInvalidPercentile = errors.New("invalid reward percentile")
 
+// FeeHistory returns the base fees, the gas used ratios and the effective tips at the given percentiles
+// of the gas used for a range of blocks. The base fee of the block following the range is returned as well.
 func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
 	res := &feeHistoryResult{}
-	res.Reward = make([][]*hexutil.Big, 0, blockCount)
-	res.BaseFee = make([]*hexutil.Big, 0, blockCount)
 	res.GasUsedRatio = make([]float64, 0, blockCount)
 	res.OldestBlock = (*hexutil.Big)(new(big.Int))
 
@@ -133,18 +140,45 @@ func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount rpc.Decim
 		oldest = 0
 	}
 
-	baseFee := s.b.MinGasPrice()
-
-	tips := make([]*hexutil.Big, 0, len(rewardPercentiles))
-	for _, p := range rewardPercentiles {
-		tip := s.b.SuggestGasTipCap(ctx, uint64(gasprice.DecimalUnit*p/100.0))
-		tips = append(tips, (*hexutil.Big)(tip))
+	if len(rewardPercentiles) != 0 {
+		res.Reward = make([][]*hexutil.Big, 0, blockCount)
 	}
+	res.BaseFee = make([]*hexutil.Big, 0, blockCount+1)
 	res.OldestBlock.ToInt().SetUint64(uint64(oldest))
-	for i := uint64(0); i < uint64(last-oldest+1); i++ {
-		res.Reward = append(res.Reward, tips)
-		res.BaseFee = append(res.BaseFee, (*hexutil.Big)(baseFee))
-		res.GasUsedRatio = append(res.GasUsedRatio, 0.99)
+	for n := oldest; n <= last; n++ {
+		fees, err := s.blockFees(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		if fees == nil {
+			if n == oldest {
+				return nil, fmt.Errorf("block %d not found", n)
+			}
+			// return the available part of the range
+			last = n - 1
+			break
+		}
+		if len(rewardPercentiles) != 0 {
+			rewards := fees.rewards(rewardPercentiles)
+			rpcRewards := make([]*hexutil.Big, len(rewards))
+			for i, r := range rewards {
+				rpcRewards[i] = (*hexutil.Big)(r)
+			}
+			res.Reward = append(res.Reward, rpcRewards)
+		}
+		res.BaseFee = append(res.BaseFee, (*hexutil.Big)(new(big.Int).Set(fees.baseFee)))
+		res.GasUsedRatio = append(res.GasUsedRatio, fees.gasUsedRatio)
+	}
+
+	// base fee of the next block
+	next, err := s.blockFees(ctx, last+1)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		res.BaseFee = append(res.BaseFee, (*hexutil.Big)(new(big.Int).Set(next.baseFee)))
+	} else {
+		res.BaseFee = append(res.BaseFee, (*hexutil.Big)(s.b.MinGasPrice()))
 	}
 	return res, nil
 }
@@ -244,12 +278,29 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// txPoolInspect is the flattened content of the transaction pool along with the policy state
+type txPoolInspect struct {
+	Pending map[string]map[string]string `json:"pending"`
+	Queued  map[string]map[string]string `json:"queued"`
+	Policy  txPoolPolicyInspect          `json:"policy"`
+}
+
+// txPoolPolicyInspect is the state of the admission and eviction policy of the transaction pool
+type txPoolPolicyInspect struct {
+	Name          string                     `json:"name"`
+	Settings      map[string]string          `json:"settings"`
+	RejectedQuota hexutil.Uint64             `json:"rejectedQuota"`
+	RejectedSpam  hexutil.Uint64             `json:"rejectedSpam"`
+	Evicted       hexutil.Uint64             `json:"evicted"`
+	SpamScores    map[common.Address]float64 `json:"spamScores"`
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
-// easily inspectable list.
-func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
-	content := map[string]map[string]map[string]string{
-		"pending": make(map[string]map[string]string),
-		"queued":  make(map[string]map[string]string),
+// easily inspectable list. The state of the admission and eviction policy is reported under "policy".
+func (s *PublicTxPoolAPI) Inspect() *txPoolInspect {
+	content := &txPoolInspect{
+		Pending: make(map[string]map[string]string),
+		Queued:  make(map[string]map[string]string),
 	}
 	pending, queue := s.b.TxPoolContent()
 
@@ -266,7 +317,7 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
 		}
-		content["pending"][account.Hex()] = dump
+		content.Pending[account.Hex()] = dump
 	}
 	// Flatten the queued transactions
 	for account, txs := range queue {
@@ -274,7 +325,16 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
 		}
-		content["queued"][account.Hex()] = dump
+		content.Queued[account.Hex()] = dump
+	}
+	policy := s.b.TxPoolPolicyStatus()
+	content.Policy = txPoolPolicyInspect{
+		Name:          policy.Name,
+		Settings:      policy.Settings,
+		RejectedQuota: hexutil.Uint64(policy.RejectedQuota),
+		RejectedSpam:  hexutil.Uint64(policy.RejectedSpam),
+		Evicted:       hexutil.Uint64(policy.Evicted),
+		SpamScores:    policy.SpamScores,
 	}
 	return content
 }
@@ -798,6 +858,9 @@ func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.H
 func (s *PublicBlockChainAPI) calculateExtBlockApi(ctx context.Context, blkNumber rpc.BlockNumber) extBlockApi {
 	var ext extBlockApi
 	if s.b.CalcBlockExtApi() && blkNumber != rpc.EarliestBlockNumber {
+		if skipped, err := s.b.GetSkippedTxs(ctx, blkNumber); err == nil {
+			ext.skippedTxs = RPCMarshalSkippedTxs(skipped)
+		}
 		receipts, err := s.b.GetReceiptsByNumber(ctx, blkNumber)
 		if err != nil {
 			return ext
@@ -918,6 +981,10 @@ type OverrideAccount struct {
 	Balance   **hexutil.Big                `json:"balance"`
 	State     *map[common.Hash]common.Hash `json:"state"`
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+
+	// MovePrecompileTo relocates the precompiled contract at the account address.
+	// It is supported by eth_simulateV1 only.
+	MovePrecompileTo *common.Address `json:"movePrecompileToAddress"`
 }
 
 // StateOverride is the collection of overridden accounts.
@@ -925,10 +992,28 @@ type StateOverride map[common.Address]OverrideAccount
 
 // Apply overrides the fields of specified accounts into the given state.
 func (diff *StateOverride) Apply(state state.StateDB) error {
+	return diff.apply(state, nil)
+}
+
+// apply overrides the fields of specified accounts into the given state.
+// If precompiles is not nil, precompiled contracts are relocated as requested.
+func (diff *StateOverride) apply(state state.StateDB, precompiles *precompileSet) error {
 	if diff == nil {
 		return nil
 	}
 	for addr, account := range *diff {
+		// Relocate the precompiled contract first, so the code may be replaced.
+		if account.MovePrecompileTo != nil {
+			if precompiles == nil {
+				return fmt.Errorf("account %s: moving precompiles is not supported by this method", addr.Hex())
+			}
+			if err := precompiles.move(addr, *account.MovePrecompileTo); err != nil {
+				return err
+			}
+		}
+		if account.Code != nil && precompiles != nil && precompiles.isBuiltin(addr) {
+			return fmt.Errorf("account %s: code of a built-in precompile cannot be overridden", addr.Hex())
+		}
 		// Override account nonce.
 		if account.Nonce != nil {
 			state.SetNonce(addr, uint64(*account.Nonce))
@@ -1267,6 +1352,8 @@ func FormatLogs(logs []vm.StructLog) (json.RawMessage, error) {
 type extBlockApi struct {
 	bloom        types.Bloom
 	receiptsRoot common.Hash
+	// skippedTxs are the skipped transactions of the block, nil if not calculated
+	skippedTxs []*RPCSkippedTx
 }
 
 // RPCMarshalHeader converts the given header to the RPC output .
@@ -1330,6 +1417,9 @@ func RPCMarshalBlock(block *evmcore.EvmBlock, ext extBlockApi, inclTx bool, full
 		uncleHashes[i] = uncle.Hash
 	}
 	fields["uncles"] = uncleHashes
+	if ext.skippedTxs != nil {
+		fields["skippedTxs"] = ext.skippedTxs
+	}
 
 	return fields, nil
 }
@@ -2126,6 +2216,8 @@ type TraceConfig struct {
 	Tracer  *string
 	Timeout *string
 	Reexec  *uint64
+	// Config specific to the native tracers (callTracer, prestateTracer and 4byteTracer)
+	TracerConfig json.RawMessage
 }
 
 // TraceTransaction returns the structured logs created during the execution of EVM
@@ -2164,6 +2256,42 @@ func (api *PublicDebugAPI) TraceTransaction(ctx context.Context, hash common.Has
 	return api.traceTx(ctx, msg, txctx, block.Header(), statedb, config)
 }
 
+// TraceCallConfig is the config for traceCall API. It holds one more
+// field to override the state for tracing.
+type TraceCallConfig struct {
+	TraceConfig
+	StateOverrides *StateOverride
+}
+
+// TraceCall lets you trace a given eth_call. It collects the structured logs
+// created during the execution of EVM if the given transaction was added on
+// top of the provided block and returns them as a JSON object.
+func (api *PublicDebugAPI) TraceCall(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	defer statedb.Release()
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		traceConfig = &config.TraceConfig
+	}
+
+	// Execute the trace
+	msg, err := args.ToMessage(api.b.RPCGasCap(), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	txctx := &tracers.Context{
+		BlockHash: header.Hash,
+	}
+	return api.traceTx(ctx, msg, txctx, header, statedb, traceConfig)
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -2197,20 +2325,29 @@ func (api *PublicDebugAPI) traceTx(ctx context.Context, message evmcore.Message,
 		if rpcTimeout := api.b.RPCEVMTimeout(); rpcTimeout != 0 && rpcTimeout < timeout {
 			timeout = rpcTimeout
 		}
-		t, err := tracers.New(*config.Tracer, txctx)
-		if err != nil {
-			return nil, err
+		// Prefer the native tracer of the name, fall back to the JavaScript one
+		var stop func(error)
+		if nt, isNative, err := txtrace.NewNativeTracer(*config.Tracer, config.TracerConfig); isNative {
+			if err != nil {
+				return nil, err
+			}
+			tracer, stop = nt, nt.Stop
+		} else {
+			t, err := tracers.New(*config.Tracer, txctx)
+			if err != nil {
+				return nil, err
+			}
+			defer t.Destroy()
+			tracer, stop = t, t.Stop
 		}
-		defer t.Destroy()
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
 			if errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
-				t.Stop(errors.New("execution timeout"))
+				stop(errors.New("execution timeout"))
 			}
 		}()
 		defer cancel()
-		tracer = t
 
 	default:
 		tracer = vm.NewStructLogger(config.LogConfig)
@@ -2237,6 +2374,10 @@ func (api *PublicDebugAPI) traceTx(ctx context.Context, message evmcore.Message,
 	// Call Prepare to clear out the statedb access list
 	statedb.Prepare(txctx.TxHash, txctx.TxIndex)
 
+	nativeTracer, isNative := tracer.(txtrace.NativeTracer)
+	if isNative {
+		nativeTracer.CaptureTxStart(message.Gas())
+	}
 	result, err := evmcore.ApplyMessage(vmenv, message, new(evmcore.GasPool).AddGas(message.Gas()))
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %w", err)
@@ -2244,6 +2385,9 @@ func (api *PublicDebugAPI) traceTx(ctx context.Context, message evmcore.Message,
 	if err := statedb.Error(); err != nil {
 		return nil, fmt.Errorf("StateDB error while tracing tx %s: %w", txctx.TxHash, err)
 	}
+	if isNative {
+		nativeTracer.CaptureTxEnd(message.Gas() - result.UsedGas)
+	}
 
 	// Depending on the tracer type, format and return the output.
 	switch tracer := tracer.(type) {
@@ -2265,6 +2409,13 @@ func (api *PublicDebugAPI) traceTx(ctx context.Context, message evmcore.Message,
 			Logs:        res,
 		}, nil
 
+	case txtrace.NativeTracer:
+		result, err := tracer.GetResult()
+		if responseSizeLimit > 0 && len(result) > responseSizeLimit {
+			return nil, ErrMaxResponseSize
+		}
+		return result, err
+
 	case *tracers.Tracer:
 		result, err := tracer.GetResult()
 		if err != nil && result == nil {
@@ -33,9 +33,12 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/gossip/evmstore"
+	"github.com/mrmikeo/Xpense/gossip/gasprice"
 	"github.com/mrmikeo/Xpense/inter"
 	"github.com/mrmikeo/Xpense/inter/iblockproc"
 	"github.com/mrmikeo/Xpense/inter/state"
+	"github.com/mrmikeo/Xpense/txtrace"
 )
 
 // PeerProgress is synchronization status of a peer
@@ -54,6 +57,7 @@ type Backend interface {
 	// General Ethereum API
 	Progress() PeerProgress
 	SuggestGasTipCap(ctx context.Context, certainty uint64) *big.Int
+	SuggestFeeEstimates(ctx context.Context) gasprice.Estimates
 	EffectiveMinGasPrice(ctx context.Context) *big.Int
 	AccountManager() *accounts.Manager
 	ExtRPCEnabled() bool
@@ -72,12 +76,19 @@ type Backend interface {
 	BlockByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmBlock, error)
 	GetReceiptsByNumber(ctx context.Context, number rpc.BlockNumber) (types.Receipts, error)
 	GetTd(hash common.Hash) *big.Int
+	GetTracedBlocksRange(ctx context.Context) (first, last idx.Block, ok bool)
+	GetBlockTraces(ctx context.Context, number idx.Block) ([]txtrace.ActionTrace, error)
+	GetTraceBlocksByAddress(ctx context.Context, addr common.Address, from, to idx.Block) ([]idx.Block, error)
+	GetAddressIndexedRange(ctx context.Context) (first, last idx.Block, ok bool)
+	GetAddressTxs(ctx context.Context, addr common.Address, from idx.Block, fromIndex uint32, to idx.Block, limit int) ([]evmstore.AddressTx, error)
 	GetEVM(ctx context.Context, msg evmcore.Message, state vm.StateDB, header *evmcore.EvmHeader, vmConfig *vm.Config) (*vm.EVM, func() error, error)
 	MinGasPrice() *big.Int
 	MaxGasLimit() uint64
 
 	// Transaction pool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
+	SendPrivateTx(ctx context.Context, signedTx *types.Transaction, maxBlock idx.Block) error
+	SendBundle(ctx context.Context, txs types.Transactions, maxBlock idx.Block, revertingTxs []common.Hash) (common.Hash, error)
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, uint64, uint64, error)
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash common.Hash) *types.Transaction
@@ -85,8 +96,15 @@ type Backend interface {
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
+	TxPoolPolicyStatus() evmcore.TxPolicyStatus
 	SubscribeNewTxsNotify(chan<- evmcore.NewTxsNotify) notify.Subscription
 
+	// Transactions lifecycle API
+	GetTxLifecycle(ctx context.Context, txHash common.Hash) *TxLifecycle
+	SubscribeTxLifecycleNotify(txHash common.Hash, ch chan<- TxLifecycle) notify.Subscription
+	GetSkippedTxs(ctx context.Context, number rpc.BlockNumber) ([]evmcore.SkippedTx, error)
+	SubscribeSkippedTxsNotify(chan<- evmcore.SkippedTxsNotify) notify.Subscription
+
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *evmcore.EvmBlock
 
@@ -102,6 +120,12 @@ type Backend interface {
 	GetDowntime(ctx context.Context, vid idx.ValidatorID) (idx.Block, inter.Timestamp, error)
 	GetUptime(ctx context.Context, vid idx.ValidatorID) (*big.Int, error)
 	GetOriginatedFee(ctx context.Context, vid idx.ValidatorID) (*big.Int, error)
+	GetValidatorEpochMetrics(ctx context.Context, epoch idx.Epoch, vid idx.ValidatorID) (*iblockproc.ValidatorEpochMetrics, error)
+	GetEpochMetrics(ctx context.Context, epoch idx.Epoch) (map[idx.ValidatorID]iblockproc.ValidatorEpochMetrics, error)
+
+	// Lachesis LLR API
+	GetLlrBlockResult(ctx context.Context, block idx.Block) *hash.Hash
+	GetLlrEpochResult(ctx context.Context, epoch idx.Epoch) *hash.Hash
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -162,6 +186,26 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicTxTraceAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "ftm",
+			Version:   "1.0",
+			Service:   NewPublicAddressAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "ftm",
+			Version:   "1.0",
+			Service:   NewPublicFeeAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "ftm",
+			Version:   "1.0",
+			Service:   NewPublicTxStatusAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "ftm",
+			Version:   "1.0",
+			Service:   NewPublicSkippedTxsAPI(apiBackend),
+			Public:    true,
 		},
 	}
 }
@@ -0,0 +1,63 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// BundleArgs represents the arguments to submit a transactions bundle
+type BundleArgs struct {
+	Txs []hexutil.Bytes `json:"txs"`
+	// MaxBlockNumber is the last block the bundle may be included into, the node's default is used if omitted
+	MaxBlockNumber *hexutil.Uint64 `json:"maxBlockNumber"`
+	// RevertingTxHashes are the bundle transactions which are allowed to revert
+	RevertingTxHashes []common.Hash `json:"revertingTxHashes"`
+}
+
+// BundleResult is the result of a bundle submission
+type BundleResult struct {
+	BundleHash common.Hash `json:"bundleHash"`
+}
+
+// SendBundle will add the signed transactions bundle into the pool of this node's emitter.
+// The transactions are included atomically into one event in the given order, and they are never announced
+// to the peers. The bundle is rejected if any transaction would revert, unless it's listed in revertingTxHashes.
+func (s *PublicTransactionPoolAPI) SendBundle(ctx context.Context, args BundleArgs) (*BundleResult, error) {
+	if len(args.Txs) == 0 {
+		return nil, errors.New("missing transactions")
+	}
+	txs := make(types.Transactions, 0, len(args.Txs))
+	for i, input := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if err := checkTxFee(tx.GasPrice(), tx.Gas(), s.b.RPCTxFeeCap()); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if !s.b.UnprotectedAllowed() && !tx.Protected() {
+			return nil, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+		}
+		txs = append(txs, tx)
+	}
+	var maxBlock idx.Block
+	if args.MaxBlockNumber != nil {
+		maxBlock = idx.Block(*args.MaxBlockNumber)
+		if maxBlock == 0 {
+			return nil, errors.New("max block number has to be positive")
+		}
+	}
+	hash, err := s.b.SendBundle(ctx, txs, maxBlock, args.RevertingTxHashes)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Submitted transactions bundle", "hash", hash.Hex(), "txs", len(txs), "maxBlock", maxBlock)
+	return &BundleResult{BundleHash: hash}, nil
+}
@@ -0,0 +1,82 @@
+package ethapi
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/mrmikeo/Xpense/gossip/gasprice"
+)
+
+// PublicFeeAPI provides an API to estimate transaction fees.
+type PublicFeeAPI struct {
+	b Backend
+}
+
+// NewPublicFeeAPI creates a new fee API.
+func NewPublicFeeAPI(b Backend) *PublicFeeAPI {
+	return &PublicFeeAPI{b}
+}
+
+// FeeTier is a fee suggestion for a given inclusion certainty
+type FeeTier struct {
+	// Certainty is a probability of the inclusion the tier aims at, from 0 to 1
+	Certainty            float64      `json:"certainty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	// GasPrice is a suggestion for legacy transactions, i.e. the base fee plus the tip
+	GasPrice *hexutil.Big `json:"gasPrice"`
+	// GasAhead is an expected gas of the pending transactions which will be included earlier
+	GasAhead hexutil.Uint64 `json:"gasAhead"`
+	// ExpectedDelay is an expected number of seconds until the network has enough gas power to include the transaction
+	ExpectedDelay float64 `json:"expectedDelay"`
+}
+
+// FeeCongestion is a state of the txpool and of the gas power the fee tiers are based on
+type FeeCongestion struct {
+	PendingGas          hexutil.Uint64 `json:"pendingGas"`
+	GasPowerLeft        hexutil.Uint64 `json:"gasPowerLeft"`
+	MaxGasPower         hexutil.Uint64 `json:"maxGasPower"`
+	GasPowerAllocPerSec hexutil.Uint64 `json:"gasPowerAllocPerSec"`
+	// FreeRatio is a share of the free gas power, from 0 to 1
+	FreeRatio float64 `json:"freeRatio"`
+}
+
+// FeeEstimates is a set of fee tiers
+type FeeEstimates struct {
+	BaseFee    *hexutil.Big  `json:"baseFeePerGas"`
+	Slow       FeeTier       `json:"slow"`
+	Standard   FeeTier       `json:"standard"`
+	Fast       FeeTier       `json:"fast"`
+	Congestion FeeCongestion `json:"congestion"`
+}
+
+func toRPCFeeTier(t gasprice.FeeTier, baseFee *big.Int) FeeTier {
+	return FeeTier{
+		Certainty:            float64(t.Certainty) / gasprice.DecimalUnit,
+		MaxPriorityFeePerGas: (*hexutil.Big)(new(big.Int).Set(t.Tip)),
+		GasPrice:             (*hexutil.Big)(new(big.Int).Add(t.Tip, baseFee)),
+		GasAhead:             hexutil.Uint64(t.GasAhead),
+		ExpectedDelay:        t.InclusionDelay.Seconds(),
+	}
+}
+
+// FeeEstimates returns slow, standard and fast fee suggestions.
+// Each tier carries the certainty it aims at, the expected inclusion delay and the congestion data behind it.
+func (s *PublicFeeAPI) FeeEstimates(ctx context.Context) *FeeEstimates {
+	e := s.b.SuggestFeeEstimates(ctx)
+	c := e.Congestion
+	return &FeeEstimates{
+		BaseFee:  (*hexutil.Big)(e.BaseFee),
+		Slow:     toRPCFeeTier(e.Slow, e.BaseFee),
+		Standard: toRPCFeeTier(e.Standard, e.BaseFee),
+		Fast:     toRPCFeeTier(e.Fast, e.BaseFee),
+		Congestion: FeeCongestion{
+			PendingGas:          hexutil.Uint64(c.PendingGas),
+			GasPowerLeft:        hexutil.Uint64(c.GasPowerLeft),
+			MaxGasPower:         hexutil.Uint64(c.MaxGasPower),
+			GasPowerAllocPerSec: hexutil.Uint64(c.GasPowerAllocPerSec),
+			FreeRatio:           float64(c.FreeRatio) / gasprice.DecimalUnit,
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/utils/signers/internaltx"
+)
+
+// feeHistoryCacheSize is a number of blocks which fee statistics are cached for eth_feeHistory
+const feeHistoryCacheSize = 2048
+
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+// blockFees is a fee statistics of a block, which is independent of the requested percentiles
+type blockFees struct {
+	baseFee      *big.Int
+	gasUsedRatio float64
+	// txs are sorted by the effective tip in ascending order
+	txs []txGasAndReward
+	// txsGasUsed is a total gas used by txs
+	txsGasUsed uint64
+}
+
+// rewards returns the effective tips at the given percentiles of the gas used in a block,
+// the percentiles are expected to be sorted in ascending order
+func (f *blockFees) rewards(percentiles []float64) []*big.Int {
+	rewards := make([]*big.Int, len(percentiles))
+	if len(f.txs) == 0 {
+		// return an all zero rewards for empty blocks
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+		}
+		return rewards
+	}
+	txIndex := 0
+	sumGasUsed := f.txs[0].gasUsed
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(f.txsGasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(f.txs)-1 {
+			txIndex++
+			sumGasUsed += f.txs[txIndex].gasUsed
+		}
+		rewards[i] = new(big.Int).Set(f.txs[txIndex].reward)
+	}
+	return rewards
+}
+
+// maxBlockGas returns the block gas limit of the epoch the block belongs to
+func (s *PublicEthereumAPI) maxBlockGas(ctx context.Context, epoch idx.Epoch) (uint64, error) {
+	_, es, err := s.b.GetEpochBlockState(ctx, rpc.BlockNumber(epoch))
+	if err != nil {
+		return 0, err
+	}
+	if es == nil {
+		// epoch history isn't available, fall back to the current rules
+		_, es, err = s.b.GetEpochBlockState(ctx, rpc.PendingBlockNumber)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return es.Rules.Blocks.MaxBlockGas, nil
+}
+
+// blockFees calculates the fee statistics of a block, nil is returned if the block isn't found
+func (s *PublicEthereumAPI) blockFees(ctx context.Context, n idx.Block) (*blockFees, error) {
+	if cached, ok := s.feeHistory.Get(n); ok {
+		return cached.(*blockFees), nil
+	}
+	block, err := s.b.BlockByNumber(ctx, rpc.BlockNumber(n))
+	if block == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := s.b.GetReceiptsByNumber(ctx, rpc.BlockNumber(n))
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("block %d has %d transactions but %d receipts", n, len(block.Transactions), len(receipts))
+	}
+	maxBlockGas, err := s.maxBlockGas(ctx, hash.Event(block.Hash).Epoch())
+	if err != nil {
+		return nil, err
+	}
+
+	f := &blockFees{
+		baseFee: new(big.Int),
+		txs:     make([]txGasAndReward, 0, len(block.Transactions)),
+	}
+	if block.BaseFee != nil {
+		f.baseFee.Set(block.BaseFee)
+	}
+	if maxBlockGas != 0 {
+		f.gasUsedRatio = float64(block.GasUsed) / float64(maxBlockGas)
+	}
+	for i, tx := range block.Transactions {
+		// internal transactions pay no fees, don't let them skew the statistics
+		if internaltx.IsInternal(tx) {
+			continue
+		}
+		reward := tx.EffectiveGasTipValue(f.baseFee)
+		if reward.Sign() < 0 {
+			reward.SetUint64(0)
+		}
+		f.txs = append(f.txs, txGasAndReward{
+			gasUsed: receipts[i].GasUsed,
+			reward:  reward,
+		})
+		f.txsGasUsed += receipts[i].GasUsed
+	}
+	sort.SliceStable(f.txs, func(i, j int) bool {
+		return f.txs[i].reward.Cmp(f.txs[j].reward) < 0
+	})
+
+	s.feeHistory.Add(n, f)
+	return f, nil
+}
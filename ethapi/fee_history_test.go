@@ -0,0 +1,34 @@
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFeesRewards(t *testing.T) {
+	require := require.New(t)
+
+	toStrings := func(v []*big.Int) []string {
+		res := make([]string, len(v))
+		for i, b := range v {
+			res[i] = b.String()
+		}
+		return res
+	}
+
+	empty := &blockFees{}
+	require.Equal([]string{"0", "0"}, toStrings(empty.rewards([]float64{10, 90})))
+
+	f := &blockFees{
+		txs: []txGasAndReward{
+			{gasUsed: 21000, reward: big.NewInt(1)},
+			{gasUsed: 100000, reward: big.NewInt(2)},
+			{gasUsed: 21000, reward: big.NewInt(3)},
+			{gasUsed: 58000, reward: big.NewInt(4)},
+		},
+		txsGasUsed: 200000,
+	}
+	require.Equal([]string{"1", "1", "2", "2", "3", "4", "4"}, toStrings(f.rewards([]float64{0, 10.5, 10.6, 60.5, 71, 71.1, 100})))
+}
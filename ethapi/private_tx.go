@@ -0,0 +1,50 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// PrivateTransactionArgs represents the arguments to submit a private transaction
+type PrivateTransactionArgs struct {
+	Tx hexutil.Bytes `json:"tx"`
+	// MaxBlockNumber is the last block the transaction may be included into, the node's default is used if omitted
+	MaxBlockNumber *hexutil.Uint64 `json:"maxBlockNumber"`
+}
+
+// SendPrivateTransaction will add the signed transaction to the transaction pool without announcing it to the public peers.
+// The transaction is emitted by this node or forwarded to the trusted peers only, and it's dropped if it isn't included
+// until the max block number.
+func (s *PublicTransactionPoolAPI) SendPrivateTransaction(ctx context.Context, args PrivateTransactionArgs) (common.Hash, error) {
+	if len(args.Tx) == 0 {
+		return common.Hash{}, errors.New("missing transaction")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(args.Tx); err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkTxFee(tx.GasPrice(), tx.Gas(), s.b.RPCTxFeeCap()); err != nil {
+		return common.Hash{}, err
+	}
+	if !s.b.UnprotectedAllowed() && !tx.Protected() {
+		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	var maxBlock idx.Block
+	if args.MaxBlockNumber != nil {
+		maxBlock = idx.Block(*args.MaxBlockNumber)
+		if maxBlock == 0 {
+			return common.Hash{}, errors.New("max block number has to be positive")
+		}
+	}
+	if err := s.b.SendPrivateTx(ctx, tx, maxBlock); err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Submitted private transaction", "hash", tx.Hash().Hex(), "nonce", tx.Nonce(), "maxBlock", maxBlock)
+	return tx.Hash(), nil
+}
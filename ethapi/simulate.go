@@ -0,0 +1,396 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/state"
+	"github.com/mrmikeo/Xpense/opera"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single eth_simulateV1 request.
+	maxSimulateBlocks = 256
+
+	// errCodeVMError is the JSON error code of a call which failed in the EVM
+	// for a reason other than revert.
+	errCodeVMError = -32015
+)
+
+var (
+	errSimulateNoBlocks       = errors.New("empty input")
+	errSimulateTooManyBlocks  = fmt.Errorf("too many blocks (max %d)", maxSimulateBlocks)
+	errSimulateGasCapExceeded = errors.New("gas cap of the simulation exceeded")
+)
+
+// BlockOverrides is a set of header fields to override in a simulated block.
+type BlockOverrides struct {
+	Number        *hexutil.Big    `json:"number"`
+	Time          *hexutil.Uint64 `json:"time"`
+	GasLimit      *hexutil.Uint64 `json:"gasLimit"`
+	FeeRecipient  *common.Address `json:"feeRecipient"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+}
+
+// simBlock is a batch of calls to be simulated sequentially in one block.
+type simBlock struct {
+	BlockOverrides *BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *StateOverride    `json:"stateOverrides"`
+	Calls          []TransactionArgs `json:"calls"`
+}
+
+// simOpts are the inputs to eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls []simBlock `json:"blockStateCalls"`
+	Validation      bool       `json:"validation"`
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+// callError describes the failure of a simulated call.
+type callError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// SimulateV1 executes a series of blocks, each containing a batch of calls, on top
+// of the state of the given block. The state changes made by every call are visible
+// to all the calls which follow it, including the calls of the subsequent blocks.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *PublicBlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, errSimulateNoBlocks
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, errSimulateTooManyBlocks
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	defer func(start time.Time) { log.Debug("Executing EVM simulation finished", "runtime", time.Since(start)) }(time.Now())
+
+	statedb, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	defer statedb.Release()
+
+	// Setup context so the whole simulation may be cancelled
+	// once the eth_call timeout is reached.
+	timeout := s.b.RPCEVMTimeout()
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	gasCap := s.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64 / 2
+	}
+	sim := &simulator{
+		b:        s.b,
+		state:    statedb,
+		base:     base,
+		gasCap:   gasCap,
+		validate: opts.Validation,
+		timeout:  timeout,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
+// simulator is a stateful object that simulates a series of blocks.
+type simulator struct {
+	b        Backend
+	state    state.StateDB
+	base     *evmcore.EvmHeader
+	gasCap   uint64 // gas left for the rest of the simulation
+	validate bool
+	timeout  time.Duration
+}
+
+// execute runs the simulation of a series of blocks.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[string]interface{}, error) {
+	headers, err := sim.makeHeaders(blocks)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]map[string]interface{}, len(blocks))
+	for bi, block := range blocks {
+		header := headers[bi]
+		precompiles := newPrecompileSet(sim.b.ChainConfig().Rules(header.Number))
+		if err := block.StateOverrides.apply(sim.state, precompiles); err != nil {
+			return nil, err
+		}
+		calls, err := sim.processBlock(ctx, header, block.Calls, precompiles)
+		if err != nil {
+			return nil, err
+		}
+		fields := RPCMarshalHeader(header, extBlockApi{receiptsRoot: types.EmptyRootHash})
+		fields["calls"] = calls
+		results[bi] = fields
+	}
+	return results, nil
+}
+
+// processBlock executes the calls of a simulated block one after another.
+func (sim *simulator) processBlock(ctx context.Context, header *evmcore.EvmHeader, calls []TransactionArgs, precompiles *precompileSet) ([]simCallResult, error) {
+	var (
+		gp      = new(evmcore.GasPool).AddGas(header.GasLimit)
+		results = make([]simCallResult, len(calls))
+	)
+	vmConfig := opera.DefaultVMConfig
+	vmConfig.StatePrecompiles = precompiles.stateful
+	vmConfig.NoBaseFee = !sim.validate
+
+	for i, call := range calls {
+		msg, err := sim.toMessage(&call, header, gp.Gas())
+		if err != nil {
+			return nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		txHash := simulatedTxHash(header, i)
+		sim.state.Prepare(txHash, i)
+
+		evm, vmError, err := sim.b.GetEVM(ctx, msg, sim.state, header, &vmConfig)
+		if err != nil {
+			return nil, err
+		}
+		// Wait for the context to be done and cancel the evm. Even if the
+		// EVM has finished, cancelling may be done (repeatedly)
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+
+		result, err := evmcore.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		if err := sim.state.Error(); err != nil {
+			return nil, fmt.Errorf("StateDB error: %w", err)
+		}
+		// If the timer caused an abort, return an appropriate error message
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", sim.timeout)
+		}
+
+		logs := sim.state.GetLogs(txHash, header.Hash)
+		if logs == nil {
+			logs = []*types.Log{}
+		}
+		sim.state.Finalise()
+
+		header.GasUsed += result.UsedGas
+		sim.gasCap -= result.UsedGas
+
+		res := simCallResult{
+			ReturnValue: result.Return(),
+			Logs:        logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+			Status:      hexutil.Uint64(types.ReceiptStatusSuccessful),
+		}
+		if result.Failed() {
+			res.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if len(result.Revert()) > 0 {
+				revertErr := newRevertError(result)
+				res.ReturnValue = result.Revert()
+				res.Error = &callError{Message: revertErr.Error(), Code: revertErr.ErrorCode(), Data: revertErr.reason}
+			} else {
+				res.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// toMessage converts the call arguments to a message, filling in the gas limit
+// from what is left in the block and in the simulation gas cap.
+func (sim *simulator) toMessage(args *TransactionArgs, header *evmcore.EvmHeader, blockGasLeft uint64) (types.Message, error) {
+	gasLeft := sim.gasCap
+	if blockGasLeft < gasLeft {
+		gasLeft = blockGasLeft
+	}
+	if gasLeft == 0 {
+		return types.Message{}, errSimulateGasCapExceeded
+	}
+	if args.Gas == nil {
+		gas := hexutil.Uint64(gasLeft)
+		args.Gas = &gas
+	} else if uint64(*args.Gas) > gasLeft {
+		return types.Message{}, fmt.Errorf("%w: call gas %d, left %d", errSimulateGasCapExceeded, uint64(*args.Gas), gasLeft)
+	}
+	msg, err := args.ToMessage(0, header.BaseFee)
+	if err != nil {
+		return types.Message{}, err
+	}
+	if !sim.validate {
+		return msg, nil
+	}
+	// In the validation mode, the message is checked the same way as a transaction
+	nonce := sim.state.GetNonce(msg.From())
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
+	return types.NewMessage(msg.From(), msg.To(), nonce, msg.Value(), msg.Gas(), msg.GasPrice(), msg.GasFeeCap(), msg.GasTipCap(), msg.Data(), msg.AccessList(), false), nil
+}
+
+// makeHeaders creates the headers of the simulated blocks, applying the block overrides.
+func (sim *simulator) makeHeaders(blocks []simBlock) ([]*evmcore.EvmHeader, error) {
+	var (
+		headers = make([]*evmcore.EvmHeader, len(blocks))
+		parent  = sim.base
+	)
+	for bi, block := range blocks {
+		overrides := block.BlockOverrides
+		if overrides == nil {
+			overrides = &BlockOverrides{}
+		}
+		number := new(big.Int).Add(parent.Number, common.Big1)
+		if overrides.Number != nil {
+			number = new(big.Int).Set(overrides.Number.ToInt())
+			if number.Cmp(parent.Number) <= 0 {
+				return nil, fmt.Errorf("block numbers must be in order: %d <= %d", number, parent.Number)
+			}
+		}
+		blockTime := parent.Time + inter.Timestamp(time.Second)
+		if overrides.Time != nil {
+			blockTime = inter.FromUnix(int64(*overrides.Time))
+			if blockTime <= parent.Time {
+				return nil, fmt.Errorf("block timestamps must be in order: %d <= %d", blockTime.Unix(), parent.Time.Unix())
+			}
+		}
+		header := &evmcore.EvmHeader{
+			Number:     number,
+			ParentHash: parent.Hash,
+			Root:       sim.base.Root,
+			TxHash:     types.EmptyRootHash,
+			Time:       blockTime,
+			Coinbase:   sim.base.Coinbase,
+			GasLimit:   sim.base.GasLimit,
+		}
+		if sim.base.BaseFee != nil {
+			header.BaseFee = new(big.Int).Set(sim.base.BaseFee)
+		}
+		if overrides.GasLimit != nil {
+			header.GasLimit = uint64(*overrides.GasLimit)
+		}
+		if overrides.FeeRecipient != nil {
+			header.Coinbase = *overrides.FeeRecipient
+		}
+		if overrides.BaseFeePerGas != nil {
+			header.BaseFee = new(big.Int).Set(overrides.BaseFeePerGas.ToInt())
+		}
+		header.Hash = simulatedBlockHash(header)
+		headers[bi] = header
+		parent = header
+	}
+	return headers, nil
+}
+
+// simulatedBlockHash derives a unique hash of a simulated block.
+func simulatedBlockHash(header *evmcore.EvmHeader) common.Hash {
+	var t [8]byte
+	binary.BigEndian.PutUint64(t[:], uint64(header.Time))
+	return crypto.Keccak256Hash(header.ParentHash.Bytes(), header.Number.Bytes(), t[:])
+}
+
+// simulatedTxHash derives a unique hash of a simulated call.
+func simulatedTxHash(header *evmcore.EvmHeader, index int) common.Hash {
+	var i [8]byte
+	binary.BigEndian.PutUint64(i[:], uint64(index))
+	return crypto.Keccak256Hash(header.Hash.Bytes(), i[:])
+}
+
+// precompileSet is the set of precompiled contracts active in a simulated block.
+// Built-in precompiles are hardwired in the EVM, so they may be exposed
+// at another address, but their original address stays active.
+type precompileSet struct {
+	builtin  map[common.Address]vm.PrecompiledContract
+	stateful map[common.Address]vm.PrecompiledStateContract
+}
+
+func newPrecompileSet(rules params.Rules) *precompileSet {
+	var builtin map[common.Address]vm.PrecompiledContract
+	switch {
+	case rules.IsBerlin:
+		builtin = vm.PrecompiledContractsBerlin
+	case rules.IsIstanbul:
+		builtin = vm.PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		builtin = vm.PrecompiledContractsByzantium
+	default:
+		builtin = vm.PrecompiledContractsHomestead
+	}
+	stateful := make(map[common.Address]vm.PrecompiledStateContract, len(opera.DefaultVMConfig.StatePrecompiles))
+	for addr, p := range opera.DefaultVMConfig.StatePrecompiles {
+		stateful[addr] = p
+	}
+	return &precompileSet{
+		builtin:  builtin,
+		stateful: stateful,
+	}
+}
+
+func (ps *precompileSet) isBuiltin(addr common.Address) bool {
+	_, ok := ps.builtin[addr]
+	return ok
+}
+
+// move relocates the precompiled contract from one address to another.
+func (ps *precompileSet) move(from, to common.Address) error {
+	if _, ok := ps.stateful[to]; ok || ps.isBuiltin(to) {
+		return fmt.Errorf("account %s is already a precompile", to.Hex())
+	}
+	if p, ok := ps.stateful[from]; ok {
+		delete(ps.stateful, from)
+		ps.stateful[to] = p
+		return nil
+	}
+	if p, ok := ps.builtin[from]; ok {
+		ps.stateful[to] = builtinPrecompile{p}
+		return nil
+	}
+	return fmt.Errorf("account %s is not a precompile", from.Hex())
+}
+
+// builtinPrecompile adapts a built-in precompiled contract to be served from another address.
+type builtinPrecompile struct {
+	vm.PrecompiledContract
+}
+
+func (p builtinPrecompile) Run(_ vm.StateDB, _ vm.BlockContext, _ vm.TxContext, _ common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+	return vm.RunPrecompiledContract(p.PrecompiledContract, input, suppliedGas)
+}
@@ -0,0 +1,94 @@
+package ethapi
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+// RPCSkippedTx is a transaction confirmed by a block, but skipped during the block execution
+type RPCSkippedTx struct {
+	Hash common.Hash `json:"hash"`
+	// Index is the position of the transaction among all the confirmed transactions of the block, including the skipped ones
+	Index  hexutil.Uint `json:"index"`
+	Reason string       `json:"reason"`
+}
+
+// RPCSkippedTxs are the skipped transactions of a block
+type RPCSkippedTxs struct {
+	BlockNumber  hexutil.Uint64  `json:"blockNumber"`
+	BlockHash    common.Hash     `json:"blockHash"`
+	Transactions []*RPCSkippedTx `json:"transactions"`
+}
+
+// RPCMarshalSkippedTxs converts the skipped transactions into the RPC output
+func RPCMarshalSkippedTxs(txs []evmcore.SkippedTx) []*RPCSkippedTx {
+	res := make([]*RPCSkippedTx, len(txs))
+	for i, tx := range txs {
+		res[i] = &RPCSkippedTx{
+			Hash:   tx.Hash,
+			Index:  hexutil.Uint(tx.Index),
+			Reason: tx.Reason,
+		}
+	}
+	return res
+}
+
+// PublicSkippedTxsAPI provides an API to access the transactions skipped during the blocks execution.
+type PublicSkippedTxsAPI struct {
+	b Backend
+}
+
+// NewPublicSkippedTxsAPI creates a new skipped transactions API.
+func NewPublicSkippedTxsAPI(b Backend) *PublicSkippedTxsAPI {
+	return &PublicSkippedTxsAPI{b}
+}
+
+// GetSkippedTransactions returns the transactions confirmed by the block, but skipped during its execution
+// (e.g. due to a wrong nonce, insufficient balance or gas limit), and the reasons of the skips.
+// The reasons are stored only for the blocks processed by this node.
+func (s *PublicSkippedTxsAPI) GetSkippedTransactions(ctx context.Context, blockNr rpc.BlockNumber) ([]*RPCSkippedTx, error) {
+	txs, err := s.b.GetSkippedTxs(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return RPCMarshalSkippedTxs(txs), nil
+}
+
+// SkippedTransactions creates a subscription that is triggered each time a block with skipped transactions is processed.
+func (s *PublicSkippedTxsAPI) SkippedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		skipped := make(chan evmcore.SkippedTxsNotify, 128)
+		skippedSub := s.b.SubscribeSkippedTxsNotify(skipped)
+
+		for {
+			select {
+			case ev := <-skipped:
+				_ = notifier.Notify(rpcSub.ID, &RPCSkippedTxs{
+					BlockNumber:  hexutil.Uint64(ev.Block.NumberU64()),
+					BlockHash:    ev.Block.Hash,
+					Transactions: RPCMarshalSkippedTxs(ev.Txs),
+				})
+			case <-rpcSub.Err():
+				skippedSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				skippedSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -0,0 +1,190 @@
+package ethapi
+
+import (
+	"context"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+// Statuses of a transaction lifecycle
+const (
+	TxStatusUnknown = "unknown"
+	TxStatusPending = "pending"
+	TxStatusDropped = "dropped"
+	TxStatusInEvent = "inEvent"
+	TxStatusInBlock = "inBlock"
+	TxStatusSkipped = "skipped"
+)
+
+// TxLifecycle is the lifecycle of a transaction as observed by this node.
+// Zero fields mean the corresponding step isn't observed.
+type TxLifecycle struct {
+	Hash common.Hash
+	// PoolTime is the time the transaction was admitted into the txpool
+	PoolTime inter.Timestamp
+	// Dropped is true if the transaction was dropped from the txpool before being included
+	Dropped bool
+
+	// Event is the first event which included the transaction
+	Event        hash.Event
+	EventCreator idx.ValidatorID
+	EventTime    inter.Timestamp
+
+	// Block is the block which confirmed the transaction
+	Block     idx.Block
+	Atropos   hash.Event
+	BlockTime inter.Timestamp
+	// Skipped is true if the transaction was confirmed, but skipped during the block execution
+	Skipped bool
+
+	// Reason is the reason of the transaction drop or skip
+	Reason string
+}
+
+// Status returns the latest step of the transaction lifecycle
+func (l *TxLifecycle) Status() string {
+	switch {
+	case l.Block != 0 && l.Skipped:
+		return TxStatusSkipped
+	case l.Block != 0:
+		return TxStatusInBlock
+	case l.Event != hash.ZeroEvent:
+		return TxStatusInEvent
+	case l.Dropped:
+		return TxStatusDropped
+	case l.PoolTime != 0:
+		return TxStatusPending
+	}
+	return TxStatusUnknown
+}
+
+// RPCTxStatus is the lifecycle status of a transaction
+type RPCTxStatus struct {
+	Hash common.Hash `json:"hash"`
+	// Status is one of "unknown", "pending", "dropped", "inEvent", "inBlock" and "skipped"
+	Status       string          `json:"status"`
+	PoolTime     *hexutil.Uint64 `json:"poolTime,omitempty"`
+	Event        hexutil.Bytes   `json:"event,omitempty"`
+	EventCreator *hexutil.Uint64 `json:"eventCreator,omitempty"`
+	EventTime    *hexutil.Uint64 `json:"eventTime,omitempty"`
+	Atropos      hexutil.Bytes   `json:"atropos,omitempty"`
+	BlockNumber  *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	BlockTime    *hexutil.Uint64 `json:"blockTime,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+}
+
+func optionalUint64(v uint64) *hexutil.Uint64 {
+	if v == 0 {
+		return nil
+	}
+	res := hexutil.Uint64(v)
+	return &res
+}
+
+// RPCMarshalTxLifecycle converts the transaction lifecycle into the RPC status
+func RPCMarshalTxLifecycle(l *TxLifecycle) *RPCTxStatus {
+	res := &RPCTxStatus{
+		Hash:         l.Hash,
+		Status:       l.Status(),
+		PoolTime:     optionalUint64(uint64(l.PoolTime)),
+		EventCreator: optionalUint64(uint64(l.EventCreator)),
+		EventTime:    optionalUint64(uint64(l.EventTime)),
+		BlockNumber:  optionalUint64(uint64(l.Block)),
+		BlockTime:    optionalUint64(uint64(l.BlockTime)),
+		Reason:       l.Reason,
+	}
+	if l.Event != hash.ZeroEvent {
+		res.Event = l.Event.Bytes()
+	}
+	if l.Atropos != hash.ZeroEvent {
+		res.Atropos = l.Atropos.Bytes()
+	}
+	return res
+}
+
+// PublicTxStatusAPI provides an API to access the lifecycle of the transactions.
+type PublicTxStatusAPI struct {
+	b Backend
+}
+
+// NewPublicTxStatusAPI creates a new transaction status API.
+func NewPublicTxStatusAPI(b Backend) *PublicTxStatusAPI {
+	return &PublicTxStatusAPI{b}
+}
+
+// GetTransactionStatus returns the lifecycle status of the transaction: its txpool admission,
+// the event which included it, the block which confirmed it, or the reason it was dropped or skipped.
+func (s *PublicTxStatusAPI) GetTransactionStatus(ctx context.Context, txHash common.Hash) (*RPCTxStatus, error) {
+	l := s.b.GetTxLifecycle(ctx, txHash)
+	if l == nil {
+		l = &TxLifecycle{Hash: txHash}
+	}
+	// lifecycles are tracked for the recent transactions only, so complete it from the index and the txpool
+	if l.Block == 0 {
+		tx, blockNumber, _, err := s.b.GetTransaction(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		if tx != nil {
+			header, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+			if err != nil {
+				return nil, err
+			}
+			if header != nil {
+				l.Block = idx.Block(blockNumber)
+				l.Atropos = hash.Event(header.Hash)
+				l.BlockTime = header.Time
+				l.Skipped = false
+				l.Reason = ""
+			}
+		}
+	}
+	if l.Status() == TxStatusUnknown && s.b.GetPoolTransaction(txHash) != nil {
+		// the admission time is unknown
+		return &RPCTxStatus{Hash: txHash, Status: TxStatusPending}, nil
+	}
+	return RPCMarshalTxLifecycle(l), nil
+}
+
+// TransactionStatus creates a subscription that is triggered each time the lifecycle status of the transaction changes.
+func (s *PublicTxStatusAPI) TransactionStatus(ctx context.Context, txHash common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		updates := make(chan TxLifecycle, 16)
+		updatesSub := s.b.SubscribeTxLifecycleNotify(txHash, updates)
+
+		for {
+			select {
+			case l := <-updates:
+				_ = notifier.Notify(rpcSub.ID, RPCMarshalTxLifecycle(&l))
+			case err := <-updatesSub.Err():
+				// the updates are dropped if the subscriber is too slow
+				if err != nil {
+					log.Debug("Transaction status subscription failed", "hash", txHash, "err", err)
+				}
+				return
+			case <-rpcSub.Err():
+				updatesSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				updatesSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"math/big"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 
@@ -86,6 +89,107 @@ func (s *PublicTxTraceAPI) Get(ctx context.Context, hash common.Hash, traceIndex
 	return s.traceTxHash(ctx, hash, &traceIndex)
 }
 
+// TraceResults is the result of trace_replayTransaction for a single transaction
+type TraceResults struct {
+	Output          hexutil.Bytes         `json:"output"`
+	StateDiff       txtrace.StateDiff     `json:"stateDiff"`
+	Trace           []txtrace.ActionTrace `json:"trace"`
+	VmTrace         *txtrace.VMTrace      `json:"vmTrace"`
+	TransactionHash *common.Hash          `json:"transactionHash,omitempty"`
+}
+
+// replayTraceTypes holds kinds of traces requested by trace_replay* calls
+type replayTraceTypes struct {
+	trace     bool
+	stateDiff bool
+	vmTrace   bool
+}
+
+// parseReplayTraceTypes parses trace types requested by trace_replay* calls
+func parseReplayTraceTypes(traceTypes []string) (replayTraceTypes, error) {
+	var res replayTraceTypes
+	for _, traceType := range traceTypes {
+		switch traceType {
+		case "trace":
+			res.trace = true
+		case "stateDiff":
+			res.stateDiff = true
+		case "vmTrace":
+			res.vmTrace = true
+		default:
+			return res, fmt.Errorf("invalid trace type %q, expected trace, stateDiff or vmTrace", traceType)
+		}
+	}
+	return res, nil
+}
+
+// ReplayTransaction - trace_replayTransaction function replays transaction and returns requested traces
+func (s *PublicTxTraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*TraceResults, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing trace_replayTransaction call finished", "txHash", hash.String(), "runtime", time.Since(start))
+	}(time.Now())
+
+	replayTypes, err := parseReplayTraceTypes(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	tx, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get transaction %s: %v", hash.String(), err)
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", hash.String())
+	}
+	blkNr := rpc.BlockNumber(blockNumber)
+	block, err := s.b.BlockByNumber(ctx, blkNr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get block from db %v, error:%v", blkNr, err.Error())
+	}
+
+	results, err := s.replayBlockResults(ctx, block, &hash, replayTypes)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("transaction %s not found in block %v", hash.String(), blkNr)
+	}
+	// transaction hash is implied by the request
+	results[0].TransactionHash = nil
+	return results[0], nil
+}
+
+// ReplayBlockTransactions - trace_replayBlockTransactions function replays all transactions
+// in given block and returns requested traces
+func (s *PublicTxTraceAPI) ReplayBlockTransactions(ctx context.Context, numberOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*TraceResults, error) {
+	replayTypes, err := parseReplayTraceTypes(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *evmcore.EvmBlock
+	if blockHash, ok := numberOrHash.Hash(); ok {
+		block, err = s.b.BlockByHash(ctx, blockHash)
+	} else {
+		blockNumber, _ := numberOrHash.Number()
+		if blockNumber == rpc.PendingBlockNumber {
+			return nil, fmt.Errorf("cannot trace pending block")
+		}
+		block, err = s.b.BlockByNumber(ctx, blockNumber)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get block from db got %v", err.Error())
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+
+	defer func(start time.Time) {
+		log.Debug("Executing trace_replayBlockTransactions call finished", "block", block.NumberU64(), "runtime", time.Since(start))
+	}(time.Now())
+
+	return s.replayBlockResults(ctx, block, nil, replayTypes)
+}
+
 // traceTxHash looks for a block of this transaction hash and trace it
 func (s *PublicTxTraceAPI) traceTxHash(ctx context.Context, hash common.Hash, traceIndex *[]hexutil.Uint) (*[]txtrace.ActionTrace, error) {
 	tx, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
@@ -155,7 +259,7 @@ func (s *PublicTxTraceAPI) replayBlock(ctx context.Context, block *evmcore.EvmBl
 				return nil, fmt.Errorf("no receipt found for transaction %s", tx.Hash().String())
 			}
 
-			txTraces, err := s.traceTx(ctx, s.b, block.Header(), msg, state, block, tx, uint64(receipts[i].TransactionIndex), receipts[i].Status, receipts[i].GasUsed)
+			txTraces, _, err := s.traceTx(ctx, s.b, block.Header(), msg, state, block, tx, uint64(receipts[i].TransactionIndex), receipts[i].Status, receipts[i].GasUsed, nil)
 			if err != nil {
 				return nil, fmt.Errorf("cannot get transaction trace for transaction %s, error %s", tx.Hash().String(), err)
 			} else {
@@ -168,45 +272,11 @@ func (s *PublicTxTraceAPI) replayBlock(ctx context.Context, block *evmcore.EvmBl
 			}
 
 		} else {
-
-			// Replay transaction without tracing to prepare state for next transaction
-			log.Debug("Replaying transaction without trace", "txHash", tx.Hash().String())
-			msg, err := evmcore.TxAsMessage(tx, signer, block.BaseFee)
-			if err != nil {
-				return nil, fmt.Errorf("cannot get message from transaction %s, error %s", tx.Hash().String(), err)
-			}
-
-			state.Prepare(tx.Hash(), i)
-			vmConfig := opera.DefaultVMConfig
-			vmConfig.NoBaseFee = true
-			vmConfig.Debug = false
-			vmConfig.Tracer = nil
-
-			vmenv, _, err := s.b.GetEVM(ctx, msg, state, block.Header(), &vmConfig)
-			if err != nil {
-				return nil, fmt.Errorf("cannot initialize vm for transaction %s, error: %s", tx.Hash().String(), err.Error())
-			}
-
-			res, err := evmcore.ApplyMessage(vmenv, msg, new(evmcore.GasPool).AddGas(msg.Gas()))
-			failed := false
-			if err != nil {
-				failed = true
-				log.Error("Cannot replay transaction", "txHash", tx.Hash().String(), "err", err.Error())
-			}
-			if err := state.Error(); err != nil {
-				return nil, fmt.Errorf("StateDB error when replaying tx %s: %w", tx.Hash().String(), err)
-			}
-
-			if res != nil && res.Err != nil {
-				failed = true
-				log.Debug("Error replaying transaction", "txHash", tx.Hash().String(), "err", res.Err.Error())
+			if len(receipts) <= i || receipts[i] == nil {
+				return nil, fmt.Errorf("no receipt found for transaction %s", tx.Hash().String())
 			}
-
-			state.Finalise()
-
-			// Check correct replay status according to receipt data
-			if (failed && receipts[i].Status == 1) || (!failed && receipts[i].Status == 0) {
-				return nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
+			if err := s.replayTxWithoutTrace(ctx, state, block, tx, i, signer, receipts[i].Status); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -223,17 +293,201 @@ func (s *PublicTxTraceAPI) replayBlock(ctx context.Context, block *evmcore.EvmBl
 	return &callTrace.Actions, nil
 }
 
-// traceTx trace transaction with EVM replay and return processed result
+// replayBlockResults replays block and returns requested traces of the transaction
+// specified by txHash, or of all transactions in the block when txHash is nil
+func (s *PublicTxTraceAPI) replayBlockResults(ctx context.Context, block *evmcore.EvmBlock, txHash *common.Hash, replayTypes replayTraceTypes) ([]*TraceResults, error) {
+	if block == nil {
+		return nil, fmt.Errorf("invalid block for tracing")
+	}
+	if block.NumberU64() == 0 {
+		return nil, fmt.Errorf("genesis block is not traceable")
+	}
+
+	blockNumber := block.Number.Int64()
+	parentBlockNr := rpc.BlockNumber(blockNumber - 1)
+	signer := gsignercache.Wrap(types.MakeSigner(s.b.ChainConfig(), block.Number))
+
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHash{BlockNumber: &parentBlockNr})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get state for block %v, error: %v", block.NumberU64(), err.Error())
+	}
+	defer state.Release()
+
+	receipts, err := s.b.GetReceiptsByNumber(ctx, rpc.BlockNumber(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get receipts for block %v, error: %v", block.NumberU64(), err.Error())
+	}
+
+	results := make([]*TraceResults, 0, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		if len(receipts) <= i || receipts[i] == nil {
+			return nil, fmt.Errorf("no receipt found for transaction %s", tx.Hash().String())
+		}
+
+		if txHash != nil && *txHash != tx.Hash() {
+			if err := s.replayTxWithoutTrace(ctx, state, block, tx, i, signer, receipts[i].Status); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		msg, err := evmcore.TxAsMessage(tx, signer, block.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get message from transaction %s, error %s", tx.Hash().String(), err)
+		}
+		res, err := s.traceTxResults(ctx, block, msg, state, tx, receipts[i], replayTypes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get transaction trace for transaction %s, error %s", tx.Hash().String(), err)
+		}
+		results = append(results, res)
+
+		// already replayed specified transaction so end loop
+		if txHash != nil {
+			break
+		}
+	}
+	return results, nil
+}
+
+// traceTxResults replays transaction with tracers of the requested trace types
+func (s *PublicTxTraceAPI) traceTxResults(ctx context.Context, block *evmcore.EvmBlock, msg types.Message,
+	statedb state.StateDB, tx *types.Transaction, receipt *types.Receipt, replayTypes replayTraceTypes) (*TraceResults, error) {
+
+	tracers := newReplayTracers(statedb, replayTypes)
+	defer tracers.release()
+
+	traces, output, err := s.traceTx(ctx, s.b, block.Header(), msg, statedb, block, tx, uint64(receipt.TransactionIndex), receipt.Status, receipt.GasUsed, tracers.tracer())
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := tx.Hash()
+	res := &TraceResults{
+		Output:          output,
+		Trace:           make([]txtrace.ActionTrace, 0),
+		TransactionHash: &txHash,
+	}
+	if replayTypes.trace && traces != nil {
+		res.Trace = *traces
+	}
+	tracers.fill(res, statedb)
+	return res, nil
+}
+
+// replayTracers holds tracers of the requested stateDiff and vmTrace trace types
+type replayTracers struct {
+	diffLogger *txtrace.StateDiffLogger
+	vmLogger   *txtrace.VMTraceLogger
+	preTxState state.StateDB
+}
+
+// newReplayTracers creates tracers for the requested trace types,
+// the state must be in the state before the traced transaction
+func newReplayTracers(statedb state.StateDB, replayTypes replayTraceTypes) *replayTracers {
+	t := &replayTracers{}
+	if replayTypes.stateDiff {
+		// keep the state before the transaction to compare it with the resulting one
+		t.preTxState = statedb.Copy()
+		t.diffLogger = txtrace.NewStateDiffLogger()
+	}
+	if replayTypes.vmTrace {
+		t.vmLogger = txtrace.NewVMTraceLogger()
+	}
+	return t
+}
+
+// tracer returns tracer dispatching events to all the tracers or nil if there is none
+func (t *replayTracers) tracer() vm.Tracer {
+	var mux txtrace.MuxTracer
+	if t.diffLogger != nil {
+		mux = append(mux, t.diffLogger)
+	}
+	if t.vmLogger != nil {
+		mux = append(mux, t.vmLogger)
+	}
+	if len(mux) == 0 {
+		return nil
+	}
+	return mux
+}
+
+// fill sets results of the tracers, the state must be in the state after the traced transaction
+func (t *replayTracers) fill(res *TraceResults, statedb state.StateDB) {
+	if t.diffLogger != nil {
+		res.StateDiff = t.diffLogger.GetResult(t.preTxState, statedb)
+	}
+	if t.vmLogger != nil {
+		res.VmTrace = t.vmLogger.GetResult()
+	}
+}
+
+// release releases the state copy held by the tracers
+func (t *replayTracers) release() {
+	if t.preTxState != nil {
+		t.preTxState.Release()
+	}
+}
+
+// replayTxWithoutTrace replays transaction without tracing to prepare state for next transaction
+func (s *PublicTxTraceAPI) replayTxWithoutTrace(ctx context.Context, state state.StateDB, block *evmcore.EvmBlock,
+	tx *types.Transaction, index int, signer types.Signer, status uint64) error {
+
+	log.Debug("Replaying transaction without trace", "txHash", tx.Hash().String())
+	msg, err := evmcore.TxAsMessage(tx, signer, block.BaseFee)
+	if err != nil {
+		return fmt.Errorf("cannot get message from transaction %s, error %s", tx.Hash().String(), err)
+	}
+
+	state.Prepare(tx.Hash(), index)
+	vmConfig := opera.DefaultVMConfig
+	vmConfig.NoBaseFee = true
+	vmConfig.Debug = false
+	vmConfig.Tracer = nil
+
+	vmenv, _, err := s.b.GetEVM(ctx, msg, state, block.Header(), &vmConfig)
+	if err != nil {
+		return fmt.Errorf("cannot initialize vm for transaction %s, error: %s", tx.Hash().String(), err.Error())
+	}
+
+	res, err := evmcore.ApplyMessage(vmenv, msg, new(evmcore.GasPool).AddGas(msg.Gas()))
+	failed := false
+	if err != nil {
+		failed = true
+		log.Error("Cannot replay transaction", "txHash", tx.Hash().String(), "err", err.Error())
+	}
+	if err := state.Error(); err != nil {
+		return fmt.Errorf("StateDB error when replaying tx %s: %w", tx.Hash().String(), err)
+	}
+
+	if res != nil && res.Err != nil {
+		failed = true
+		log.Debug("Error replaying transaction", "txHash", tx.Hash().String(), "err", res.Err.Error())
+	}
+
+	state.Finalise()
+
+	// Check correct replay status according to receipt data
+	if (failed && status == 1) || (!failed && status == 0) {
+		return fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
+	}
+	return nil
+}
+
+// traceTx trace transaction with EVM replay and return processed result and output of the transaction.
+// Extra tracer, when not nil, receives the same events as the transaction tracer.
 func (s *PublicTxTraceAPI) traceTx(
 	ctx context.Context, b Backend, header *evmcore.EvmHeader, msg types.Message,
 	state state.StateDB, block *evmcore.EvmBlock, tx *types.Transaction, index uint64,
-	status uint64, gasUsed uint64) (*[]txtrace.ActionTrace, error) {
+	status uint64, gasUsed uint64, extra vm.Tracer) (*[]txtrace.ActionTrace, []byte, error) {
 
 	// Providing default config with tracer
 	cfg := opera.DefaultVMConfig
 	cfg.Debug = true
 	txTracer := txtrace.NewTraceStructLogger(block, tx, msg, uint(index), gasUsed)
 	cfg.Tracer = txTracer
+	if extra != nil {
+		cfg.Tracer = txtrace.NewMuxTracer(txTracer, extra)
+	}
 	cfg.NoBaseFee = true
 
 	// Setup context so it may be cancelled the call has completed
@@ -251,7 +505,7 @@ func (s *PublicTxTraceAPI) traceTx(
 
 	vmenv, _, err := b.GetEVM(ctx, msg, state, header, &cfg)
 	if err != nil {
-		return nil, fmt.Errorf("cannot initialize vm for transaction %s, error: %s", tx.Hash().String(), err.Error())
+		return nil, nil, fmt.Errorf("cannot initialize vm for transaction %s, error: %s", tx.Hash().String(), err.Error())
 	}
 
 	// Wait for the context to be done and cancel the evm. Even if the
@@ -276,16 +530,16 @@ func (s *PublicTxTraceAPI) traceTx(
 		at = append(at, *errTrace)
 		// check correct replay state
 		if status == 1 {
-			return nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
+			return nil, nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
 		}
-		return &at, nil
+		return &at, nil, nil
 	}
 	if err := state.Error(); err != nil {
-		return nil, fmt.Errorf("StateDB error when replaying tx %s: %w", tx.Hash().String(), err)
+		return nil, nil, fmt.Errorf("StateDB error when replaying tx %s: %w", tx.Hash().String(), err)
 	}
 	// If the timer caused an abort, return an appropriate error message
 	if vmenv.Cancelled() {
-		return nil, fmt.Errorf("EVM was cancelled when replaying tx")
+		return nil, nil, fmt.Errorf("EVM was cancelled when replaying tx")
 	}
 
 	// result.Err is error during EVM execution
@@ -295,20 +549,20 @@ func (s *PublicTxTraceAPI) traceTx(
 			errTrace := txtrace.GetErrorTraceFromMsg(&msg, block.Hash, *block.Number, tx.Hash(), index, result.Err)
 			at := make([]txtrace.ActionTrace, 0)
 			at = append(at, *errTrace)
-			return &at, nil
+			return &at, result.ReturnData, nil
 		}
 		// check correct replay state
 		if status == 1 {
-			return nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
+			return nil, nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
 		}
-		return traceActions, nil
+		return traceActions, result.ReturnData, nil
 	}
 
 	// check correct replay state
 	if status == 0 {
-		return nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
+		return nil, nil, fmt.Errorf("invalid transaction replay state at %s", tx.Hash().String())
 	}
-	return traceActions, nil
+	return traceActions, result.ReturnData, nil
 }
 
 // getEmptyBlockTrace returns trace for empty block
@@ -342,6 +596,11 @@ func (s *PublicTxTraceAPI) Filter(ctx context.Context, args FilterArgs) (json.Ra
 		log.Debug("Executing trace_filter call finished", data...)
 	}(time.Now())
 
+	// answer by lookup when the whole range is covered by the trace index
+	if result, ok, err := filterIndexedBlocks(ctx, s, args); ok {
+		return result, err
+	}
+
 	if args.Count == 0 && args.After == 0 {
 		// count and order of traces doesn't matter so filter blocks in parallel
 		return filterBlocksInParallel(ctx, s, args)
@@ -395,6 +654,94 @@ func filterBlocks(ctx context.Context, s *PublicTxTraceAPI, args FilterArgs) (js
 	return resultBuffer.GetResult()
 }
 
+// Filter specified block range using the trace index, ok is false
+// when the index doesn't cover the whole block range
+func filterIndexedBlocks(ctx context.Context, s *PublicTxTraceAPI, args FilterArgs) (result json.RawMessage, ok bool, err error) {
+	// parse arguments
+	fromBlock, toBlock, fromAddresses, toAddresses := parseFilterArguments(s.b, args)
+	if fromBlock < 1 {
+		// genesis block is not traceable
+		fromBlock = 1
+	}
+	if fromBlock > toBlock {
+		return nil, false, nil
+	}
+	first, last, indexed := s.b.GetTracedBlocksRange(ctx)
+	if !indexed || idx.Block(fromBlock) < first || idx.Block(toBlock) > last {
+		return nil, false, nil
+	}
+
+	// select blocks containing the filtered addresses, all of them have to be matched
+	// so the blocks of one group of addresses are enough
+	var blocks []idx.Block
+	addresses := fromAddresses
+	if len(addresses) == 0 {
+		addresses = toAddresses
+	}
+	if len(addresses) > 0 {
+		unique := make(map[idx.Block]struct{})
+		for addr := range addresses {
+			addrBlocks, err := s.b.GetTraceBlocksByAddress(ctx, addr, idx.Block(fromBlock), idx.Block(toBlock))
+			if err != nil {
+				return nil, true, err
+			}
+			for _, n := range addrBlocks {
+				unique[n] = struct{}{}
+			}
+		}
+		for n := range unique {
+			blocks = append(blocks, n)
+		}
+		sort.Slice(blocks, func(i, j int) bool {
+			return blocks[i] < blocks[j]
+		})
+	} else {
+		for n := idx.Block(fromBlock); n <= idx.Block(toBlock); n++ {
+			blocks = append(blocks, n)
+		}
+	}
+
+	var traceAdded, traceCount uint
+
+	// resultBuffer is buffer for collecting result traces
+	resultBuffer, err := NewJsonResultBuffer()
+	if err != nil {
+		return nil, true, err
+	}
+
+	for _, n := range blocks {
+		traces, err := s.b.GetBlockTraces(ctx, n)
+		if err != nil {
+			return nil, true, err
+		}
+
+		for _, trace := range traces {
+			if trace.Action == nil || !containsAddress(trace.Action.From, trace.Action.To, fromAddresses, toAddresses) {
+				continue
+			}
+			if traceCount >= args.After {
+				err := resultBuffer.AddObject(&trace)
+				if err != nil {
+					return nil, true, err
+				}
+				traceAdded++
+			}
+			if args.Count != 0 && traceAdded >= args.Count {
+				result, err := resultBuffer.GetResult()
+				return result, true, err
+			}
+			traceCount++
+		}
+
+		// when context ended return error
+		if ctx.Err() != nil {
+			return nil, true, ctx.Err()
+		}
+	}
+	result, err = resultBuffer.GetResult()
+	return result, true, err
+}
+
 // Filter specified block range in parallel
 func filterBlocksInParallel(ctx context.Context, s *PublicTxTraceAPI, args FilterArgs) (json.RawMessage, error) {
 
@@ -0,0 +1,180 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/inter/state"
+	"github.com/mrmikeo/Xpense/opera"
+	"github.com/mrmikeo/Xpense/txtrace"
+)
+
+// TraceCallRequest is a single call of trace_callMany,
+// encoded as a pair of the call arguments and requested trace types
+type TraceCallRequest struct {
+	Args       TransactionArgs
+	TraceTypes []string
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (r *TraceCallRequest) UnmarshalJSON(input []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(input, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected [call, traceTypes] pair, got %d elements", len(pair))
+	}
+	if err := json.Unmarshal(pair[0], &r.Args); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &r.TraceTypes)
+}
+
+// Call - trace_call function executes a new message call on top of the given block
+// and returns requested traces without including it into the chain
+func (s *PublicTxTraceAPI) Call(ctx context.Context, args TransactionArgs, traceTypes []string, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (*TraceResults, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing trace_call call finished", "runtime", time.Since(start))
+	}(time.Now())
+
+	results, err := s.traceCalls(ctx, []TraceCallRequest{{Args: args, TraceTypes: traceTypes}}, blockNrOrHash, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CallMany - trace_callMany function executes message calls in sequence, each of them
+// on top of the state left by the previous one, and returns their requested traces
+func (s *PublicTxTraceAPI) CallMany(ctx context.Context, calls []TraceCallRequest, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) ([]*TraceResults, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing trace_callMany call finished", "calls", len(calls), "runtime", time.Since(start))
+	}(time.Now())
+
+	return s.traceCalls(ctx, calls, blockNrOrHash, overrides)
+}
+
+// traceCalls executes calls in sequence on the state of the given block
+func (s *PublicTxTraceAPI) traceCalls(ctx context.Context, calls []TraceCallRequest, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) ([]*TraceResults, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("no calls to trace")
+	}
+	replayTypes := make([]replayTraceTypes, len(calls))
+	for i, call := range calls {
+		var err error
+		if replayTypes[i], err = parseReplayTraceTypes(call.TraceTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	defer statedb.Release()
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
+	}
+
+	// Setup context so it may be cancelled when the calls have completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	results := make([]*TraceResults, 0, len(calls))
+	for i, call := range calls {
+		args := call.Args
+		msg, err := args.ToMessage(s.b.RPCGasCap(), header.BaseFee)
+		if err != nil {
+			return nil, err
+		}
+		res, err := s.traceCall(ctx, header, msg, statedb, i, replayTypes[i])
+		if err != nil {
+			if len(calls) > 1 {
+				return nil, fmt.Errorf("call %d: %w", i, err)
+			}
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// traceCall executes a single call with tracers of the requested trace types
+func (s *PublicTxTraceAPI) traceCall(ctx context.Context, header *evmcore.EvmHeader, msg types.Message,
+	statedb state.StateDB, index int, replayTypes replayTraceTypes) (*TraceResults, error) {
+
+	tracers := newReplayTracers(statedb, replayTypes)
+	defer tracers.release()
+
+	txTracer := txtrace.NewTraceStructLoggerForCall(header, msg, uint(index))
+	cfg := opera.DefaultVMConfig
+	cfg.Debug = true
+	cfg.Tracer = txTracer
+	if extra := tracers.tracer(); extra != nil {
+		cfg.Tracer = txtrace.NewMuxTracer(txTracer, extra)
+	}
+	cfg.NoBaseFee = true
+
+	vmenv, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop the EVM once the context is done, it may happen during any of the calls
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vmenv.Cancel()
+		case <-stop:
+		}
+	}()
+
+	statedb.Prepare(common.Hash{}, index)
+	result, err := evmcore.ApplyMessage(vmenv, msg, new(evmcore.GasPool).AddGas(msg.Gas()))
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, fmt.Errorf("StateDB error: %w", err)
+	}
+	if vmenv.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", s.b.RPCEVMTimeout())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+	}
+	statedb.Finalise()
+	txTracer.SetGasUsed(result.UsedGas)
+
+	res := &TraceResults{
+		Output: result.ReturnData,
+		Trace:  make([]txtrace.ActionTrace, 0),
+	}
+	if replayTypes.trace {
+		res.Trace = *txTracer.GetResult()
+	}
+	tracers.fill(res, statedb)
+	return res, nil
+}
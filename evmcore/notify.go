@@ -24,6 +24,27 @@ import (
 // NewTxsNotify is posted when a batch of transactions enter the transaction pool.
 type NewTxsNotify struct{ Txs []*types.Transaction }
 
+// DroppedTxsNotify is posted when a batch of transactions is dropped from the transaction pool.
+// Transactions dropped with DropReasonNonceTooLow may be already included into a block.
+type DroppedTxsNotify struct {
+	Txs    []*types.Transaction
+	Reason string
+}
+
+// SkippedTx is a transaction confirmed by a block, but skipped during the block execution.
+type SkippedTx struct {
+	// Index is the position of the transaction among all the block transactions, including the skipped ones
+	Index  uint32
+	Hash   common.Hash
+	Reason string
+}
+
+// SkippedTxsNotify is posted when a block with skipped transactions is processed.
+type SkippedTxsNotify struct {
+	Block *EvmBlock
+	Txs   []SkippedTx
+}
+
 // PendingLogsNotify is posted pre mining and notifies of pending logs.
 type PendingLogsNotify struct {
 	Logs []*types.Log
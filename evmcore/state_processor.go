@@ -36,10 +36,15 @@ import (
 //
 // StateProcessor implements Processor.
 type StateProcessor struct {
-	config *params.ChainConfig // Chain configuration options
-	bc     DummyChain          // Canonical block chain
+	config   *params.ChainConfig // Chain configuration options
+	bc       DummyChain          // Canonical block chain
+	txTracer TxTracer            // Optional tracer of the processed transactions
+	onSkip   TxSkipListener      // Optional listener of the skipped transactions
 }
 
+// TxSkipListener is notified about a transaction skipped by StateProcessor and the reason of the skip
+type TxSkipListener func(tx *types.Transaction, err error)
+
 // NewStateProcessor initialises a new StateProcessor.
 func NewStateProcessor(config *params.ChainConfig, bc DummyChain) *StateProcessor {
 	return &StateProcessor{
@@ -48,6 +53,16 @@ func NewStateProcessor(config *params.ChainConfig, bc DummyChain) *StateProcesso
 	}
 }
 
+// SetTxTracer sets the tracer of the transactions processed by the processor
+func (p *StateProcessor) SetTxTracer(txTracer TxTracer) {
+	p.txTracer = txTracer
+}
+
+// SetTxSkipListener sets the listener of the transactions skipped by the processor
+func (p *StateProcessor) SetTxSkipListener(onSkip TxSkipListener) {
+	p.onSkip = onSkip
+}
+
 // Process processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
@@ -61,6 +76,12 @@ func (p *StateProcessor) Process(
 	receipts types.Receipts, allLogs []*types.Log, skipped []uint32, err error,
 ) {
 	skipped = make([]uint32, 0, len(block.Transactions))
+	var tracerProxy *txTracerProxy
+	if p.txTracer != nil {
+		tracerProxy = &txTracerProxy{}
+		cfg.Debug = true
+		cfg.Tracer = tracerProxy
+	}
 	var (
 		gp           = new(GasPool).AddGas(block.GasLimit)
 		receipt      *types.Receipt
@@ -80,8 +101,20 @@ func (p *StateProcessor) Process(
 		}
 
 		statedb.Prepare(tx.Hash(), i)
+		if tracerProxy != nil {
+			tracerProxy.current = p.txTracer.TxStart(block, tx, msg, i)
+		}
 		receipt, _, skip, err = applyTransaction(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, onNewLog)
+		if tracerProxy != nil {
+			tracerProxy.current = nil
+			if err == nil || skip {
+				p.txTracer.TxEnd(tx, receipt)
+			}
+		}
 		if skip {
+			if p.onSkip != nil {
+				p.onSkip(tx, err)
+			}
 			skipped = append(skipped, uint32(i))
 			err = nil
 			continue
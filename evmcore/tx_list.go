@@ -495,6 +495,10 @@ type txPricedList struct {
 	all              *txLookup // Pointer to the map of all transactions
 	urgent, floating priceHeap // Heaps of prices of all the stored **remote** transactions
 	stales           int       // Number of stale price points to (re-heap trigger)
+
+	// tips is a heap of the effective tips of all the stored remote transactions, used by the eviction policy
+	tips      priceHeap
+	tipStales int // Number of stale tips
 }
 
 const (
@@ -506,7 +510,8 @@ const (
 // newTxPricedList creates a new price-sorted transaction heap.
 func newTxPricedList(all *txLookup) *txPricedList {
 	return &txPricedList{
-		all: all,
+		all:  all,
+		tips: priceHeap{baseFee: new(big.Int)},
 	}
 }
 
@@ -517,12 +522,35 @@ func (l *txPricedList) Put(tx *types.Transaction, local bool) {
 	}
 	// Insert every new transaction to the urgent heap first; Discard will balance the heaps
 	heap.Push(&l.urgent, tx)
+	heap.Push(&l.tips, tx)
+}
+
+// staleTips notifies the tips heap that transactions dropped from the pool, the stale tips are
+// filtered out if a large enough ratio of them go stale.
+func (l *txPricedList) staleTips(count int) {
+	l.tipStales += count
+	if l.tipStales <= len(l.tips.list)/4 {
+		return
+	}
+	list := l.tips.list[:0]
+	for _, tx := range l.tips.list {
+		if l.all.GetRemote(tx.Hash()) != nil {
+			list = append(list, tx)
+		}
+	}
+	for i := len(list); i < len(l.tips.list); i++ {
+		l.tips.list[i] = nil
+	}
+	l.tips.list = list
+	l.tipStales = 0
+	heap.Init(&l.tips)
 }
 
 // Removed notifies the prices transaction list that an old transaction dropped
 // from the pool. The list will just keep a counter of stale objects and update
 // the heap if a large enough ratio of transactions go stale.
 func (l *txPricedList) Removed(count int) {
+	l.staleTips(count)
 	// Bump the stale counter, but exit if still too low (< 25%)
 	l.stales += count
 	if l.stales <= (len(l.urgent.list)+len(l.floating.list))/4 {
@@ -606,6 +634,28 @@ func (l *txPricedList) Discard(slots int, force bool) (types.Transactions, bool)
 	return drop, true
 }
 
+// RangeCheapest iterates over the remote transactions from the lowest effective tip up,
+// skipping the transactions already removed from the pool.
+func (l *txPricedList) RangeCheapest(f func(tx *types.Transaction) bool) {
+	popped := make([]*types.Transaction, 0, 16)
+	for len(l.tips.list) > 0 {
+		tx := heap.Pop(&l.tips).(*types.Transaction)
+		if l.all.GetRemote(tx.Hash()) == nil { // Removed or migrated
+			if l.tipStales > 0 {
+				l.tipStales--
+			}
+			continue
+		}
+		popped = append(popped, tx)
+		if !f(tx) {
+			break
+		}
+	}
+	for _, tx := range popped {
+		heap.Push(&l.tips, tx)
+	}
+}
+
 // Reheap forcibly rebuilds the heap based on the current remote transaction set.
 func (l *txPricedList) Reheap() {
 	start := time.Now()
@@ -615,6 +665,9 @@ func (l *txPricedList) Reheap() {
 		l.urgent.list = append(l.urgent.list, tx)
 		return true
 	}, false, true) // Only iterate remotes
+	l.tips.list = append(make([]*types.Transaction, 0, len(l.urgent.list)), l.urgent.list...)
+	l.tipStales = 0
+	heap.Init(&l.tips)
 	heap.Init(&l.urgent)
 
 	// balance out the two heaps by moving the worse half of transactions into the
@@ -636,6 +689,7 @@ func (l *txPricedList) Reheap() {
 func (l *txPricedList) SetBaseFee(baseFee *big.Int) {
 	if l.urgent.baseFee == nil || l.urgent.baseFee.Cmp(baseFee) != 0 {
 		l.urgent.baseFee = baseFee
+		l.tips.baseFee = baseFee
 		l.Reheap()
 	}
 }
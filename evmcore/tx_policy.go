@@ -0,0 +1,450 @@
+package evmcore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// EvictByFeeCap evicts the remote transactions with the lowest fee caps first
+	EvictByFeeCap = "price"
+	// EvictByEffectiveTip evicts the remote transactions with the lowest effective tips under the current MinGasPrice first.
+	// Senders above the quota and senders with higher spam scores are evicted before the others.
+	EvictByEffectiveTip = "tip"
+)
+
+var (
+	// ErrSenderQuota is returned if the pool is full and the sender already holds its share of the pool.
+	ErrSenderQuota = errors.New("sender exceeds txpool quota")
+
+	// ErrSpamScore is returned if too many transactions of the sender were dropped from the pool recently.
+	ErrSpamScore = errors.New("sender spam score is too high")
+)
+
+var (
+	policyQuotaMeter    = metrics.GetOrRegisterMeter("txpool/policy/rejected/quota", nil)
+	policySpamMeter     = metrics.GetOrRegisterMeter("txpool/policy/rejected/spam", nil)
+	policyEvictedMeter  = metrics.GetOrRegisterMeter("txpool/policy/evicted", nil)
+	policyPenaltyMeter  = metrics.GetOrRegisterMeter("txpool/policy/penalized", nil)
+	policySpammersGauge = metrics.GetOrRegisterGauge("txpool/policy/spammers", nil)
+)
+
+// TxPoolView is a read-only view of the pool, which is available to the policy.
+// It's valid only during the policy call as it's made under the pool lock.
+type TxPoolView interface {
+	// Slots returns the number of slots used by all the transactions
+	Slots() int
+	// MaxSlots returns the capacity of the pool
+	MaxSlots() int
+	// MinGasPrice returns the current on-chain minimum gas price
+	MinGasPrice() *big.Int
+	// SenderSlots returns the number of slots used by the transactions of a sender
+	SenderSlots(addr common.Address) int
+	// SendersAbove returns the remote senders using more than the given number of slots along with their slots
+	SendersAbove(slots int) map[common.Address]int
+	// SenderRemotes returns the transactions of a remote sender
+	SenderRemotes(addr common.Address) types.Transactions
+	// RangeCheapest iterates over the remote transactions along with their senders
+	// from the lowest effective tip under MinGasPrice up
+	RangeCheapest(f func(from common.Address, tx *types.Transaction) bool)
+	// Underpriced checks whether a transaction is cheaper than (or as cheap as) the
+	// lowest priced remote transaction according to the price heaps of the pool.
+	Underpriced(tx *types.Transaction) bool
+	// DiscardCheapest picks the lowest priced remote transactions according to the
+	// price heaps of the pool to free up the slots.
+	DiscardCheapest(slots int) (types.Transactions, bool)
+}
+
+// TxPoolPolicy decides on the admission of the remote transactions into the pool
+// and on the eviction of the remote transactions when the pool is full.
+// Local transactions are never subject to the policy.
+type TxPoolPolicy interface {
+	// Admit checks whether a new remote transaction may enter the pool.
+	// It isn't called for the transactions which replace already pooled ones.
+	Admit(from common.Address, tx *types.Transaction, view TxPoolView) error
+	// Evict is called when the pool is full. It returns the remote transactions to drop
+	// to free up the slots for tx, or an error if tx should be rejected instead.
+	Evict(slots int, from common.Address, tx *types.Transaction, view TxPoolView) (types.Transactions, error)
+	// Dropped notifies the policy that remote transactions of a sender were rejected because the pool
+	// is full or dropped for exceeding the pool limits. The transactions evicted by Evict aren't reported.
+	Dropped(from common.Address, count int)
+	// Status returns the policy settings and decision statistics.
+	Status() TxPolicyStatus
+}
+
+// TxPolicyStatus is a summary of the policy state for inspection
+type TxPolicyStatus struct {
+	Name     string
+	Settings map[string]string
+	// Number of transactions rejected due to the sender quota
+	RejectedQuota uint64
+	// Number of transactions rejected due to the spam score
+	RejectedSpam uint64
+	// Number of remote transactions evicted in favor of the new ones
+	Evicted uint64
+	// SpamScores are the senders with a non-negligible spam score
+	SpamScores map[common.Address]float64
+}
+
+type spamScore struct {
+	value   float64
+	updated time.Time
+}
+
+// txPolicy is the built-in policy, configured by TxPoolConfig
+type txPolicy struct {
+	eviction    string
+	senderQuota uint64 // percentage of the pool slots
+	spamLimit   float64
+	halfLife    time.Duration
+
+	mu            sync.Mutex
+	scores        map[common.Address]spamScore
+	lastPrune     time.Time
+	rejectedQuota uint64
+	rejectedSpam  uint64
+	evicted       uint64
+
+	now func() time.Time
+}
+
+// NewTxPoolPolicy creates the built-in policy according to the pool config.
+func NewTxPoolPolicy(config TxPoolConfig) TxPoolPolicy {
+	return &txPolicy{
+		eviction:    config.Eviction,
+		senderQuota: config.SenderQuota,
+		spamLimit:   float64(config.SpamScoreLimit),
+		halfLife:    config.SpamScoreHalfLife,
+		scores:      make(map[common.Address]spamScore),
+		now:         time.Now,
+	}
+}
+
+// quotaSlots returns the max number of slots a sender may hold in a full pool
+func (p *txPolicy) quotaSlots(view TxPoolView) int {
+	if p.senderQuota == 0 {
+		return math.MaxInt
+	}
+	quota := view.MaxSlots() * int(p.senderQuota) / 100
+	if quota < 1 {
+		return 1
+	}
+	return quota
+}
+
+func isFull(view TxPoolView, tx *types.Transaction) bool {
+	return view.Slots()+numSlots(tx) > view.MaxSlots()
+}
+
+// score returns the decayed spam score of a sender.
+// Note, this method assumes the policy lock is held!
+func (p *txPolicy) score(addr common.Address, now time.Time) float64 {
+	s, ok := p.scores[addr]
+	if !ok {
+		return 0
+	}
+	return s.value * math.Exp2(-float64(now.Sub(s.updated))/float64(p.halfLife))
+}
+
+// Admit implements TxPoolPolicy.
+func (p *txPolicy) Admit(from common.Address, tx *types.Transaction, view TxPoolView) error {
+	if p.spamLimit > 0 {
+		p.mu.Lock()
+		score := p.score(from, p.now())
+		if score >= p.spamLimit {
+			p.rejectedSpam++
+		}
+		p.mu.Unlock()
+		if score >= p.spamLimit {
+			policySpamMeter.Mark(1)
+			return ErrSpamScore
+		}
+	}
+	if p.senderQuota != 0 && isFull(view, tx) && view.SenderSlots(from)+numSlots(tx) > p.quotaSlots(view) {
+		p.mu.Lock()
+		p.rejectedQuota++
+		p.mu.Unlock()
+		policyQuotaMeter.Mark(1)
+		return ErrSenderQuota
+	}
+	return nil
+}
+
+type evictionCandidate struct {
+	tx        *types.Transaction
+	overQuota bool
+	score     float64
+	tip       *big.Int
+}
+
+// worse reports whether a should be evicted before b
+func (a *evictionCandidate) worse(b *evictionCandidate) bool {
+	if a.overQuota != b.overQuota {
+		return a.overQuota
+	}
+	// compare only the whole points of the scores, so that a decay doesn't shadow the tips
+	if sa, sb := math.Floor(a.score), math.Floor(b.score); sa != sb {
+		return sa > sb
+	}
+	if c := a.tip.Cmp(b.tip); c != 0 {
+		return c < 0
+	}
+	return a.tx.Nonce() > b.tx.Nonce()
+}
+
+// Evict implements TxPoolPolicy.
+func (p *txPolicy) Evict(slots int, from common.Address, tx *types.Transaction, view TxPoolView) (types.Transactions, error) {
+	if p.eviction != EvictByEffectiveTip {
+		if view.Underpriced(tx) {
+			return nil, ErrUnderpriced
+		}
+		drop, success := view.DiscardCheapest(slots)
+		if !success {
+			return nil, ErrTxPoolOverflow
+		}
+		p.markEvicted(len(drop))
+		return drop, nil
+	}
+
+	minGasPrice := view.MinGasPrice()
+	if minGasPrice == nil {
+		minGasPrice = new(big.Int)
+	}
+	quota := p.quotaSlots(view)
+	// the senders above the quota and the senders with spam scores are penalized,
+	// any of their transactions is worse than the transactions of the other senders
+	var overQuota map[common.Address]int
+	if quota != math.MaxInt {
+		overQuota = view.SendersAbove(quota)
+	}
+	p.mu.Lock()
+	now := p.now()
+	newCandidate := &evictionCandidate{
+		tx:        tx,
+		overQuota: view.SenderSlots(from)+numSlots(tx) > quota,
+		score:     p.score(from, now),
+		tip:       tx.EffectiveGasTipValue(minGasPrice),
+	}
+	penalized := make(map[common.Address]float64, len(overQuota))
+	for addr := range overQuota {
+		penalized[addr] = p.score(addr, now)
+	}
+	for addr := range p.scores {
+		if score := p.score(addr, now); score >= 1 {
+			penalized[addr] = score
+		}
+	}
+	p.mu.Unlock()
+
+	candidates := make([]*evictionCandidate, 0, len(penalized))
+	for addr, score := range penalized {
+		for _, tx := range view.SenderRemotes(addr) {
+			candidates = append(candidates, &evictionCandidate{
+				tx:        tx,
+				overQuota: overQuota[addr] > 0,
+				score:     score,
+				tip:       tx.EffectiveGasTipValue(minGasPrice),
+			})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].worse(candidates[j])
+	})
+
+	drop := make(types.Transactions, 0, slots)
+	// take reports whether more transactions have to be evicted
+	var err error
+	take := func(c *evictionCandidate) bool {
+		// the new transaction has to be better than every transaction it evicts
+		if !c.worse(newCandidate) {
+			err = ErrUnderpriced
+			return false
+		}
+		drop = append(drop, c.tx)
+		slots -= numSlots(c.tx)
+		return slots > 0
+	}
+	more := true
+	for i := 0; i < len(candidates) && more; i++ {
+		more = take(candidates[i])
+	}
+	if more && err == nil {
+		// the transactions of the other senders are ordered by the tips only
+		view.RangeCheapest(func(addr common.Address, tx *types.Transaction) bool {
+			if _, ok := penalized[addr]; ok {
+				return true
+			}
+			more = take(&evictionCandidate{
+				tx:  tx,
+				tip: tx.EffectiveGasTipValue(minGasPrice),
+			})
+			return more
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if slots > 0 {
+		return nil, ErrTxPoolOverflow
+	}
+	p.markEvicted(len(drop))
+	return drop, nil
+}
+
+func (p *txPolicy) markEvicted(n int) {
+	p.mu.Lock()
+	p.evicted += uint64(n)
+	p.mu.Unlock()
+	policyEvictedMeter.Mark(int64(n))
+}
+
+// Dropped implements TxPoolPolicy.
+func (p *txPolicy) Dropped(from common.Address, count int) {
+	if p.spamLimit <= 0 || count <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.scores[from] = spamScore{
+		value:   p.score(from, now) + float64(count),
+		updated: now,
+	}
+	policyPenaltyMeter.Mark(int64(count))
+
+	// forget the senders whose scores are decayed
+	if now.Sub(p.lastPrune) >= p.halfLife {
+		p.lastPrune = now
+		spammers := int64(0)
+		for addr := range p.scores {
+			score := p.score(addr, now)
+			if score < 0.01 {
+				delete(p.scores, addr)
+			} else if score >= p.spamLimit {
+				spammers++
+			}
+		}
+		policySpammersGauge.Update(spammers)
+	}
+}
+
+// Status implements TxPoolPolicy.
+func (p *txPolicy) Status() TxPolicyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := TxPolicyStatus{
+		Name: p.eviction,
+		Settings: map[string]string{
+			"eviction":          p.eviction,
+			"senderQuota":       fmt.Sprintf("%d%%", p.senderQuota),
+			"spamScoreLimit":    fmt.Sprintf("%g", p.spamLimit),
+			"spamScoreHalfLife": p.halfLife.String(),
+		},
+		RejectedQuota: p.rejectedQuota,
+		RejectedSpam:  p.rejectedSpam,
+		Evicted:       p.evicted,
+		SpamScores:    make(map[common.Address]float64),
+	}
+	now := p.now()
+	for addr := range p.scores {
+		if score := p.score(addr, now); score >= 1 {
+			status.SpamScores[addr] = score
+		}
+	}
+	return status
+}
+
+// txPoolView implements TxPoolView on top of the pool internals.
+// Note, the pool lock is assumed to be held while the view is used!
+type txPoolView struct {
+	pool *TxPool
+	// discarded are the transactions already removed from the price heaps
+	discarded map[common.Hash]struct{}
+}
+
+func (v *txPoolView) Slots() int {
+	return v.pool.all.Slots()
+}
+
+func (v *txPoolView) MaxSlots() int {
+	return int(v.pool.config.GlobalSlots + v.pool.config.GlobalQueue)
+}
+
+func (v *txPoolView) MinGasPrice() *big.Int {
+	return v.pool.chain.MinGasPrice()
+}
+
+func (v *txPoolView) SenderSlots(addr common.Address) int {
+	slots := 0
+	for _, lists := range []map[common.Address]*txList{v.pool.pending, v.pool.queue} {
+		if list := lists[addr]; list != nil {
+			for _, tx := range list.txs.items {
+				slots += numSlots(tx)
+			}
+		}
+	}
+	return slots
+}
+
+func (v *txPoolView) SendersAbove(slots int) map[common.Address]int {
+	txs := make(map[common.Address]int)
+	for _, lists := range []map[common.Address]*txList{v.pool.pending, v.pool.queue} {
+		for addr, list := range lists {
+			txs[addr] += list.Len()
+		}
+	}
+	senders := make(map[common.Address]int)
+	for addr, n := range txs {
+		// a transaction takes at most txMaxSize, so the slots of the senders with a few transactions aren't counted
+		if n*int(txMaxSize/txSlotSize) <= slots || v.pool.locals.contains(addr) {
+			continue
+		}
+		if used := v.SenderSlots(addr); used > slots {
+			senders[addr] = used
+		}
+	}
+	return senders
+}
+
+func (v *txPoolView) SenderRemotes(addr common.Address) types.Transactions {
+	if v.pool.locals.contains(addr) {
+		return nil
+	}
+	var txs types.Transactions
+	for _, lists := range []map[common.Address]*txList{v.pool.pending, v.pool.queue} {
+		if list := lists[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+	}
+	return txs
+}
+
+func (v *txPoolView) RangeCheapest(f func(from common.Address, tx *types.Transaction) bool) {
+	v.pool.priced.RangeCheapest(func(tx *types.Transaction) bool {
+		from, _ := types.Sender(v.pool.signer, tx) // already validated
+		return f(from, tx)
+	})
+}
+
+func (v *txPoolView) Underpriced(tx *types.Transaction) bool {
+	return v.pool.priced.Underpriced(tx)
+}
+
+func (v *txPoolView) DiscardCheapest(slots int) (types.Transactions, bool) {
+	drop, success := v.pool.priced.Discard(slots, false)
+	for _, tx := range drop {
+		v.discarded[tx.Hash()] = struct{}{}
+	}
+	return drop, success
+}
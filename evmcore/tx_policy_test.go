@@ -0,0 +1,162 @@
+package evmcore
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that a sender can't push the others out of a full pool beyond its quota.
+func TestTxPolicySenderQuota(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	pool.config.GlobalSlots = 4
+	pool.config.GlobalQueue = 4
+
+	config := testTxPoolConfig
+	config.Eviction = EvictByEffectiveTip
+	config.SenderQuota = 25
+	pool.SetPolicy(NewTxPoolPolicy(config))
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	spammer, user := keys[0], keys[1]
+
+	// the spammer fills the whole pool while it's not full
+	for nonce := uint64(0); nonce < 8; nonce++ {
+		require.NoError(t, pool.AddRemote(pricedTransaction(nonce, 100000, big.NewInt(10), spammer)))
+	}
+	require.Equal(t, 8, pool.all.Slots())
+
+	// but can't exceed the quota once the pool is full, even with a higher price
+	require.Equal(t, ErrSenderQuota, pool.AddRemote(pricedTransaction(8, 100000, big.NewInt(100), spammer)))
+
+	// a cheaper transaction of another sender evicts the latest transaction of the spammer
+	require.NoError(t, pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(2), user)))
+	require.Equal(t, 8, pool.all.Slots())
+	pending, queued := pool.ContentFrom(crypto.PubkeyToAddress(spammer.PublicKey))
+	require.Equal(t, 7, len(pending)+len(queued))
+	require.Nil(t, pool.all.Get(pricedTransaction(7, 100000, big.NewInt(10), spammer).Hash()))
+
+	// every sender gets its share of the pool at the expense of the spammer
+	require.NoError(t, pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(2), user)))
+	require.NoError(t, pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(3), keys[2])))
+	require.NoError(t, pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(3), keys[2])))
+	require.Equal(t, ErrSenderQuota, pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(1), user)))
+	pending, queued = pool.ContentFrom(crypto.PubkeyToAddress(spammer.PublicKey))
+	require.Equal(t, 4, len(pending)+len(queued))
+
+	status := pool.PolicyStatus()
+	require.Equal(t, EvictByEffectiveTip, status.Name)
+	require.Equal(t, uint64(2), status.RejectedQuota)
+	require.Equal(t, uint64(4), status.Evicted)
+
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that senders whose transactions keep getting dropped are rejected until their score decays.
+func TestTxPolicySpamScore(t *testing.T) {
+	config := DefaultTxPoolConfig
+	config.SpamScoreLimit = 3
+	config.SpamScoreHalfLife = time.Minute
+	p := NewTxPoolPolicy(config).(*txPolicy)
+	now := time.Unix(1000, 0)
+	p.now = func() time.Time {
+		return now
+	}
+
+	key, _ := crypto.GenerateKey()
+	tx := pricedTransaction(0, 100000, big.NewInt(1), key)
+	spammer := common.Address{1}
+	honest := common.Address{2}
+
+	p.Dropped(spammer, 2)
+	require.NoError(t, p.Admit(spammer, tx, nil))
+	p.Dropped(spammer, 1)
+	require.Equal(t, ErrSpamScore, p.Admit(spammer, tx, nil))
+	require.NoError(t, p.Admit(honest, tx, nil))
+
+	status := p.Status()
+	require.Equal(t, uint64(1), status.RejectedSpam)
+	require.Equal(t, map[common.Address]float64{spammer: 3}, status.SpamScores)
+
+	// the score halves after the half-life
+	now = now.Add(time.Minute)
+	require.NoError(t, p.Admit(spammer, tx, nil))
+	require.InDelta(t, 1.5, p.Status().SpamScores[spammer], 1e-9)
+
+	// decayed scores are forgotten
+	now = now.Add(time.Hour)
+	p.Dropped(honest, 1)
+	require.NotContains(t, p.scores, spammer)
+	require.Contains(t, p.scores, honest)
+}
+
+// Tests that the cheapest transactions are evicted first, after the transactions of the penalized senders,
+// and that only the senders of the rejected transactions are scored.
+func TestTxPolicyEvictByTip(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	pool.config.GlobalSlots = 2
+	pool.config.GlobalQueue = 2
+
+	config := testTxPoolConfig
+	config.Eviction = EvictByEffectiveTip
+	config.SpamScoreLimit = 10
+	config.SpamScoreHalfLife = time.Hour
+	policy := NewTxPoolPolicy(config)
+	pool.SetPolicy(policy)
+
+	keys := make([]*ecdsa.PrivateKey, 6)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	addr := func(i int) common.Address {
+		return crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	txs := make([]*types.Transaction, len(keys))
+	for i, price := range []int64{5, 3, 7, 4, 6, 2} {
+		txs[i] = pricedTransaction(0, 100000, big.NewInt(price), keys[i])
+	}
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.AddRemote(txs[i]))
+	}
+
+	// the cheapest transaction is evicted, its sender isn't scored
+	require.NoError(t, pool.AddRemote(txs[4]))
+	require.Nil(t, pool.all.Get(txs[1].Hash()))
+	require.Empty(t, pool.PolicyStatus().SpamScores)
+
+	// a cheaper transaction is rejected and its sender is scored
+	require.Equal(t, ErrUnderpriced, pool.AddRemote(txs[5]))
+	require.InDelta(t, 1, policy.(*txPolicy).scores[addr(5)].value, 1e-9)
+
+	// a transaction of a penalized sender is evicted before the cheaper ones
+	policy.Dropped(addr(2), 2)
+	require.NoError(t, pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(3), keys[1])))
+	require.Nil(t, pool.all.Get(txs[2].Hash()))
+	require.NotNil(t, pool.all.Get(txs[3].Hash()))
+	require.Equal(t, uint64(2), pool.PolicyStatus().Evicted)
+
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
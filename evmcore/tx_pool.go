@@ -89,6 +89,17 @@ var (
 	ErrOversizedData = errors.New("oversized data")
 )
 
+// Reasons of the transactions removal from the pool, see DroppedTxsNotify
+const (
+	DropReasonReplaced     = "replaced by another transaction with the same nonce"
+	DropReasonUnderpriced  = "underpriced"
+	DropReasonNonceTooLow  = "nonce too low"
+	DropReasonNoFunds      = "insufficient funds or gas limit exceeded"
+	DropReasonExpired      = "queued for too long"
+	DropReasonAccountLimit = "account limit of the pool exceeded"
+	DropReasonPoolLimit    = "pool limit exceeded"
+)
+
 var (
 	evictionInterval    = time.Minute     // Time interval to check for evictable transactions
 	statsReportInterval = 8 * time.Second // Time interval to report transaction pool stats
@@ -177,6 +188,11 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Eviction          string        // Eviction order of the remote transactions when the pool is full: "price" or "tip"
+	SenderQuota       uint64        // Maximum percentage of the full pool slots a single remote sender may hold (0 = unlimited)
+	SpamScoreLimit    uint64        // Number of recently dropped transactions after which a remote sender is rejected (0 = disabled)
+	SpamScoreHalfLife time.Duration // Time interval for the spam score of a sender to decay twice
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -194,6 +210,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  256,
 
 	Lifetime: 3 * time.Hour,
+
+	Eviction:          EvictByFeeCap,
+	SpamScoreHalfLife: 10 * time.Minute,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -232,6 +251,18 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.Eviction != EvictByFeeCap && conf.Eviction != EvictByEffectiveTip {
+		log.Warn("Sanitizing invalid txpool eviction policy", "provided", conf.Eviction, "updated", DefaultTxPoolConfig.Eviction)
+		conf.Eviction = DefaultTxPoolConfig.Eviction
+	}
+	if conf.SenderQuota > 100 {
+		log.Warn("Sanitizing invalid txpool sender quota", "provided", conf.SenderQuota, "updated", 100)
+		conf.SenderQuota = 100
+	}
+	if conf.SpamScoreHalfLife < time.Second {
+		log.Warn("Sanitizing invalid txpool spam score half-life", "provided", conf.SpamScoreHalfLife, "updated", DefaultTxPoolConfig.SpamScoreHalfLife)
+		conf.SpamScoreHalfLife = DefaultTxPoolConfig.SpamScoreHalfLife
+	}
 	return conf
 }
 
@@ -248,6 +279,7 @@ type TxPool struct {
 	chain       StateReader
 	gasPrice    *big.Int
 	txFeed      notify.Feed
+	dropFeed    notify.Feed
 	scope       notify.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
@@ -260,14 +292,17 @@ type TxPool struct {
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals      *accountSet              // Set of local transaction to exempt from eviction rules
+	journal     *txJournal               // Journal of local transaction to back up to disk
+	unjournaled map[common.Hash]struct{} // Transactions never written into the journal, even if they are local
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	policy  TxPoolPolicy                 // Admission and eviction policy of remote transactions
+	drops   []DroppedTxsNotify           // Dropped transactions which aren't notified yet
 
 	chainHeadCh     chan ChainHeadNotify
 	chainHeadSub    notify.Subscription
@@ -306,6 +341,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain State
 		reorgDoneCh:     make(chan chan struct{}),
 		reorgShutdownCh: make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
+		unjournaled:     make(map[common.Hash]struct{}),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -313,6 +349,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain State
 		pool.locals.add(addr)
 	}
 	pool.priced = newTxPricedList(pool.all)
+	pool.policy = NewTxPoolPolicy(config)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
@@ -326,7 +363,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain State
 		if err := pool.journal.load(pool.AddLocals); err != nil {
 			log.Warn("Failed to load transaction journal", "err", err)
 		}
-		if err := pool.journal.rotate(pool.local()); err != nil {
+		if err := pool.journal.rotate(pool.journaled()); err != nil {
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
@@ -399,15 +436,18 @@ func (pool *TxPool) loop() {
 						pool.removeTx(tx.Hash(), true)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
+					pool.policy.Dropped(addr, len(list))
+					pool.dropped(DropReasonExpired, list)
 				}
 			}
 			pool.mu.Unlock()
+			pool.sendDropped()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
-				if err := pool.journal.rotate(pool.local()); err != nil {
+				if err := pool.journal.rotate(pool.journaled()); err != nil {
 					log.Warn("Failed to rotate local tx journal", "err", err)
 				}
 				pool.mu.Unlock()
@@ -437,6 +477,36 @@ func (pool *TxPool) SubscribeNewTxsNotify(ch chan<- NewTxsNotify) notify.Subscri
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxsNotify registers a subscription of DroppedTxsNotify and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeDroppedTxsNotify(ch chan<- DroppedTxsNotify) notify.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// dropped memorizes the dropped transactions to notify the subscribers once the pool is unlocked.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) dropped(reason string, txs types.Transactions) {
+	if len(txs) == 0 {
+		return
+	}
+	pool.drops = append(pool.drops, DroppedTxsNotify{Txs: txs, Reason: reason})
+}
+
+// sendDropped notifies the subscribers about the dropped transactions.
+//
+// Note, this method assumes the pool lock isn't held!
+func (pool *TxPool) sendDropped() {
+	pool.mu.Lock()
+	drops := pool.drops
+	pool.drops = nil
+	pool.mu.Unlock()
+
+	for _, ev := range drops {
+		pool.dropFeed.Send(ev)
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -448,6 +518,7 @@ func (pool *TxPool) GasPrice() *big.Int {
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
+	defer pool.sendDropped()
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -461,11 +532,28 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 			pool.removeTx(tx.Hash(), true)
 		}
 		pool.priced.Removed(len(drop))
+		pool.dropped(DropReasonUnderpriced, drop)
 	}
 
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// SetPolicy replaces the admission and eviction policy of remote transactions.
+func (pool *TxPool) SetPolicy(policy TxPoolPolicy) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.policy = policy
+}
+
+// PolicyStatus returns the state of the admission and eviction policy.
+func (pool *TxPool) PolicyStatus() TxPolicyStatus {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.policy.Status()
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *TxPool) Nonce(addr common.Address) uint64 {
@@ -642,6 +730,32 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// journaled retrieves the local transactions to back up into the journal, the unjournaled ones are skipped.
+// The unjournaled transactions which aren't in the pool anymore are forgotten.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) journaled() map[common.Address]types.Transactions {
+	for hash := range pool.unjournaled {
+		if pool.all.Get(hash) == nil {
+			delete(pool.unjournaled, hash)
+		}
+	}
+	txs := pool.local()
+	if len(pool.unjournaled) == 0 {
+		return txs
+	}
+	for addr, list := range txs {
+		filtered := make(types.Transactions, 0, len(list))
+		for _, tx := range list {
+			if _, ok := pool.unjournaled[tx.Hash()]; !ok {
+				filtered = append(filtered, tx)
+			}
+		}
+		txs[addr] = filtered
+	}
+	return txs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -747,34 +861,57 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	// Mark a new received valid tx
 	receivedTxsMeter.Mark(1)
 
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	view := &txPoolView{pool: pool, discarded: make(map[common.Hash]struct{})}
+
+	// Let the policy decide on the admission of new remote transactions
+	replacing := pool.pending[from] != nil && pool.pending[from].Overlaps(tx) || pool.queue[from] != nil && pool.queue[from].Overlaps(tx)
+	if !isLocal && !replacing {
+		if err := pool.policy.Admit(from, tx, view); err != nil {
+			log.Trace("Discarding transaction rejected by policy", "hash", hash, "from", from, "err", err)
+			if err == ErrSenderQuota {
+				pool.policy.Dropped(from, 1)
+			}
+			return false, err
+		}
+	}
+
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
-		// If the new transaction is underpriced, don't accept it
-		if !isLocal && pool.priced.Underpriced(tx) {
-			log.Trace("Discarding underpriced transaction", "hash", hash, "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
-			underpricedTxMeter.Mark(1)
-			return false, ErrUnderpriced
-		}
-		// New transaction is better than our worse ones, make room for it.
-		// If it's a local transaction, forcibly discard all available transactions.
-		// Otherwise if we can't make enough room for new one, abort the operation.
-		drop, success := pool.priced.Discard(pool.all.Slots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), isLocal)
-
-		// Special case, we still can't make the room for the new remote one.
-		if !isLocal && !success {
-			log.Trace("Discarding overflown transaction", "hash", hash)
-			overflowedTxMeter.Mark(1)
-			return false, ErrTxPoolOverflow
+		slots := pool.all.Slots() - int(pool.config.GlobalSlots+pool.config.GlobalQueue) + numSlots(tx)
+		var drop types.Transactions
+		if isLocal {
+			// If it's a local transaction, forcibly discard all available transactions.
+			drop, _ = pool.priced.Discard(slots, true)
+			for _, tx := range drop {
+				view.discarded[tx.Hash()] = struct{}{}
+			}
+		} else {
+			// Otherwise let the policy make room for it or reject it
+			var err error
+			drop, err = pool.policy.Evict(slots, from, tx, view)
+			if err != nil {
+				if err == ErrUnderpriced {
+					log.Trace("Discarding underpriced transaction", "hash", hash, "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
+					underpricedTxMeter.Mark(1)
+				} else {
+					log.Trace("Discarding overflown transaction", "hash", hash)
+					overflowedTxMeter.Mark(1)
+				}
+				pool.policy.Dropped(from, 1)
+				return false, err
+			}
 		}
 		// Kick out the underpriced remote transactions.
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
 			underpricedTxMeter.Mark(1)
-			pool.removeTx(tx.Hash(), false) // don't remove from priced, already removed by Discard
+			_, discarded := view.discarded[tx.Hash()]
+			pool.removeTx(tx.Hash(), !discarded) // don't remove from priced if already removed by Discard
 		}
+		pool.dropped(DropReasonUnderpriced, drop)
 	}
 	// Try to replace an existing transaction in the pending pool
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -787,6 +924,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.dropped(DropReasonReplaced, types.Transactions{old})
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
@@ -849,6 +987,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.dropped(DropReasonReplaced, types.Transactions{old})
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -876,6 +1015,9 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	if _, ok := pool.unjournaled[tx.Hash()]; ok {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -898,6 +1040,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.dropped(DropReasonReplaced, types.Transactions{tx})
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -905,6 +1048,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.dropped(DropReasonReplaced, types.Transactions{old})
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -933,6 +1077,18 @@ func (pool *TxPool) AddLocal(tx *types.Transaction) error {
 	return errs[0]
 }
 
+// AddUnjournaled enqueues a single remote transaction into the pool if it is valid and waits for pool
+// reorganization. The transaction is never written into the journal, even if its sender is local,
+// so it isn't restored after a restart. It's used for the private transactions, which registry isn't persisted.
+func (pool *TxPool) AddUnjournaled(tx *types.Transaction) error {
+	if pool.journal != nil {
+		pool.mu.Lock()
+		pool.unjournaled[tx.Hash()] = struct{}{}
+		pool.mu.Unlock()
+	}
+	return pool.addRemoteSync(tx)
+}
+
 // AddRemotes enqueues a batch of transactions into the pool if they are valid. If the
 // senders are not among the locally tracked ones, full pricing constraints will apply.
 //
@@ -1074,6 +1230,15 @@ func (pool *TxPool) OnlyNotExisting(hashes []common.Hash) []common.Hash {
 	return pool.all.OnlyNotExisting(hashes)
 }
 
+// RemoveTx removes a transaction from the pool, the subsequent transactions
+// of the sender are moved back to the future queue.
+func (pool *TxPool) RemoveTx(hash common.Hash) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.removeTx(hash, true)
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash, removeFromPriced bool) {
@@ -1088,6 +1253,9 @@ func (pool *TxPool) removeTx(hash common.Hash, removeFromPriced bool) {
 	pool.all.Remove(hash)
 	if removeFromPriced {
 		pool.priced.Removed(1)
+	} else {
+		// the discarded transaction is still in the tips heap
+		pool.priced.staleTips(1)
 	}
 	if pool.locals.contains(addr) {
 		localGauge.Dec(1)
@@ -1303,6 +1471,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		}
 		pool.txFeed.Send(NewTxsNotify{txs})
 	}
+	pool.sendDropped()
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
@@ -1424,6 +1593,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			pool.all.Remove(hash)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
+		pool.dropped(DropReasonNonceTooLow, forwards)
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1432,6 +1602,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
+		pool.dropped(DropReasonNoFunds, drops)
 
 		// Gather all executable transactions and promote them
 		readies := list.Ready(pool.pendingNonces.get(addr))
@@ -1454,6 +1625,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
+			pool.dropped(DropReasonAccountLimit, caps)
 		}
 		// Mark all the items dropped as removed
 		pool.priced.Removed(len(forwards) + len(drops) + len(caps))
@@ -1520,6 +1692,8 @@ func (pool *TxPool) truncatePending() {
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
 					pool.priced.Removed(len(caps))
+					pool.policy.Dropped(offenders[i], len(caps))
+					pool.dropped(DropReasonPoolLimit, caps)
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
 						localGauge.Dec(int64(len(caps)))
@@ -1547,6 +1721,8 @@ func (pool *TxPool) truncatePending() {
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
 				pool.priced.Removed(len(caps))
+				pool.policy.Dropped(addr, len(caps))
+				pool.dropped(DropReasonPoolLimit, caps)
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
 					localGauge.Dec(int64(len(caps)))
@@ -1586,19 +1762,24 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
+			pool.dropped(DropReasonPoolLimit, txs)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
+			pool.policy.Dropped(addr.address, int(size))
 			continue
 		}
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.dropped(DropReasonPoolLimit, txs[i:i+1])
 			drop--
 			queuedRateLimitMeter.Mark(1)
+			pool.policy.Dropped(addr.address, 1)
 		}
 	}
 }
@@ -1622,6 +1803,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.dropped(DropReasonNonceTooLow, olds)
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1630,6 +1812,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
+		pool.dropped(DropReasonNoFunds, drops)
 
 		for _, tx := range invalids {
 			hash := tx.Hash()
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -2438,6 +2439,58 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the unjournaled transactions aren't restored after a restart, even if their sender is local,
+// neither from the inserted journal entries nor from the rotated journal.
+func TestTransactionJournalingUnjournaled(t *testing.T) {
+	t.Parallel()
+
+	journal := filepath.Join(t.TempDir(), "transactions.rlp")
+
+	statedb := newTestTxPoolStateDb()
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+	config.Rejournal = time.Second
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	private, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(private.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	// the sender is local, but the transaction isn't journaled
+	if err := pool.AddUnjournaled(pricedTransaction(1, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add unjournaled transaction: %v", err)
+	}
+	if err := pool.AddUnjournaled(pricedTransaction(0, 100000, big.NewInt(1), private)); err != nil {
+		t.Fatalf("failed to add unjournaled transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if pool.locals.contains(crypto.PubkeyToAddress(private.PublicKey)) {
+		t.Fatalf("sender of an unjournaled transaction is marked as local")
+	}
+	// restart after the journal is rotated
+	time.Sleep(2 * config.Rejournal)
+	pool.Stop()
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("restored transactions mismatched: have %d pending and %d queued, want 1 pending", pending, queued)
+	}
+	if pool.Get(pricedTransaction(0, 100000, big.NewInt(1), local).Hash()) == nil {
+		t.Fatalf("journaled transaction isn't restored")
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -0,0 +1,128 @@
+package evmcore
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// TxTracer provides tracers of the transactions executed by StateProcessor
+type TxTracer interface {
+	// TxStart returns the tracer of the transaction at the given position of the block,
+	// nil means the transaction is not traced
+	TxStart(block *EvmBlock, tx *types.Transaction, msg types.Message, index int) vm.Tracer
+	// TxEnd is called after the transaction execution, receipt is nil for a skipped transaction
+	TxEnd(tx *types.Transaction, receipt *types.Receipt)
+}
+
+// txTracerProxy forwards the EVM callbacks to the tracer of the current transaction.
+// The EVM of a block is created only once, so its tracer can't be replaced per transaction.
+type txTracerProxy struct {
+	current vm.Tracer
+}
+
+func (p *txTracerProxy) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	if p.current != nil {
+		p.current.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+func (p *txTracerProxy) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if p.current != nil {
+		p.current.CaptureState(env, pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (p *txTracerProxy) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if p.current != nil {
+		p.current.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+func (p *txTracerProxy) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if p.current != nil {
+		p.current.CaptureExit(output, gasUsed, err)
+	}
+}
+
+func (p *txTracerProxy) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if p.current != nil {
+		p.current.CaptureFault(env, pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (p *txTracerProxy) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	if p.current != nil {
+		p.current.CaptureEnd(output, gasUsed, t, err)
+	}
+}
+
+// TxTracers combines several tracers of the transactions into one
+type TxTracers []TxTracer
+
+// TxStart creates the tracers of the transaction, it returns nil if no tracer traces it
+func (tt TxTracers) TxStart(block *EvmBlock, tx *types.Transaction, msg types.Message, index int) vm.Tracer {
+	tracers := make(vmTracers, 0, len(tt))
+	for _, t := range tt {
+		if tracer := t.TxStart(block, tx, msg, index); tracer != nil {
+			tracers = append(tracers, tracer)
+		}
+	}
+	switch len(tracers) {
+	case 0:
+		return nil
+	case 1:
+		return tracers[0]
+	default:
+		return tracers
+	}
+}
+
+// TxEnd finishes tracing of the transaction by all the tracers
+func (tt TxTracers) TxEnd(tx *types.Transaction, receipt *types.Receipt) {
+	for _, t := range tt {
+		t.TxEnd(tx, receipt)
+	}
+}
+
+// vmTracers forwards the EVM callbacks to several tracers
+type vmTracers []vm.Tracer
+
+func (tt vmTracers) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	for _, t := range tt {
+		t.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+func (tt vmTracers) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for _, t := range tt {
+		t.CaptureState(env, pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (tt vmTracers) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	for _, t := range tt {
+		t.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+func (tt vmTracers) CaptureExit(output []byte, gasUsed uint64, err error) {
+	for _, t := range tt {
+		t.CaptureExit(output, gasUsed, err)
+	}
+}
+
+func (tt vmTracers) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	for _, t := range tt {
+		t.CaptureFault(env, pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (tt vmTracers) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	for _, tracer := range tt {
+		tracer.CaptureEnd(output, gasUsed, t, err)
+	}
+}
@@ -0,0 +1,131 @@
+package gossip
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+// txAddressesTracer collects the addresses which take part in the calls of a transaction.
+type txAddressesTracer struct {
+	addrs []common.Address
+	seen  map[common.Address]struct{}
+}
+
+func newTxAddressesTracer() *txAddressesTracer {
+	return &txAddressesTracer{
+		seen: make(map[common.Address]struct{}),
+	}
+}
+
+func (t *txAddressesTracer) add(addr common.Address) {
+	if _, ok := t.seen[addr]; ok {
+		return
+	}
+	t.seen[addr] = struct{}{}
+	t.addrs = append(t.addrs, addr)
+}
+
+func (t *txAddressesTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.add(from)
+	t.add(to)
+}
+
+func (t *txAddressesTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *txAddressesTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.add(from)
+	t.add(to)
+}
+
+func (t *txAddressesTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (t *txAddressesTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *txAddressesTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {}
+
+// txAddresses is a transaction with the addresses it touches
+type txAddresses struct {
+	index uint32
+	hash  common.Hash
+	addrs []common.Address
+}
+
+// blockAddressesRecorder collects the addresses touched by the transactions executed in a block:
+// sender, recipient, created contract and participants of internal calls.
+// Transactions are positioned by the not skipped transactions of the block, as their receipts.
+type blockAddressesRecorder struct {
+	current *txAddressesTracer
+	txs     []txAddresses
+}
+
+func newBlockAddressesRecorder() *blockAddressesRecorder {
+	return &blockAddressesRecorder{}
+}
+
+// TxStart creates the tracer of the transaction
+func (r *blockAddressesRecorder) TxStart(block *evmcore.EvmBlock, tx *types.Transaction, msg types.Message, index int) vm.Tracer {
+	r.current = newTxAddressesTracer()
+	r.current.add(msg.From())
+	if tx.To() != nil {
+		r.current.add(*tx.To())
+	}
+	return r.current
+}
+
+// TxEnd collects addresses of the transaction, skipped transactions are not recorded
+func (r *blockAddressesRecorder) TxEnd(tx *types.Transaction, receipt *types.Receipt) {
+	if r.current == nil {
+		return
+	}
+	if receipt != nil {
+		if tx.To() == nil {
+			r.current.add(receipt.ContractAddress)
+		}
+		r.txs = append(r.txs, txAddresses{
+			index: uint32(len(r.txs)),
+			hash:  tx.Hash(),
+			addrs: r.current.addrs,
+		})
+	}
+	r.current = nil
+}
+
+// flush indexes the recorded transactions of the block n
+func (r *blockAddressesRecorder) flush(s *Store, n idx.Block) {
+	for _, tx := range r.txs {
+		s.evm.SetTxAddresses(n, tx.index, tx.hash, tx.addrs)
+	}
+}
+
+// BackfillAddressIndex replays the blocks [from, to] on the archive state
+// and indexes their transactions by the addresses they touch.
+func (s *Store) BackfillAddressIndex(ctx context.Context, from, to idx.Block, onBlock func(n idx.Block)) error {
+	if !s.evm.AddressIndexingEnabled() {
+		return fmt.Errorf("address indexing is not enabled")
+	}
+	if from == 0 {
+		// genesis block has no transactions
+		from = 1
+	}
+
+	return s.replayBlocks(ctx, from, to, func() evmcore.TxTracer {
+		return newBlockAddressesRecorder()
+	}, func(n idx.Block, tracer evmcore.TxTracer) {
+		tracer.(*blockAddressesRecorder).flush(s, n)
+		s.evm.MarkAddressIndexedBlocks(from, n)
+		if onBlock != nil {
+			onBlock(n)
+		}
+	})
+}
@@ -91,32 +91,38 @@ func (p *DriverTxPreTransactor) PopInternalTxs(block iblockproc.BlockCtx, bs ibl
 
 	// push data into Driver before epoch sealing
 	if sealing {
-		metrics := make([]drivercall.ValidatorEpochMetric, es.Validators.Len())
-		for oldValIdx := idx.Validator(0); oldValIdx < es.Validators.Len(); oldValIdx++ {
-			info := bs.ValidatorStates[oldValIdx]
-			// forgive downtime if below BlockMissedSlack
-			missed := opera.BlocksMissed{
-				BlocksNum: maxBlockIdx(block.Idx, info.LastBlock) - info.LastBlock,
-				Period:    inter.MaxTimestamp(block.Time, info.LastOnlineTime) - info.LastOnlineTime,
-			}
-			uptime := info.Uptime
-			if missed.BlocksNum <= es.Rules.Economy.BlockMissedSlack {
-				missed = opera.BlocksMissed{}
-				prevOnlineTime := inter.MaxTimestamp(info.LastOnlineTime, es.EpochStart)
-				uptime += inter.MaxTimestamp(block.Time, prevOnlineTime) - prevOnlineTime
-			}
-			metrics[oldValIdx] = drivercall.ValidatorEpochMetric{
-				Missed:          missed,
-				Uptime:          uptime,
-				OriginatedTxFee: info.Originated,
-			}
-		}
-		calldata := drivercall.SealEpoch(metrics)
+		calldata := drivercall.SealEpoch(SealEpochMetrics(block, bs, es))
 		internalTxs = append(internalTxs, buildTx(calldata, driver.ContractAddress))
 	}
 	return internalTxs
 }
 
+// SealEpochMetrics calculates the metrics of the validators which are passed to Driver in the epoch sealing block,
+// the metrics are indexed by validator index
+func SealEpochMetrics(block iblockproc.BlockCtx, bs iblockproc.BlockState, es iblockproc.EpochState) []drivercall.ValidatorEpochMetric {
+	metrics := make([]drivercall.ValidatorEpochMetric, es.Validators.Len())
+	for oldValIdx := idx.Validator(0); oldValIdx < es.Validators.Len(); oldValIdx++ {
+		info := bs.ValidatorStates[oldValIdx]
+		// forgive downtime if below BlockMissedSlack
+		missed := opera.BlocksMissed{
+			BlocksNum: maxBlockIdx(block.Idx, info.LastBlock) - info.LastBlock,
+			Period:    inter.MaxTimestamp(block.Time, info.LastOnlineTime) - info.LastOnlineTime,
+		}
+		uptime := info.Uptime
+		if missed.BlocksNum <= es.Rules.Economy.BlockMissedSlack {
+			missed = opera.BlocksMissed{}
+			prevOnlineTime := inter.MaxTimestamp(info.LastOnlineTime, es.EpochStart)
+			uptime += inter.MaxTimestamp(block.Time, prevOnlineTime) - prevOnlineTime
+		}
+		metrics[oldValIdx] = drivercall.ValidatorEpochMetric{
+			Missed:          missed,
+			Uptime:          uptime,
+			OriginatedTxFee: info.Originated,
+		}
+	}
+	return metrics
+}
+
 func (p *DriverTxTransactor) PopInternalTxs(_ iblockproc.BlockCtx, _ iblockproc.BlockState, es iblockproc.EpochState, sealing bool, statedb state.StateDB) types.Transactions {
 	buildTx := InternalTxBuilder(statedb)
 	internalTxs := make(types.Transactions, 0, 1)
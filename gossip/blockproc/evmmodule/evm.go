@@ -52,6 +52,8 @@ type OperaEVMProcessor struct {
 	onNewLog func(*types.Log)
 	net      opera.Rules
 	evmCfg   *params.ChainConfig
+	txTracer evmcore.TxTracer
+	onSkip   evmcore.TxSkipListener
 
 	blockIdx      *big.Int
 	prevBlockHash common.Hash
@@ -83,9 +85,25 @@ func (p *OperaEVMProcessor) evmBlockWith(txs types.Transactions) *evmcore.EvmBlo
 	return evmcore.NewEvmBlock(h, txs)
 }
 
+// SetTxTracer sets the tracer of the executed transactions
+func (p *OperaEVMProcessor) SetTxTracer(txTracer evmcore.TxTracer) {
+	p.txTracer = txTracer
+}
+
+// SetTxSkipListener sets the listener of the skipped transactions
+func (p *OperaEVMProcessor) SetTxSkipListener(onSkip evmcore.TxSkipListener) {
+	p.onSkip = onSkip
+}
+
 func (p *OperaEVMProcessor) Execute(txs types.Transactions) types.Receipts {
 	evmProcessor := evmcore.NewStateProcessor(p.evmCfg, p.reader)
 	txsOffset := uint(len(p.incomingTxs))
+	if p.txTracer != nil {
+		evmProcessor.SetTxTracer(p.txTracer)
+	}
+	if p.onSkip != nil {
+		evmProcessor.SetTxSkipListener(p.onSkip)
+	}
 
 	// Process txs
 	evmBlock := p.evmBlockWith(txs)
@@ -47,6 +47,8 @@ type ConfirmedEventsModule interface {
 }
 
 type EVMProcessor interface {
+	SetTxTracer(txTracer evmcore.TxTracer)
+	SetTxSkipListener(onSkip evmcore.TxSkipListener)
 	Execute(txs types.Transactions) types.Receipts
 	Finalize() (evmBlock *evmcore.EvmBlock, skippedTxs []uint32, receipts types.Receipts)
 }
@@ -0,0 +1,339 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/inter/state"
+	"github.com/mrmikeo/Xpense/opera"
+	"github.com/mrmikeo/Xpense/utils/signers/gsignercache"
+)
+
+var (
+	errNoBundlesEmitter = errors.New("transaction bundles require a local emitter")
+	errBundlesPoolFull  = errors.New("transaction bundles pool is full")
+	errEmptyBundle      = errors.New("empty bundle")
+)
+
+// txBundle is a sequence of transactions, which are included atomically into one event in the given order
+type txBundle struct {
+	hash     common.Hash
+	txs      types.Transactions
+	maxBlock idx.Block
+	// revertingTxs are the transactions which are allowed to revert
+	revertingTxs map[common.Hash]bool
+	// seq is an order of the bundle submission
+	seq uint64
+	// nonces are the nonces of the senders in the state the bundle was simulated on,
+	// the bundle is simulated again only once they change
+	nonces map[common.Address]uint64
+}
+
+// bundleHash is a hash of the bundle transactions hashes
+func bundleHash(txs types.Transactions) common.Hash {
+	hashes := make([]byte, 0, len(txs)*common.HashLength)
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// TxBundles is a pool of the transaction bundles submitted to the local emitters.
+// Bundles are never announced to the peers, they are dropped once included, expired or failing.
+type TxBundles struct {
+	config BundlesConfig
+	store  *Store
+	reader *EvmStateReader
+
+	mu      sync.RWMutex
+	bundles map[common.Hash]*txBundle
+	seq     uint64
+
+	sub notify.Subscription
+	wg  sync.WaitGroup
+}
+
+func newTxBundles(config BundlesConfig, store *Store, reader *EvmStateReader) *TxBundles {
+	return &TxBundles{
+		config:  config,
+		store:   store,
+		reader:  reader,
+		bundles: make(map[common.Hash]*txBundle),
+	}
+}
+
+// Add simulates the bundle on top of the latest state and adds it into the pool, the bundle hash is returned.
+// The bundle is rejected if any of its transactions is invalid or reverts, unless it's listed in revertingTxs.
+func (p *TxBundles) Add(txs types.Transactions, maxBlock idx.Block, revertingTxs []common.Hash) (common.Hash, error) {
+	if len(txs) == 0 {
+		return common.Hash{}, errEmptyBundle
+	}
+	if len(txs) > p.config.MaxBundleTxs {
+		return common.Hash{}, fmt.Errorf("too many bundle transactions %d, at most %d are allowed", len(txs), p.config.MaxBundleTxs)
+	}
+	maxBlock, err := checkDeadline(maxBlock, p.store.GetLatestBlockIndex(), p.config.DefaultBlocks, p.config.MaxBlocks)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	bundle := &txBundle{
+		hash:         bundleHash(txs),
+		txs:          txs,
+		maxBlock:     maxBlock,
+		revertingTxs: make(map[common.Hash]bool, len(revertingTxs)),
+	}
+	contained := make(map[common.Hash]bool, len(txs))
+	for _, tx := range txs {
+		if contained[tx.Hash()] {
+			return common.Hash{}, fmt.Errorf("duplicate bundle transaction %s", tx.Hash().Hex())
+		}
+		contained[tx.Hash()] = true
+	}
+	for _, txid := range revertingTxs {
+		if !contained[txid] {
+			return common.Hash{}, fmt.Errorf("reverting transaction %s isn't in the bundle", txid.Hex())
+		}
+		bundle.revertingTxs[txid] = true
+	}
+	bundle.nonces, err = p.simulate(bundle)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prev, ok := p.bundles[bundle.hash]; ok {
+		// resubmission may only prolong the deadline, the bundle is copied as it may be read concurrently
+		if prev.maxBlock < bundle.maxBlock {
+			prolonged := *prev
+			prolonged.maxBlock = bundle.maxBlock
+			p.bundles[bundle.hash] = &prolonged
+		}
+		return bundle.hash, nil
+	}
+	if len(p.bundles) >= p.config.MaxBundles {
+		return common.Hash{}, errBundlesPoolFull
+	}
+	p.seq++
+	bundle.seq = p.seq
+	p.bundles[bundle.hash] = bundle
+	return bundle.hash, nil
+}
+
+// Pending returns the bundles which nonces match the latest state, in the order of submission.
+// Safe for concurrent use.
+func (p *TxBundles) Pending() []types.Transactions {
+	p.mu.RLock()
+	bundles := make([]*txBundle, 0, len(p.bundles))
+	for _, b := range p.bundles {
+		bundles = append(bundles, b)
+	}
+	p.mu.RUnlock()
+	if len(bundles) == 0 {
+		return nil
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].seq < bundles[j].seq
+	})
+
+	statedb, err := p.store.evm.GetTxPoolStateDB()
+	if err != nil {
+		log.Warn("Failed to get state for the bundles", "err", err)
+		return nil
+	}
+	defer statedb.Release()
+	signer := gsignercache.Wrap(types.MakeSigner(p.reader.Config(), p.reader.CurrentHeader().Number))
+	latest := p.store.GetLatestBlockIndex()
+
+	pending := make([]types.Transactions, 0, len(bundles))
+	for _, b := range bundles {
+		if b.maxBlock <= latest {
+			continue
+		}
+		// skip the bundles which are already included or outdated
+		nonces := make(map[common.Address]uint64)
+		ok := true
+		for _, tx := range b.txs {
+			sender, err := types.Sender(signer, tx)
+			if err != nil {
+				ok = false
+				break
+			}
+			nonce, seen := nonces[sender]
+			if !seen {
+				nonce = statedb.GetNonce(sender)
+			}
+			if tx.Nonce() != nonce {
+				ok = false
+				break
+			}
+			nonces[sender] = nonce + 1
+		}
+		if ok {
+			pending = append(pending, b.txs)
+		}
+	}
+	return pending
+}
+
+// simulate applies the bundle transactions on top of the latest state,
+// the nonces of the senders in the latest state are returned
+func (p *TxBundles) simulate(b *txBundle) (map[common.Address]uint64, error) {
+	header := p.reader.CurrentHeader()
+	statedb, err := p.store.evm.GetTxPoolStateDB()
+	if err != nil {
+		return nil, err
+	}
+	defer statedb.Release()
+
+	config := p.reader.Config()
+	signer := gsignercache.Wrap(types.MakeSigner(config, header.Number))
+	blockContext := evmcore.NewEVMBlockContext(header, p.reader, nil)
+	gp := new(evmcore.GasPool).AddGas(math.MaxUint64)
+	nonces := make(map[common.Address]uint64)
+	for i, tx := range b.txs {
+		msg, err := evmcore.TxAsMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("bundle transaction %s is invalid: %v", tx.Hash().Hex(), err)
+		}
+		if _, ok := nonces[msg.From()]; !ok {
+			// the nonce of a sender is changed only by its own transactions
+			nonces[msg.From()] = statedb.GetNonce(msg.From())
+		}
+		evm := vm.NewEVM(blockContext, evmcore.NewEVMTxContext(msg), statedb, config, opera.DefaultVMConfig)
+		statedb.Prepare(tx.Hash(), i)
+		res, err := evmcore.ApplyMessage(evm, msg, gp)
+		if err != nil {
+			return nil, fmt.Errorf("bundle transaction %s is invalid: %v", tx.Hash().Hex(), err)
+		}
+		if res.Failed() && !b.revertingTxs[tx.Hash()] {
+			return nil, fmt.Errorf("bundle transaction %s reverted: %v", tx.Hash().Hex(), res.Err)
+		}
+		if err := statedb.Error(); err != nil {
+			return nil, err
+		}
+		statedb.Finalise()
+	}
+	return nonces, nil
+}
+
+// noncesChanged tells whether any sender of the bundle sent a transaction since the bundle was simulated
+func (b *txBundle) noncesChanged(statedb state.StateDB) bool {
+	for sender, nonce := range b.nonces {
+		if statedb.GetNonce(sender) != nonce {
+			return true
+		}
+	}
+	return false
+}
+
+// onNewBlock drops the included, expired and failing bundles.
+// Only the bundles which senders sent transactions since the previous simulation are simulated again.
+func (p *TxBundles) onNewBlock(block idx.Block) {
+	p.mu.RLock()
+	bundles := make([]*txBundle, 0, len(p.bundles))
+	for _, b := range p.bundles {
+		bundles = append(bundles, b)
+	}
+	p.mu.RUnlock()
+	if len(bundles) == 0 {
+		return
+	}
+	statedb, err := p.store.evm.GetTxPoolStateDB()
+	if err != nil {
+		log.Warn("Failed to get state for the bundles", "err", err)
+		return
+	}
+	var (
+		dropped []common.Hash
+		changed []*txBundle
+	)
+	for _, b := range bundles {
+		if b.maxBlock <= block {
+			dropped = append(dropped, b.hash)
+			continue
+		}
+		if p.store.evm.GetTxPosition(b.txs[0].Hash()) != nil {
+			dropped = append(dropped, b.hash)
+			continue
+		}
+		if b.noncesChanged(statedb) {
+			changed = append(changed, b)
+		}
+	}
+	statedb.Release()
+
+	simulated := make(map[common.Hash]map[common.Address]uint64)
+	for _, b := range changed {
+		nonces, err := p.simulate(b)
+		if err != nil {
+			log.Debug("Dropped failing bundle", "bundle", b.hash, "err", err)
+			dropped = append(dropped, b.hash)
+			continue
+		}
+		simulated[b.hash] = nonces
+	}
+	if len(dropped) == 0 && len(simulated) == 0 {
+		return
+	}
+	p.mu.Lock()
+	for _, h := range dropped {
+		delete(p.bundles, h)
+	}
+	for h, nonces := range simulated {
+		// the bundle is copied as it may be read concurrently
+		if cur := p.bundles[h]; cur != nil {
+			resimulated := *cur
+			resimulated.nonces = nonces
+			p.bundles[h] = &resimulated
+		}
+	}
+	p.mu.Unlock()
+	if len(dropped) != 0 {
+		log.Debug("Dropped transaction bundles", "count", len(dropped), "block", block)
+	}
+}
+
+func (p *TxBundles) loop(newBlocks chan evmcore.ChainHeadNotify) {
+	defer p.wg.Done()
+	for {
+		select {
+		case head := <-newBlocks:
+			p.onNewBlock(idx.Block(head.Block.Number.Uint64()))
+		// Err() channel will be closed when unsubscribing.
+		case <-p.sub.Err():
+			return
+		}
+	}
+}
+
+func (p *TxBundles) Start(feed *ServiceFeed) {
+	newBlocks := make(chan evmcore.ChainHeadNotify, 10)
+	p.sub = feed.SubscribeNewBlock(newBlocks)
+	p.wg.Add(1)
+	go p.loop(newBlocks)
+}
+
+func (p *TxBundles) Stop() {
+	p.sub.Unsubscribe()
+	p.wg.Wait()
+}
+
+// sendBundle adds the bundle into the pool of the local emitters
+func (s *Service) sendBundle(txs types.Transactions, maxBlock idx.Block, revertingTxs []common.Hash) (common.Hash, error) {
+	if len(s.emitters) == 0 {
+		return common.Hash{}, errNoBundlesEmitter
+	}
+	return s.bundles.Add(txs, maxBlock, revertingTxs)
+}
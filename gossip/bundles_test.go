@@ -0,0 +1,108 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+func TestBundleHash(t *testing.T) {
+	require := require.New(t)
+
+	newTx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	tx1 := newTx(1)
+	tx2 := newTx(2)
+
+	require.Equal(bundleHash(types.Transactions{tx1, tx2}), bundleHash(types.Transactions{tx1, tx2}))
+	// order of the transactions matters
+	require.NotEqual(bundleHash(types.Transactions{tx1, tx2}), bundleHash(types.Transactions{tx2, tx1}))
+	require.NotEqual(bundleHash(types.Transactions{tx1}), bundleHash(types.Transactions{tx1, tx2}))
+}
+
+func TestCheckDeadline(t *testing.T) {
+	require := require.New(t)
+
+	maxBlock, err := checkDeadline(0, 100, 25, 1000)
+	require.NoError(err)
+	require.Equal(uint64(125), uint64(maxBlock))
+
+	maxBlock, err = checkDeadline(101, 100, 25, 1000)
+	require.NoError(err)
+	require.Equal(uint64(101), uint64(maxBlock))
+
+	maxBlock, err = checkDeadline(1100, 100, 25, 1000)
+	require.NoError(err)
+	require.Equal(uint64(1100), uint64(maxBlock))
+
+	_, err = checkDeadline(100, 100, 25, 1000)
+	require.Error(err)
+
+	_, err = checkDeadline(1101, 100, 25, 1000)
+	require.Error(err)
+}
+
+func TestTxBundles(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	env := newTestEnv(2, 3, t)
+	defer env.Close()
+	bundles := env.bundles
+
+	nonce := func(addr common.Address) uint64 {
+		statedb := env.State()
+		defer statedb.Release()
+		return statedb.GetNonce(addr)
+	}
+	sender := env.Address(1)
+	tx1 := env.Transfer(1, 2, big.NewInt(1))
+	tx2 := env.Transfer(1, 3, big.NewInt(1))
+	// conflicting with tx1
+	conflicting, err := types.SignTx(types.NewTransaction(tx1.Nonce(), env.Address(3), big.NewInt(2), tx1.Gas(), tx1.GasPrice(), nil), env.EthAPI.signer, env.privateKey(1))
+	require.NoError(err)
+	expiring := env.Transfer(3, 1, big.NewInt(1))
+
+	// invalid bundles are rejected
+	_, err = bundles.Add(nil, 0, nil)
+	require.Equal(errEmptyBundle, err)
+	_, err = bundles.Add(types.Transactions{tx1, tx1}, 0, nil)
+	require.Error(err)
+	_, err = bundles.Add(types.Transactions{tx1}, 0, []common.Hash{tx2.Hash()})
+	require.Error(err)
+	// nonce gap
+	_, err = bundles.Add(types.Transactions{tx2}, 0, nil)
+	require.Error(err)
+	require.Empty(bundles.Pending())
+
+	latest := env.store.GetLatestBlockIndex()
+	hash1, err := bundles.Add(types.Transactions{tx1, tx2}, 0, nil)
+	require.NoError(err)
+	require.Equal(bundleHash(types.Transactions{tx1, tx2}), hash1)
+	hash2, err := bundles.Add(types.Transactions{conflicting}, 0, nil)
+	require.NoError(err)
+	hash3, err := bundles.Add(types.Transactions{expiring}, latest+1, nil)
+	require.NoError(err)
+	require.Equal([]types.Transactions{{tx1, tx2}, {conflicting}, {expiring}}, bundles.Pending())
+
+	// the expired bundle is dropped, the others aren't simulated again as their senders' nonces are the same
+	bundle1 := bundles.bundles[hash1]
+	bundles.onNewBlock(latest + 1)
+	require.NotContains(bundles.bundles, hash3)
+	require.Same(bundle1, bundles.bundles[hash1])
+	require.Contains(bundles.bundles, hash2)
+
+	// the first bundle is included by the emitters, the conflicting one is dropped
+	require.NoError(env.EmitUntil(func() bool {
+		return nonce(sender) == tx2.Nonce()+1
+	}))
+	require.Empty(bundles.Pending())
+	bundles.onNewBlock(env.store.GetLatestBlockIndex())
+	require.Empty(bundles.bundles)
+}
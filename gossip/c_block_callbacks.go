@@ -19,6 +19,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/gossip/blockproc/drivermodule"
 	"github.com/mrmikeo/Xpense/gossip/blockproc/verwatcher"
 	"github.com/mrmikeo/Xpense/gossip/emitter"
 	"github.com/mrmikeo/Xpense/gossip/evmstore"
@@ -61,6 +62,7 @@ func (s *Service) GetConsensusCallbacks() lachesis.ConsensusCallbacks {
 			s.config.TxIndex,
 			&s.feed,
 			&s.emitters,
+			s.txStatuses,
 			s.verWatcher,
 			&s.bootstrapping,
 		),
@@ -78,6 +80,7 @@ func consensusCallbackBeginBlockFn(
 	txIndex bool,
 	feed *ServiceFeed,
 	emitters *[]*emitter.Emitter,
+	txStatuses *txStatuses,
 	verWatcher *verwatcher.VerWarcher,
 	bootstrapping *bool,
 ) lachesis.BeginBlockFn {
@@ -119,6 +122,8 @@ func consensusCallbackBeginBlockFn(
 		atroposDegenerate := true
 		// events with txs
 		confirmedEvents := make(hash.OrderedEvents, 0, 3*es.Validators.Len())
+		// confirmed events of validators, for the epoch metrics
+		eventsStats := make(map[idx.ValidatorID]validatorEventsStats, es.Validators.Len())
 
 		mpsCheatersMap := make(map[idx.ValidatorID]struct{})
 		reportCheater := func(reporter, cheater idx.ValidatorID) {
@@ -135,6 +140,10 @@ func consensusCallbackBeginBlockFn(
 				if e.AnyTxs() {
 					confirmedEvents = append(confirmedEvents, e.ID())
 				}
+				stats := eventsStats[e.Creator()]
+				stats.Events++
+				stats.GasPowerUsed += e.GasPowerUsed()
+				eventsStats[e.Creator()] = stats
 				if e.AnyMisbehaviourProofs() {
 					mps := store.GetEventPayload(e.ID()).MisbehaviourProofs()
 					for _, mp := range mps {
@@ -210,6 +219,7 @@ func consensusCallbackBeginBlockFn(
 				skipBlock = skipBlock || (emptyBlock && blockCtx.Time < bs.LastBlock.Time+es.Rules.Blocks.MaxEmptyBlockSkipPeriod)
 				// Finalize the progress of eventProcessor
 				bs = eventProcessor.Finalize(blockCtx, skipBlock) // TODO: refactor to not mutate the bs, it is unclear
+				store.addValidatorEpochEvents(es.Epoch, eventsStats)
 				{                                                 // sort and merge MPs cheaters
 					mpsCheaters := make(lachesis.Cheaters, 0, len(mpsCheatersMap))
 					for vid := range mpsCheatersMap {
@@ -252,8 +262,29 @@ func consensusCallbackBeginBlockFn(
 				}
 
 				evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, onNewLogAll, es.Rules, es.Rules.EvmChainConfig(store.GetUpgradeHeights()))
+				var txTracers evmcore.TxTracers
+				var traceRecorder *blockTraceRecorder
+				if store.evm.TraceIndexingEnabled() {
+					traceRecorder = newBlockTraceRecorder()
+					txTracers = append(txTracers, traceRecorder)
+				}
+				var addressesRecorder *blockAddressesRecorder
+				if store.evm.AddressIndexingEnabled() {
+					addressesRecorder = newBlockAddressesRecorder()
+					txTracers = append(txTracers, addressesRecorder)
+				}
+				if len(txTracers) != 0 {
+					evmProcessor.SetTxTracer(txTracers)
+				}
+				skippedRecorder := newBlockSkippedTxsRecorder()
+				evmProcessor.SetTxSkipListener(skippedRecorder.OnSkip)
 				executionStart := time.Now()
 
+				// Record the validators metrics which are passed to Driver
+				if sealing {
+					store.sealValidatorEpochMetrics(es.Epoch, es.Validators, drivermodule.SealEpochMetrics(blockCtx, bs, es))
+				}
+
 				// Execute pre-internal transactions
 				preInternalTxs := blockProc.PreTxTransactor.PopInternalTxs(blockCtx, bs, es, sealing, statedb)
 				preInternalReceipts := evmProcessor.Execute(preInternalTxs)
@@ -314,6 +345,10 @@ func consensusCallbackBeginBlockFn(
 
 					evmBlock, skippedTxs, allReceipts := evmProcessor.Finalize()
 					block.SkippedTxs = skippedTxs
+					skipped, err := skippedRecorder.result(skippedTxs)
+					if err != nil {
+						log.Error("Reasons of the skipped transactions aren't recorded", "block", blockCtx.Idx, "err", err)
+					}
 					block.Root = hash.Hash(evmBlock.Root)
 					block.GasUsed = evmBlock.GasUsed
 
@@ -370,10 +405,22 @@ func consensusCallbackBeginBlockFn(
 								store.evm.IndexLogs(r.Logs...)
 							}
 						}
+						// Store reasons of the skipped txs
+						store.evm.SetSkippedTxs(blockCtx.Idx, skipped)
 					}
 					for _, tx := range append(preInternalTxs, internalTxs...) {
 						store.evm.SetTx(tx.Hash(), tx)
 					}
+					// Store traces of the block txs
+					if traceRecorder != nil {
+						store.evm.SetBlockTraces(blockCtx.Idx, traceRecorder.traces)
+						store.evm.MarkTracedBlocks(blockCtx.Idx, blockCtx.Idx)
+					}
+					// Index the block txs by addresses
+					if addressesRecorder != nil {
+						addressesRecorder.flush(store, blockCtx.Idx)
+						store.evm.MarkAddressIndexedBlocks(blockCtx.Idx, blockCtx.Idx)
+					}
 
 					bs.LastBlock = blockCtx
 					bs.CheatersWritten = uint32(bs.EpochCheaters.Len())
@@ -396,6 +443,9 @@ func consensusCallbackBeginBlockFn(
 					headHeaderGauge.Update(int64(blockCtx.Idx))
 					headFastBlockGauge.Update(int64(blockCtx.Idx))
 
+					// Track the lifecycle of the block txs
+					txStatuses.OnBlock(blockCtx.Idx, block.Atropos, block.Time, evmBlock.Transactions, skipped)
+
 					// Notify about new block
 					if feed != nil {
 						feed.newBlock.Send(evmcore.ChainHeadNotify{Block: evmBlock})
@@ -406,6 +456,9 @@ func consensusCallbackBeginBlockFn(
 							}
 						}
 						feed.newLogs.Send(logs)
+						if len(skipped) != 0 {
+							feed.newSkippedTxs.Send(evmcore.SkippedTxsNotify{Block: evmBlock, Txs: skipped})
+						}
 					}
 
 					now := time.Now()
@@ -1,6 +1,7 @@
 package gossip
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"testing"
@@ -65,3 +66,47 @@ func TestConsensusCallback(t *testing.T) {
 	}
 
 }
+
+func TestValidatorEpochMetrics(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	const validatorsNum = 3
+
+	env := newTestEnv(2, validatorsNum, t)
+	defer env.Close()
+
+	epoch := env.store.GetEpoch()
+	_, err := env.ApplyTxs(sameEpoch, env.Transfer(1, 2, utils.ToFtm(1)))
+	require.NoError(err)
+	_, err = env.ApplyTxs(nextEpoch, env.Transfer(1, 2, utils.ToFtm(1)))
+	require.NoError(err)
+	require.Greater(env.store.GetEpoch(), epoch)
+
+	metrics := env.store.GetEpochMetrics(epoch)
+	require.Len(metrics, validatorsNum)
+	originated := new(big.Int)
+	for vid := idx.ValidatorID(1); vid <= validatorsNum; vid++ {
+		m := env.store.GetValidatorEpochMetrics(epoch, vid)
+		require.NotNil(m)
+		require.Equal(metrics[vid], *m)
+		require.NotZero(m.Events)
+		require.NotZero(m.GasPowerUsed)
+		require.NotZero(m.Uptime)
+		require.Zero(m.MissedBlocks)
+		originated.Add(originated, m.OriginatedFee)
+	}
+	require.Positive(originated.Sign())
+
+	// the metrics of the current epoch are being accumulated, they aren't served until the epoch is sealed
+	_, err = env.ApplyTxs(sameEpoch, env.Transfer(1, 2, utils.ToFtm(1)))
+	require.NoError(err)
+	current := env.store.GetEpoch()
+	require.NotNil(env.store.GetValidatorEpochMetrics(current, 1))
+	m, err := env.EthAPI.GetValidatorEpochMetrics(context.Background(), current, 1)
+	require.NoError(err)
+	require.Nil(m)
+	metrics, err = env.EthAPI.GetEpochMetrics(context.Background(), current)
+	require.NoError(err)
+	require.Nil(metrics)
+}
@@ -27,6 +27,7 @@ var (
 	errWrongEpochHash   = errors.New("wrong event epoch hash")
 	errNonExistingEpoch = errors.New("epoch doesn't exist")
 	errSameEpoch        = errors.New("epoch hasn't changed")
+	errSnapshotBehind   = errors.New("snapshot is behind the current epoch")
 )
 var (
 	processedEventsMeter = metrics.GetOrRegisterMeter("chain/events/processed", nil) // txs received into lachesis processing
@@ -163,6 +164,55 @@ func (s *Service) SwitchEpochTo(newEpoch idx.Epoch) error {
 	return nil
 }
 
+// ApplyStateSnapshot replaces the EVM state with the snapshot taken before the given epoch,
+// and switches the node to the epoch. The epoch record has to be already known.
+func (s *Service) ApplyStateSnapshot(newEpoch idx.Epoch, path string) error {
+	bs, es := s.store.GetHistoryBlockEpochState(newEpoch)
+	if bs == nil {
+		return errNonExistingEpoch
+	}
+	s.engineMu.Lock()
+	defer s.engineMu.Unlock()
+	s.blockProcWg.Wait()
+	if newEpoch <= s.store.GetEpoch() {
+		return errSnapshotBehind
+	}
+	err := s.store.evm.ReplaceWorldState(path, bs.LastBlock.Idx, bs.FinalizedStateRoot)
+	if err != nil {
+		return err
+	}
+	err = s.engine.Reset(newEpoch, es.Validators)
+	if err != nil {
+		return err
+	}
+	s.store.SetBlockEpochState(*bs, *es)
+	if !s.store.HasBlock(bs.LastBlock.Idx) {
+		// transactions of the last block aren't known, similarly to the blocks before a genesis
+		s.store.SetBlock(bs.LastBlock.Idx, &inter.Block{
+			Time:        bs.LastBlock.Time,
+			Atropos:     bs.LastBlock.Atropos,
+			Events:      hash.Events{},
+			Txs:         []common.Hash{},
+			InternalTxs: []common.Hash{},
+			SkippedTxs:  []uint32{},
+			Root:        bs.FinalizedStateRoot,
+		})
+		s.store.SetBlockIndex(bs.LastBlock.Atropos, bs.LastBlock.Idx)
+	}
+	// blocks before the snapshot are neither decided nor filled by LLR, similarly to the blocks before a genesis
+	s.store.ModifyLlrState(func(llrs *LlrState) {
+		if llrs.LowestBlockToDecide <= bs.LastBlock.Idx {
+			llrs.LowestBlockToDecide = bs.LastBlock.Idx + 1
+		}
+		if llrs.LowestBlockToFill <= bs.LastBlock.Idx {
+			llrs.LowestBlockToFill = bs.LastBlock.Idx + 1
+		}
+	})
+	s.switchEpochTo(newEpoch)
+	s.commit(true)
+	return nil
+}
+
 func (s *Service) processEventEpochIndex(e *inter.EventPayload, oldEpoch, newEpoch idx.Epoch) {
 	// index DAG heads and last events
 	s.store.SetHeads(oldEpoch, processEventHeads(s.store.GetHeads(oldEpoch), e))
@@ -249,6 +299,7 @@ func (s *Service) processEvent(e *inter.EventPayload) error {
 	for _, em := range s.emitters {
 		em.OnEventConnected(e)
 	}
+	s.txStatuses.OnEvent(e)
 
 	if newEpoch != oldEpoch {
 		s.switchEpochTo(newEpoch)
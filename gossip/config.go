@@ -1,6 +1,7 @@
 package gossip
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"path/filepath"
@@ -11,8 +12,10 @@ import (
 	"github.com/Fantom-foundation/lachesis-base/inter/dag"
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 
+	"github.com/mrmikeo/Xpense/authrpc"
 	"github.com/mrmikeo/Xpense/eventcheck/heavycheck"
 	"github.com/mrmikeo/Xpense/gossip/evmstore"
 	"github.com/mrmikeo/Xpense/gossip/filters"
@@ -28,6 +31,9 @@ import (
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epprocessor"
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream/epstreamleecher"
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream/epstreamseeder"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream/snstreamleecher"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream/snstreamseeder"
+	"github.com/mrmikeo/Xpense/rpclimit"
 )
 
 const nominalSize uint = 1
@@ -62,6 +68,8 @@ type (
 		BrStreamSeeder   brstreamseeder.Config
 		EpStreamLeecher  epstreamleecher.Config
 		EpStreamSeeder   epstreamseeder.Config
+		SnStreamLeecher  snstreamleecher.Config
+		SnStreamSeeder   snstreamseeder.Config
 
 		MaxInitialTxHashesSend   int
 		MaxRandomTxHashesSend    int
@@ -113,7 +121,66 @@ type (
 		// MaxResponseSize is a limit for maximum response size in some RPC calls
 		MaxResponseSize int
 
+		// TxStatusesCacheSize is a number of the recent transactions which lifecycle is tracked, zero disables the tracking
+		TxStatusesCacheSize int
+
 		RPCBlockExt bool
+
+		// RPCLimits are the per-client rate limits of the HTTP-RPC, WebSocket-RPC and GraphQL calls
+		RPCLimits rpclimit.Config
+
+		// AuthRPC is the JWT-authenticated HTTP-RPC endpoint serving the privileged namespaces
+		AuthRPC authrpc.Config
+
+		// State snapshots serving and syncing options
+		Snapshots SnapshotsConfig
+
+		// Private transactions submission options
+		PrivateTxs PrivateTxsConfig
+
+		// Transaction bundles submission options
+		Bundles BundlesConfig
+	}
+
+	// BundlesConfig is a config of the transaction bundles, which are included atomically into one event by the local emitter
+	BundlesConfig struct {
+		// MaxBundles is a maximum number of the pending bundles
+		MaxBundles int
+		// MaxBundleTxs is a maximum number of transactions in a bundle
+		MaxBundleTxs int
+		// DefaultBlocks is a number of blocks a bundle is kept for if no deadline is specified
+		DefaultBlocks idx.Block
+		// MaxBlocks is a maximum number of blocks a deadline of a bundle may be ahead of the latest block
+		MaxBlocks idx.Block
+	}
+
+	// PrivateTxsConfig is a config of the private transactions, which are never announced to the public peers
+	PrivateTxsConfig struct {
+		// TrustedPeers are the peers (usually validators) which private transactions are forwarded to and accepted from
+		TrustedPeers []*enode.Node
+		// DefaultBlocks is a number of blocks a private transaction is kept for if no deadline is specified
+		DefaultBlocks idx.Block
+		// MaxBlocks is a maximum number of blocks a deadline of a private transaction may be ahead of the latest block
+		MaxBlocks idx.Block
+	}
+
+	// SnapshotsConfig is a config of the state snapshots, which let new nodes
+	// start from a recent LLR-finalized epoch instead of replaying the whole history.
+	SnapshotsConfig struct {
+		// Serve enables periodic creation of the snapshots for the syncing peers, requires the archive state
+		Serve bool
+		// Sync enables downloading of a snapshot from the peers if the node is far behind
+		Sync bool
+		// Directory for the created and downloaded snapshots
+		Dir string
+		// Number of epochs between the created snapshots
+		EpochsInterval idx.Epoch
+		// Number of the latest created snapshots to keep
+		Keep int
+		// Min number of epochs the node has to be behind a snapshot to download it
+		MinEpochsBehind idx.Epoch
+		// Period of checks for a new snapshot to create or to download
+		Period time.Duration
 	}
 
 	StoreCacheConfig struct {
@@ -139,9 +206,27 @@ type (
 		EVM               evmstore.StoreConfig
 		MaxNonFlushedSize int
 		MaxNonFlushedPeriod time.Duration
+		// Pruning of the blocks history
+		Pruning PruningConfig
+	}
+
+	// PruningConfig is a config of the blocks history pruning.
+	PruningConfig struct {
+		// Number of the last blocks to keep the history of, 0 means no pruning by blocks
+		HistoryBlocks idx.Block
+		// Number of the sealed epochs to keep the history of in addition to the current epoch,
+		// 0 means no pruning by epochs
+		HistoryEpochs idx.Epoch
+		// Period of the background pruning
+		Period time.Duration
+		// Max number of blocks pruned at once
+		BatchBlocks idx.Block
 	}
 )
 
+// MinHistoryBlocks is the minimum number of blocks to keep the history of, as the EVM may access their hashes
+const MinHistoryBlocks = 256
+
 type PeerCacheConfig struct {
 	MaxKnownTxs    int // Maximum transactions hashes to keep in the known list (prevent DOS)
 	MaxKnownEvents int // Maximum event hashes to keep in the known list (prevent DOS)
@@ -209,6 +294,8 @@ func DefaultConfig(scale cachescale.Func) Config {
 			BrStreamSeeder:           brstreamseeder.DefaultConfig(scale),
 			EpStreamLeecher:          epstreamleecher.DefaultConfig(),
 			EpStreamSeeder:           epstreamseeder.DefaultConfig(scale),
+			SnStreamLeecher:          snstreamleecher.DefaultConfig(),
+			SnStreamSeeder:           snstreamseeder.DefaultConfig(scale),
 			MaxInitialTxHashesSend:   20000,
 			MaxRandomTxHashesSend:    250, // match softLimitItems to fit into one message
 			RandomTxHashesSendPeriod: 1 * time.Second,
@@ -234,6 +321,30 @@ func DefaultConfig(scale cachescale.Func) Config {
 		JSTracerLimit: 1000,
 
 		MaxResponseSize: 25 * 1024 * 1024,
+
+		TxStatusesCacheSize: 100000,
+
+		RPCLimits: rpclimit.DefaultConfig(),
+		AuthRPC:   authrpc.DefaultConfig(),
+
+		Snapshots: SnapshotsConfig{
+			EpochsInterval:  100,
+			Keep:            2,
+			MinEpochsBehind: 100,
+			Period:          30 * time.Second,
+		},
+
+		PrivateTxs: PrivateTxsConfig{
+			DefaultBlocks: 25,
+			MaxBlocks:     1000,
+		},
+
+		Bundles: BundlesConfig{
+			MaxBundles:    256,
+			MaxBundleTxs:  16,
+			DefaultBlocks: 25,
+			MaxBlocks:     1000,
+		},
 	}
 	sessionCfg := cfg.Protocol.DagStreamLeecher.Session
 	cfg.Protocol.DagProcessor.EventsBufferLimit.Num = idx.Event(sessionCfg.ParallelChunksDownload)*
@@ -273,6 +384,21 @@ func (c *Config) Validate() error {
 	if p.DagProcessor.EventsBufferLimit.Size < protocolMaxMsgSize {
 		return fmt.Errorf("EventsBufferLimit.Size has to be at least %d", protocolMaxMsgSize)
 	}
+	if err := c.Snapshots.Validate(); err != nil {
+		return err
+	}
+	if err := c.PrivateTxs.Validate(); err != nil {
+		return err
+	}
+	if err := c.Bundles.Validate(); err != nil {
+		return err
+	}
+	if err := c.RPCLimits.Validate(); err != nil {
+		return err
+	}
+	if err := c.AuthRPC.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -293,7 +419,70 @@ func DefaultStoreConfig(scale cachescale.Func) StoreConfig {
 		EVM:                 evmstore.DefaultStoreConfig(scale),
 		MaxNonFlushedSize:   21*opt.MiB + scale.I(2*opt.MiB),
 		MaxNonFlushedPeriod: 30 * time.Minute,
+		Pruning: PruningConfig{
+			Period:      10 * time.Second,
+			BatchBlocks: 1000,
+		},
+	}
+}
+
+// Enabled tells whether the blocks history is pruned
+func (c PruningConfig) Enabled() bool {
+	return c.HistoryBlocks != 0 || c.HistoryEpochs != 0
+}
+
+// Validate checks the pruning config
+func (c PruningConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.HistoryBlocks != 0 && c.HistoryBlocks < MinHistoryBlocks {
+		return fmt.Errorf("history of at least %d blocks has to be kept", MinHistoryBlocks)
+	}
+	if c.Period <= 0 || c.BatchBlocks == 0 {
+		return errors.New("pruning period and batch have to be positive")
+	}
+	return nil
+}
+
+// Validate checks the snapshots config
+func (c SnapshotsConfig) Validate() error {
+	if !c.Serve && !c.Sync {
+		return nil
+	}
+	if c.Dir == "" {
+		return errors.New("snapshots directory isn't set")
+	}
+	if c.Period <= 0 {
+		return errors.New("snapshots period has to be positive")
 	}
+	if c.Serve && (c.EpochsInterval == 0 || c.Keep <= 0) {
+		return errors.New("snapshots interval and number of kept snapshots have to be positive")
+	}
+	return nil
+}
+
+func (c PrivateTxsConfig) Validate() error {
+	if c.DefaultBlocks == 0 || c.MaxBlocks == 0 {
+		return errors.New("private transactions default and max number of blocks have to be positive")
+	}
+	if c.DefaultBlocks > c.MaxBlocks {
+		return errors.New("private transactions default number of blocks has to be not greater than max number of blocks")
+	}
+	return nil
+}
+
+func (c BundlesConfig) Validate() error {
+	if c.MaxBundles <= 0 || c.MaxBundleTxs <= 0 {
+		return errors.New("max number of bundles and max number of bundle transactions have to be positive")
+	}
+	if c.DefaultBlocks == 0 || c.MaxBlocks == 0 {
+		return errors.New("bundles default and max number of blocks have to be positive")
+	}
+	if c.DefaultBlocks > c.MaxBlocks {
+		return errors.New("bundles default number of blocks has to be not greater than max number of blocks")
+	}
+	return nil
 }
 
 // MemTestStoreConfig is for tests or inmemory.
@@ -15,9 +15,10 @@ import (
 
 // dummyTxPool is a fake, helper transaction pool for testing purposes
 type dummyTxPool struct {
-	txFeed notify.Feed
-	pool   []*types.Transaction        // Collection of all transactions
-	added  chan<- []*types.Transaction // Notification channel for new transactions
+	txFeed   notify.Feed
+	dropFeed notify.Feed
+	pool     []*types.Transaction        // Collection of all transactions
+	added    chan<- []*types.Transaction // Notification channel for new transactions
 
 	signer types.Signer
 
@@ -45,6 +46,10 @@ func (p *dummyTxPool) AddLocal(tx *types.Transaction) error {
 	return p.AddLocals([]*types.Transaction{tx})[0]
 }
 
+func (p *dummyTxPool) AddUnjournaled(tx *types.Transaction) error {
+	return p.AddRemotes([]*types.Transaction{tx})[0]
+}
+
 func (p *dummyTxPool) Nonce(addr common.Address) uint64 {
 	return 0
 }
@@ -81,10 +86,18 @@ func (p *dummyTxPool) GasPrice() *big.Int {
 	return big.NewInt(0)
 }
 
+func (p *dummyTxPool) PolicyStatus() evmcore.TxPolicyStatus {
+	return evmcore.TxPolicyStatus{}
+}
+
 func (p *dummyTxPool) SubscribeNewTxsNotify(ch chan<- evmcore.NewTxsNotify) notify.Subscription {
 	return p.txFeed.Subscribe(ch)
 }
 
+func (p *dummyTxPool) SubscribeDroppedTxsNotify(ch chan<- evmcore.DroppedTxsNotify) notify.Subscription {
+	return p.dropFeed.Subscribe(ch)
+}
+
 func (p *dummyTxPool) Map() map[common.Hash]*types.Transaction {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -175,3 +188,7 @@ func (p *dummyTxPool) Delete(needle common.Hash) {
 	}
 	p.pool = notErased
 }
+
+func (p *dummyTxPool) RemoveTx(txid common.Hash) {
+	p.Delete(txid)
+}
@@ -0,0 +1,230 @@
+package emitter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+// PrivateEmitterAPI provides an API to control the events emission and to inspect the emit decisions.
+type PrivateEmitterAPI struct {
+	em *Emitter
+}
+
+// NewPrivateEmitterAPI creates a new emitter API.
+func NewPrivateEmitterAPI(em *Emitter) *PrivateEmitterAPI {
+	return &PrivateEmitterAPI{em}
+}
+
+// RPCEmitIntervals is the emit intervals in the RPC form, the durations are in the Go format, e.g. "110ms"
+type RPCEmitIntervals struct {
+	Min                        string `json:"min"`
+	Max                        string `json:"max"`
+	Confirming                 string `json:"confirming"`
+	ParallelInstanceProtection string `json:"parallelInstanceProtection"`
+	DoublesignProtection       string `json:"doublesignProtection"`
+}
+
+func toRPCEmitIntervals(i EmitIntervals) RPCEmitIntervals {
+	return RPCEmitIntervals{
+		Min:                        i.Min.String(),
+		Max:                        i.Max.String(),
+		Confirming:                 i.Confirming.String(),
+		ParallelInstanceProtection: i.ParallelInstanceProtection.String(),
+		DoublesignProtection:       i.DoublesignProtection.String(),
+	}
+}
+
+// SetEmitIntervalsArgs is the arguments of SetIntervals, the omitted intervals are kept
+type SetEmitIntervalsArgs struct {
+	Min                        *string `json:"min"`
+	Max                        *string `json:"max"`
+	Confirming                 *string `json:"confirming"`
+	ParallelInstanceProtection *string `json:"parallelInstanceProtection"`
+	DoublesignProtection       *string `json:"doublesignProtection"`
+}
+
+// Pause stops the events emission until Resume is called. The emission isn't resumed after a restart of the node.
+func (api *PrivateEmitterAPI) Pause() bool {
+	if !api.em.paused.Swap(true) {
+		api.em.Log.Warn("Events emission is paused via API")
+	}
+	return true
+}
+
+// Resume resumes the events emission.
+func (api *PrivateEmitterAPI) Resume() bool {
+	if api.em.paused.Swap(false) {
+		api.em.Log.Info("Events emission is resumed via API")
+	}
+	return true
+}
+
+// Status returns the state of the emitter.
+func (api *PrivateEmitterAPI) Status() map[string]interface{} {
+	em := api.em
+	em.world.Lock()
+	defer em.world.Unlock()
+
+	res := map[string]interface{}{
+		"paused":             em.paused.Load(),
+		"validator":          hexutil.Uint64(em.config.Validator.ID),
+		"isValidator":        em.isValidator(),
+		"epoch":              hexutil.Uint64(em.epoch),
+		"config":             toRPCEmitIntervals(em.config.EmitIntervals),
+		"intervals":          toRPCEmitIntervals(em.intervals),
+		"prevEmittedAtTime":  em.prevEmittedAtTime,
+		"prevEmittedAtBlock": hexutil.Uint64(em.prevEmittedAtBlock),
+		"idle":               em.idle(),
+		"lastEmittedEventID": nil,
+		"gasPowerLeft":       nil,
+		"lastDecisionReason": nil,
+		"standby":            em.world.Lease != nil && !em.world.Lease.IsLeader(),
+	}
+	if id := em.readLastEmittedEventID(); id != nil {
+		res["lastEmittedEventID"] = hexutil.Bytes(id.Bytes())
+	}
+	for _, d := range em.decisions.last(0) {
+		if d.GasPowerLeft != nil {
+			res["gasPowerLeft"] = rpcGasPowerLeft(*d.GasPowerLeft)
+			break
+		}
+	}
+	if last := em.decisions.last(1); len(last) != 0 {
+		res["lastDecisionReason"] = last[0].Reason
+	}
+	return res
+}
+
+// Intervals returns the emit intervals configured and the intervals in force.
+// The intervals in force are adjusted by the emitterdriver contract and by the stake of the validator.
+func (api *PrivateEmitterAPI) Intervals() map[string]RPCEmitIntervals {
+	em := api.em
+	em.world.Lock()
+	defer em.world.Unlock()
+	return map[string]RPCEmitIntervals{
+		"config":    toRPCEmitIntervals(em.config.EmitIntervals),
+		"intervals": toRPCEmitIntervals(em.intervals),
+	}
+}
+
+// SetIntervals changes the configured emit intervals until a restart of the node.
+func (api *PrivateEmitterAPI) SetIntervals(args SetEmitIntervalsArgs) (map[string]RPCEmitIntervals, error) {
+	em := api.em
+	em.world.Lock()
+	cfg := em.config.EmitIntervals
+	em.world.Unlock()
+
+	set := func(name string, dst *time.Duration, src *string) error {
+		if src == nil {
+			return nil
+		}
+		d, err := time.ParseDuration(*src)
+		if err != nil {
+			return fmt.Errorf("invalid %s interval: %w", name, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("negative %s interval", name)
+		}
+		*dst = d
+		return nil
+	}
+	if err := set("min", &cfg.Min, args.Min); err != nil {
+		return nil, err
+	}
+	if err := set("max", &cfg.Max, args.Max); err != nil {
+		return nil, err
+	}
+	if err := set("confirming", &cfg.Confirming, args.Confirming); err != nil {
+		return nil, err
+	}
+	if err := set("parallelInstanceProtection", &cfg.ParallelInstanceProtection, args.ParallelInstanceProtection); err != nil {
+		return nil, err
+	}
+	if err := set("doublesignProtection", &cfg.DoublesignProtection, args.DoublesignProtection); err != nil {
+		return nil, err
+	}
+	if cfg.Max == 0 {
+		return nil, errors.New("max interval must be positive")
+	}
+	if cfg.Min > cfg.Max {
+		return nil, errors.New("min interval must not exceed max interval")
+	}
+	if cfg.Confirming > cfg.Max {
+		return nil, errors.New("confirming interval must not exceed max interval")
+	}
+
+	em.world.Lock()
+	defer em.world.Unlock()
+	em.setEmitIntervals(cfg)
+	em.Log.Warn("Emit intervals are changed via API", "min", cfg.Min, "max", cfg.Max, "confirming", cfg.Confirming,
+		"parallelInstanceProtection", cfg.ParallelInstanceProtection, "doublesignProtection", cfg.DoublesignProtection)
+	return map[string]RPCEmitIntervals{
+		"config":    toRPCEmitIntervals(em.config.EmitIntervals),
+		"intervals": toRPCEmitIntervals(em.intervals),
+	}, nil
+}
+
+// RPCDecision is an emit decision in the RPC form
+type RPCDecision struct {
+	Time         time.Time              `json:"time"`
+	LastTime     time.Time              `json:"lastTime"`
+	Count        hexutil.Uint64         `json:"count"`
+	Emitted      bool                   `json:"emitted"`
+	Reason       string                 `json:"reason"`
+	Synced       bool                   `json:"synced"`
+	Event        hexutil.Bytes          `json:"event,omitempty"`
+	Seq          hexutil.Uint64         `json:"seq"`
+	Parents      []hexutil.Bytes        `json:"parents"`
+	Metric       hexutil.Uint64         `json:"metric"`
+	GasPowerLeft map[string]interface{} `json:"gasPowerLeft"`
+	Txs          hexutil.Uint64         `json:"txs"`
+}
+
+// Decisions returns up to n last emit decisions, the newest first.
+// All the kept decisions are returned if n isn't specified.
+func (api *PrivateEmitterAPI) Decisions(n *hexutil.Uint64) []RPCDecision {
+	limit := 0
+	if n != nil {
+		if *n == 0 {
+			return []RPCDecision{}
+		}
+		if *n < DecisionsLogSize {
+			limit = int(*n)
+		}
+	}
+	decisions := api.em.decisions.last(limit)
+	res := make([]RPCDecision, len(decisions))
+	for i, d := range decisions {
+		res[i] = RPCDecision{
+			Time:     d.Time,
+			LastTime: d.LastTime,
+			Count:    hexutil.Uint64(d.Count),
+			Emitted:  d.Emitted,
+			Reason:   d.Reason,
+			Synced:   d.Synced,
+			Seq:      hexutil.Uint64(d.Seq),
+			Parents:  inter.EventIDsToHex(d.Parents),
+			Metric:   hexutil.Uint64(d.Metric),
+			Txs:      hexutil.Uint64(d.Txs),
+		}
+		if d.Event != nil {
+			res[i].Event = d.Event.Bytes()
+		}
+		if d.GasPowerLeft != nil {
+			res[i].GasPowerLeft = rpcGasPowerLeft(*d.GasPowerLeft)
+		}
+	}
+	return res
+}
+
+func rpcGasPowerLeft(g inter.GasPowerLeft) map[string]interface{} {
+	return map[string]interface{}{
+		"shortTerm": hexutil.Uint64(g.Gas[inter.ShortTermGas]),
+		"longTerm":  hexutil.Uint64(g.Gas[inter.LongTermGas]),
+	}
+}
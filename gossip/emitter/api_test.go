@@ -0,0 +1,122 @@
+package emitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/inter/pos"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/gossip/emitter/mock"
+	"github.com/mrmikeo/Xpense/integration/makefakegenesis"
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/opera"
+	"github.com/mrmikeo/Xpense/vecmt"
+)
+
+func TestDecisionsLog(t *testing.T) {
+	require := require.New(t)
+
+	var l decisionsLog
+	require.Empty(l.last(0))
+
+	l.add(Decision{Reason: reasonIdle})
+	l.add(Decision{Reason: reasonIdle})
+	l.add(Decision{Reason: reasonAllowed, Emitted: true})
+	l.add(Decision{Reason: reasonAllowed, Emitted: true})
+	l.add(Decision{Reason: reasonPaused})
+
+	got := l.last(0)
+	require.Len(got, 4)
+	require.Equal(reasonPaused, got[0].Reason)
+	require.True(got[1].Emitted)
+	require.True(got[2].Emitted)
+	require.Equal(reasonIdle, got[3].Reason)
+	require.Equal(2, got[3].Count)
+	require.Len(l.last(2), 2)
+
+	// the oldest decisions are dropped
+	for i := 0; i < DecisionsLogSize; i++ {
+		l.add(Decision{Reason: reasonAllowed, Emitted: true, Seq: idx.Event(i)})
+	}
+	got = l.last(0)
+	require.Len(got, DecisionsLogSize)
+	require.Equal(idx.Event(DecisionsLogSize-1), got[0].Seq)
+	require.Equal(idx.Event(0), got[DecisionsLogSize-1].Seq)
+}
+
+func TestPrivateEmitterAPI(t *testing.T) {
+	require := require.New(t)
+
+	cfg := DefaultConfig()
+	gValidators := makefakegenesis.GetFakeValidators(3)
+	vv := pos.NewBuilder()
+	for _, v := range gValidators {
+		vv.Set(v.ID, pos.Weight(1))
+	}
+	validators := vv.Build()
+	cfg.Validator.ID = gValidators[0].ID
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	external.EXPECT().Lock().AnyTimes()
+	external.EXPECT().Unlock().AnyTimes()
+	external.EXPECT().DagIndex().Return((*vecmt.Index)(nil)).AnyTimes()
+	external.EXPECT().IsSynced().Return(true).AnyTimes()
+	external.EXPECT().PeersNum().Return(3).AnyTimes()
+	external.EXPECT().StateDB().Return(nil).AnyTimes()
+	external.EXPECT().GetRules().Return(opera.FakeNetRules()).AnyTimes()
+	external.EXPECT().GetEpochValidators().Return(validators, idx.Epoch(1)).AnyTimes()
+	external.EXPECT().GetLastEvent(idx.Epoch(1), cfg.Validator.ID).Return((*hash.Event)(nil)).AnyTimes()
+	external.EXPECT().GetGenesisTime().Return(inter.Timestamp(uint64(time.Now().UnixNano()))).AnyTimes()
+	external.EXPECT().IsBusy().Return(true).AnyTimes()
+
+	em := NewEmitter(cfg, World{
+		External: external,
+		TxPool:   mock.NewMockTxPool(ctrl),
+		Signer:   mock.NewMockSigner(ctrl),
+		TxSigner: mock.NewMockTxSigner(ctrl),
+	})
+	em.init()
+	api := NewPrivateEmitterAPI(em)
+
+	// paused emitter doesn't try to emit
+	require.True(api.Pause())
+	em.tick()
+	em.tick()
+	decisions := api.Decisions(nil)
+	require.Len(decisions, 1)
+	require.Equal(reasonPaused, decisions[0].Reason)
+	require.Equal(hexutil.Uint64(2), decisions[0].Count)
+	require.Equal(true, api.Status()["paused"])
+
+	require.True(api.Resume())
+	em.tick()
+	n := hexutil.Uint64(1)
+	decisions = api.Decisions(&n)
+	require.Len(decisions, 1)
+	require.Equal(reasonBusy, decisions[0].Reason)
+	require.Equal(false, api.Status()["paused"])
+
+	// intervals are changed at runtime
+	minInterval, maxInterval := "300ms", "5s"
+	res, err := api.SetIntervals(SetEmitIntervalsArgs{Min: &minInterval, Max: &maxInterval})
+	require.NoError(err)
+	require.Equal("300ms", res["config"].Min)
+	require.Equal("300ms", res["intervals"].Min)
+	require.Equal("5s", res["intervals"].Max)
+	require.Equal(300*time.Millisecond, em.intervals.Min)
+	require.Equal(cfg.EmitIntervals.Confirming, em.config.EmitIntervals.Confirming)
+
+	invalid := "soon"
+	_, err = api.SetIntervals(SetEmitIntervalsArgs{Min: &invalid})
+	require.Error(err)
+	tooLarge := "6s"
+	_, err = api.SetIntervals(SetEmitIntervalsArgs{Min: &tooLarge})
+	require.Error(err)
+	require.Equal(300*time.Millisecond, em.intervals.Min)
+}
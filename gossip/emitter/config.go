@@ -52,6 +52,10 @@ type Config struct {
 	PrevEmittedEventFile FileConfig
 	PrevBlockVotesFile   FileConfig
 	PrevEpochVoteFile    FileConfig
+
+	// SlashingProtectionDB is the directory of the slashing protection database, which records
+	// every signed event and vote, and refuses to sign the conflicting ones. Disabled if empty.
+	SlashingProtectionDB string
 }
 
 // DefaultConfig returns the default configurations for the events emitter.
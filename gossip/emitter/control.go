@@ -41,7 +41,8 @@ func eventMetric(orig ancestor.Metric, seq idx.Event) ancestor.Metric {
 	return kickStartMetric(ancestor.Metric(eventMetricF(uint64(orig))), seq)
 }
 
-func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Metric, selfParent *inter.Event) bool {
+// isAllowedToEmit decides whether the event should be emitted, returns the reason of the decision
+func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Metric, selfParent *inter.Event) (bool, string) {
 	passedTime := e.CreationTime().Time().Sub(em.prevEmittedAtTime)
 	if passedTime < 0 {
 		passedTime = 0
@@ -73,7 +74,7 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 					"power", e.GasPowerLeft().String(),
 					"selfParentPower", selfParent.GasPowerLeft().String(),
 					"stake%", 100*float64(em.validators.Get(e.Creator()))/float64(em.validators.TotalWeight()))
-				return false
+				return false, reasonEmergencyGasPower
 			}
 		}
 	}
@@ -84,10 +85,12 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 		if rules.Economy.BlockMissedSlack > maxBlocks && maxBlocks < rules.Economy.BlockMissedSlack-5 {
 			maxBlocks = rules.Economy.BlockMissedSlack - 5
 		}
-		if passedTime >= em.intervals.Max ||
-			passedBlocks >= maxBlocks*4/5 && metric >= piecefunc.DecimalUnit/2 ||
+		if passedTime >= em.intervals.Max {
+			return true, reasonMaxInterval
+		}
+		if passedBlocks >= maxBlocks*4/5 && metric >= piecefunc.DecimalUnit/2 ||
 			passedBlocks >= maxBlocks {
-			return true
+			return true, reasonMissedBlocks
 		}
 	}
 	// Slow down emitting if power is low
@@ -100,7 +103,7 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 			factor := float64(e.GasPowerLeft().Min()) / float64(threshold)
 			adjustedEmitInterval := time.Duration(maxT - (maxT-minT)*factor)
 			if passedTime < adjustedEmitInterval {
-				return false
+				return false, reasonLowGasPower
 			}
 		}
 	}
@@ -108,7 +111,7 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 	{
 		if em.idle() &&
 			!eTxs {
-			return false
+			return false, reasonIdle
 		}
 	}
 	// enforced !em.idle() || eTxs
@@ -117,23 +120,23 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 	{
 		// Min already enforced in tick(), just to make sure
 		if passedTime < em.intervals.Min {
-			return false
+			return false, reasonMinInterval
 		}
 
 		// Slow down emitting if no txs to confirm and will not help the consensus significantly
 		if adjustedPassedTime < em.intervals.Min &&
 			em.idle() {
-			return false
+			return false, reasonLowMetric
 		}
 
 		// Slow down if no txs to originate (but at least 1 tx to confirm)
 		if adjustedPassedIdleTime < em.intervals.Confirming &&
 			!eTxs {
-			return false
+			return false, reasonConfirmingInterval
 		}
 	}
 
-	return true
+	return true, reasonAllowed
 }
 
 func (em *Emitter) recheckIdleTime() {
@@ -0,0 +1,120 @@
+package emitter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/emitter/ancestor"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+// DecisionsLogSize is the number of the last emit decisions kept for the inspection
+const DecisionsLogSize = 256
+
+// Reasons of the emit decisions
+const (
+	reasonAllowed            = "allowed"
+	reasonMaxInterval        = "max interval passed"
+	reasonMissedBlocks       = "too many blocks passed since previous event"
+	reasonEmergencyGasPower  = "not enough gas power and it is decreasing"
+	reasonLowGasPower        = "low gas power, slowing down"
+	reasonIdle               = "idle, no txs to confirm or originate"
+	reasonMinInterval        = "min interval not passed"
+	reasonLowMetric          = "idle, event would not advance the consensus enough"
+	reasonConfirmingInterval = "confirming interval not passed"
+	reasonPaused             = "paused"
+	reasonBusy               = "node is busy"
+	reasonNoParents          = "no parents chosen"
+	reasonFork               = "self-fork detected"
+	reasonNotEnoughGasPower  = "not enough gas power to build event"
+	reasonBuildFailed        = "failed to build event"
+)
+
+// Decision is the outcome of an attempt to emit an event.
+// Consecutive attempts which didn't emit an event for the same reason are merged into a single decision.
+type Decision struct {
+	Time     time.Time // time of the first attempt
+	LastTime time.Time // time of the last merged attempt
+	Count    int       // number of merged attempts
+	Emitted  bool
+	Reason   string
+	Synced   bool // whether the sync status allowed emitting
+
+	// fields below are set only if the event was built
+	Event        *hash.Event
+	Seq          idx.Event
+	Parents      hash.Events
+	Metric       ancestor.Metric
+	GasPowerLeft *inter.GasPowerLeft
+	Txs          int
+}
+
+// decisionsLog is a ring buffer of the last decisions, it's safe for concurrent use
+type decisionsLog struct {
+	mu    sync.Mutex
+	items []Decision
+	next  int
+}
+
+func (l *decisionsLog) add(d Decision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+	d.LastTime = d.Time
+	d.Count = 1
+	if len(l.items) != 0 {
+		last := &l.items[(l.next+len(l.items)-1)%len(l.items)]
+		if !d.Emitted && !last.Emitted && last.Reason == d.Reason {
+			last.LastTime = d.Time
+			last.Count++
+			if d.GasPowerLeft != nil {
+				last.GasPowerLeft = d.GasPowerLeft
+				last.Metric = d.Metric
+			}
+			return
+		}
+	}
+	if len(l.items) < DecisionsLogSize {
+		l.items = append(l.items, d)
+		return
+	}
+	l.items[l.next] = d
+	l.next = (l.next + 1) % DecisionsLogSize
+}
+
+// last returns up to n last decisions, the newest first
+func (l *decisionsLog) last(n int) []Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n > len(l.items) || n <= 0 {
+		n = len(l.items)
+	}
+	res := make([]Decision, 0, n)
+	for i := 0; i < n; i++ {
+		res = append(res, l.items[(l.next+len(l.items)-1-i)%len(l.items)])
+	}
+	return res
+}
+
+// decide records the decision and returns whether the event is allowed to be emitted
+func (em *Emitter) decide(e inter.EventI, eTxs bool, metric ancestor.Metric, selfParent *inter.Event) bool {
+	allowed, reason := em.isAllowedToEmit(e, eTxs, metric, selfParent)
+	if !allowed {
+		gasPowerLeft := e.GasPowerLeft()
+		em.decisions.add(Decision{
+			Reason:       reason,
+			Synced:       true,
+			Seq:          e.Seq(),
+			Parents:      e.Parents(),
+			Metric:       metric,
+			GasPowerLeft: &gasPowerLeft,
+		})
+	}
+	em.lastReason = reason
+	return allowed
+}
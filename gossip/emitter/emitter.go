@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Fantom-foundation/lachesis-base/emitter/ancestor"
@@ -24,6 +25,8 @@ import (
 	"github.com/mrmikeo/Xpense/tracing"
 	"github.com/mrmikeo/Xpense/utils/errlock"
 	"github.com/mrmikeo/Xpense/utils/rate"
+	"github.com/mrmikeo/Xpense/valkeystore"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
 )
 
 const (
@@ -40,6 +43,8 @@ var (
 	txsSkippedConflictingSender = metrics.GetOrRegisterCounter("emitter/skipped/conflictingsender", nil) // tx by given sender in some unconfirmed event
 	txsSkippedNotMyTurn         = metrics.GetOrRegisterCounter("emitter/skipped/notmyturn", nil)         // tx should be handled by other validator
 	txsSkippedOutdated          = metrics.GetOrRegisterCounter("emitter/skipped/outdated", nil)          // tx skipped because it is outdated
+	bundlesSkipped              = metrics.GetOrRegisterCounter("emitter/skipped/bundles", nil)           // bundle skipped because it cannot be included entirely
+	bundlesOriginated           = metrics.GetOrRegisterCounter("emitter/originated/bundles", nil)        // bundle included into an emitted event
 
 	skippedOfflineValidatorsCounter = metrics.GetOrRegisterCounter("emitter/skipped_offline", nil)
 
@@ -82,6 +87,8 @@ type Emitter struct {
 
 	intervals                EmitIntervals
 	globalConfirmingInterval time.Duration
+	// driverIntervals are the adjustments of the intervals by emitterdriver contract, zero if not set
+	driverIntervals EmitIntervals
 
 	done chan struct{}
 	wg   sync.WaitGroup
@@ -98,8 +105,16 @@ type Emitter struct {
 	emittedEventFile *os.File
 	emittedBvsFile   *os.File
 	emittedEvFile    *os.File
+	protectionDB     *protection.DB
+	protectionMu     sync.Mutex
 	busyRate         *rate.Gauge
 
+	// paused is set via the emitter API to stop the emission
+	paused     atomic.Bool
+	decisions  decisionsLog
+	lastReason string
+	lastMetric ancestor.Metric
+
 	logger.Periodic
 }
 
@@ -140,6 +155,15 @@ func (em *Emitter) init() {
 	if len(em.config.PrevEpochVoteFile.Path) != 0 {
 		em.emittedEvFile = openPrevActionFile(em.config.PrevEpochVoteFile.Path, em.config.PrevEpochVoteFile.SyncMode)
 	}
+	if em.world.Lease == nil {
+		// in the active/passive mode, the database is opened once the lease is acquired
+		if err := em.openProtection(); err != nil {
+			em.Log.Crit("Failed to open slashing protection database", "path", em.config.SlashingProtectionDB, "err", err)
+		}
+	} else {
+		// the protected signer refuses to sign once the database is closed, until it's reopened by the leader
+		em.world.Lease.OnLost(em.closeProtection)
+	}
 	em.busyRate = rate.NewGauge()
 }
 
@@ -185,6 +209,42 @@ func (em *Emitter) Stop() {
 	em.done = nil
 	em.wg.Wait()
 	em.busyRate.Stop()
+	em.closeProtection()
+}
+
+// openProtection opens the slashing protection database and wraps the signer with it
+func (em *Emitter) openProtection() error {
+	em.protectionMu.Lock()
+	defer em.protectionMu.Unlock()
+	if em.protectionDB != nil || len(em.config.SlashingProtectionDB) == 0 {
+		return nil
+	}
+	db, err := protection.Open(em.config.SlashingProtectionDB)
+	if err != nil {
+		return err
+	}
+	em.protectionDB = db
+	em.world.Signer = protection.NewSigner(em.world.Signer, db)
+	return nil
+}
+
+func (em *Emitter) closeProtection() {
+	em.protectionMu.Lock()
+	defer em.protectionMu.Unlock()
+	if em.protectionDB == nil {
+		return
+	}
+	if err := em.protectionDB.Close(); err != nil {
+		em.Log.Warn("Failed to close slashing protection database", "err", err)
+	}
+	em.protectionDB = nil
+}
+
+// protection returns the opened slashing protection database, nil if it isn't opened
+func (em *Emitter) protection() *protection.DB {
+	em.protectionMu.Lock()
+	defer em.protectionMu.Unlock()
+	return em.protectionDB
 }
 
 func (em *Emitter) tick() {
@@ -202,7 +262,12 @@ func (em *Emitter) tick() {
 	} else {
 		em.busyRate.Mark(1)
 	}
+	if em.paused.Load() {
+		em.decisions.add(Decision{Reason: reasonPaused})
+		return
+	}
 	if em.world.IsBusy() {
+		em.decisions.add(Decision{Reason: reasonBusy})
 		return
 	}
 
@@ -211,7 +276,7 @@ func (em *Emitter) tick() {
 	if time.Since(em.prevEmittedAtTime) >= em.intervals.Min {
 		_, err := em.EmitEvent()
 		if err != nil {
-			em.Log.Error("Event emitting error", "err", err)
+			em.Periodic.Error(time.Second, "Event emitting error", "err", err)
 		}
 	}
 }
@@ -281,6 +346,20 @@ func (em *Emitter) EmitEvent() (*inter.EventPayload, error) {
 	// broadcast the event
 	em.world.Broadcast(e)
 
+	id := e.ID()
+	gasPowerLeft := e.GasPowerLeft()
+	em.decisions.add(Decision{
+		Emitted:      true,
+		Reason:       em.lastReason,
+		Synced:       true,
+		Event:        &id,
+		Seq:          e.Seq(),
+		Parents:      e.Parents(),
+		Metric:       em.lastMetric,
+		GasPowerLeft: &gasPowerLeft,
+		Txs:          e.Txs().Len(),
+	})
+
 	// metrics
 	emittedEventsTxsCounter.Inc(int64(e.Txs().Len()))
 	emittedGasCounter.Inc(int64(e.GasPowerUsed()))
@@ -318,8 +397,10 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 		return nil, nil
 	}
 
-	if synced := em.logSyncStatus(em.isSyncedToEmit()); !synced {
+	wait, syncErr := em.isSyncedToEmit()
+	if synced := em.logSyncStatus(wait, syncErr); !synced {
 		// I'm reindexing my old events, so don't create events until connect all the existing self-events
+		em.decisions.add(Decision{Reason: syncErr.Error()})
 		return nil, nil
 	}
 
@@ -333,6 +414,7 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 	// Find parents
 	selfParent, parents, ok := em.chooseParents(em.epoch, em.config.Validator.ID)
 	if !ok {
+		em.decisions.add(Decision{Reason: reasonNoParents, Synced: true})
 		return nil, nil
 	}
 	prevEmitted := em.readLastEmittedEventID()
@@ -353,6 +435,7 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 		if parentHeaders[i].Creator() == em.config.Validator.ID && i != 0 {
 			// there are 2 heads from me, i.e. due to a fork, chooseParents could have found multiple self-parents
 			em.Periodic.Error(5*time.Second, "I've created a fork, events emitting isn't allowed", "creator", em.config.Validator.ID)
+			em.decisions.add(Decision{Reason: reasonFork, Synced: true, Parents: parents})
 			return nil, nil
 		}
 		maxLamport = idx.MaxLamport(maxLamport, parent.Lamport())
@@ -418,34 +501,38 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 		if err == ErrNotEnoughGasPower {
 			em.Periodic.Warn(time.Second, "Not enough gas power to emit event. Too small stake?",
 				"stake%", 100*float64(em.validators.Get(em.config.Validator.ID))/float64(em.validators.TotalWeight()))
+			em.decisions.add(Decision{Reason: reasonNotEnoughGasPower, Synced: true, Parents: parents})
 		} else {
 			em.Log.Warn("Dropped event while emitting", "err", err)
+			em.decisions.add(Decision{Reason: reasonBuildFailed + ": " + err.Error(), Synced: true, Parents: parents})
 		}
 		return nil, nil
 	}
 
 	// Pre-check if event should be emitted
 	// It is checked in advance to avoid adding transactions just to immediately drop the event later
-	if !em.isAllowedToEmit(mutEvent, true, metric, selfParentHeader) {
+	if !em.decide(mutEvent, true, metric, selfParentHeader) {
 		return nil, nil
 	}
 
-	// Add txs
-	em.addTxs(mutEvent, sortedTxs)
+	// Add txs, bundles go first so they aren't split by the txs of the same senders
+	bundleSenders := em.addBundles(mutEvent)
+	em.addTxs(mutEvent, sortedTxs, bundleSenders)
 
 	// Check if event should be emitted
 	// Check only if no txs were added, since check in a case with added txs was performed above
 	if mutEvent.Txs().Len() == 0 {
-		if !em.isAllowedToEmit(mutEvent, mutEvent.Txs().Len() != 0, metric, selfParentHeader) {
+		if !em.decide(mutEvent, mutEvent.Txs().Len() != 0, metric, selfParentHeader) {
 			return nil, nil
 		}
 	}
+	em.lastMetric = metric
 
 	// calc Payload hash
 	mutEvent.SetPayloadHash(inter.CalcPayloadHash(mutEvent))
 
 	// sign
-	bSig, err := em.world.Signer.Sign(em.config.Validator.PubKey, mutEvent.HashToSign().Bytes())
+	bSig, err := valkeystore.SignEvent(em.world.Signer, em.config.Validator.PubKey, mutEvent)
 	if err != nil {
 		em.Periodic.Error(time.Second, "Failed to sign event", "err", err)
 		return nil, err
@@ -58,16 +58,9 @@ func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch)
 		extConfirmingInterval = time.Duration(statedb.GetState(emitterdriver.ContractAddress, utils.U64to256(2)).Big().Uint64())
 		statedb.Release()
 	}
-	if extMinInterval == 0 {
-		extMinInterval = em.config.EmitIntervals.Min
-	}
-	if extConfirmingInterval == 0 {
-		extConfirmingInterval = em.config.EmitIntervals.Confirming
-	}
-
-	// sanity check to ensure that durations aren't too small/large
-	em.intervals.Min = maxDuration(minDuration(em.config.EmitIntervals.Min*20, extMinInterval), em.config.EmitIntervals.Min/4)
-	em.globalConfirmingInterval = maxDuration(minDuration(em.config.EmitIntervals.Confirming*20, extConfirmingInterval), em.config.EmitIntervals.Confirming/4)
+	em.driverIntervals.Min = extMinInterval
+	em.driverIntervals.Confirming = extConfirmingInterval
+	em.applyIntervals()
 	em.recountConfirmingIntervals(newValidators)
 
 	if switchToFCIndexer {
@@ -87,6 +80,34 @@ func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch)
 	em.payloadIndexer = ancestor.NewPayloadIndexer(PayloadIndexerSize)
 }
 
+// applyIntervals sets the intervals in force from the config and the adjustments of emitterdriver contract
+func (em *Emitter) applyIntervals() {
+	extMinInterval := em.driverIntervals.Min
+	if extMinInterval == 0 {
+		extMinInterval = em.config.EmitIntervals.Min
+	}
+	extConfirmingInterval := em.driverIntervals.Confirming
+	if extConfirmingInterval == 0 {
+		extConfirmingInterval = em.config.EmitIntervals.Confirming
+	}
+
+	// sanity check to ensure that durations aren't too small/large
+	em.intervals.Min = maxDuration(minDuration(em.config.EmitIntervals.Min*20, extMinInterval), em.config.EmitIntervals.Min/4)
+	em.globalConfirmingInterval = maxDuration(minDuration(em.config.EmitIntervals.Confirming*20, extConfirmingInterval), em.config.EmitIntervals.Confirming/4)
+}
+
+// setEmitIntervals replaces the configured intervals at runtime
+func (em *Emitter) setEmitIntervals(cfg EmitIntervals) {
+	em.config.EmitIntervals = cfg
+	em.intervals = cfg
+	em.globalConfirmingInterval = cfg.Confirming
+	if !em.isValidator() {
+		return
+	}
+	em.applyIntervals()
+	em.recountConfirmingIntervals(em.validators)
+}
+
 // OnEventConnected tracks new events
 func (em *Emitter) OnEventConnected(e inter.EventPayloadI) {
 	if !em.isValidator() {
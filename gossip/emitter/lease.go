@@ -0,0 +1,76 @@
+package emitter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/emitter/doublesign"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+)
+
+var errStandby = errors.New("standby, the validator lease is held by another node")
+
+// isLeaderToEmit replaces the doublesign protection heuristics in the active/passive mode.
+// The lease guarantees that no other node emits, so the node takes over as soon as it acquires the lease,
+// opens the slashing protection and downloads the events signed by the former leader.
+// The slashing protection isn't synchronized by the node, it has to be on the storage shared with the former leader
+// unless the key is held by a remote signer.
+// The lease keeper closes the slashing protection once the leadership is lost, so a stalled emitter doesn't keep
+// the database locked. A leader hung inside the database keeps the lock anyway, which blocks the takeover
+// until the process dies.
+func (em *Emitter) isLeaderToEmit() (time.Duration, error) {
+	if !em.world.Lease.IsLeader() {
+		em.park()
+		return 0, errStandby
+	}
+	if em.syncStatus.leaderSince.IsZero() {
+		em.syncStatus.leaderSince = time.Now()
+		em.Log.Info("Taking over the validator emission", "validator", em.config.Validator.ID)
+	}
+	if em.intervals.DoublesignProtection != 0 {
+		if em.world.PeersNum() == 0 {
+			return 0, doublesign.ErrNoConnections
+		}
+		if !em.world.IsSynced() {
+			return 0, doublesign.ErrP2PSyncOngoing
+		}
+	}
+	if err := em.openProtection(); err != nil {
+		return 0, fmt.Errorf("slashing protection isn't opened: %w", err)
+	}
+	db := em.protection()
+	if db == nil {
+		return 0, nil
+	}
+	epoch, seq, err := db.LastEvent(em.config.Validator.PubKey)
+	if err != nil {
+		return 0, fmt.Errorf("slashing protection isn't read: %w", err)
+	}
+	if epoch == em.epoch && seq > em.lastSelfSeq() {
+		return 0, doublesign.ErrSelfEventsOngoing
+	}
+	return 0, nil
+}
+
+// park stops the emission after the lease is lost
+func (em *Emitter) park() {
+	if em.syncStatus.leaderSince.IsZero() {
+		return
+	}
+	em.syncStatus.leaderSince = time.Time{}
+	em.Log.Warn("Validator lease is lost, emitting is parked", "validator", em.config.Validator.ID)
+}
+
+// lastSelfSeq returns the seq of the last self-event of the current epoch in the DAG
+func (em *Emitter) lastSelfSeq() idx.Event {
+	id := em.world.GetLastEvent(em.epoch, em.config.Validator.ID)
+	if id == nil {
+		return 0
+	}
+	e := em.world.GetEvent(*id)
+	if e == nil {
+		return 0
+	}
+	return e.Seq()
+}
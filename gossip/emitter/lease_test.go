@@ -0,0 +1,123 @@
+package emitter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/emitter/doublesign"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/gossip/emitter/mock"
+	"github.com/mrmikeo/Xpense/integration/makefakegenesis"
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
+)
+
+type testLease struct {
+	leader bool
+	onLost []func()
+}
+
+func (l *testLease) IsLeader() bool {
+	return l.leader
+}
+
+func (l *testLease) OnLost(fn func()) {
+	l.onLost = append(l.onLost, fn)
+}
+
+func (l *testLease) lose() {
+	l.leader = false
+	for _, fn := range l.onLost {
+		fn()
+	}
+}
+
+func TestEmitterLease(t *testing.T) {
+	require := require.New(t)
+	validator := makefakegenesis.GetFakeValidators(1)[0]
+	cfg := DefaultConfig()
+	cfg.Validator.ID = validator.ID
+	cfg.Validator.PubKey = validator.PubKey
+	cfg.SlashingProtectionDB = filepath.Join(t.TempDir(), "protection")
+
+	// the former leader has signed the event 2 of the epoch 1
+	db, err := protection.Open(cfg.SlashingProtectionDB)
+	require.NoError(err)
+	require.NoError(db.Approve(validator.PubKey, protection.SignedEvent{Epoch: 1, Seq: 2, Lamport: 2, Digest: hash.Hash{1}}))
+	require.NoError(db.Close())
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	external.EXPECT().IsSynced().Return(true).AnyTimes()
+	external.EXPECT().PeersNum().Return(3).AnyTimes()
+
+	lease := &testLease{}
+	em := NewEmitter(cfg, World{
+		External: external,
+		Signer:   mock.NewMockSigner(ctrl),
+		Lease:    lease,
+	})
+	em.epoch = 1
+
+	selfEvent := func(seq idx.Event) {
+		e := &inter.MutableEventPayload{}
+		e.SetEpoch(1)
+		e.SetSeq(seq)
+		e.SetCreator(validator.ID)
+		id := e.Build().ID()
+		external.EXPECT().GetLastEvent(idx.Epoch(1), validator.ID).Return(&id).AnyTimes()
+		external.EXPECT().GetEvent(id).Return(&e.Build().Event).AnyTimes()
+	}
+
+	em.world.Lease.OnLost(em.closeProtection)
+
+	// standby
+	_, err = em.isSyncedToEmit()
+	require.Equal(errStandby, err)
+	require.Nil(em.protectionDB)
+
+	// the events of the active node don't stop the standby
+	e := &inter.MutableEventPayload{}
+	e.SetEpoch(1)
+	e.SetSeq(1)
+	e.SetCreator(validator.ID)
+	em.onNewExternalEvent(e.Build())
+
+	// taking over, the last event signed by the former leader isn't downloaded yet
+	lease.leader = true
+	selfEvent(1)
+	_, err = em.isSyncedToEmit()
+	require.Equal(doublesign.ErrSelfEventsOngoing, err)
+	require.NotNil(em.protectionDB)
+	require.IsType(&protection.Signer{}, em.world.Signer)
+
+	ctrl.Finish()
+	ctrl = gomock.NewController(t)
+	external = mock.NewMockExternal(ctrl)
+	external.EXPECT().IsSynced().Return(true).AnyTimes()
+	external.EXPECT().PeersNum().Return(3).AnyTimes()
+	em.world.External = external
+	selfEvent(2)
+	_, err = em.isSyncedToEmit()
+	require.NoError(err)
+
+	// the lease is lost, the slashing protection is released for the new leader without waiting for the emitter
+	lease.lose()
+	require.Nil(em.protection())
+	db, err = protection.Open(cfg.SlashingProtectionDB)
+	require.NoError(err)
+	require.NoError(db.Close())
+	_, err = em.isSyncedToEmit()
+	require.Equal(errStandby, err)
+
+	// the protection is reopened once the lease is reacquired
+	lease.leader = true
+	_, err = em.isSyncedToEmit()
+	require.NoError(err)
+	require.NotNil(em.protection())
+	em.closeProtection()
+}
@@ -19,11 +19,19 @@ type syncStatus struct {
 	externalSelfEventCreated  time.Time
 	externalSelfEventDetected time.Time
 	becameValidator           time.Time
+	// leaderSince is the time of the takeover in the active/passive mode, zero while standing by
+	leaderSince time.Time
 }
 
 func (em *Emitter) onNewExternalEvent(e inter.EventPayloadI) {
 	em.syncStatus.externalSelfEventDetected = time.Now()
 	em.syncStatus.externalSelfEventCreated = e.CreationTime().Time()
+	if em.world.Lease != nil {
+		// the events of the other node are expected until the takeover
+		if em.syncStatus.leaderSince.IsZero() || e.CreationTime().Time().Before(em.syncStatus.leaderSince) {
+			return
+		}
+	}
 	status := em.currentSyncStatus()
 	if doublesign.DetectParallelInstance(status, em.config.EmitIntervals.ParallelInstanceProtection) {
 		passedSinceEvent := status.Since(status.ExternalSelfEventCreated)
@@ -59,6 +67,9 @@ func (em *Emitter) currentSyncStatus() doublesign.SyncStatus {
 }
 
 func (em *Emitter) isSyncedToEmit() (time.Duration, error) {
+	if em.world.Lease != nil {
+		return em.isLeaderToEmit()
+	}
 	if em.intervals.DoublesignProtection == 0 {
 		return 0, nil // protection disabled
 	}
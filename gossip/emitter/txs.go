@@ -142,7 +142,63 @@ func (em *Emitter) isMyTxTurn(txHash common.Hash, sender common.Address, account
 	return false
 }
 
-func (em *Emitter) addTxs(e *inter.MutableEventPayload, sorted *types.TransactionsByPriceAndNonce) {
+// addBundles adds the pending bundles into the event, every bundle is either added entirely or skipped.
+// It returns the senders of the added bundles.
+func (em *Emitter) addBundles(e *inter.MutableEventPayload) map[common.Address]bool {
+	senders := make(map[common.Address]bool)
+	if em.world.Bundles == nil {
+		return senders
+	}
+	maxGasUsed := em.maxGasPowerToUse(e)
+	if maxGasUsed <= e.GasPowerUsed() {
+		return senders
+	}
+
+	rules := em.world.GetRules()
+	for _, bundle := range em.world.Bundles.Pending() {
+		// check transactions epoch rules (tx type, gas price)
+		if epochcheck.CheckTxs(bundle, rules) != nil {
+			bundlesSkipped.Inc(1)
+			continue
+		}
+		// check there's enough gas power to originate the whole bundle
+		gas := uint64(0)
+		for _, tx := range bundle {
+			gas += tx.Gas()
+		}
+		if gas >= e.GasPowerLeft().Min() || e.GasPowerUsed()+gas >= maxGasUsed {
+			bundlesSkipped.Inc(1)
+			continue
+		}
+		// check not conflicted with already originated txs and with the bundles added before
+		bundleSenders := make([]common.Address, 0, len(bundle))
+		conflicting := false
+		for _, tx := range bundle {
+			sender, _ := types.Sender(em.world.TxSigner, tx)
+			if em.originatedTxs.TotalOf(sender) != 0 || senders[sender] {
+				conflicting = true
+				break
+			}
+			bundleSenders = append(bundleSenders, sender)
+		}
+		if conflicting {
+			bundlesSkipped.Inc(1)
+			continue
+		}
+		// add
+		for _, sender := range bundleSenders {
+			senders[sender] = true
+		}
+		e.SetGasPowerUsed(e.GasPowerUsed() + gas)
+		e.SetGasPowerLeft(e.GasPowerLeft().Sub(gas))
+		e.SetTxs(append(e.Txs(), bundle...))
+		bundlesOriginated.Inc(1)
+	}
+	return senders
+}
+
+// addTxs adds the pending txs into the event, txs of the skipped senders aren't added.
+func (em *Emitter) addTxs(e *inter.MutableEventPayload, sorted *types.TransactionsByPriceAndNonce, skipSenders map[common.Address]bool) {
 	maxGasUsed := em.maxGasPowerToUse(e)
 	if maxGasUsed <= e.GasPowerUsed() {
 		return
@@ -169,7 +225,7 @@ func (em *Emitter) addTxs(e *inter.MutableEventPayload, sorted *types.Transactio
 			continue
 		}
 		// check not conflicted with already originated txs (in any connected event)
-		if em.originatedTxs.TotalOf(sender) != 0 {
+		if em.originatedTxs.TotalOf(sender) != 0 || skipSenders[sender] {
 			txsSkippedConflictingSender.Inc(1)
 			sorted.Pop()
 			continue
@@ -40,6 +40,13 @@ type (
 		StateDB() state.StateDB
 	}
 
+	// Lease decides which of the nodes sharing the validator identity may emit events
+	Lease interface {
+		IsLeader() bool
+		// OnLost registers the callback which is run once the leadership is lost
+		OnLost(fn func())
+	}
+
 	// aliases for mock generator
 	Signer   valkeystore.SignerI
 	TxSigner types.Signer
@@ -50,6 +57,10 @@ type (
 		TxPool   TxPool
 		Signer   valkeystore.SignerI
 		TxSigner types.Signer
+		// Bundles is nil if the node doesn't accept transaction bundles
+		Bundles BundlePool
+		// Lease is nil if the node isn't a part of an active/passive pair
+		Lease Lease
 	}
 )
 
@@ -88,3 +99,9 @@ type TxPool interface {
 	// Count returns the total number of transactions
 	Count() int
 }
+
+// BundlePool provides the transaction bundles, which have to be included atomically into one event.
+type BundlePool interface {
+	// Pending returns the bundles which may be included on top of the latest state, ordered by priority.
+	Pending() []types.Transactions
+}
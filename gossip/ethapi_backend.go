@@ -22,12 +22,14 @@ import (
 	"github.com/mrmikeo/Xpense/ethapi"
 	"github.com/mrmikeo/Xpense/evmcore"
 	"github.com/mrmikeo/Xpense/gossip/evmstore"
+	"github.com/mrmikeo/Xpense/gossip/gasprice"
 	"github.com/mrmikeo/Xpense/inter"
 	"github.com/mrmikeo/Xpense/inter/iblockproc"
 	"github.com/mrmikeo/Xpense/inter/state"
 	"github.com/mrmikeo/Xpense/opera"
 	"github.com/mrmikeo/Xpense/topicsdb"
 	"github.com/mrmikeo/Xpense/tracing"
+	"github.com/mrmikeo/Xpense/txtrace"
 )
 
 // EthAPIBackend implements ethapi.Backend.
@@ -105,6 +107,9 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	} else {
 		n := uint64(number.Int64())
 		blk = b.state.GetBlock(common.Hash{}, n)
+		if blk == nil {
+			return nil, b.svc.store.PrunedError(idx.Block(n))
+		}
 	}
 
 	return blk, nil
@@ -269,6 +274,9 @@ func (b *EthAPIBackend) BlockByHash(ctx context.Context, h common.Hash) (*evmcor
 	} else {
 		n := uint64(*index)
 		blk = b.state.GetBlock(common.Hash{}, n)
+		if blk == nil {
+			return nil, b.svc.store.PrunedError(idx.Block(n))
+		}
 	}
 
 	return blk, nil
@@ -289,6 +297,9 @@ func (b *EthAPIBackend) GetReceiptsByNumber(ctx context.Context, number rpc.Bloc
 	}
 
 	block := b.state.GetBlock(common.Hash{}, uint64(number))
+	if block == nil {
+		return nil, b.svc.store.PrunedError(idx.Block(number))
+	}
 	receipts := b.svc.store.evm.GetReceipts(idx.Block(number), b.signer, block.Hash, block.Transactions)
 	return receipts, nil
 }
@@ -319,6 +330,46 @@ func (b *EthAPIBackend) GetTd(_ common.Hash) *big.Int {
 	return big.NewInt(0)
 }
 
+// HistoryStart returns the first block with not pruned history, 0 if nothing is pruned.
+func (b *EthAPIBackend) HistoryStart() idx.Block {
+	return b.svc.store.evm.GetHistoryStart()
+}
+
+// GetTracedBlocksRange returns the range of blocks with indexed traces, ok is false if there are none.
+func (b *EthAPIBackend) GetTracedBlocksRange(ctx context.Context) (first, last idx.Block, ok bool) {
+	return b.svc.store.evm.GetTracedBlocksRange()
+}
+
+// GetBlockTraces returns indexed traces of the block txs.
+func (b *EthAPIBackend) GetBlockTraces(ctx context.Context, number idx.Block) ([]txtrace.ActionTrace, error) {
+	traces := b.svc.store.evm.GetBlockTraces(number)
+	if traces == nil {
+		return nil, fmt.Errorf("traces of block %d are not indexed", number)
+	}
+	return traces, nil
+}
+
+// GetTraceBlocksByAddress returns blocks within [from, to] with traces from or to the address.
+func (b *EthAPIBackend) GetTraceBlocksByAddress(ctx context.Context, addr common.Address, from, to idx.Block) ([]idx.Block, error) {
+	if !b.svc.store.evm.TraceIndexingEnabled() {
+		return nil, errors.New("trace indexing is disabled")
+	}
+	return b.svc.store.evm.GetTraceBlocksByAddress(addr, from, to), nil
+}
+
+// GetAddressIndexedRange returns the range of blocks with txs indexed by addresses, ok is false if there are none.
+func (b *EthAPIBackend) GetAddressIndexedRange(ctx context.Context) (first, last idx.Block, ok bool) {
+	return b.svc.store.evm.GetAddressIndexedRange()
+}
+
+// GetAddressTxs returns positions of at most limit txs touching the address, starting from the position (from, fromIndex).
+func (b *EthAPIBackend) GetAddressTxs(ctx context.Context, addr common.Address, from idx.Block, fromIndex uint32, to idx.Block, limit int) ([]evmstore.AddressTx, error) {
+	if !b.svc.store.evm.AddressIndexingEnabled() {
+		return nil, errors.New("address indexing is disabled")
+	}
+	return b.svc.store.evm.GetAddressTxs(addr, from, fromIndex, to, limit), nil
+}
+
 func (b *EthAPIBackend) GetEVM(ctx context.Context, msg evmcore.Message, state vm.StateDB, header *evmcore.EvmHeader, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
 	vmError := func() error { return nil }
 
@@ -340,6 +391,64 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return err
 }
 
+// SendPrivateTx adds the transaction into the txpool without announcing it to the public peers.
+// The transaction is dropped if it isn't included until maxBlock, the default deadline is used if maxBlock is zero.
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, maxBlock idx.Block) error {
+	err := b.svc.sendPrivateTx(signedTx, maxBlock)
+	if err == nil {
+		tracing.StartTx(signedTx.Hash(), "EthAPIBackend.SendPrivateTx()")
+	}
+	return err
+}
+
+// SendBundle adds the transactions bundle, which is included atomically into one event by the local emitter.
+// The bundle is dropped if it isn't included until maxBlock, the default deadline is used if maxBlock is zero.
+func (b *EthAPIBackend) SendBundle(ctx context.Context, txs types.Transactions, maxBlock idx.Block, revertingTxs []common.Hash) (common.Hash, error) {
+	return b.svc.sendBundle(txs, maxBlock, revertingTxs)
+}
+
+// GetTxLifecycle returns the lifecycle of a recent transaction, nil if it isn't tracked
+func (b *EthAPIBackend) GetTxLifecycle(ctx context.Context, txHash common.Hash) *ethapi.TxLifecycle {
+	return b.svc.txStatuses.Get(txHash)
+}
+
+func (b *EthAPIBackend) SubscribeTxLifecycleNotify(txHash common.Hash, ch chan<- ethapi.TxLifecycle) notify.Subscription {
+	return b.svc.txStatuses.Subscribe(txHash, ch)
+}
+
+// GetSkippedTxs returns the txs confirmed by the block, but skipped during its execution, with the reasons of the skips.
+func (b *EthAPIBackend) GetSkippedTxs(ctx context.Context, number rpc.BlockNumber) ([]evmcore.SkippedTx, error) {
+	if number == rpc.PendingBlockNumber {
+		number = rpc.LatestBlockNumber
+	}
+	if number == rpc.LatestBlockNumber {
+		header := b.state.CurrentHeader()
+		number = rpc.BlockNumber(header.Number.Uint64())
+	}
+
+	n := idx.Block(number)
+	block := b.svc.store.GetBlock(n)
+	if block == nil {
+		return nil, b.svc.store.PrunedError(n)
+	}
+	if len(block.SkippedTxs) == 0 {
+		// empty rather than nil, so that the block without skipped txs is shown as [] rather than null
+		return []evmcore.SkippedTx{}, nil
+	}
+	if skipped := b.svc.store.evm.GetSkippedTxs(n); skipped != nil {
+		return skipped, nil
+	}
+	// the reasons weren't recorded, e.g. the block was processed before the upgrade, snapshot-synced or TxIndex is disabled
+	if err := b.svc.store.PrunedError(n); err != nil {
+		return nil, err
+	}
+	return unknownSkippedTxs(b.svc.store.GetBlockAllTxs(block), block.SkippedTxs), nil
+}
+
+func (b *EthAPIBackend) SubscribeSkippedTxsNotify(ch chan<- evmcore.SkippedTxsNotify) notify.Subscription {
+	return b.svc.feed.SubscribeNewSkippedTxs(ch)
+}
+
 func (b *EthAPIBackend) SubscribeLogsNotify(ch chan<- []*types.Log) notify.Subscription {
 	return b.svc.feed.SubscribeNewLogs(ch)
 }
@@ -348,8 +457,31 @@ func (b *EthAPIBackend) SubscribeNewBlockNotify(ch chan<- evmcore.ChainHeadNotif
 	return b.svc.feed.SubscribeNewBlock(ch)
 }
 
+// SubscribeNewTxsNotify subscribes to the new public transactions of the txpool, the private ones are filtered out
 func (b *EthAPIBackend) SubscribeNewTxsNotify(ch chan<- evmcore.NewTxsNotify) notify.Subscription {
-	return b.svc.txpool.SubscribeNewTxsNotify(ch)
+	txsCh := make(chan evmcore.NewTxsNotify, cap(ch))
+	sub := b.svc.txpool.SubscribeNewTxsNotify(txsCh)
+	return notify.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-txsCh:
+				ev.Txs = b.svc.privateTxs.FilterTxs(ev.Txs)
+				if len(ev.Txs) == 0 {
+					continue
+				}
+				select {
+				case ch <- ev:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
 }
 
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -359,12 +491,15 @@ func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	}
 	var txs types.Transactions
 	for _, batch := range pending {
-		txs = append(txs, batch...)
+		txs = append(txs, b.svc.privateTxs.FilterTxs(batch)...)
 	}
 	return txs, nil
 }
 
 func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	if b.svc.privateTxs.Has(hash) {
+		return nil
+	}
 	return b.svc.txpool.Get(hash)
 }
 
@@ -409,8 +544,26 @@ func (b *EthAPIBackend) Stats() (pending int, queued int) {
 	return b.svc.txpool.Stats()
 }
 
+func (b *EthAPIBackend) TxPoolPolicyStatus() evmcore.TxPolicyStatus {
+	return b.svc.txpool.PolicyStatus()
+}
+
 func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
-	return b.svc.txpool.Content()
+	pending, queued := b.svc.txpool.Content()
+	return b.filterPrivateTxs(pending), b.filterPrivateTxs(queued)
+}
+
+// filterPrivateTxs removes the private transactions from the txpool content
+func (b *EthAPIBackend) filterPrivateTxs(content map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	for addr, txs := range content {
+		txs = b.svc.privateTxs.FilterTxs(txs)
+		if len(txs) == 0 {
+			delete(content, addr)
+		} else {
+			content[addr] = txs
+		}
+	}
+	return content
 }
 
 // Progress returns current synchronization status of this node
@@ -430,13 +583,18 @@ func (b *EthAPIBackend) Progress() ethapi.PeerProgress {
 }
 
 func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
-	return b.svc.txpool.ContentFrom(addr)
+	pending, queued := b.svc.txpool.ContentFrom(addr)
+	return b.svc.privateTxs.FilterTxs(pending), b.svc.privateTxs.FilterTxs(queued)
 }
 
 func (b *EthAPIBackend) SuggestGasTipCap(ctx context.Context, certainty uint64) *big.Int {
 	return b.svc.gpo.SuggestTip(certainty)
 }
 
+func (b *EthAPIBackend) SuggestFeeEstimates(ctx context.Context) gasprice.Estimates {
+	return b.svc.gpo.Estimates()
+}
+
 func (b *EthAPIBackend) EffectiveMinGasPrice(ctx context.Context) *big.Int {
 	return b.svc.gpo.EffectiveMinGasPrice()
 }
@@ -520,6 +678,22 @@ func (b *EthAPIBackend) GetDowntime(ctx context.Context, vid idx.ValidatorID) (i
 	return missedBlocks, missedTime, nil
 }
 
+// GetValidatorEpochMetrics returns the metrics of the validator in a sealed epoch, nil if they aren't recorded.
+func (b *EthAPIBackend) GetValidatorEpochMetrics(ctx context.Context, epoch idx.Epoch, vid idx.ValidatorID) (*iblockproc.ValidatorEpochMetrics, error) {
+	if epoch >= b.svc.store.GetEpoch() {
+		return nil, nil
+	}
+	return b.svc.store.GetValidatorEpochMetrics(epoch, vid), nil
+}
+
+// GetEpochMetrics returns the metrics of the validators in a sealed epoch, nil if they aren't recorded.
+func (b *EthAPIBackend) GetEpochMetrics(ctx context.Context, epoch idx.Epoch) (map[idx.ValidatorID]iblockproc.ValidatorEpochMetrics, error) {
+	if epoch >= b.svc.store.GetEpoch() {
+		return nil, nil
+	}
+	return b.svc.store.GetEpochMetrics(epoch), nil
+}
+
 func (b *EthAPIBackend) GetEpochBlockState(ctx context.Context, epoch rpc.BlockNumber) (*iblockproc.BlockState, *iblockproc.EpochState, error) {
 	if epoch == rpc.PendingBlockNumber {
 		bs, es := b.svc.store.GetBlockState(), b.svc.store.GetEpochState()
@@ -532,6 +706,16 @@ func (b *EthAPIBackend) GetEpochBlockState(ctx context.Context, epoch rpc.BlockN
 	return bs, es, nil
 }
 
+// GetLlrBlockResult returns the block record hash decided by LLR votes, or nil if the block isn't decided yet.
+func (b *EthAPIBackend) GetLlrBlockResult(ctx context.Context, block idx.Block) *hash.Hash {
+	return b.svc.store.GetLlrBlockResult(block)
+}
+
+// GetLlrEpochResult returns the epoch record hash decided by LLR votes, or nil if the epoch isn't decided yet.
+func (b *EthAPIBackend) GetLlrEpochResult(ctx context.Context, epoch idx.Epoch) *hash.Hash {
+	return b.svc.store.GetLlrEpochResult(epoch)
+}
+
 func (b *EthAPIBackend) CalcBlockExtApi() bool {
 	return b.svc.config.RPCBlockExt
 }
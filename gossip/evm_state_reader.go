@@ -98,6 +98,10 @@ func (r *EvmStateReader) GetBlock(h common.Hash, n uint64) *evmcore.EvmBlock {
 }
 
 func (r *EvmStateReader) getBlock(h hash.Event, n idx.Block, readTxs bool) *evmcore.EvmBlock {
+	if readTxs && r.store.IsHistoryPruned(n) {
+		// txs of the pruned blocks are deleted
+		return nil
+	}
 	block := r.store.GetBlock(n)
 	if block == nil {
 		return nil
@@ -150,7 +154,7 @@ func (r *EvmStateReader) getBlock(h hash.Event, n idx.Block, readTxs bool) *evmc
 
 // GetTxPoolStateDB obtains StateDB for TxPool
 func (r *EvmStateReader) GetTxPoolStateDB() (evmcore.TxPoolStateDB, error) {
-	return r.store.evm.GetTxPoolStateDB()
+	return r.store.evm.GetTxPoolState()
 }
 
 // GetRpcStateDB obtains archive StateDB for RPC requests evaluation
@@ -49,7 +49,8 @@ func (c *CarmenStateDB) AddLog(log *types.Log) {
 
 func (c *CarmenStateDB) GetLogs(txHash common.Hash, blockHash common.Hash) []*types.Log {
 	if txHash != c.txHash {
-		panic("obtaining logs of not-current tx not supported")
+		// Carmen keeps logs of the current tx only - logs of other txs are not available
+		return nil
 	}
 	carmenLogs := c.db.GetLogs()
 	logs := make([]*types.Log, len(carmenLogs))
@@ -167,7 +168,9 @@ func (c *CarmenStateDB) SubBalance(addr common.Address, amountInt *big.Int) {
 }
 
 func (c *CarmenStateDB) SetBalance(addr common.Address, amount *big.Int) {
-	panic("not supported")
+	// Carmen provides no direct setter - apply the difference instead (used by RPC state overrides)
+	diff := new(big.Int).Sub(amount, c.GetBalance(addr))
+	c.AddBalance(addr, diff)
 }
 
 func (c *CarmenStateDB) SetNonce(addr common.Address, nonce uint64) {
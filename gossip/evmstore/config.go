@@ -31,6 +31,10 @@ type (
 		DisableLogsIndexing bool
 		// Disables storing of txs positions
 		DisableTxHashesIndexing bool
+		// Enables storing of txs traces indexed by addresses
+		EnableTraceIndexing bool
+		// Enables storing of txs indexed by addresses they touch
+		EnableAddressIndexing bool
 	}
 )
 
@@ -1,6 +1,7 @@
 package evmstore
 
 import (
+	"errors"
 	"fmt"
 	cc "github.com/Fantom-foundation/Carmen/go/common"
 	carmen "github.com/Fantom-foundation/Carmen/go/state"
@@ -10,8 +11,106 @@ import (
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/common"
 	"math/big"
+	"sync"
+
+	"github.com/mrmikeo/Xpense/evmcore"
 )
 
+var errStateReplacing = errors.New("EVM state is being replaced")
+
+// acquireState registers a reader of the state, it fails while the state is being replaced
+func (s *Store) acquireState() error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.stateReplacing {
+		return errStateReplacing
+	}
+	s.stateReaders++
+	return nil
+}
+
+// acquireStateGen registers a reader of the state if the state wasn't replaced since the generation
+func (s *Store) acquireStateGen(gen uint64) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.stateReplacing || s.stateGen != gen {
+		return false
+	}
+	s.stateReaders++
+	return true
+}
+
+func (s *Store) releaseState() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.stateReaders--
+	if s.stateReaders == 0 {
+		s.stateReleased.Broadcast()
+	}
+}
+
+// beginStateReplacement waits until the state readers release it, new readers are refused until endStateReplacement
+func (s *Store) beginStateReplacement() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.stateReplacing = true
+	for s.stateReaders != 0 {
+		s.stateReleased.Wait()
+	}
+}
+
+func (s *Store) endStateReplacement() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.stateReplacing = false
+	s.stateGen++
+}
+
+// readerStateDB is a state DB which keeps the state from being replaced until it's released
+type readerStateDB struct {
+	state.StateDB
+	store   *Store
+	release sync.Once
+}
+
+func (r *readerStateDB) Release() {
+	r.StateDB.Release()
+	r.release.Do(r.store.releaseState)
+}
+
+// txPoolState is the state of the txpool, which is kept by the txpool until it obtains the next one.
+// It doesn't delay the state replacement, instead its reads return zero values once the state is replaced.
+type txPoolState struct {
+	store *Store
+	gen   uint64
+	db    state.StateDB
+}
+
+func (p *txPoolState) GetNonce(addr common.Address) uint64 {
+	if !p.store.acquireStateGen(p.gen) {
+		return 0
+	}
+	defer p.store.releaseState()
+	return p.db.GetNonce(addr)
+}
+
+func (p *txPoolState) GetBalance(addr common.Address) *big.Int {
+	if !p.store.acquireStateGen(p.gen) {
+		return new(big.Int)
+	}
+	defer p.store.releaseState()
+	return p.db.GetBalance(addr)
+}
+
+func (p *txPoolState) Release() {
+	if !p.store.acquireStateGen(p.gen) {
+		// the replaced state is closed along with its state DBs
+		return
+	}
+	defer p.store.releaseState()
+	p.db.Release()
+}
+
 // GetLiveStateDb obtains StateDB for block processing - the live writable state
 func (s *Store) GetLiveStateDb(stateRoot hash.Hash) (state.StateDB, error) {
 	if s.liveStateDb == nil {
@@ -25,37 +124,72 @@ func (s *Store) GetLiveStateDb(stateRoot hash.Hash) (state.StateDB, error) {
 
 // GetTxPoolStateDB obtains StateDB for TxPool evaluation - the latest finalized, read-only.
 // It is also used in emitter for emitterdriver contract reading at the start of an epoch.
+// The state isn't replaced until the StateDB is released.
 func (s *Store) GetTxPoolStateDB() (state.StateDB, error) {
+	if err := s.acquireState(); err != nil {
+		return nil, err
+	}
 	// for TxPool and emitter it is ok to provide the newest state (and ignore the expected hash)
 	if s.carmenState == nil {
+		s.releaseState()
 		return nil, fmt.Errorf("unable to get TxPool StateDb - EvmStore is not open")
 	}
 	stateDb := carmen.CreateNonCommittableStateDBUsing(s.carmenState)
-	return CreateCarmenStateDb(stateDb), nil
+	return &readerStateDB{StateDB: CreateCarmenStateDb(stateDb), store: s}, nil
+}
+
+// GetTxPoolState obtains the latest state for the TxPool, which may keep it for long.
+// Unlike GetTxPoolStateDB, it doesn't delay the state replacement.
+func (s *Store) GetTxPoolState() (evmcore.TxPoolStateDB, error) {
+	if err := s.acquireState(); err != nil {
+		return nil, err
+	}
+	defer s.releaseState()
+	if s.carmenState == nil {
+		return nil, fmt.Errorf("unable to get TxPool StateDb - EvmStore is not open")
+	}
+	stateDb := carmen.CreateNonCommittableStateDBUsing(s.carmenState)
+	return &txPoolState{
+		store: s,
+		gen:   s.stateGen,
+		db:    CreateCarmenStateDb(stateDb),
+	}, nil
 }
 
 // GetArchiveBlockHeight provides the last block number available in the archive. Returns 0 if not known.
 func (s *Store) GetArchiveBlockHeight() (height uint64, empty bool, err error) {
+	if err := s.acquireState(); err != nil {
+		return 0, true, err
+	}
+	defer s.releaseState()
 	if s.liveStateDb == nil {
 		return 0, true, fmt.Errorf("unable to get archive block height - EvmStore is not open")
 	}
 	return s.liveStateDb.GetArchiveBlockHeight()
 }
 
-// GetRpcStateDb obtains archive StateDB for RPC requests evaluation
+// GetRpcStateDb obtains archive StateDB for RPC requests evaluation.
+// The state isn't replaced until the StateDB is released.
 func (s *Store) GetRpcStateDb(blockNum *big.Int, stateRoot common.Hash) (state.StateDB, error) {
+	if err := s.acquireState(); err != nil {
+		return nil, err
+	}
 	// always use archive state (live state may mix data from various block heights)
 	if s.liveStateDb == nil {
+		s.releaseState()
 		return nil, fmt.Errorf("unable to get RPC StateDb - EvmStore is not open")
 	}
 	stateDb, err := s.liveStateDb.GetArchiveStateDB(blockNum.Uint64())
 	if err != nil {
+		s.releaseState()
 		return nil, err
 	}
-	if stateDb.GetHash() != cc.Hash(stateRoot) && blockNum.Sign() != 0 {
-		return nil, fmt.Errorf("unable to get Carmen archive StateDB - unexpected state root (%x != %x)", stateDb.GetHash(), stateRoot)
+	if stateHash := stateDb.GetHash(); stateHash != cc.Hash(stateRoot) && blockNum.Sign() != 0 {
+		stateDb.Release()
+		s.releaseState()
+		return nil, fmt.Errorf("unable to get Carmen archive StateDB - unexpected state root (%x != %x)", stateHash, stateRoot)
 	}
-	return CreateCarmenStateDb(stateDb), nil
+	return &readerStateDB{StateDB: CreateCarmenStateDb(stateDb), store: s}, nil
 }
 
 // CheckLiveStateHash returns if the hash of the current live StateDB hash matches (and fullsync is possible)
@@ -72,6 +206,10 @@ func (s *Store) CheckLiveStateHash(blockNum idx.Block, root hash.Hash) error {
 
 // CheckArchiveStateHash returns if the hash of the given archive StateDB hash matches
 func (s *Store) CheckArchiveStateHash(blockNum idx.Block, root hash.Hash) error {
+	if err := s.acquireState(); err != nil {
+		return err
+	}
+	defer s.releaseState()
 	if s.carmenState == nil {
 		return fmt.Errorf("unable to get live state - EvmStore is not open")
 	}
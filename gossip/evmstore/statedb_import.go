@@ -10,6 +10,8 @@ import (
 	carmen "github.com/Fantom-foundation/Carmen/go/state"
 	"github.com/mrmikeo/Xpense/opera/genesis"
 	"github.com/mrmikeo/Xpense/utils/adapters/kvdb2ethdb"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/nokeyiserr"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/pebble"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/table"
@@ -234,3 +236,164 @@ func (s *Store) ImportLegacyEvmData(evmItems genesis.EvmItems, blockNum uint64,
 	}
 	return nil
 }
+
+// ExportBlockWorldState exports Fantom World State data of the given block from the archive,
+// in the format of the live state genesis section. Returns the root hash of the exported state.
+// The Store must be open during the call.
+func (s *Store) ExportBlockWorldState(ctx context.Context, block idx.Block, out io.Writer) (hash.Hash, error) {
+	if err := s.acquireState(); err != nil {
+		return hash.Hash{}, err
+	}
+	defer s.releaseState()
+	if s.carmenState == nil {
+		return hash.Hash{}, fmt.Errorf("unable to export block state - EvmStore is not open")
+	}
+	archiveState, err := s.carmenState.GetArchiveState(uint64(block))
+	if err != nil {
+		return hash.Hash{}, fmt.Errorf("unable to get archive state: %w", err)
+	}
+	defer archiveState.Close()
+
+	root, err := archiveState.Export(ctx, out)
+	if err != nil {
+		return hash.Hash{}, fmt.Errorf("failed to export StateDB of block %d; %v", block, err)
+	}
+	return hash.Hash(root), nil
+}
+
+// replacedStateSuffix is the suffix of the state dirs moved aside by the state replacement
+const replacedStateSuffix = ".replaced"
+
+// ReplaceWorldState replaces the live and archive states with the live state data read from the given file.
+// The data is imported aside and checked against the state root first, so the current state is kept if it doesn't match.
+// The state readers are waited for before the state is closed, and new readers are refused until it's reopened.
+// The Store must be open during the call.
+func (s *Store) ReplaceWorldState(path string, block idx.Block, root hash.Hash) error {
+	importDir := filepath.Join(s.parameters.Directory, "import")
+	if err := os.RemoveAll(importDir); err != nil {
+		return fmt.Errorf("failed to clean carmen import dir; %v", err)
+	}
+	defer os.RemoveAll(importDir)
+
+	if err := s.importWorldStateFile(importDir, path, block, root); err != nil {
+		return err
+	}
+
+	s.beginStateReplacement()
+	defer s.endStateReplacement()
+	if err := s.closeStateDb(); err != nil {
+		return err
+	}
+	// the current dirs are moved aside rather than removed, so that they're restored if the imported state fails
+	err := s.swapStateDirs(importDir)
+	if err == nil {
+		err = s.open()
+		if err == nil {
+			err = s.CheckLiveStateHash(block, root)
+		}
+	}
+	if err != nil {
+		if closeErr := s.closeStateDb(); closeErr != nil {
+			s.Log.Error("Failed to close the imported state", "err", closeErr)
+		}
+		if restoreErr := restoreStateDirs(s.parameters.Directory); restoreErr != nil {
+			return fmt.Errorf("%w; failed to restore the previous state: %v", err, restoreErr)
+		}
+		if openErr := s.open(); openErr != nil {
+			return fmt.Errorf("%w; failed to reopen the previous state: %v", err, openErr)
+		}
+		return err
+	}
+	for _, name := range []string{"live", "archive"} {
+		if err := os.RemoveAll(filepath.Join(s.parameters.Directory, name+replacedStateSuffix)); err != nil {
+			s.Log.Warn("Failed to remove the replaced state", "dir", name, "err", err)
+		}
+	}
+	return nil
+}
+
+// swapStateDirs moves the current state dirs aside and the imported ones in their place
+func (s *Store) swapStateDirs(importDir string) error {
+	for _, name := range []string{"live", "archive"} {
+		imported := filepath.Join(importDir, name)
+		if _, err := os.Stat(imported); err != nil {
+			continue
+		}
+		current := filepath.Join(s.parameters.Directory, name)
+		replaced := current + replacedStateSuffix
+		if err := os.RemoveAll(replaced); err != nil {
+			return fmt.Errorf("failed to clean replaced carmen %s dir; %v", name, err)
+		}
+		if err := os.Rename(current, replaced); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move carmen %s dir aside; %v", name, err)
+		}
+		if err := os.Rename(imported, current); err != nil {
+			return fmt.Errorf("failed to move imported carmen %s dir; %v", name, err)
+		}
+	}
+	return nil
+}
+
+// restoreStateDirs moves back the state dirs which were moved aside by an unfinished state replacement
+func restoreStateDirs(dir string) error {
+	for _, name := range []string{"live", "archive"} {
+		current := filepath.Join(dir, name)
+		replaced := current + replacedStateSuffix
+		if _, err := os.Stat(replaced); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(current); err != nil {
+			return fmt.Errorf("failed to remove imported carmen %s dir; %v", name, err)
+		}
+		if err := os.Rename(replaced, current); err != nil {
+			return fmt.Errorf("failed to restore carmen %s dir; %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) importWorldStateFile(importDir string, path string, block idx.Block, root hash.Hash) error {
+	liveFile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer liveFile.Close()
+	if err := io2.ImportLiveDb(io2.NewLog(), filepath.Join(importDir, "live"), liveFile); err != nil {
+		return fmt.Errorf("failed to import LiveDB; %v", err)
+	}
+
+	// the import only checks the data against the hash it contains, so check the hash itself
+	params := s.parameters
+	params.Directory = importDir
+	params.Archive = carmen.NoArchive
+	imported, err := carmen.NewState(params)
+	if err != nil {
+		return fmt.Errorf("failed to open imported state; %v", err)
+	}
+	importedHash, err := imported.GetHash()
+	if closeErr := imported.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get imported state hash; %v", err)
+	}
+	if cc.Hash(root) != importedHash {
+		return fmt.Errorf("hash of the imported EVM state is incorrect: blockNum: %d expected: %x imported: %x", block, root, importedHash)
+	}
+
+	if s.parameters.Archive == carmen.NoArchive {
+		return nil
+	}
+	if s.parameters.Archive != carmen.S5Archive {
+		return fmt.Errorf("archive is used, but cannot be initialized from FWS live data")
+	}
+	archiveFile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+	if err := io2.InitializeArchive(io2.NewLog(), filepath.Join(importDir, "archive"), archiveFile, uint64(block)); err != nil {
+		return fmt.Errorf("failed to initialize Archive; %v", err)
+	}
+	return nil
+}
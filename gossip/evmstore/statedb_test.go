@@ -0,0 +1,78 @@
+package evmstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+func TestStateReplacementWaitsForReaders(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := cachedStore()
+	require.NoError(store.acquireState())
+	gen := store.stateGen
+
+	replaced := make(chan struct{})
+	go func() {
+		store.beginStateReplacement()
+		close(replaced)
+	}()
+	select {
+	case <-replaced:
+		t.Fatal("state is replaced while it's read")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// new readers are refused during the replacement
+	require.Eventually(func() bool {
+		return store.acquireState() == errStateReplacing
+	}, time.Second, time.Millisecond)
+
+	store.releaseState()
+	<-replaced
+	store.endStateReplacement()
+
+	require.NoError(store.acquireState())
+	store.releaseState()
+	// readers of the replaced state are refused
+	require.False(store.acquireStateGen(gen))
+	require.True(store.acquireStateGen(store.stateGen))
+	store.releaseState()
+}
+
+func TestRestoreStateDirs(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	write := func(name, content string) {
+		require.NoError(os.MkdirAll(filepath.Join(dir, name), 0700))
+		require.NoError(os.WriteFile(filepath.Join(dir, name, "data"), []byte(content), 0600))
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name, "data"))
+		require.NoError(err)
+		return string(data)
+	}
+
+	// interrupted after the live dir is moved aside
+	write("live"+replacedStateSuffix, "old live")
+	// interrupted after the imported archive is moved in
+	write("archive"+replacedStateSuffix, "old archive")
+	write("archive", "imported archive")
+
+	require.NoError(restoreStateDirs(dir))
+	require.Equal("old live", read("live"))
+	require.Equal("old archive", read("archive"))
+	require.NoDirExists(filepath.Join(dir, "live"+replacedStateSuffix))
+	require.NoDirExists(filepath.Join(dir, "archive"+replacedStateSuffix))
+
+	// nothing to restore
+	require.NoError(restoreStateDirs(dir))
+	require.Equal("old live", read("live"))
+}
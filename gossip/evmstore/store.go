@@ -12,6 +12,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 )
 
 const nominalSize uint = 1
@@ -26,6 +28,15 @@ type Store struct {
 		Receipts    kvdb.Store `table:"r"`
 		TxPositions kvdb.Store `table:"x"`
 		Txs         kvdb.Store `table:"X"`
+		SkippedTxs  kvdb.Store `table:"k"`
+		// Trace index tables
+		Traces         kvdb.Store `table:"c"`
+		TraceAddresses kvdb.Store `table:"a"`
+		// Address index tables
+		AddressTxs     kvdb.Store `table:"A"`
+		BlockAddresses kvdb.Store `table:"w"`
+		// Ranges of indexed blocks and start of the history
+		IndexRanges kvdb.Store `table:"d"`
 	}
 
 	EvmLogs  topicsdb.Index
@@ -38,11 +49,22 @@ type Store struct {
 
 	rlp rlpstore.Helper
 
+	// first block with not pruned history
+	historyStart atomic.Uint64
+
 	logger.Instance
 
 	parameters carmen.Parameters
 	carmenState carmen.State
 	liveStateDb carmen.StateDB
+
+	// readers of the state which has to be released before the state is replaced
+	stateMu        sync.Mutex
+	stateReleased  *sync.Cond
+	stateReaders   int
+	stateReplacing bool
+	// stateGen is incremented on every replacement of the state
+	stateGen uint64
 }
 
 // NewStore creates store over key-value db.
@@ -54,6 +76,7 @@ func NewStore(mainDB kvdb.Store, cfg StoreConfig) *Store {
 		rlp:      rlpstore.Helper{logger.New("rlp")},
 		parameters: cfg.StateDb,
 	}
+	s.stateReleased = sync.NewCond(&s.stateMu)
 
 	table.MigrateTables(&s.table, s.mainDB)
 
@@ -63,12 +86,21 @@ func NewStore(mainDB kvdb.Store, cfg StoreConfig) *Store {
 		s.EvmLogs = topicsdb.NewWithThreadPool(mainDB)
 	}
 	s.initCache()
+	s.loadHistoryStart()
 
 	return s
 }
 
 // Open the StateDB database (after the genesis import)
 func (s *Store) Open() error {
+	// the state replacement may be interrupted before the imported state is checked
+	if err := restoreStateDirs(s.parameters.Directory); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *Store) open() error {
 	err := s.initCarmen()
 	if err != nil {
 		return err
@@ -90,6 +122,11 @@ func (s *Store) Close() error {
 	})
 	s.EvmLogs.Close()
 
+	return s.closeStateDb()
+}
+
+// closeStateDb closes the StateDB, keeping the rest of the store open.
+func (s *Store) closeStateDb() error {
 	if s.liveStateDb != nil {
 		s.Log.Info("Closing State DB...")
 		err := s.liveStateDb.Close()
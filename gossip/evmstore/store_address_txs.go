@@ -0,0 +1,124 @@
+package evmstore
+
+import (
+	"encoding/binary"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var addressRangeKey = []byte("a")
+
+// AddressTx is a position of the tx which touches an address.
+type AddressTx struct {
+	Block idx.Block
+	Index uint32
+	Hash  common.Hash
+}
+
+// AddressIndexingEnabled tells whether txs are indexed by addresses
+func (s *Store) AddressIndexingEnabled() bool {
+	return s.cfg.EnableAddressIndexing
+}
+
+func addressTxKey(addr common.Address, n idx.Block, index uint32) []byte {
+	key := make([]byte, 0, common.AddressLength+8+4)
+	key = append(key, addr.Bytes()...)
+	key = append(key, n.Bytes()...)
+	return binary.BigEndian.AppendUint32(key, index)
+}
+
+func blockAddressesKey(n idx.Block, index uint32) []byte {
+	return binary.BigEndian.AppendUint32(n.Bytes(), index)
+}
+
+// SetTxAddresses indexes the tx at the position of the block by the addresses it touches.
+// The addresses are also kept by the tx position, so that the block may be deleted from the index.
+func (s *Store) SetTxAddresses(n idx.Block, index uint32, txHash common.Hash, addrs []common.Address) {
+	if !s.cfg.EnableAddressIndexing {
+		return
+	}
+
+	buf := make([]byte, 0, len(addrs)*common.AddressLength)
+	for _, addr := range addrs {
+		if err := s.table.AddressTxs.Put(addressTxKey(addr, n, index), txHash.Bytes()); err != nil {
+			s.Log.Crit("Failed to put key-value", "err", err)
+		}
+		buf = append(buf, addr.Bytes()...)
+	}
+	if err := s.table.BlockAddresses.Put(blockAddressesKey(n, index), buf); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// DeleteBlockTxAddresses deletes the txs of the block from the address index.
+func (s *Store) DeleteBlockTxAddresses(n idx.Block) {
+	if !s.cfg.EnableAddressIndexing {
+		return
+	}
+
+	var txKeys, blockKeys [][]byte
+	it := s.table.BlockAddresses.NewIterator(n.Bytes(), nil)
+	for it.Next() {
+		index := binary.BigEndian.Uint32(it.Key()[8:])
+		addrs := it.Value()
+		for i := 0; i+common.AddressLength <= len(addrs); i += common.AddressLength {
+			txKeys = append(txKeys, addressTxKey(common.BytesToAddress(addrs[i:i+common.AddressLength]), n, index))
+		}
+		blockKeys = append(blockKeys, common.CopyBytes(it.Key()))
+	}
+	it.Release()
+
+	for _, key := range txKeys {
+		if err := s.table.AddressTxs.Delete(key); err != nil {
+			s.Log.Crit("Failed to delete key-value", "err", err)
+		}
+	}
+	for _, key := range blockKeys {
+		if err := s.table.BlockAddresses.Delete(key); err != nil {
+			s.Log.Crit("Failed to delete key-value", "err", err)
+		}
+	}
+}
+
+// GetAddressTxs returns at most limit txs which touch the address, ordered by their positions.
+// The txs start from the position (from, fromIndex) and end with the block to.
+func (s *Store) GetAddressTxs(addr common.Address, from idx.Block, fromIndex uint32, to idx.Block, limit int) []AddressTx {
+	if !s.cfg.EnableAddressIndexing {
+		return nil
+	}
+
+	it := s.table.AddressTxs.NewIterator(addr.Bytes(), addressTxKey(addr, from, fromIndex)[common.AddressLength:])
+	defer it.Release()
+
+	var txs []AddressTx
+	for len(txs) < limit && it.Next() {
+		key := it.Key()[common.AddressLength:]
+		n := idx.BytesToBlock(key[:8])
+		if n > to {
+			break
+		}
+		txs = append(txs, AddressTx{
+			Block: n,
+			Index: binary.BigEndian.Uint32(key[8:]),
+			Hash:  common.BytesToHash(it.Value()),
+		})
+	}
+	return txs
+}
+
+// GetAddressIndexedRange returns the continuous range of blocks with txs indexed by addresses.
+func (s *Store) GetAddressIndexedRange() (first, last idx.Block, ok bool) {
+	if !s.cfg.EnableAddressIndexing {
+		return 0, 0, false
+	}
+	return s.getIndexedRange(addressRangeKey)
+}
+
+// MarkAddressIndexedBlocks extends the range of blocks with txs indexed by addresses by [from, to].
+func (s *Store) MarkAddressIndexedBlocks(from, to idx.Block) {
+	if !s.cfg.EnableAddressIndexing {
+		return
+	}
+	s.markIndexedRange(addressRangeKey, from, to)
+}
@@ -0,0 +1,112 @@
+package evmstore
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+func addressStore() *Store {
+	cfg := LiteStoreConfig()
+	cfg.EnableAddressIndexing = true
+	return NewStore(memorydb.New(), cfg)
+}
+
+func TestStoreAddressTxs(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	store := addressStore()
+	store.SetTxAddresses(1, 0, common.Hash{1, 0}, []common.Address{a, b})
+	store.SetTxAddresses(1, 1, common.Hash{1, 1}, []common.Address{b})
+	store.SetTxAddresses(2, 0, common.Hash{2, 0}, []common.Address{b, c})
+	store.SetTxAddresses(3, 5, common.Hash{3, 5}, []common.Address{a, c})
+	store.SetTxAddresses(256, 0, common.Hash{1, 0, 0}, []common.Address{b})
+
+	require.Equal([]AddressTx{
+		{Block: 1, Index: 0, Hash: common.Hash{1, 0}},
+		{Block: 3, Index: 5, Hash: common.Hash{3, 5}},
+	}, store.GetAddressTxs(a, 0, 0, 10, 10))
+	require.Equal([]AddressTx{
+		{Block: 1, Index: 1, Hash: common.Hash{1, 1}},
+		{Block: 2, Index: 0, Hash: common.Hash{2, 0}},
+	}, store.GetAddressTxs(b, 1, 1, 10, 10))
+	require.Equal([]AddressTx{
+		{Block: 1, Index: 0, Hash: common.Hash{1, 0}},
+	}, store.GetAddressTxs(b, 0, 0, 300, 1))
+	require.Equal([]AddressTx{
+		{Block: 256, Index: 0, Hash: common.Hash{1, 0, 0}},
+	}, store.GetAddressTxs(b, 3, 0, 300, 10))
+	require.Equal([]AddressTx{
+		{Block: 2, Index: 0, Hash: common.Hash{2, 0}},
+	}, store.GetAddressTxs(c, 0, 0, 2, 10))
+	require.Empty(store.GetAddressTxs(c, 3, 6, 10, 10))
+	require.Empty(store.GetAddressTxs(common.Address{4}, 0, 0, 10, 10))
+}
+
+func TestStoreAddressIndexedRange(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	cfg := LiteStoreConfig()
+	cfg.EnableAddressIndexing = true
+	cfg.EnableTraceIndexing = true
+	store := NewStore(memorydb.New(), cfg)
+	_, _, ok := store.GetAddressIndexedRange()
+	require.False(ok)
+
+	store.MarkAddressIndexedBlocks(5, 10)
+	store.MarkAddressIndexedBlocks(11, 11)
+	first, last, ok := store.GetAddressIndexedRange()
+	require.True(ok)
+	require.Equal(idx.Block(5), first)
+	require.Equal(idx.Block(11), last)
+
+	// the ranges of the indexes are independent
+	_, _, ok = store.GetTracedBlocksRange()
+	require.False(ok)
+}
+
+func TestStoreDeleteBlockTxAddresses(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	a, b := common.Address{1}, common.Address{2}
+	store := addressStore()
+	store.SetTxAddresses(1, 0, common.Hash{1, 0}, []common.Address{a, b})
+	store.SetTxAddresses(1, 1, common.Hash{1, 1}, []common.Address{b})
+	store.SetTxAddresses(2, 0, common.Hash{2, 0}, []common.Address{a})
+	store.MarkAddressIndexedBlocks(1, 2)
+
+	store.DeleteBlockTxAddresses(1)
+	require.Equal([]AddressTx{
+		{Block: 2, Index: 0, Hash: common.Hash{2, 0}},
+	}, store.GetAddressTxs(a, 0, 0, 10, 10))
+	require.Empty(store.GetAddressTxs(b, 0, 0, 10, 10))
+
+	// the range follows the history start
+	store.SetHistoryStart(2)
+	first, last, ok := store.GetAddressIndexedRange()
+	require.True(ok)
+	require.Equal(idx.Block(2), first)
+	require.Equal(idx.Block(2), last)
+}
+
+func TestStoreAddressTxsDisabled(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := cachedStore()
+	store.SetTxAddresses(1, 0, common.Hash{1}, []common.Address{{1}})
+	store.MarkAddressIndexedBlocks(1, 1)
+
+	require.Empty(store.GetAddressTxs(common.Address{1}, 0, 0, 10, 10))
+	_, _, ok := store.GetAddressIndexedRange()
+	require.False(ok)
+}
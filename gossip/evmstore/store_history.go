@@ -0,0 +1,83 @@
+package evmstore
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var historyStartKey = []byte("h")
+
+// PrunedError is returned when the history of the requested block is pruned.
+type PrunedError struct {
+	Block        idx.Block
+	HistoryStart idx.Block
+}
+
+func (e *PrunedError) Error() string {
+	return fmt.Sprintf("history of block %d is pruned, the oldest available block is %d", e.Block, e.HistoryStart)
+}
+
+// ErrorCode returns the JSON-RPC error code of the pruned history.
+func (e *PrunedError) ErrorCode() int {
+	return 4444
+}
+
+// GetHistoryStart returns the first block with not pruned history, 0 if nothing is pruned.
+func (s *Store) GetHistoryStart() idx.Block {
+	return idx.Block(s.historyStart.Load())
+}
+
+// SetHistoryStart stores the first block with not pruned history.
+// The ranges of the trace and address indexes are moved up to it, as the pruned blocks are deleted from them.
+func (s *Store) SetHistoryStart(n idx.Block) {
+	if err := s.table.IndexRanges.Put(historyStartKey, n.Bytes()); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+	s.historyStart.Store(uint64(n))
+	s.trimIndexedRange(traceRangeKey, n)
+	s.trimIndexedRange(addressRangeKey, n)
+}
+
+func (s *Store) loadHistoryStart() {
+	buf, err := s.table.IndexRanges.Get(historyStartKey)
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if buf != nil {
+		s.historyStart.Store(uint64(idx.BytesToBlock(buf)))
+	}
+}
+
+// DeleteReceipts deletes transaction receipts of the block.
+func (s *Store) DeleteReceipts(n idx.Block) {
+	if err := s.table.Receipts.Delete(n.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key-value", "err", err)
+	}
+	s.cache.Receipts.Remove(n)
+}
+
+// DeleteTxPosition deletes transaction block and position.
+func (s *Store) DeleteTxPosition(txid common.Hash) {
+	if err := s.table.TxPositions.Delete(txid.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key-value", "err", err)
+	}
+	s.cache.TxPositions.Remove(txid.String())
+}
+
+// DeleteTx deletes non-event transaction.
+func (s *Store) DeleteTx(txid common.Hash) {
+	if err := s.table.Txs.Delete(txid.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key-value", "err", err)
+	}
+}
+
+// DeleteLogs deletes EVM logs from the index
+func (s *Store) DeleteLogs(recs ...*types.Log) {
+	err := s.EvmLogs.Delete(recs...)
+	if err != nil {
+		s.Log.Crit("DB logs index error", "err", err)
+	}
+}
@@ -0,0 +1,56 @@
+package evmstore
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+func TestStoreHistoryStart(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	db := memorydb.New()
+	store := NewStore(db, LiteStoreConfig())
+	require.Equal(idx.Block(0), store.GetHistoryStart())
+
+	store.SetHistoryStart(100)
+	require.Equal(idx.Block(100), store.GetHistoryStart())
+
+	// reopened store keeps the history start
+	store = NewStore(db, LiteStoreConfig())
+	require.Equal(idx.Block(100), store.GetHistoryStart())
+}
+
+func TestStoreDeleteHistory(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := cachedStore()
+
+	block, receipts := fakeReceipts()
+	store.SetRawReceipts(block, receipts)
+	got, _ := store.GetRawReceipts(block)
+	require.NotNil(got)
+	store.DeleteReceipts(block)
+	got, _ = store.GetRawReceipts(block)
+	require.Nil(got)
+
+	txid := common.Hash{1}
+	store.SetTxPosition(txid, TxPosition{Block: block})
+	require.NotNil(store.GetTxPosition(txid))
+	store.DeleteTxPosition(txid)
+	require.Nil(store.GetTxPosition(txid))
+
+	tx := types.NewTransaction(1, common.Address{1}, nil, 21000, nil, nil)
+	store.SetTx(tx.Hash(), tx)
+	require.NotNil(store.GetTx(tx.Hash()))
+	store.DeleteTx(tx.Hash())
+	require.Nil(store.GetTx(tx.Hash()))
+}
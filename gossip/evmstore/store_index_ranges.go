@@ -0,0 +1,63 @@
+package evmstore
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+)
+
+// getIndexedRange returns the continuous range of indexed blocks stored under the key.
+func (s *Store) getIndexedRange(key []byte) (first, last idx.Block, ok bool) {
+	buf, err := s.table.IndexRanges.Get(key)
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if len(buf) != 16 {
+		return 0, 0, false
+	}
+	return idx.BytesToBlock(buf[:8]), idx.BytesToBlock(buf[8:]), true
+}
+
+// markIndexedRange extends the range of indexed blocks stored under the key by [from, to].
+// A range which is not adjacent to the current one replaces it only if it is newer,
+// as the range has to be continuous.
+func (s *Store) markIndexedRange(key []byte, from, to idx.Block) {
+	first, last, ok := s.getIndexedRange(key)
+	switch {
+	case !ok:
+		first, last = from, to
+	case from <= last+1 && to+1 >= first:
+		if from < first {
+			first = from
+		}
+		if to > last {
+			last = to
+		}
+	case from > last:
+		first, last = from, to
+	default:
+		// older disjoint range is not usable
+		return
+	}
+
+	if err := s.table.IndexRanges.Put(key, append(first.Bytes(), last.Bytes()...)); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// trimIndexedRange moves the start of the indexed range stored under the key up to the block start,
+// the range is deleted if it ends before the start.
+func (s *Store) trimIndexedRange(key []byte, start idx.Block) {
+	first, last, ok := s.getIndexedRange(key)
+	if !ok || first >= start {
+		return
+	}
+
+	if last < start {
+		if err := s.table.IndexRanges.Delete(key); err != nil {
+			s.Log.Crit("Failed to delete key-value", "err", err)
+		}
+		return
+	}
+	if err := s.table.IndexRanges.Put(key, append(start.Bytes(), last.Bytes()...)); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
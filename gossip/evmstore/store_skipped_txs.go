@@ -0,0 +1,45 @@
+package evmstore
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+// SetSkippedTxs stores the skipped transactions of the block and the reasons of the skips.
+func (s *Store) SetSkippedTxs(n idx.Block, txs []evmcore.SkippedTx) {
+	if len(txs) == 0 {
+		return
+	}
+	buf, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		s.Log.Crit("Failed to encode rlp", "err", err)
+	}
+	if err := s.table.SkippedTxs.Put(n.Bytes(), buf); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// GetSkippedTxs returns the stored skipped transactions of the block, nil if there are none.
+func (s *Store) GetSkippedTxs(n idx.Block) []evmcore.SkippedTx {
+	buf, err := s.table.SkippedTxs.Get(n.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if buf == nil {
+		return nil
+	}
+	var txs []evmcore.SkippedTx
+	if err := rlp.DecodeBytes(buf, &txs); err != nil {
+		s.Log.Crit("Failed to decode rlp", "err", err, "size", len(buf))
+	}
+	return txs
+}
+
+// DeleteSkippedTxs deletes the skipped transactions of the block.
+func (s *Store) DeleteSkippedTxs(n idx.Block) {
+	if err := s.table.SkippedTxs.Delete(n.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key-value", "err", err)
+	}
+}
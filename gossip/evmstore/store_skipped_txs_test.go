@@ -0,0 +1,31 @@
+package evmstore
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+func TestStoreSkippedTxs(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := nonCachedStore()
+	expect := []evmcore.SkippedTx{
+		{Index: 1, Hash: common.Hash{1}, Reason: "nonce too high"},
+		{Index: 5, Hash: common.Hash{5}, Reason: "insufficient funds for gas * price + value"},
+	}
+	store.SetSkippedTxs(2, expect)
+	store.SetSkippedTxs(3, nil)
+
+	require.Equal(expect, store.GetSkippedTxs(2))
+	require.Nil(store.GetSkippedTxs(3))
+	require.Nil(store.GetSkippedTxs(4))
+
+	store.DeleteSkippedTxs(2)
+	require.Nil(store.GetSkippedTxs(2))
+}
@@ -0,0 +1,134 @@
+package evmstore
+
+import (
+	"encoding/json"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/mrmikeo/Xpense/txtrace"
+)
+
+var traceRangeKey = []byte("r")
+
+// TraceIndexingEnabled tells whether txs traces are stored
+func (s *Store) TraceIndexingEnabled() bool {
+	return s.cfg.EnableTraceIndexing
+}
+
+// SetBlockTraces stores traces of the block txs and indexes the block by from/to addresses of the traces.
+func (s *Store) SetBlockTraces(n idx.Block, traces []txtrace.ActionTrace) {
+	if !s.cfg.EnableTraceIndexing {
+		return
+	}
+
+	buf, err := json.Marshal(traces)
+	if err != nil {
+		s.Log.Crit("Failed to encode traces", "err", err)
+	}
+	if err := s.table.Traces.Put(n.Bytes(), buf); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+
+	for _, addr := range traceAddresses(traces) {
+		if err := s.table.TraceAddresses.Put(append(addr.Bytes(), n.Bytes()...), []byte{}); err != nil {
+			s.Log.Crit("Failed to put key-value", "err", err)
+		}
+	}
+}
+
+// DeleteBlockTraces deletes traces of the block txs and the block from the addresses index.
+func (s *Store) DeleteBlockTraces(n idx.Block) {
+	if !s.cfg.EnableTraceIndexing {
+		return
+	}
+
+	for _, addr := range traceAddresses(s.GetBlockTraces(n)) {
+		if err := s.table.TraceAddresses.Delete(append(addr.Bytes(), n.Bytes()...)); err != nil {
+			s.Log.Crit("Failed to delete key-value", "err", err)
+		}
+	}
+	if err := s.table.Traces.Delete(n.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key-value", "err", err)
+	}
+}
+
+// traceAddresses returns the distinct from/to addresses of the traces
+func traceAddresses(traces []txtrace.ActionTrace) []common.Address {
+	var addrs []common.Address
+	seen := make(map[common.Address]struct{})
+	for _, trace := range traces {
+		if trace.Action == nil {
+			continue
+		}
+		for _, addr := range []*common.Address{trace.Action.From, trace.Action.To} {
+			if addr == nil {
+				continue
+			}
+			if _, ok := seen[*addr]; ok {
+				continue
+			}
+			seen[*addr] = struct{}{}
+			addrs = append(addrs, *addr)
+		}
+	}
+	return addrs
+}
+
+// GetBlockTraces returns stored traces of the block txs, nil if the block traces are not stored.
+func (s *Store) GetBlockTraces(n idx.Block) []txtrace.ActionTrace {
+	if !s.cfg.EnableTraceIndexing {
+		return nil
+	}
+
+	buf, err := s.table.Traces.Get(n.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if buf == nil {
+		return nil
+	}
+
+	traces := make([]txtrace.ActionTrace, 0)
+	if err := json.Unmarshal(buf, &traces); err != nil {
+		s.Log.Crit("Failed to decode traces", "err", err)
+	}
+	return traces
+}
+
+// GetTraceBlocksByAddress returns ascending numbers of the blocks within [from, to]
+// which contain a trace with the address as its from or to address.
+func (s *Store) GetTraceBlocksByAddress(addr common.Address, from, to idx.Block) []idx.Block {
+	if !s.cfg.EnableTraceIndexing {
+		return nil
+	}
+
+	it := s.table.TraceAddresses.NewIterator(addr.Bytes(), from.Bytes())
+	defer it.Release()
+
+	var blocks []idx.Block
+	for it.Next() {
+		n := idx.BytesToBlock(it.Key()[common.AddressLength:])
+		if n > to {
+			break
+		}
+		blocks = append(blocks, n)
+	}
+	return blocks
+}
+
+// GetTracedBlocksRange returns the continuous range of blocks with stored traces.
+func (s *Store) GetTracedBlocksRange() (first, last idx.Block, ok bool) {
+	if !s.cfg.EnableTraceIndexing {
+		return 0, 0, false
+	}
+	return s.getIndexedRange(traceRangeKey)
+}
+
+// MarkTracedBlocks extends the range of blocks with stored traces by [from, to].
+func (s *Store) MarkTracedBlocks(from, to idx.Block) {
+	if !s.cfg.EnableTraceIndexing {
+		return
+	}
+	s.markIndexedRange(traceRangeKey, from, to)
+}
@@ -0,0 +1,121 @@
+package evmstore
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/logger"
+	"github.com/mrmikeo/Xpense/txtrace"
+)
+
+func traceStore() *Store {
+	cfg := LiteStoreConfig()
+	cfg.EnableTraceIndexing = true
+	return NewStore(memorydb.New(), cfg)
+}
+
+func fakeTrace(n idx.Block, from, to common.Address) txtrace.ActionTrace {
+	trace := txtrace.NewActionTrace(common.Hash{byte(n)}, *big.NewInt(int64(n)), common.Hash{1, byte(n)}, 0, txtrace.CALL)
+	trace.Action = txtrace.NewAddressAction(from, 21000, []byte{}, &to, hexutil.Big{}, nil)
+	return *trace
+}
+
+func TestStoreBlockTraces(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	store := traceStore()
+	store.SetBlockTraces(1, []txtrace.ActionTrace{fakeTrace(1, a, b)})
+	store.SetBlockTraces(2, []txtrace.ActionTrace{fakeTrace(2, b, c)})
+	store.SetBlockTraces(3, []txtrace.ActionTrace{fakeTrace(3, a, c), fakeTrace(3, c, a)})
+
+	traces := store.GetBlockTraces(3)
+	require.Len(traces, 2)
+	require.Equal(c, *traces[1].Action.From)
+	require.Equal(a, *traces[1].Action.To)
+	require.Equal(int64(3), traces[1].BlockNumber.Int64())
+	require.Nil(store.GetBlockTraces(4))
+
+	require.Equal([]idx.Block{1, 3}, store.GetTraceBlocksByAddress(a, 1, 3))
+	require.Equal([]idx.Block{2, 3}, store.GetTraceBlocksByAddress(c, 1, 3))
+	require.Equal([]idx.Block{2}, store.GetTraceBlocksByAddress(c, 1, 2))
+	require.Equal([]idx.Block{3}, store.GetTraceBlocksByAddress(a, 2, 5))
+	require.Empty(store.GetTraceBlocksByAddress(common.Address{4}, 1, 3))
+}
+
+func TestStoreTracedBlocksRange(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := traceStore()
+	_, _, ok := store.GetTracedBlocksRange()
+	require.False(ok)
+
+	check := func(first, last idx.Block) {
+		f, l, ok := store.GetTracedBlocksRange()
+		require.True(ok)
+		require.Equal(first, f)
+		require.Equal(last, l)
+	}
+
+	store.MarkTracedBlocks(10, 10)
+	check(10, 10)
+	store.MarkTracedBlocks(11, 11)
+	check(10, 11)
+	// older disjoint range is ignored
+	store.MarkTracedBlocks(1, 5)
+	check(10, 11)
+	// adjacent older range is merged
+	store.MarkTracedBlocks(1, 9)
+	check(1, 11)
+	// newer disjoint range replaces the current one
+	store.MarkTracedBlocks(20, 20)
+	check(20, 20)
+}
+
+func TestStoreDeleteBlockTraces(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	store := traceStore()
+	store.SetBlockTraces(1, []txtrace.ActionTrace{fakeTrace(1, a, b)})
+	store.SetBlockTraces(2, []txtrace.ActionTrace{fakeTrace(2, b, c)})
+	store.MarkTracedBlocks(1, 2)
+
+	store.DeleteBlockTraces(1)
+	require.Nil(store.GetBlockTraces(1))
+	require.Empty(store.GetTraceBlocksByAddress(a, 1, 2))
+	require.Equal([]idx.Block{2}, store.GetTraceBlocksByAddress(b, 1, 2))
+
+	// the range follows the history start
+	store.SetHistoryStart(2)
+	first, last, ok := store.GetTracedBlocksRange()
+	require.True(ok)
+	require.Equal(idx.Block(2), first)
+	require.Equal(idx.Block(2), last)
+	store.SetHistoryStart(3)
+	_, _, ok = store.GetTracedBlocksRange()
+	require.False(ok)
+}
+
+func TestStoreTracesDisabled(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := cachedStore()
+	store.SetBlockTraces(1, []txtrace.ActionTrace{fakeTrace(1, common.Address{1}, common.Address{2})})
+	store.MarkTracedBlocks(1, 1)
+
+	require.Nil(store.GetBlockTraces(1))
+	require.Empty(store.GetTraceBlocksByAddress(common.Address{1}, 0, 10))
+	_, _, ok := store.GetTracedBlocksRange()
+	require.False(ok)
+}
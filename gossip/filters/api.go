@@ -54,12 +54,15 @@ type Config struct {
 	IndexedLogsBlockRangeLimit idx.Block
 	// Block range limit for logs search (unindexed).
 	UnindexedLogsBlockRangeLimit idx.Block
+	// Max number of logs in a page of paginated logs search.
+	MaxLogsPageSize int
 }
 
 func DefaultConfig() Config {
 	return Config{
 		IndexedLogsBlockRangeLimit:   999999999999999999,
 		UnindexedLogsBlockRangeLimit: 100,
+		MaxLogsPageSize:              10000,
 	}
 }
 
@@ -370,6 +373,44 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 	return returnLogs(logs), err
 }
 
+// GetLogsPage returns a page of logs matching the given argument that are stored within the state.
+// The search is continued from the cursor if it's provided, the returned page contains at most
+// limit logs (MaxLogsPageSize by default) and the cursor to request the next page with.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *LogsCursor, limit *hexutil.Uint) (*LogsPage, error) {
+	pageSize := api.config.MaxLogsPageSize
+	if limit != nil && int(*limit) < pageSize {
+		pageSize = int(*limit)
+	}
+	if pageSize <= 0 {
+		return nil, errors.New("page size must be positive")
+	}
+
+	var filter *Filter
+	if crit.BlockHash != nil {
+		// Block filter requested, construct a single-shot filter
+		filter = NewBlockFilter(api.backend, api.config, *crit.BlockHash, crit.Addresses, crit.Topics)
+	} else {
+		// Convert the RPC block numbers into internal representations
+		begin := rpc.LatestBlockNumber.Int64()
+		if crit.FromBlock != nil {
+			begin = crit.FromBlock.Int64()
+		}
+		end := rpc.LatestBlockNumber.Int64()
+		if crit.ToBlock != nil {
+			end = crit.ToBlock.Int64()
+		}
+		// Construct the range filter
+		filter = NewRangeFilter(api.backend, api.config, begin, end, crit.Addresses, crit.Topics)
+	}
+	// Run the filter and return the page of logs
+	page, err := filter.LogsPage(ctx, cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	page.Logs = returnLogs(page.Logs)
+	return page, nil
+}
+
 // UninstallFilter removes the filter with the given filter id.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_uninstallfilter
@@ -21,10 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/Fantom-foundation/lachesis-base/hash"
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	notify "github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -42,6 +44,7 @@ type Backend interface {
 	GetReceiptsByNumber(ctx context.Context, number rpc.BlockNumber) (types.Receipts, error)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
 	GetTxPosition(txid common.Hash) *evmstore.TxPosition
+	HistoryStart() idx.Block
 
 	SubscribeNewBlockNotify(ch chan<- evmcore.ChainHeadNotify) notify.Subscription
 	SubscribeNewTxsNotify(chan<- evmcore.NewTxsNotify) notify.Subscription
@@ -130,6 +133,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if begin > end {
 		return []*types.Log{}, nil
 	}
+	if err := f.checkHistory(begin); err != nil {
+		return nil, err
+	}
 
 	if isEmpty(f.topics) && len(f.addresses) == 0 {
 		return f.unindexedLogs(ctx, begin, end)
@@ -138,6 +144,212 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 }
 
+// checkHistory returns an error if the history of the first block of the range is pruned.
+func (f *Filter) checkHistory(begin idx.Block) error {
+	if start := f.backend.HistoryStart(); begin < start {
+		return &evmstore.PrunedError{Block: begin, HistoryStart: start}
+	}
+	return nil
+}
+
+// LogsCursor is a position in the logs to continue a paginated search from
+type LogsCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+}
+
+// LogsPage is a page of logs matching the filter criteria
+type LogsPage struct {
+	Logs []*types.Log `json:"logs"`
+	// Next is the position of the first log of the next page, nil if there are no more logs
+	Next *LogsCursor `json:"next"`
+}
+
+// before tells whether the log precedes the cursor position
+func (c *LogsCursor) before(l *types.Log) bool {
+	return c != nil && (l.BlockNumber < uint64(c.BlockNumber) || l.BlockNumber == uint64(c.BlockNumber) && l.Index < uint(c.LogIndex))
+}
+
+// LogsPage searches the blockchain for matching log entries starting from the cursor.
+// It returns at most limit logs ordered by block number and log index,
+// and the position to continue from when there are more logs.
+func (f *Filter) LogsPage(ctx context.Context, cursor *LogsCursor, limit int) (*LogsPage, error) {
+	// If we're doing singleton block filtering, execute and return
+	if f.block != common.Hash(hash.Zero) {
+		header, err := f.backend.HeaderByHash(ctx, f.block)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errors.New("unknown block")
+		}
+		logs, err := f.blockLogs(ctx, header.Hash)
+		if err != nil {
+			return nil, err
+		}
+		return pageLogs(logs, cursor, limit, nil), nil
+	}
+	// Figure out the limits of the filter range
+	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil {
+		return &LogsPage{}, nil
+	}
+	head := idx.Block(header.Number.Uint64())
+
+	begin := idx.Block(f.begin)
+	if f.begin < 0 {
+		begin = head
+	}
+	end := idx.Block(f.end)
+	if f.end < 0 {
+		end = head
+	}
+	if cursor != nil && idx.Block(cursor.BlockNumber) > begin {
+		begin = idx.Block(cursor.BlockNumber)
+	}
+	if begin > end {
+		return &LogsPage{}, nil
+	}
+	if err := f.checkHistory(begin); err != nil {
+		return nil, err
+	}
+
+	if isEmpty(f.topics) && len(f.addresses) == 0 {
+		return f.unindexedLogsPage(ctx, begin, end, cursor, limit)
+	} else {
+		return f.indexedLogsPage(ctx, begin, end, cursor, limit)
+	}
+}
+
+// indexedLogsPage returns a page of the logs matching the filter criteria based on topics index.
+func (f *Filter) indexedLogsPage(ctx context.Context, begin, end idx.Block, cursor *LogsCursor, limit int) (*LogsPage, error) {
+	if end-begin > f.config.IndexedLogsBlockRangeLimit {
+		return nil, fmt.Errorf("too wide blocks range, the limit is %d", f.config.IndexedLogsBlockRangeLimit)
+	}
+
+	addresses := make([]common.Hash, len(f.addresses))
+	for i, addr := range f.addresses {
+		addresses[i] = addr.Hash()
+	}
+
+	pattern := make([][]common.Hash, 1, len(f.topics)+1)
+	pattern[0] = addresses
+	pattern = append(pattern, f.topics...)
+
+	// The index passes blocks in ascending order, but the logs of a block are not ordered
+	// and the search may be split into several passes over the range when there are
+	// too many topic variants. So the search is stopped at the beginning of a block,
+	// when all the previous blocks are complete, and the next passes take the logs
+	// of the previous blocks only.
+	var (
+		logs      []*types.Log
+		lastBlock uint64
+		stopped   *LogsCursor
+	)
+	err := f.backend.EvmLogIndex().ForEachInBlocks(ctx, begin, end, pattern, func(l *types.Log) bool {
+		if cursor.before(l) {
+			return true
+		}
+		if stopped != nil {
+			if l.BlockNumber >= uint64(stopped.BlockNumber) {
+				return false
+			}
+			logs = append(logs, l)
+			return true
+		}
+		if len(logs) >= limit && l.BlockNumber > lastBlock {
+			stopped = &LogsCursor{BlockNumber: hexutil.Uint64(l.BlockNumber)}
+			return false
+		}
+		logs = append(logs, l)
+		if l.BlockNumber > lastBlock {
+			lastBlock = l.BlockNumber
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageLogs(logs, nil, limit, stopped)
+	for _, l := range page.Logs {
+		pos := f.backend.GetTxPosition(l.TxHash)
+		if pos != nil {
+			l.TxIndex = uint(pos.BlockOffset)
+		} else {
+			log.Warn("tx index empty", "hash", l.TxHash)
+		}
+	}
+
+	return page, nil
+}
+
+// unindexedLogsPage returns a page of the logs matching the filter criteria based on raw block
+// iteration.
+func (f *Filter) unindexedLogsPage(ctx context.Context, begin, end idx.Block, cursor *LogsCursor, limit int) (*LogsPage, error) {
+	if end-begin > f.config.UnindexedLogsBlockRangeLimit {
+		return nil, fmt.Errorf("too wide blocks range, the limit is %d", f.config.UnindexedLogsBlockRangeLimit)
+	}
+
+	var logs []*types.Log
+	for n := begin; n <= end; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(logs) >= limit {
+			return pageLogs(logs, nil, limit, &LogsCursor{BlockNumber: hexutil.Uint64(n)}), nil
+		}
+
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(n))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			break
+		}
+		found, err := f.blockLogs(ctx, header.Hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range found {
+			if !cursor.before(l) {
+				logs = append(logs, l)
+			}
+		}
+	}
+	return pageLogs(logs, nil, limit, nil), nil
+}
+
+// pageLogs orders the logs and cuts the page of the logs following the cursor.
+// The next position is the first log over the limit, or the provided one if all the logs fit.
+func pageLogs(logs []*types.Log, cursor *LogsCursor, limit int, next *LogsCursor) *LogsPage {
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	page := &LogsPage{
+		Logs: make([]*types.Log, 0, len(logs)),
+		Next: next,
+	}
+	for _, l := range logs {
+		if cursor.before(l) {
+			continue
+		}
+		if len(page.Logs) >= limit {
+			page.Next = &LogsCursor{
+				BlockNumber: hexutil.Uint64(l.BlockNumber),
+				LogIndex:    hexutil.Uint(l.Index),
+			}
+			break
+		}
+		page.Logs = append(page.Logs, l)
+	}
+	return page
+}
+
 // indexedLogs returns the logs matching the filter criteria based on topics index.
 func (f *Filter) indexedLogs(ctx context.Context, begin, end idx.Block) ([]*types.Log, error) {
 	if end-begin > f.config.IndexedLogsBlockRangeLimit {
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -43,6 +44,8 @@ type testBackend struct {
 	blocksFeed *notify.Feed
 	txsFeed    *notify.Feed
 	logsFeed   *notify.Feed
+
+	historyStart idx.Block
 }
 
 func newTestBackend() *testBackend {
@@ -147,6 +150,10 @@ func (b *testBackend) GetTxPosition(txid common.Hash) *evmstore.TxPosition {
 	return nil
 }
 
+func (b *testBackend) HistoryStart() idx.Block {
+	return b.historyStart
+}
+
 func (b *testBackend) CalcBlockExtApi() bool {
 	return true
 }
@@ -26,6 +26,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/mrmikeo/Xpense/gossip/evmstore"
 	"github.com/mrmikeo/Xpense/topicsdb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -40,6 +41,7 @@ func testConfig() Config {
 	return Config{
 		IndexedLogsBlockRangeLimit:   1000,
 		UnindexedLogsBlockRangeLimit: 1000,
+		MaxLogsPageSize:              1000,
 	}
 }
 
@@ -263,3 +265,148 @@ func TestFilters(t *testing.T) {
 	}
 
 }
+
+func TestFiltersPage(t *testing.T) {
+	var (
+		backend = newTestBackend()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+
+	var expect []*types.Log
+	genesis := core.GenesisBlockForTesting(backend.db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), backend.db, 20, func(i int, gen *core.BlockGen) {
+		if i%3 == 0 {
+			return
+		}
+		n := uint64(i + 1)
+		receipt := types.NewReceipt(nil, false, 0)
+		// blocks contain different number of logs, which are pushed not in index order
+		for j := i % 5; j >= 0; j-- {
+			receipt.Logs = append(receipt.Logs, &types.Log{
+				BlockNumber: n,
+				Address:     addr,
+				Topics:      []common.Hash{hash1, hash2},
+				TxHash:      common.Hash{byte(n), byte(j)},
+				Index:       uint(j),
+			})
+		}
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(n, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		backend.MustPushLogs(receipt.Logs...)
+		for j := len(receipt.Logs) - 1; j >= 0; j-- {
+			expect = append(expect, receipt.Logs[j])
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(backend.db, block)
+		rawdb.WriteCanonicalHash(backend.db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(backend.db, block.Hash())
+		rawdb.WriteReceipts(backend.db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+
+	for _, topics := range [][][]common.Hash{
+		{{hash1}},
+		{{hash1, hash2}, {hash1, hash2}},
+	} {
+		for _, limit := range []int{1, 2, 3, 7, len(expect), len(expect) + 1} {
+			var (
+				got    []*types.Log
+				cursor *LogsCursor
+				pages  int
+			)
+			for {
+				filter := NewRangeFilter(backend, testConfig(), 0, -1, []common.Address{addr}, topics)
+				page, err := filter.LogsPage(context.Background(), cursor, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page.Logs) > limit {
+					t.Fatalf("limit %d: expected at most %d logs, got %d", limit, limit, len(page.Logs))
+				}
+				got = append(got, page.Logs...)
+				pages++
+				if page.Next == nil {
+					break
+				}
+				if len(page.Logs) == 0 {
+					t.Fatalf("limit %d: empty page with next cursor", limit)
+				}
+				cursor = page.Next
+			}
+			if len(got) != len(expect) {
+				t.Fatalf("limit %d: expected %d logs, got %d", limit, len(expect), len(got))
+			}
+			for i := range expect {
+				if got[i].BlockNumber != expect[i].BlockNumber || got[i].Index != expect[i].Index {
+					t.Fatalf("limit %d: log %d is %d/%d, expected %d/%d", limit, i,
+						got[i].BlockNumber, got[i].Index, expect[i].BlockNumber, expect[i].Index)
+				}
+			}
+			if limit < len(expect) && pages < 2 {
+				t.Fatalf("limit %d: expected several pages", limit)
+			}
+		}
+	}
+
+	// the cursor from the middle of a block
+	filter := NewRangeFilter(backend, testConfig(), 0, -1, []common.Address{addr}, nil)
+	page, err := filter.LogsPage(context.Background(), &LogsCursor{BlockNumber: 5, LogIndex: 2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Logs) != 2 || page.Logs[0].BlockNumber != 5 || page.Logs[0].Index != 2 || page.Logs[1].Index != 3 {
+		t.Fatal("unexpected logs of the page", page.Logs)
+	}
+	if page.Next == nil || page.Next.BlockNumber != 5 || page.Next.LogIndex != 4 {
+		t.Fatal("unexpected next cursor", page.Next)
+	}
+}
+
+func TestFiltersPrunedHistory(t *testing.T) {
+	var (
+		backend = newTestBackend()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+	)
+
+	genesis := core.GenesisBlockForTesting(backend.db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), backend.db, 20, func(i int, gen *core.BlockGen) {})
+	for i, block := range chain {
+		rawdb.WriteBlock(backend.db, block)
+		rawdb.WriteCanonicalHash(backend.db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(backend.db, block.Hash())
+		rawdb.WriteReceipts(backend.db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	backend.historyStart = 10
+
+	for _, filter := range []*Filter{
+		NewRangeFilter(backend, testConfig(), 5, -1, []common.Address{addr}, nil),
+		NewRangeFilter(backend, testConfig(), 0, 15, nil, nil),
+	} {
+		_, err := filter.Logs(context.Background())
+		pruned, ok := err.(*evmstore.PrunedError)
+		if !ok {
+			t.Fatal("expected pruned error, got", err)
+		}
+		if pruned.HistoryStart != 10 {
+			t.Error("expected history start 10, got", pruned.HistoryStart)
+		}
+		if _, err := filter.LogsPage(context.Background(), nil, 10); err == nil {
+			t.Error("expected pruned error of logs page")
+		}
+	}
+
+	filter := NewRangeFilter(backend, testConfig(), 10, -1, []common.Address{addr}, nil)
+	if _, err := filter.Logs(context.Background()); err != nil {
+		t.Error(err)
+	}
+	// the cursor may point over the pruned blocks
+	filter = NewRangeFilter(backend, testConfig(), 0, -1, []common.Address{addr}, nil)
+	if _, err := filter.LogsPage(context.Background(), &LogsCursor{BlockNumber: 12}, 10); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,127 @@
+package gasprice
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+const (
+	// SlowCertainty is a certainty of the slow fee tier
+	SlowCertainty = 0.2 * DecimalUnit
+	// FastCertainty is a certainty of the fast fee tier
+	FastCertainty = 0.9 * DecimalUnit
+)
+
+// Congestion is a state of the txpool and of the gas power, which the fee estimates are derived from
+type Congestion struct {
+	// PendingGas is an averaged gas of the txpool transactions which are ready for execution
+	PendingGas uint64
+	// GasPowerLeft is a total gas power left of the validators
+	GasPowerLeft uint64
+	// MaxGasPower is a maximum total gas power the validators may accumulate
+	MaxGasPower uint64
+	// GasPowerAllocPerSec is a speed of the gas power allocation, i.e. a sustained network throughput
+	GasPowerAllocPerSec uint64
+	// FreeRatio is a share of the free gas power, scaled by DecimalUnit
+	FreeRatio uint64
+}
+
+// FeeTier is a fee estimate for a given certainty of the inclusion
+type FeeTier struct {
+	Certainty uint64
+	// Tip is a suggested gas tip on top of the base fee
+	Tip *big.Int
+	// GasAhead is an expected gas of the pending transactions which will be included earlier
+	GasAhead uint64
+	// InclusionDelay is an expected time until the gas power is sufficient to include the transaction
+	InclusionDelay time.Duration
+}
+
+// Estimates is a set of the fee tiers along with the data they are based on
+type Estimates struct {
+	BaseFee    *big.Int
+	Slow       FeeTier
+	Standard   FeeTier
+	Fast       FeeTier
+	Congestion Congestion
+}
+
+func (gpo *Oracle) congestion() Congestion {
+	rules := gpo.backend.GetRules()
+	c := Congestion{
+		PendingGas:          gpo.c.totalGas(),
+		GasPowerLeft:        gpo.backend.TotalGasPowerLeft(),
+		MaxGasPower:         gpo.maxTotalGasPower().Uint64(),
+		GasPowerAllocPerSec: rules.Economy.LongGasPower.AllocPerSec,
+	}
+	if c.MaxGasPower != 0 {
+		free := new(big.Int).SetUint64(c.GasPowerLeft)
+		free.Mul(free, DecimalUnitBn)
+		free.Div(free, new(big.Int).SetUint64(c.MaxGasPower))
+		c.FreeRatio = math.BigMin(free, DecimalUnitBn).Uint64()
+	}
+	return c
+}
+
+// inclusionDelay estimates how long it takes to allocate the gas power for the gas ahead of the transaction.
+// The gas power left is consumed first, the rest is allocated at the sustained rate.
+func (c Congestion) inclusionDelay(gasAhead uint64) time.Duration {
+	if gasAhead <= c.GasPowerLeft {
+		return 0
+	}
+	if c.GasPowerAllocPerSec == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	deficit := new(big.Int).SetUint64(gasAhead - c.GasPowerLeft)
+	deficit.Mul(deficit, secondBn)
+	deficit.Div(deficit, new(big.Int).SetUint64(c.GasPowerAllocPerSec))
+	if !deficit.IsInt64() {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(deficit.Int64())
+}
+
+func (gpo *Oracle) feeTier(certainty uint64, c Congestion) FeeTier {
+	gasAhead := certaintyToGasAbove(certainty)
+	if gasAhead > c.PendingGas {
+		gasAhead = c.PendingGas
+	}
+	return FeeTier{
+		Certainty:      certainty,
+		Tip:            gpo.SuggestTip(certainty),
+		GasAhead:       gasAhead,
+		InclusionDelay: c.inclusionDelay(gasAhead),
+	}
+}
+
+// Estimates returns the slow, standard and fast fee tiers.
+// The standard tier uses the default certainty, and the tiers are ordered so that a faster tier is never cheaper.
+func (gpo *Oracle) Estimates() Estimates {
+	if gpo.backend == nil {
+		zero := FeeTier{Tip: new(big.Int)}
+		return Estimates{
+			BaseFee:  new(big.Int),
+			Slow:     zero,
+			Standard: zero,
+			Fast:     zero,
+		}
+	}
+	c := gpo.congestion()
+	e := Estimates{
+		BaseFee:    new(big.Int).Set(gpo.backend.GetRules().Economy.MinGasPrice),
+		Slow:       gpo.feeTier(SlowCertainty, c),
+		Standard:   gpo.feeTier(gpo.cfg.DefaultCertainty, c),
+		Fast:       gpo.feeTier(FastCertainty, c),
+		Congestion: c,
+	}
+	// DefaultCertainty is configurable, so keep the tiers consistent
+	if e.Standard.Tip.Cmp(e.Slow.Tip) < 0 {
+		e.Standard = e.Slow
+	}
+	if e.Fast.Tip.Cmp(e.Standard.Tip) < 0 {
+		e.Fast = e.Standard
+	}
+	return e
+}
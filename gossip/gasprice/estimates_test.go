@@ -0,0 +1,83 @@
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/opera"
+)
+
+func TestOracle_Estimates(t *testing.T) {
+	backend := &TestBackend{
+		rules:        opera.FakeNetRules(),
+		pendingRules: opera.FakeNetRules(),
+	}
+
+	gpo := NewOracle(Config{})
+	gpo.cfg.MaxGasPrice = math.MaxBig256
+	gpo.cfg.MinGasPrice = new(big.Int)
+
+	// no backend
+	e := gpo.Estimates()
+	require.Equal(t, "0", e.BaseFee.String())
+	require.Equal(t, "0", e.Fast.Tip.String())
+
+	gpo.backend = backend
+	maxGasPower := gpo.maxTotalGasPower().Uint64()
+	allocPerSec := backend.rules.Economy.LongGasPower.AllocPerSec
+
+	// empty txpool and free gas power, all tiers are included immediately
+	backend.totalGasPowerLeft = maxGasPower
+	gpo.txpoolStatsTick()
+	e = gpo.Estimates()
+	require.Equal(t, backend.rules.Economy.MinGasPrice.String(), e.BaseFee.String())
+	require.Equal(t, uint64(DecimalUnit), e.Congestion.FreeRatio)
+	require.Equal(t, maxGasPower, e.Congestion.MaxGasPower)
+	require.Equal(t, allocPerSec, e.Congestion.GasPowerAllocPerSec)
+	for _, tier := range []FeeTier{e.Slow, e.Standard, e.Fast} {
+		require.Equal(t, uint64(0), tier.GasAhead)
+		require.Equal(t, time.Duration(0), tier.InclusionDelay)
+	}
+	require.Equal(t, uint64(SlowCertainty), e.Slow.Certainty)
+	require.Equal(t, gpo.cfg.DefaultCertainty, e.Standard.Certainty)
+	require.Equal(t, uint64(FastCertainty), e.Fast.Certainty)
+
+	// congested txpool and half of the gas power left
+	backend.totalGasPowerLeft = maxGasPower / 2
+	for i := 0; i < 100; i++ {
+		backend.pendingTxs = append(backend.pendingTxs, fakeTx{
+			gas: 1000000,
+			tip: big.NewInt(int64(i) * 1e8),
+			cap: big.NewInt(100 * 1e9),
+		})
+	}
+	gpo.tCache.Purge()
+	gpo.txpoolStatsTick()
+	e = gpo.Estimates()
+	require.Equal(t, uint64(DecimalUnit/2), e.Congestion.FreeRatio)
+	require.Equal(t, uint64(maxGasToIndex), e.Congestion.PendingGas/maxGasToIndex*maxGasToIndex)
+	require.True(t, e.Slow.Tip.Cmp(e.Standard.Tip) < 0)
+	require.True(t, e.Standard.Tip.Cmp(e.Fast.Tip) < 0)
+	require.True(t, e.Slow.GasAhead > e.Standard.GasAhead)
+	require.True(t, e.Standard.GasAhead > e.Fast.GasAhead)
+	require.Equal(t, time.Duration(0), e.Slow.InclusionDelay)
+
+	// the gas power left is consumed first, the rest is allocated at the sustained rate
+	c := e.Congestion
+	c.GasPowerLeft = 0
+	require.Equal(t, time.Duration(e.Fast.GasAhead*uint64(time.Second)/allocPerSec), c.inclusionDelay(e.Fast.GasAhead))
+	require.True(t, c.inclusionDelay(e.Slow.GasAhead) > c.inclusionDelay(e.Fast.GasAhead))
+	c.GasPowerLeft = e.Fast.GasAhead
+	require.Equal(t, time.Duration(0), c.inclusionDelay(e.Fast.GasAhead))
+	require.Equal(t, time.Duration((e.Slow.GasAhead-e.Fast.GasAhead)*uint64(time.Second)/allocPerSec), c.inclusionDelay(e.Slow.GasAhead))
+
+	// misconfigured default certainty doesn't break the tiers order
+	gpo.cfg.DefaultCertainty = DecimalUnit
+	gpo.tCache.Purge()
+	e = gpo.Estimates()
+	require.Equal(t, e.Standard, e.Fast)
+}
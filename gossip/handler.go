@@ -33,6 +33,9 @@ import (
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream"
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream/epstreamleecher"
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream/epstreamseeder"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream/snstreamleecher"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream/snstreamseeder"
 	"github.com/mrmikeo/Xpense/inter"
 	"github.com/mrmikeo/Xpense/inter/ibr"
 	"github.com/mrmikeo/Xpense/inter/ier"
@@ -93,6 +96,7 @@ type processCallback struct {
 	BR               func(ibr.LlrIdxFullBlockRecord) error
 	EV               func(inter.LlrSignedEpochVote) error
 	ER               func(ier.LlrIdxFullEpochRecord) error
+	StateSnapshot    func(idx.Epoch, string) error
 }
 
 // handlerConfig is the collection of initialization parameters to create a full
@@ -105,6 +109,10 @@ type handlerConfig struct {
 	checkers *eventcheck.Checkers
 	s        *Store
 	process  processCallback
+	// snapshots is nil if the node doesn't serve state snapshots
+	snapshots *StateSnapshots
+	// privateTxs are never announced to the public peers
+	privateTxs *privateTxs
 }
 
 type handler struct {
@@ -121,6 +129,9 @@ type handler struct {
 	txsCh  chan evmcore.NewTxsNotify
 	txsSub notify.Subscription
 
+	privateTxs   *privateTxs
+	trustedPeers map[enode.ID]bool
+
 	dagLeecher   *dagstreamleecher.Leecher
 	dagSeeder    *dagstreamseeder.Seeder
 	dagProcessor *dagprocessor.Processor
@@ -138,6 +149,11 @@ type handler struct {
 	epSeeder    *epstreamseeder.Seeder
 	epProcessor *epprocessor.Processor
 
+	snLeecher *snstreamleecher.Leecher
+	snSeeder  *snstreamseeder.Seeder
+	snSync    *snapshotSync
+	snapshots *StateSnapshots
+
 	process processCallback
 
 	txFetcher *itemsfetcher.Fetcher
@@ -193,9 +209,15 @@ func newHandler(
 		txsyncCh:             make(chan *txsync),
 		quitSync:             make(chan struct{}),
 		quitProgressBradcast: make(chan struct{}),
+		snapshots:            c.snapshots,
+		privateTxs:           c.privateTxs,
+		trustedPeers:         make(map[enode.ID]bool),
 
 		Instance: logger.New("PM"),
 	}
+	for _, n := range c.config.PrivateTxs.TrustedPeers {
+		h.trustedPeers[n.ID()] = true
+	}
 	h.started.Add(1)
 
 	h.dagFetcher = itemsfetcher.New(h.config.Protocol.DagFetcher, itemsfetcher.Callback{
@@ -327,7 +349,7 @@ func newHandler(
 			return llrs.LowestEpochToDecide
 		},
 		MaxEpochToFetch: func() idx.Epoch {
-			if !h.syncStatus.RequestLLR() {
+			if !h.syncStatus.RequestLLR() && !h.snSync.Active() {
 				return 0
 			}
 			return h.store.GetLlrState().LowestEpochToDecide + 10000
@@ -355,6 +377,35 @@ func newHandler(
 		Iterate: h.store.IterateEpochPacksRLP,
 	})
 
+	if h.config.Snapshots.Sync {
+		h.snSync = newSnapshotSync(h.config.Snapshots, h.store, h.process.StateSnapshot)
+	}
+	h.snLeecher = snstreamleecher.New(h.config.Protocol.SnStreamLeecher, snstreamleecher.Callbacks{
+		Target: h.snSync.Target,
+		LowestChunkToFetch: func() uint32 {
+			return h.snSync.LowestChunkToFetch()
+		},
+		IsProcessed: func(l snstream.Locator) bool {
+			return h.snSync.IsProcessed(l)
+		},
+		RequestChunk: func(peer string, r snstream.Request) error {
+			p := h.peers.Peer(peer)
+			if p == nil {
+				return errNotRegistered
+			}
+			return p.RequestSnapshotStream(r)
+		},
+		Suspend: func(_ string) bool {
+			return false
+		},
+		PeerHasSnapshot: func(peer string, m *snstream.Manifest) bool {
+			return h.snSync.PeerHasSnapshot(peer, m)
+		},
+	})
+	h.snSeeder = snstreamseeder.New(h.config.Protocol.SnStreamSeeder, snstreamseeder.Callbacks{
+		ReadChunk: h.snapshots.ReadChunk,
+	})
+
 	return h, nil
 }
 
@@ -584,6 +635,11 @@ func (h *handler) unregisterPeer(id string) {
 	_ = h.brSeeder.UnregisterPeer(id)
 	_ = h.bvLeecher.UnregisterPeer(id)
 	_ = h.bvSeeder.UnregisterPeer(id)
+	_ = h.snLeecher.UnregisterPeer(id)
+	_ = h.snSeeder.UnregisterPeer(id)
+	if h.snSync != nil {
+		h.snSync.UnregisterPeer(id)
+	}
 	if err := h.peers.UnregisterPeer(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
 	}
@@ -612,6 +668,10 @@ func (h *handler) Start(maxPeers int) {
 		go h.progressBroadcastLoop()
 		go h.onNewEpochLoop()
 	}
+	if h.snSync != nil {
+		h.loopsWg.Add(1)
+		go h.snapshotManifestsLoop()
+	}
 
 	// start sync handlers
 	go h.txsyncLoop()
@@ -634,12 +694,19 @@ func (h *handler) Start(maxPeers int) {
 	h.brProcessor.Start()
 	h.brSeeder.Start()
 	h.brLeecher.Start()
+
+	h.snSeeder.Start()
+	h.snLeecher.Start()
 	h.started.Done()
 }
 
 func (h *handler) Stop() {
 	log.Info("Stopping Fantom protocol")
 
+	h.snLeecher.Stop()
+	h.snSeeder.Stop()
+	h.snSync.Stop()
+
 	h.brLeecher.Stop()
 	h.brSeeder.Stop()
 	h.brProcessor.Stop()
@@ -766,7 +833,7 @@ func (h *handler) handle(p *peer) error {
 		p.Log().Warn("Leecher peer registration failed", "err", err)
 		return err
 	}
-	if p.RunningCap(ProtocolName, []uint{FTM63}) {
+	if p.RunningCap(ProtocolName, []uint{FTM63, FTM64}) {
 		if err := h.epLeecher.RegisterPeer(p.id); err != nil {
 			p.Log().Warn("Leecher peer registration failed", "err", err)
 			return err
@@ -780,11 +847,20 @@ func (h *handler) handle(p *peer) error {
 			return err
 		}
 	}
+	if p.RunningCap(ProtocolName, []uint{FTM64}) {
+		if err := h.snLeecher.RegisterPeer(p.id); err != nil {
+			p.Log().Warn("Leecher peer registration failed", "err", err)
+			return err
+		}
+		if h.snSync.Active() {
+			_ = p.RequestSnapshotManifest()
+		}
+	}
 	defer h.unregisterPeer(p.id)
 
 	// Propagate existing transactions. new transactions appearing
 	// after this will be sent via broadcasts.
-	h.syncTransactions(p, h.txpool.SampleHashes(h.config.Protocol.MaxInitialTxHashesSend))
+	h.syncTransactions(p, h.privateTxs.FilterHashes(h.txpool.SampleHashes(h.config.Protocol.MaxInitialTxHashesSend)))
 
 	// Handle incoming messages until the connection is torn down
 	for {
@@ -998,7 +1074,7 @@ func (h *handler) handleMsg(p *peer) error {
 		}
 
 		txs := make(types.Transactions, 0, len(requests))
-		for _, txid := range requests {
+		for _, txid := range h.privateTxs.FilterHashes(requests) {
 			tx := h.txpool.Get(txid)
 			if tx == nil {
 				continue
@@ -1239,6 +1315,114 @@ func (h *handler) handleMsg(p *peer) error {
 
 		_ = h.epLeecher.NotifyChunkReceived(chunk.SessionID, last, chunk.Done)
 
+	case msg.Code == GetSnapshotManifestMsg:
+		manifest := h.snapshots.Latest()
+		if manifest == nil {
+			manifest = &snstream.Manifest{}
+		}
+		if err := p.SendSnapshotManifest(manifest); err != nil {
+			return err
+		}
+
+	case msg.Code == SnapshotManifestMsg:
+		var manifest snstream.Manifest
+		if err := msg.Decode(&manifest); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if h.snSync.Active() {
+			h.snSync.OnManifest(p.id, &manifest)
+		}
+
+	case msg.Code == RequestSnapshotStream:
+		var request snstream.Request
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if request.Limit.Num > hardLimitItems-1 {
+			return errResp(ErrMsgTooLarge, "%v", msg)
+		}
+		if request.Limit.Size > protocolMaxMsgSize*2/3 {
+			return errResp(ErrMsgTooLarge, "%v", msg)
+		}
+
+		pid := p.id
+		_, peerErr := h.snSeeder.NotifyRequestReceived(snstreamseeder.Peer{
+			ID:        pid,
+			SendChunk: p.SendSnapshotStream,
+			Misbehaviour: func(err error) {
+				h.peerMisbehaviour(pid, err)
+			},
+		}, request)
+		if peerErr != nil {
+			return peerErr
+		}
+
+	case msg.Code == SnapshotStreamResponse:
+		if !h.snSync.Active() {
+			break
+		}
+
+		var chunk snstream.Response
+		if err := msg.Decode(&chunk); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if err := checkLenLimits(len(chunk.Payload)+1, chunk); err != nil {
+			return err
+		}
+
+		var last snstream.Locator
+		if len(chunk.Payload) != 0 {
+			if err := h.snSync.OnChunks(chunk.Payload); err != nil {
+				return err
+			}
+			last = chunk.Payload[len(chunk.Payload)-1].Locator
+		}
+
+		_ = h.snLeecher.NotifyChunkReceived(chunk.SessionID, last, chunk.Done)
+
+	case msg.Code == PrivateTxsMsg:
+		// Private transactions are accepted only from the trusted peers
+		if !h.trustedPeers[p.ID()] {
+			break
+		}
+		if !h.syncStatus.AcceptTxs() {
+			break
+		}
+		var data privateTxsData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := checkLenLimits(len(data.Txs), data.Txs); err != nil {
+			return err
+		}
+		// the peer may be ahead of the node, so a too far deadline is clamped rather than rejected
+		cfg := h.config.PrivateTxs
+		latest := h.store.GetLatestBlockIndex()
+		if data.MaxBlock > latest+cfg.MaxBlocks {
+			data.MaxBlock = latest + cfg.MaxBlocks
+		}
+		maxBlock, err := checkDeadline(data.MaxBlock, latest, cfg.DefaultBlocks, cfg.MaxBlocks)
+		if err != nil {
+			p.Log().Trace("Ignoring private transactions", "count", len(data.Txs), "err", err)
+			break
+		}
+		// mark the transactions before they get into the txpool, so they are never broadcast
+		wasPrivate := make([]bool, len(data.Txs))
+		for i, tx := range data.Txs {
+			wasPrivate[i] = h.privateTxs.Has(tx.Hash())
+		}
+		h.privateTxs.Add(data.Txs, maxBlock)
+		for i, err := range h.txpool.AddRemotes(data.Txs) {
+			if err == nil {
+				continue
+			}
+			// a transaction already known as public stays public
+			if !wasPrivate[i] {
+				h.privateTxs.Remove(data.Txs[i].Hash())
+			}
+			p.Log().Debug("Failed to add private transaction", "hash", data.Txs[i].Hash(), "err", err)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -1320,6 +1504,7 @@ func (h *handler) BroadcastEvent(event *inter.EventPayload, passed time.Duration
 // BroadcastTxs will propagate a batch of transactions to all peers which are not known to
 // already have the given transaction.
 func (h *handler) BroadcastTxs(txs types.Transactions) {
+	txs = h.privateTxs.FilterTxs(txs)
 	broadcastedTxsCounter.Inc(int64(txs.Len()))
 	var txset = make(map[*peer]types.Transactions)
 
@@ -1351,6 +1536,28 @@ func (h *handler) BroadcastTxs(txs types.Transactions) {
 	}
 }
 
+// SendPrivateTxs forwards private transactions to the connected trusted peers, it returns the number of the recipients
+func (h *handler) SendPrivateTxs(txs types.Transactions, maxBlock idx.Block) int {
+	sent := 0
+	for _, peer := range h.peers.List() {
+		if !h.trustedPeers[peer.ID()] || !peer.RunningCap(ProtocolName, []uint{FTM64}) {
+			continue
+		}
+		SplitTransactions(txs, func(batch types.Transactions) {
+			err := peer.SendPrivateTransactions(privateTxsData{
+				Txs:      batch,
+				MaxBlock: maxBlock,
+			})
+			if err != nil {
+				peer.Log().Debug("Failed to forward private transactions", "err", err)
+			}
+		})
+		sent++
+	}
+	log.Trace("Forward private transactions", "count", txs.Len(), "recipients", sent)
+	return sent
+}
+
 // Mined broadcast loop
 func (h *handler) emittedBroadcastLoop() {
 	defer h.loopsWg.Done()
@@ -1402,6 +1609,29 @@ func (h *handler) onNewEpochLoop() {
 	}
 }
 
+// snapshotManifestsLoop requests the snapshot manifests from the peers which haven't announced a snapshot,
+// as the peers create the new snapshots periodically
+func (h *handler) snapshotManifestsLoop() {
+	ticker := time.NewTicker(h.config.Snapshots.Period)
+	defer ticker.Stop()
+	defer h.loopsWg.Done()
+	for {
+		select {
+		case <-ticker.C:
+			if !h.snSync.Active() || h.snSync.Target() != nil {
+				continue
+			}
+			for _, peer := range h.peers.List() {
+				if peer.RunningCap(ProtocolName, []uint{FTM64}) && !h.snSync.HasManifest(peer.id) {
+					_ = peer.RequestSnapshotManifest()
+				}
+			}
+		case <-h.quitProgressBradcast:
+			return
+		}
+	}
+}
+
 func (h *handler) txBroadcastLoop() {
 	ticker := time.NewTicker(h.config.Protocol.RandomTxHashesSendPeriod)
 	defer ticker.Stop()
@@ -1424,7 +1654,7 @@ func (h *handler) txBroadcastLoop() {
 				continue
 			}
 			randPeer := peers[rand.Intn(len(peers))]
-			h.syncTransactions(randPeer, h.txpool.SampleHashes(h.config.Protocol.MaxRandomTxHashesSend))
+			h.syncTransactions(randPeer, h.privateTxs.FilterHashes(h.txpool.SampleHashes(h.config.Protocol.MaxRandomTxHashesSend)))
 		}
 	}
 }
@@ -101,12 +101,13 @@ func makeFuzzedHandler() (h *handler, err error) {
 
 	h, err = newHandler(
 		handlerConfig{
-			config:   config,
-			notifier: feed,
-			txpool:   txpool,
-			engineMu: mu,
-			checkers: checkers,
-			s:        store,
+			config:     config,
+			notifier:   feed,
+			txpool:     txpool,
+			engineMu:   mu,
+			checkers:   checkers,
+			s:          store,
+			privateTxs: newPrivateTxs(),
 			process: processCallback{
 				Event: func(event *inter.EventPayload) error {
 					return nil
@@ -0,0 +1,137 @@
+package gossip
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+)
+
+// HistoryPruner periodically prunes the blocks history which is out of the configured window
+type HistoryPruner struct {
+	period time.Duration
+	// prune prunes a batch of blocks, it returns true if more blocks have to be pruned
+	prune func() (more bool)
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+func (p *HistoryPruner) loop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for p.prune() {
+				select {
+				case <-p.quit:
+					return
+				default:
+				}
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *HistoryPruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+func (p *HistoryPruner) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// makeHistoryPruner makes HistoryPruner, nil if the pruning is disabled
+func (s *Service) makeHistoryPruner() *HistoryPruner {
+	cfg := s.store.cfg.Pruning
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &HistoryPruner{
+		period: cfg.Period,
+		prune:  s.pruneHistory,
+		quit:   make(chan struct{}),
+	}
+}
+
+// pruneStepBlocks is the max number of blocks pruned while the events/blocks processing is locked
+const pruneStepBlocks = 64
+
+// pruneHistory prunes a batch of blocks out of the history window.
+// The batch is pruned by small steps, so that the events/blocks processing isn't locked for long.
+func (s *Service) pruneHistory() (more bool) {
+	batch := s.store.cfg.Pruning.BatchBlocks
+	for pruned := idx.Block(0); pruned < batch; {
+		limit := min(pruneStepBlocks, batch-pruned)
+		step := s.pruneHistoryStep(limit)
+		if step < limit {
+			return false
+		}
+		pruned += step
+	}
+	return true
+}
+
+// pruneHistoryStep prunes at most limit blocks out of the history window, it returns the number of pruned blocks
+func (s *Service) pruneHistoryStep(limit idx.Block) idx.Block {
+	// don't hurt events/blocks pipeline
+	if atomic.LoadUint32(&s.eventBusyFlag) != 0 || atomic.LoadUint32(&s.blockBusyFlag) != 0 {
+		return 0
+	}
+	s.engineMu.Lock()
+	defer s.engineMu.Unlock()
+	if s.stopped {
+		return 0
+	}
+	s.blockProcWg.Wait()
+
+	to := s.historyWindowStart()
+	if to == 0 {
+		return 0
+	}
+	pruned := s.store.PruneHistory(to, limit)
+	if pruned != 0 {
+		s.Log.Debug("Blocks history pruned", "blocks", pruned, "start", s.store.evm.GetHistoryStart())
+		if s.store.IsCommitNeeded() {
+			s.commit(false)
+		}
+	}
+	return pruned
+}
+
+// historyWindowStart returns the first block of the history to keep, 0 if nothing has to be pruned.
+// If both blocks and epochs windows are configured, the longer history is kept.
+func (s *Service) historyWindowStart() idx.Block {
+	cfg := s.store.cfg.Pruning
+	last := s.store.GetLatestBlockIndex()
+	if last < MinHistoryBlocks {
+		return 0
+	}
+	start := last - MinHistoryBlocks + 1
+	if cfg.HistoryBlocks != 0 {
+		if last < cfg.HistoryBlocks {
+			return 0
+		}
+		start = min(start, last-cfg.HistoryBlocks+1)
+	}
+	if cfg.HistoryEpochs != 0 {
+		epoch := s.store.GetEpoch()
+		if epoch <= cfg.HistoryEpochs {
+			return 0
+		}
+		// the state of the epoch refers to the last block of the previous one
+		bs, _ := s.store.GetHistoryBlockEpochState(epoch - cfg.HistoryEpochs)
+		if bs == nil {
+			return 0
+		}
+		start = min(start, bs.LastBlock.Idx+1)
+	}
+	return start
+}
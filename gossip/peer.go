@@ -22,6 +22,7 @@ import (
 	"github.com/mrmikeo/Xpense/gossip/protocols/blockvotes/bvstream"
 	"github.com/mrmikeo/Xpense/gossip/protocols/dag/dagstream"
 	"github.com/mrmikeo/Xpense/gossip/protocols/epochpacks/epstream"
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
 	"github.com/mrmikeo/Xpense/inter"
 )
 
@@ -473,6 +474,28 @@ func (p *peer) RequestEPsStream(r epstream.Request) error {
 	return p2p.Send(p.rw, RequestEPsStream, r)
 }
 
+func (p *peer) SendSnapshotManifest(m *snstream.Manifest) error {
+	return p2p.Send(p.rw, SnapshotManifestMsg, m)
+}
+
+func (p *peer) RequestSnapshotManifest() error {
+	return p2p.Send(p.rw, GetSnapshotManifestMsg, []interface{}{})
+}
+
+func (p *peer) SendSnapshotStream(r snstream.Response) error {
+	return p2p.Send(p.rw, SnapshotStreamResponse, r)
+}
+
+func (p *peer) RequestSnapshotStream(r snstream.Request) error {
+	return p2p.Send(p.rw, RequestSnapshotStream, r)
+}
+
+// SendPrivateTransactions sends private transactions directly, without marking them as known,
+// so the peer is never announced about them by the regular transactions propagation.
+func (p *peer) SendPrivateTransactions(data privateTxsData) error {
+	return p2p.Send(p.rw, PrivateTxsMsg, data)
+}
+
 func (p *peer) SendEventsStream(r dagstream.Response, ids hash.Events) error {
 	// Mark all the event hash as known, but ensure we don't overflow our limits
 	for _, id := range ids {
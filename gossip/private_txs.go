@@ -0,0 +1,204 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+var errNoPrivateTxsRecipients = errors.New("private transactions require either an emitter or trusted peers")
+
+// privateTxs is a registry of the private transactions, which are never announced to the public peers.
+// A private transaction is kept until its max block, after which it's dropped from the txpool if not included.
+type privateTxs struct {
+	mu        sync.RWMutex
+	deadlines map[common.Hash]idx.Block
+}
+
+func newPrivateTxs() *privateTxs {
+	return &privateTxs{
+		deadlines: make(map[common.Hash]idx.Block),
+	}
+}
+
+// Add marks the transactions as private until the max block
+func (p *privateTxs) Add(txs types.Transactions, maxBlock idx.Block) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, tx := range txs {
+		if prev, ok := p.deadlines[tx.Hash()]; !ok || prev < maxBlock {
+			p.deadlines[tx.Hash()] = maxBlock
+		}
+	}
+}
+
+// Remove unmarks the transaction
+func (p *privateTxs) Remove(txid common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.deadlines, txid)
+}
+
+// Has returns true if the transaction is private
+func (p *privateTxs) Has(txid common.Hash) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.deadlines[txid]
+	return ok
+}
+
+// FilterTxs returns the public transactions only
+func (p *privateTxs) FilterTxs(txs types.Transactions) types.Transactions {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.deadlines) == 0 {
+		return txs
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if _, ok := p.deadlines[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+// FilterHashes returns the hashes of the public transactions only
+func (p *privateTxs) FilterHashes(txids []common.Hash) []common.Hash {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.deadlines) == 0 {
+		return txids
+	}
+	public := make([]common.Hash, 0, len(txids))
+	for _, txid := range txids {
+		if _, ok := p.deadlines[txid]; !ok {
+			public = append(public, txid)
+		}
+	}
+	return public
+}
+
+// Include unmarks the transactions included into a block, so they are never reported as expired
+func (p *privateTxs) Include(txs types.Transactions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.deadlines) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		delete(p.deadlines, tx.Hash())
+	}
+}
+
+// Expire unmarks the transactions which max block is lower than the given block and returns them
+func (p *privateTxs) Expire(block idx.Block) []common.Hash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var expired []common.Hash
+	for txid, maxBlock := range p.deadlines {
+		if maxBlock < block {
+			expired = append(expired, txid)
+			delete(p.deadlines, txid)
+		}
+	}
+	return expired
+}
+
+// PrivateTxsExpirer drops the expired private transactions from the txpool on every new block,
+// the included private transactions are unmarked
+type PrivateTxsExpirer struct {
+	txs      *privateTxs
+	txpool   TxPool
+	feed     *ServiceFeed
+	statuses *txStatuses
+
+	sub notify.Subscription
+	wg  sync.WaitGroup
+}
+
+func (e *PrivateTxsExpirer) loop(newBlocks chan evmcore.ChainHeadNotify) {
+	defer e.wg.Done()
+	for {
+		select {
+		case head := <-newBlocks:
+			e.onNewBlock(head.Block)
+		// Err() channel will be closed when unsubscribing.
+		case <-e.sub.Err():
+			return
+		}
+	}
+}
+
+func (e *PrivateTxsExpirer) onNewBlock(block *evmcore.EvmBlock) {
+	e.txs.Include(block.Transactions)
+	expired := e.txs.Expire(idx.Block(block.Number.Uint64()))
+	for _, txid := range expired {
+		e.txpool.RemoveTx(txid)
+	}
+	e.statuses.OnDropped(expired, "private transaction deadline reached")
+	if len(expired) != 0 {
+		log.Debug("Dropped expired private transactions", "count", len(expired), "block", block.Number)
+	}
+}
+
+func (e *PrivateTxsExpirer) Start() {
+	newBlocks := make(chan evmcore.ChainHeadNotify, 10)
+	e.sub = e.feed.SubscribeNewBlock(newBlocks)
+	e.wg.Add(1)
+	go e.loop(newBlocks)
+}
+
+func (e *PrivateTxsExpirer) Stop() {
+	e.sub.Unsubscribe()
+	e.wg.Wait()
+}
+
+// checkDeadline checks the requested max block is ahead of the latest block at most by maxBlocks,
+// latest+defaultBlocks is returned if zero is requested
+func checkDeadline(maxBlock, latest, defaultBlocks, maxBlocks idx.Block) (idx.Block, error) {
+	if maxBlock == 0 {
+		return latest + defaultBlocks, nil
+	}
+	if maxBlock <= latest {
+		return 0, fmt.Errorf("max block %d is already reached, latest block is %d", maxBlock, latest)
+	}
+	if maxBlock > latest+maxBlocks {
+		return 0, fmt.Errorf("max block %d is too far, at most %d blocks ahead of latest block %d are allowed", maxBlock, maxBlocks, latest)
+	}
+	return maxBlock, nil
+}
+
+// sendPrivateTx adds the transaction into the txpool without announcing it to the public peers.
+// The transaction is emitted by the local emitters or forwarded to the trusted peers.
+func (s *Service) sendPrivateTx(tx *types.Transaction, maxBlock idx.Block) error {
+	if len(s.emitters) == 0 && len(s.config.PrivateTxs.TrustedPeers) == 0 {
+		return errNoPrivateTxsRecipients
+	}
+	cfg := s.config.PrivateTxs
+	maxBlock, err := checkDeadline(maxBlock, s.store.GetLatestBlockIndex(), cfg.DefaultBlocks, cfg.MaxBlocks)
+	if err != nil {
+		return err
+	}
+	txs := types.Transactions{tx}
+	// mark the transaction before it gets into the txpool, so it's never broadcast
+	wasPrivate := s.privateTxs.Has(tx.Hash())
+	s.privateTxs.Add(txs, maxBlock)
+	// the registry isn't persisted, so the transaction isn't journaled to be never restored as a public one
+	if err := s.txpool.AddUnjournaled(tx); err != nil {
+		if !wasPrivate {
+			s.privateTxs.Remove(tx.Hash())
+		}
+		return err
+	}
+	s.handler.SendPrivateTxs(txs, maxBlock)
+	return nil
+}
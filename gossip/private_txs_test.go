@@ -0,0 +1,79 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+func TestPrivateTxs(t *testing.T) {
+	require := require.New(t)
+
+	newTx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	public := newTx(0)
+	private1 := newTx(1)
+	private2 := newTx(2)
+
+	p := newPrivateTxs()
+	p.Add(types.Transactions{private1}, 10)
+	p.Add(types.Transactions{private2}, 20)
+	// deadline is never shortened
+	p.Add(types.Transactions{private2}, 5)
+
+	require.True(p.Has(private1.Hash()))
+	require.False(p.Has(public.Hash()))
+
+	all := types.Transactions{public, private1, private2}
+	require.Equal(types.Transactions{public}, p.FilterTxs(all))
+	require.Equal([]common.Hash{public.Hash()}, p.FilterHashes([]common.Hash{private1.Hash(), public.Hash(), private2.Hash()}))
+
+	require.Empty(p.Expire(10))
+	require.Equal([]common.Hash{private1.Hash()}, p.Expire(11))
+	require.False(p.Has(private1.Hash()))
+	require.True(p.Has(private2.Hash()))
+
+	p.Remove(private2.Hash())
+	require.Equal(all, p.FilterTxs(all))
+}
+
+func TestPrivateTxsExpirer(t *testing.T) {
+	require := require.New(t)
+
+	newTx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	included := newTx(1)
+	expired := newTx(2)
+	newBlock := func(n int64, txs ...*types.Transaction) *evmcore.EvmBlock {
+		return evmcore.NewEvmBlock(&evmcore.EvmHeader{Number: big.NewInt(n)}, txs)
+	}
+
+	txpool := &dummyTxPool{}
+	txpool.AddRemotes(types.Transactions{included, expired})
+	e := &PrivateTxsExpirer{
+		txs:      newPrivateTxs(),
+		txpool:   txpool,
+		statuses: newTxStatuses(10, nil),
+	}
+	e.txs.Add(types.Transactions{included, expired}, 10)
+
+	e.onNewBlock(newBlock(5, included))
+	require.False(e.txs.Has(included.Hash()))
+	require.True(e.txs.Has(expired.Hash()))
+
+	// the included transaction isn't reported as dropped once the deadline is reached
+	e.onNewBlock(newBlock(11))
+	require.False(e.txs.Has(expired.Hash()))
+	require.Nil(e.statuses.Get(included.Hash()))
+	l := e.statuses.Get(expired.Hash())
+	require.NotNil(l)
+	require.True(l.Dropped)
+	require.Nil(txpool.Get(expired.Hash()))
+}
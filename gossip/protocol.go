@@ -19,17 +19,18 @@ import (
 const (
 	FTM62           = 62
 	FTM63           = 63
-	ProtocolVersion = FTM63
+	FTM64           = 64
+	ProtocolVersion = FTM64
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 const ProtocolName = "opera"
 
 // ProtocolVersions are the supported versions of the protocol (first is primary).
-var ProtocolVersions = []uint{FTM62, FTM63}
+var ProtocolVersions = []uint{FTM62, FTM63, FTM64}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = map[uint]uint64{FTM62: EventsStreamResponse + 1, FTM63: EPsStreamResponse + 1}
+var protocolLengths = map[uint]uint64{FTM62: EventsStreamResponse + 1, FTM63: EPsStreamResponse + 1, FTM64: PrivateTxsMsg + 1}
 
 const protocolMaxMsgSize = inter.ProtocolMaxMsgSize // Maximum cap on the size of a protocol message
 
@@ -68,6 +69,18 @@ const (
 	BRsStreamResponse = 13
 	RequestEPsStream  = 14
 	EPsStreamResponse = 15
+
+	// Request the manifest of the latest state snapshot served by the peer
+	GetSnapshotManifestMsg = 16
+	// Contains the manifest of the latest state snapshot, or an empty manifest
+	SnapshotManifestMsg = 17
+	// Request a range of chunks of a state snapshot
+	RequestSnapshotStream = 18
+	// Contains the requested chunks by RequestSnapshotStream
+	SnapshotStreamResponse = 19
+
+	// Contains private transactions, which are exchanged only between the trusted peers and never announced
+	PrivateTxsMsg = 20
 )
 
 type errCode int
@@ -106,21 +119,26 @@ var errorToString = map[int]string{
 type TxPool interface {
 	emitter.TxPool
 	SubscribeNewTxsNotify(chan<- evmcore.NewTxsNotify) notify.Subscription
+	SubscribeDroppedTxsNotify(chan<- evmcore.DroppedTxsNotify) notify.Subscription
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 	AddLocals(txs []*types.Transaction) []error
 	AddLocal(tx *types.Transaction) error
+	// AddUnjournaled adds the transaction which isn't restored after a restart, e.g. a private one
+	AddUnjournaled(tx *types.Transaction) error
 
 	Get(common.Hash) *types.Transaction
 
 	OnlyNotExisting(hashes []common.Hash) []common.Hash
 	SampleHashes(max int) []common.Hash
+	RemoveTx(hash common.Hash)
 
 	Nonce(addr common.Address) uint64
 	Stats() (int, int)
 	Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	ContentFrom(addr common.Address) (types.Transactions, types.Transactions)
 	GasPrice() *big.Int
+	PolicyStatus() evmcore.TxPolicyStatus
 }
 
 // handshakeData is the network packet for the initial handshake message
@@ -139,6 +157,13 @@ type PeerProgress struct {
 	HighestLamport idx.Lamport
 }
 
+// privateTxsData is the network packet for the private transactions forwarding
+type privateTxsData struct {
+	Txs types.Transactions
+	// MaxBlock is a block after which the transactions are dropped if not included
+	MaxBlock idx.Block
+}
+
 type dagChunk struct {
 	SessionID uint32
 	Done      bool
@@ -0,0 +1,183 @@
+package snstreamleecher
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/gossip/basestream/basestreamleecher"
+	"github.com/Fantom-foundation/lachesis-base/gossip/basestream/basestreamleecher/basepeerleecher"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
+)
+
+// Leecher is responsible for requesting chunks of the target state snapshot
+type Leecher struct {
+	*basestreamleecher.BaseLeecher
+
+	// Callbacks
+	callback Callbacks
+
+	cfg Config
+
+	// State
+	session sessionState
+}
+
+// New creates a snapshot chunks downloader to request chunks of the target snapshot
+func New(cfg Config, callback Callbacks) *Leecher {
+	l := &Leecher{
+		cfg:      cfg,
+		callback: callback,
+	}
+	l.BaseLeecher = basestreamleecher.New(cfg.RecheckInterval, basestreamleecher.Callbacks{
+		SelectSessionPeerCandidates: l.selectSessionPeerCandidates,
+		ShouldTerminateSession:      l.shouldTerminateSession,
+		StartSession:                l.startSession,
+		TerminateSession:            l.terminateSession,
+		OngoingSession: func() bool {
+			return l.session.agent != nil
+		},
+		OngoingSessionPeer: func() string {
+			return l.session.peer
+		},
+	})
+	return l
+}
+
+type Callbacks struct {
+	// Target returns the snapshot to download, or nil if nothing has to be downloaded
+	Target             func() *snstream.Manifest
+	LowestChunkToFetch func() uint32
+	IsProcessed        func(snstream.Locator) bool
+
+	RequestChunk func(peer string, r snstream.Request) error
+	Suspend      func(peer string) bool
+	// PeerHasSnapshot tells whether the peer has announced the same snapshot
+	PeerHasSnapshot func(peer string, m *snstream.Manifest) bool
+}
+
+type sessionState struct {
+	agent        *basepeerleecher.BasePeerLeecher
+	peer         string
+	startTime    time.Time
+	endTime      time.Time
+	lastReceived time.Time
+	try          uint32
+
+	sessionID uint32
+
+	block              idx.Block
+	lowestChunkToFetch uint32
+}
+
+func (d *Leecher) shouldTerminateSession() bool {
+	if d.session.agent.Stopped() {
+		return true
+	}
+	target := d.callback.Target()
+	if target == nil || target.Block != d.session.block {
+		return true
+	}
+
+	noProgress := time.Since(d.session.lastReceived) >= d.cfg.BaseProgressWatchdog*time.Duration(d.session.try+5)/5
+	stuck := time.Since(d.session.startTime) >= d.cfg.BaseSessionWatchdog*time.Duration(d.session.try+5)/5
+	return stuck || noProgress
+}
+
+func (d *Leecher) terminateSession() {
+	// force the chunks download to end
+	if d.session.agent != nil {
+		d.session.agent.Terminate()
+		d.session.agent = nil
+		d.session.endTime = time.Now()
+		if d.callback.LowestChunkToFetch() > d.session.lowestChunkToFetch {
+			// reset the counter of unsuccessful sync attempts
+			d.session.try = 0
+		}
+	}
+}
+
+func (d *Leecher) selectSessionPeerCandidates() []string {
+	target := d.callback.Target()
+	if target == nil {
+		return nil
+	}
+	if d.session.try != 0 && time.Since(d.session.endTime) < d.cfg.MinSessionRestart {
+		return nil
+	}
+	candidates := make([]string, 0, len(d.Peers))
+	for p := range d.Peers {
+		if d.callback.PeerHasSnapshot(p, target) {
+			candidates = append(candidates, p)
+		}
+	}
+	return candidates
+}
+
+func getSessionID(block idx.Block, chunk uint32, try uint32) uint32 {
+	return (uint32(block) << 20) ^ (chunk << 8) ^ try
+}
+
+func (d *Leecher) startSession(candidates []string) {
+	peer := candidates[rand.Intn(len(candidates))]
+
+	target := d.callback.Target()
+	start := d.callback.LowestChunkToFetch()
+	session := snstream.Session{
+		ID:    getSessionID(target.Block, start, d.session.try),
+		Start: snstream.Locator{Block: target.Block, Chunk: start},
+		Stop:  snstream.Locator{Block: target.Block, Chunk: uint32(len(target.Chunks))},
+	}
+
+	d.session.agent = basepeerleecher.New(&d.Wg, d.cfg.Session, basepeerleecher.EpochDownloaderCallbacks{
+		IsProcessed: func(id interface{}) bool {
+			return d.callback.IsProcessed(id.(snstream.Locator))
+		},
+		RequestChunks: func(maxNum uint32, maxSize uint64, chunks uint32) error {
+			return d.callback.RequestChunk(peer,
+				snstream.Request{
+					Session:   session,
+					Limit:     snstream.Metric{Num: maxNum, Size: maxSize},
+					Type:      0,
+					MaxChunks: chunks,
+				})
+		},
+		Suspend: func() bool {
+			return d.callback.Suspend(peer)
+		},
+		Done: func() bool {
+			return false
+		},
+	})
+
+	now := time.Now()
+	d.session.startTime = now
+	d.session.lastReceived = now
+	d.session.endTime = now
+	d.session.try++
+	d.session.peer = peer
+	d.session.sessionID = session.ID
+	d.session.block = target.Block
+	d.session.lowestChunkToFetch = start
+
+	d.session.agent.Start()
+}
+
+func (d *Leecher) NotifyChunkReceived(sessionID uint32, last snstream.Locator, done bool) error {
+	d.Mu.Lock()
+	defer d.Mu.Unlock()
+	if d.session.agent == nil {
+		return nil
+	}
+	if d.session.sessionID != sessionID {
+		return nil
+	}
+
+	d.session.lastReceived = time.Now()
+	if done {
+		d.terminateSession()
+		return nil
+	}
+	return d.session.agent.NotifyChunkReceived(last)
+}
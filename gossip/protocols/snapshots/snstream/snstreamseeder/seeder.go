@@ -0,0 +1,90 @@
+package snstreamseeder
+
+import (
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/Fantom-foundation/lachesis-base/gossip/basestream"
+	"github.com/Fantom-foundation/lachesis-base/gossip/basestream/basestreamseeder"
+
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
+)
+
+var (
+	ErrWrongType        = errors.New("wrong request type")
+	ErrSelectorMismatch = errors.New("session start and stop refer to different snapshots")
+)
+
+type Seeder struct {
+	*basestreamseeder.BaseSeeder
+}
+
+type Callbacks struct {
+	// ReadChunk returns the chunk of the snapshot of the block, or false if the chunk doesn't exist
+	ReadChunk func(block idx.Block, chunk uint32) ([]byte, bool)
+}
+
+type Peer struct {
+	ID           string
+	SendChunk    func(snstream.Response) error
+	Misbehaviour func(error)
+}
+
+func New(cfg Config, callbacks Callbacks) *Seeder {
+	return &Seeder{
+		BaseSeeder: basestreamseeder.New(basestreamseeder.Config(cfg), basestreamseeder.Callbacks{
+			ForEachItem: func(start basestream.Locator, _ basestream.RequestType, onKey func(key basestream.Locator) bool, onAppended func(items basestream.Payload) bool) basestream.Payload {
+				res := &snstream.Payload{
+					Items: []snstream.Chunk{},
+					Size:  0,
+				}
+				st := start.(snstream.Locator)
+				for key := st; ; key = key.Inc().(snstream.Locator) {
+					if !onKey(key) {
+						break
+					}
+					data, ok := callbacks.ReadChunk(key.Block, key.Chunk)
+					if !ok {
+						break
+					}
+					res.AddChunk(key, data)
+					if !onAppended(res) {
+						break
+					}
+				}
+				return res
+			},
+		}),
+	}
+}
+
+func (s *Seeder) NotifyRequestReceived(peer Peer, r snstream.Request) (err error, peerErr error) {
+	if r.Type != 0 {
+		return nil, ErrWrongType
+	}
+	if r.Session.Start.Block != r.Session.Stop.Block {
+		return nil, ErrSelectorMismatch
+	}
+	return s.BaseSeeder.NotifyRequestReceived(basestreamseeder.Peer{
+		ID: peer.ID,
+		SendChunk: func(response basestream.Response) error {
+			return peer.SendChunk(snstream.Response{
+				SessionID: response.SessionID,
+				Done:      response.Done,
+				Payload:   response.Payload.(*snstream.Payload).Items,
+			})
+		},
+		Misbehaviour: peer.Misbehaviour,
+	}, basestream.Request{
+		Session: basestream.Session{
+			ID:    r.Session.ID,
+			Start: r.Session.Start,
+			Stop:  r.Session.Stop,
+		},
+		Type:           r.Type,
+		MaxPayloadNum:  r.Limit.Num,
+		MaxPayloadSize: r.Limit.Size,
+		MaxChunks:      r.MaxChunks,
+	})
+}
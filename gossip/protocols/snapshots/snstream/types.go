@@ -0,0 +1,229 @@
+package snstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/lachesis-base/gossip/basestream"
+)
+
+// ChunkSize is the size of every snapshot chunk except the last one.
+// It's fixed by the protocol so that the honest peers produce identical manifests for the same block.
+const ChunkSize = 1024 * 1024
+
+var (
+	ErrChunkIndex    = errors.New("snapshot chunk index out of range")
+	ErrChunkMismatch = errors.New("snapshot chunk doesn't match the manifest")
+)
+
+type Request struct {
+	Session   Session
+	Limit     Metric
+	Type      basestream.RequestType
+	MaxChunks uint32
+}
+
+type Response struct {
+	SessionID uint32
+	Done      bool
+	Payload   []Chunk
+}
+
+type Session struct {
+	ID    uint32
+	Start Locator
+	Stop  Locator
+}
+
+// Locator addresses a chunk of the snapshot of a block
+type Locator struct {
+	Block idx.Block
+	Chunk uint32
+}
+
+func (l Locator) Compare(b basestream.Locator) int {
+	bl := b.(Locator)
+	if l.Block != bl.Block {
+		if l.Block < bl.Block {
+			return -1
+		}
+		return 1
+	}
+	if l.Chunk == bl.Chunk {
+		return 0
+	}
+	if l.Chunk < bl.Chunk {
+		return -1
+	}
+	return 1
+}
+
+func (l Locator) Inc() basestream.Locator {
+	return Locator{
+		Block: l.Block,
+		Chunk: l.Chunk + 1,
+	}
+}
+
+type Chunk struct {
+	Locator Locator
+	Data    []byte
+}
+
+type Payload struct {
+	Items []Chunk
+	Size  uint64
+}
+
+func (p *Payload) AddChunk(id Locator, data []byte) {
+	p.Items = append(p.Items, Chunk{
+		Locator: id,
+		Data:    data,
+	})
+	p.Size += uint64(len(data))
+}
+
+func (p Payload) Len() int {
+	return len(p.Items)
+}
+
+func (p Payload) TotalSize() uint64 {
+	return p.Size
+}
+
+func (p Payload) TotalMemSize() int {
+	return int(p.Size) + len(p.Items)*32
+}
+
+type Metric struct {
+	Num  uint32
+	Size uint64
+}
+
+func (m Metric) String() string {
+	return fmt.Sprintf("{Num=%d,Size=%d}", m.Num, m.Size)
+}
+
+// Manifest describes a snapshot of the EVM state at the last block before the epoch.
+// Epoch, Block and StateRoot are checked against the LLR-finalized epoch record,
+// chunk hashes are checked on every received chunk.
+type Manifest struct {
+	Epoch     idx.Epoch
+	Block     idx.Block
+	StateRoot hash.Hash
+	Size      uint64
+	Chunks    []hash.Hash
+}
+
+// Hash identifies the snapshot, including its content
+func (m *Manifest) Hash() hash.Hash {
+	b, _ := rlp.EncodeToBytes(m)
+	return hash.Of(b)
+}
+
+// Empty tells whether the manifest describes no snapshot
+func (m *Manifest) Empty() bool {
+	return m == nil || len(m.Chunks) == 0
+}
+
+// Validate checks that the chunks list is consistent with the snapshot size
+func (m *Manifest) Validate() error {
+	if m.Empty() {
+		return errors.New("empty snapshot manifest")
+	}
+	if uint64(len(m.Chunks)) != (m.Size+ChunkSize-1)/ChunkSize {
+		return errors.New("snapshot manifest chunks don't match its size")
+	}
+	return nil
+}
+
+// ChunkLen returns the expected size of the chunk
+func (m *Manifest) ChunkLen(i uint32) int {
+	if i+1 < uint32(len(m.Chunks)) {
+		return ChunkSize
+	}
+	return int(m.Size - uint64(i)*ChunkSize)
+}
+
+// VerifyChunk checks that the chunk data matches the manifest
+func (m *Manifest) VerifyChunk(i uint32, data []byte) error {
+	if i >= uint32(len(m.Chunks)) {
+		return ErrChunkIndex
+	}
+	if len(data) != m.ChunkLen(i) || hash.Hash(crypto.Keccak256Hash(data)) != m.Chunks[i] {
+		return ErrChunkMismatch
+	}
+	return nil
+}
+
+// VerifyData checks that the whole data read from r matches the manifest
+func (m *Manifest) VerifyData(r io.Reader) error {
+	hasher := NewChunkHasher()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return err
+	}
+	chunks := hasher.Finish()
+	if hasher.Size != m.Size || len(chunks) != len(m.Chunks) {
+		return fmt.Errorf("snapshot size %d doesn't match the manifest size %d", hasher.Size, m.Size)
+	}
+	for i, h := range chunks {
+		if h != m.Chunks[i] {
+			return fmt.Errorf("snapshot chunk %d: %w", i, ErrChunkMismatch)
+		}
+	}
+	return nil
+}
+
+// ChunkHasher computes the hashes of the ChunkSize-long pieces of the data written into it
+type ChunkHasher struct {
+	state  crypto.KeccakState
+	filled int
+	Size   uint64
+	Chunks []hash.Hash
+}
+
+func NewChunkHasher() *ChunkHasher {
+	return &ChunkHasher{
+		state: crypto.NewKeccakState(),
+	}
+}
+
+func (h *ChunkHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) != 0 {
+		l := ChunkSize - h.filled
+		if l > len(p) {
+			l = len(p)
+		}
+		h.state.Write(p[:l])
+		h.filled += l
+		h.Size += uint64(l)
+		p = p[l:]
+		if h.filled == ChunkSize {
+			h.flush()
+		}
+	}
+	return n, nil
+}
+
+func (h *ChunkHasher) flush() {
+	var res hash.Hash
+	_, _ = h.state.Read(res[:])
+	h.Chunks = append(h.Chunks, res)
+	h.state.Reset()
+	h.filled = 0
+}
+
+// Finish hashes the last incomplete chunk and returns the hashes of all the chunks
+func (h *ChunkHasher) Finish() []hash.Hash {
+	if h.filled != 0 {
+		h.flush()
+	}
+	return h.Chunks
+}
@@ -0,0 +1,72 @@
+package snstream
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestVerifyChunk(t *testing.T) {
+	require := require.New(t)
+
+	for _, size := range []int{1, ChunkSize - 1, ChunkSize, 2*ChunkSize + 7} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		hasher := NewChunkHasher()
+		// write in uneven pieces to cross the chunks boundaries
+		for rest := data; len(rest) != 0; {
+			n := 1 + rand.Intn(ChunkSize/3)
+			if n > len(rest) {
+				n = len(rest)
+			}
+			_, err := hasher.Write(rest[:n])
+			require.NoError(err)
+			rest = rest[n:]
+		}
+		m := &Manifest{
+			Block:  1,
+			Size:   hasher.Size,
+			Chunks: hasher.Finish(),
+		}
+		require.NoError(m.Validate())
+		require.Equal(uint64(size), m.Size)
+
+		for i := range m.Chunks {
+			from := i * ChunkSize
+			to := from + m.ChunkLen(uint32(i))
+			chunk := data[from:to]
+			require.NoError(m.VerifyChunk(uint32(i), chunk))
+
+			tampered := append([]byte{}, chunk...)
+			tampered[0]++
+			require.ErrorIs(m.VerifyChunk(uint32(i), tampered), ErrChunkMismatch)
+			require.ErrorIs(m.VerifyChunk(uint32(i), chunk[:len(chunk)-1]), ErrChunkMismatch)
+		}
+		require.ErrorIs(m.VerifyChunk(uint32(len(m.Chunks)), nil), ErrChunkIndex)
+
+		require.NoError(m.VerifyData(bytes.NewReader(data)))
+		require.Error(m.VerifyData(bytes.NewReader(data[:size-1])))
+		require.Error(m.VerifyData(bytes.NewReader(append(data, 0))))
+		tampered := append([]byte{}, data...)
+		tampered[size-1]++
+		require.ErrorIs(m.VerifyData(bytes.NewReader(tampered)), ErrChunkMismatch)
+
+		m.Size += ChunkSize
+		require.Error(m.Validate())
+	}
+	require.Error((&Manifest{}).Validate())
+}
+
+func TestLocatorCompare(t *testing.T) {
+	require := require.New(t)
+
+	a := Locator{Block: 1, Chunk: 5}
+	require.Equal(0, a.Compare(a))
+	require.Equal(-1, a.Compare(a.Inc()))
+	require.Equal(1, a.Inc().Compare(a))
+	require.Equal(-1, a.Compare(Locator{Block: 2}))
+	require.Equal(1, Locator{Block: 2}.Compare(a))
+}
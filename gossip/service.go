@@ -60,6 +60,7 @@ type ServiceFeed struct {
 	newEmittedEvent notify.Feed
 	newBlock        notify.Feed
 	newLogs         notify.Feed
+	newSkippedTxs   notify.Feed
 }
 
 func (f *ServiceFeed) SubscribeNewEpoch(ch chan<- idx.Epoch) notify.Subscription {
@@ -78,6 +79,10 @@ func (f *ServiceFeed) SubscribeNewLogs(ch chan<- []*types.Log) notify.Subscripti
 	return f.scope.Track(f.newLogs.Subscribe(ch))
 }
 
+func (f *ServiceFeed) SubscribeNewSkippedTxs(ch chan<- evmcore.SkippedTxsNotify) notify.Subscription {
+	return f.scope.Track(f.newSkippedTxs.Subscribe(ch))
+}
+
 type BlockProc struct {
 	SealerModule     blockproc.SealerModule
 	TxListenerModule blockproc.TxListenerModule
@@ -148,7 +153,14 @@ type Service struct {
 	stopped   bool
 	haltCheck func(oldEpoch, newEpoch idx.Epoch, time time.Time) bool
 
-	tflusher PeriodicFlusher
+	tflusher  PeriodicFlusher
+	pruner    *HistoryPruner
+	snapshots *StateSnapshots
+
+	privateTxs        *privateTxs
+	privateTxsExpirer *PrivateTxsExpirer
+	bundles           *TxBundles
+	txStatuses        *txStatuses
 
 	bootstrapping bool
 
@@ -236,14 +248,28 @@ func newService(config Config, store *Store, blockProc BlockProc, engine lachesi
 		return nil, err
 	}
 
+	svc.snapshots = svc.makeStateSnapshots()
+
+	svc.txStatuses = newTxStatuses(config.TxStatusesCacheSize, svc.txpool)
+	svc.privateTxs = newPrivateTxs()
+	svc.privateTxsExpirer = &PrivateTxsExpirer{
+		txs:      svc.privateTxs,
+		txpool:   svc.txpool,
+		feed:     &svc.feed,
+		statuses: svc.txStatuses,
+	}
+	svc.bundles = newTxBundles(config.Bundles, store, stateReader)
+
 	// create protocol manager
 	svc.handler, err = newHandler(handlerConfig{
-		config:   config,
-		notifier: &svc.feed,
-		txpool:   svc.txpool,
-		engineMu: svc.engineMu,
-		checkers: svc.checkers,
-		s:        store,
+		config:     config,
+		notifier:   &svc.feed,
+		txpool:     svc.txpool,
+		engineMu:   svc.engineMu,
+		checkers:   svc.checkers,
+		s:          store,
+		snapshots:  svc.snapshots,
+		privateTxs: svc.privateTxs,
 		process: processCallback{
 			Event: func(event *inter.EventPayload) error {
 				done := svc.procLogger.EventConnectionStarted(event, false)
@@ -255,6 +281,7 @@ func newService(config Config, store *Store, blockProc BlockProc, engine lachesi
 			BR:               svc.ProcessFullBlockRecord,
 			EV:               svc.ProcessEpochVote,
 			ER:               svc.ProcessFullEpochRecord,
+			StateSnapshot:    svc.ApplyStateSnapshot,
 		},
 	})
 	if err != nil {
@@ -271,6 +298,7 @@ func newService(config Config, store *Store, blockProc BlockProc, engine lachesi
 
 	svc.verWatcher = verwatcher.New(netVerStore)
 	svc.tflusher = svc.makePeriodicFlusher()
+	svc.pruner = svc.makeHistoryPruner()
 
 	return svc, nil
 }
@@ -333,6 +361,7 @@ func (s *Service) EmitterWorld(signer valkeystore.SignerI) emitter.World {
 		TxPool:   s.txpool,
 		Signer:   signer,
 		TxSigner: s.EthAPI.signer,
+		Bundles:  s.bundles,
 	}
 }
 
@@ -411,6 +440,15 @@ func (s *Service) APIs() []rpc.API {
 		},
 	}...)
 
+	if len(s.emitters) != 0 {
+		apis = append(apis, rpc.API{
+			Namespace: "emitter",
+			Version:   "1.0",
+			Service:   emitter.NewPrivateEmitterAPI(s.emitters[0]),
+			Public:    false,
+		})
+	}
+
 	// eth-namespace is doubled as ftm-namespace for branding purpose
 	for _, api := range apis {
 		if api.Namespace == "eth" {
@@ -431,6 +469,15 @@ func (s *Service) Start() error {
 	s.gpo.Start(&GPOBackend{s.store, s.txpool})
 	// start tflusher before starting snapshots generation
 	s.tflusher.Start()
+	if s.pruner != nil {
+		s.pruner.Start()
+	}
+	if s.snapshots != nil {
+		s.snapshots.Start()
+	}
+	s.privateTxsExpirer.Start()
+	s.bundles.Start(&s.feed)
+	s.txStatuses.Start()
 	blockState := s.store.GetBlockState()
 	if s.store.evm.CheckLiveStateHash(blockState.LastBlock.Idx, blockState.FinalizedStateRoot) != nil {
 		return errors.New("fullsync isn't possible because state root is missing")
@@ -441,6 +488,11 @@ func (s *Service) Start() error {
 
 	// start p2p
 	StartENRUpdater(s, s.p2pServer.LocalNode())
+	// keep connections to the peers which private transactions are forwarded to
+	for _, n := range s.config.PrivateTxs.TrustedPeers {
+		s.p2pServer.AddTrustedPeer(n)
+		s.p2pServer.AddPeer(n)
+	}
 	s.handler.Start(s.p2pServer.MaxPeers)
 
 	// start emitters
@@ -479,6 +531,15 @@ func (s *Service) Stop() error {
 	s.gpo.Stop()
 	// it's safe to stop tflusher only before locking engineMu
 	s.tflusher.Stop()
+	if s.pruner != nil {
+		s.pruner.Stop()
+	}
+	if s.snapshots != nil {
+		s.snapshots.Stop()
+	}
+	s.privateTxsExpirer.Stop()
+	s.bundles.Stop()
+	s.txStatuses.Stop()
 
 	// flush the state at exit, after all the routines stopped
 	s.engineMu.Lock()
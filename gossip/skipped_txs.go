@@ -0,0 +1,59 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+// skippedTxUnknownReason is the reason of the skipped transactions which reasons weren't recorded,
+// e.g. the block was processed before the reasons were stored or was snapshot-synced.
+const skippedTxUnknownReason = "unknown"
+
+// blockSkippedTxsRecorder collects the transactions skipped during a block execution and the reasons of the skips.
+type blockSkippedTxsRecorder struct {
+	txs []evmcore.SkippedTx
+}
+
+func newBlockSkippedTxsRecorder() *blockSkippedTxsRecorder {
+	return &blockSkippedTxsRecorder{}
+}
+
+// OnSkip records the skipped transaction, it's used as evmcore.TxSkipListener
+func (r *blockSkippedTxsRecorder) OnSkip(tx *types.Transaction, err error) {
+	r.txs = append(r.txs, evmcore.SkippedTx{
+		Hash:   tx.Hash(),
+		Reason: err.Error(),
+	})
+}
+
+// result returns the recorded transactions positioned by the indexes of the block skipped transactions.
+// Transactions are skipped and recorded in the same order, so the indexes are matched one by one.
+func (r *blockSkippedTxsRecorder) result(skippedTxs []uint32) ([]evmcore.SkippedTx, error) {
+	if len(r.txs) != len(skippedTxs) {
+		return nil, fmt.Errorf("recorded %d skipped transactions, but %d are skipped", len(r.txs), len(skippedTxs))
+	}
+	for i, index := range skippedTxs {
+		r.txs[i].Index = index
+	}
+	return r.txs, nil
+}
+
+// unknownSkippedTxs returns the block skipped transactions with the unknown reason of the skips.
+// txs are all the block transactions including the skipped ones.
+func unknownSkippedTxs(txs types.Transactions, skippedTxs []uint32) []evmcore.SkippedTx {
+	skipped := make([]evmcore.SkippedTx, 0, len(skippedTxs))
+	for _, index := range skippedTxs {
+		if int(index) >= len(txs) {
+			break
+		}
+		skipped = append(skipped, evmcore.SkippedTx{
+			Index:  index,
+			Hash:   txs[index].Hash(),
+			Reason: skippedTxUnknownReason,
+		})
+	}
+	return skipped
+}
@@ -0,0 +1,64 @@
+package gossip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+)
+
+func TestBlockSkippedTxsRecorder(t *testing.T) {
+	require := require.New(t)
+
+	var txs types.Transactions
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		txs = append(txs, types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	}
+
+	recorder := newBlockSkippedTxsRecorder()
+	recorder.OnSkip(txs[1], errors.New("nonce too low"))
+	recorder.OnSkip(txs[3], errors.New("intrinsic gas too low"))
+
+	skipped, err := recorder.result([]uint32{1, 3})
+	require.NoError(err)
+	require.Equal([]evmcore.SkippedTx{
+		{Index: 1, Hash: txs[1].Hash(), Reason: "nonce too low"},
+		{Index: 3, Hash: txs[3].Hash(), Reason: "intrinsic gas too low"},
+	}, skipped)
+
+	// mismatched records aren't positioned
+	skipped, err = recorder.result([]uint32{1})
+	require.Error(err)
+	require.Nil(skipped)
+
+	// the unrecorded reasons are unknown
+	require.Equal([]evmcore.SkippedTx{
+		{Index: 1, Hash: txs[1].Hash(), Reason: skippedTxUnknownReason},
+		{Index: 3, Hash: txs[3].Hash(), Reason: skippedTxUnknownReason},
+	}, unknownSkippedTxs(txs, []uint32{1, 3}))
+	require.Equal([]evmcore.SkippedTx{
+		{Index: 1, Hash: txs[1].Hash(), Reason: skippedTxUnknownReason},
+	}, unknownSkippedTxs(txs, []uint32{1, 4}))
+}
+
+func TestGetSkippedTxsEmpty(t *testing.T) {
+	require := require.New(t)
+
+	env := newTestEnv(2, 3, t)
+	defer env.Close()
+
+	// the block without skipped txs has an empty list rather than null
+	skipped, err := env.EthAPI.GetSkippedTxs(context.Background(), rpc.LatestBlockNumber)
+	require.NoError(err)
+	out, err := json.Marshal(skipped)
+	require.NoError(err)
+	require.Equal("[]", string(out))
+}
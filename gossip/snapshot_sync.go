@@ -0,0 +1,275 @@
+package gossip
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+const snapshotDownloadFile = "download" + snapshotStateExt
+
+// snapshotSync downloads the state snapshot of an LLR-finalized epoch from the peers.
+// A snapshot is accepted only if its epoch, block and state root match the epoch record
+// verified by the LLR votes, and every chunk is checked against the manifest as it arrives.
+// Once all the chunks are received, the state is imported and checked against the state root.
+type snapshotSync struct {
+	cfg   SnapshotsConfig
+	store *Store
+	apply func(epoch idx.Epoch, path string) error
+
+	mu        sync.Mutex
+	manifests map[string]*snstream.Manifest // latest snapshot announced by a peer
+	rejected  map[hash.Hash]bool            // snapshots which failed to be applied
+	target    *snstream.Manifest
+	file      *os.File
+	received  []bool
+	lowest    uint32
+	left      int
+	applying  bool
+
+	wg       sync.WaitGroup
+	finished uint32
+
+	logger.Instance
+}
+
+func newSnapshotSync(cfg SnapshotsConfig, store *Store, apply func(epoch idx.Epoch, path string) error) *snapshotSync {
+	return &snapshotSync{
+		cfg:       cfg,
+		store:     store,
+		apply:     apply,
+		manifests: make(map[string]*snstream.Manifest),
+		rejected:  make(map[hash.Hash]bool),
+		Instance:  logger.New("snapshot-sync"),
+	}
+}
+
+// Active tells whether the node still may sync from a snapshot
+func (s *snapshotSync) Active() bool {
+	return s != nil && atomic.LoadUint32(&s.finished) == 0
+}
+
+// Stop interrupts the snapshot sync, waiting for the snapshot being applied
+func (s *snapshotSync) Stop() {
+	if s == nil || atomic.SwapUint32(&s.finished, 1) != 0 {
+		return
+	}
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+func (s *snapshotSync) path() string {
+	return filepath.Join(s.cfg.Dir, snapshotDownloadFile)
+}
+
+// OnManifest remembers the snapshot announced by the peer
+func (s *snapshotSync) OnManifest(peer string, m *snstream.Manifest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.Empty() {
+		delete(s.manifests, peer)
+		return
+	}
+	s.manifests[peer] = m
+}
+
+// HasManifest tells whether the peer has announced a snapshot
+func (s *snapshotSync) HasManifest(peer string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manifests[peer] != nil
+}
+
+// UnregisterPeer forgets the snapshot announced by the peer
+func (s *snapshotSync) UnregisterPeer(peer string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.manifests, peer)
+}
+
+// PeerHasSnapshot tells whether the peer has announced the same snapshot
+func (s *snapshotSync) PeerHasSnapshot(peer string, m *snstream.Manifest) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pm := s.manifests[peer]
+	return pm != nil && pm.Block == m.Block && pm.Hash() == m.Hash()
+}
+
+// verifyManifest checks the snapshot against the LLR-finalized epoch record
+func (s *snapshotSync) verifyManifest(m *snstream.Manifest) bool {
+	if m.Validate() != nil || s.rejected[m.Hash()] {
+		return false
+	}
+	if s.store.GetLlrEpochResult(m.Epoch) == nil {
+		return false
+	}
+	bs, _ := s.store.GetHistoryBlockEpochState(m.Epoch)
+	return bs != nil && bs.LastBlock.Idx == m.Block && bs.FinalizedStateRoot == m.StateRoot
+}
+
+// Target returns the snapshot being downloaded, selecting a new one if there's none
+func (s *snapshotSync) Target() *snstream.Manifest {
+	if !s.Active() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target != nil {
+		if !s.applying && s.store.GetEpoch() >= s.target.Epoch {
+			// the node has caught up on its own
+			s.reset()
+		}
+		return s.target
+	}
+
+	// select the latest verified snapshot, preferring the one announced by more peers
+	var best *snstream.Manifest
+	votes := make(map[hash.Hash]int)
+	minEpoch := s.store.GetEpoch() + s.cfg.MinEpochsBehind
+	for _, m := range s.manifests {
+		if m.Epoch < minEpoch || !s.verifyManifest(m) {
+			continue
+		}
+		id := m.Hash()
+		votes[id]++
+		if best == nil || m.Epoch > best.Epoch || (m.Epoch == best.Epoch && votes[id] > votes[best.Hash()]) {
+			best = m
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	if err := s.start(best); err != nil {
+		s.Log.Error("Failed to start snapshot download", "epoch", best.Epoch, "block", best.Block, "err", err)
+		return nil
+	}
+	return s.target
+}
+
+func (s *snapshotSync) start(m *snstream.Manifest) error {
+	if err := os.MkdirAll(s.cfg.Dir, 0700); err != nil {
+		return err
+	}
+	// the file grows with the verified chunks only, so the size claimed by the manifest isn't allocated upfront
+	file, err := os.OpenFile(s.path(), os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	s.target = m
+	s.file = file
+	s.received = make([]bool, len(m.Chunks))
+	s.lowest = 0
+	s.left = len(m.Chunks)
+	s.Log.Info("Snapshot download started", "epoch", m.Epoch, "block", m.Block, "root", m.StateRoot, "size", m.Size)
+	return nil
+}
+
+func (s *snapshotSync) reset() {
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.path())
+	}
+	s.target = nil
+	s.file = nil
+	s.received = nil
+	s.lowest = 0
+	s.left = 0
+	s.applying = false
+}
+
+// LowestChunkToFetch returns the first not received chunk of the target snapshot
+func (s *snapshotSync) LowestChunkToFetch() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lowest
+}
+
+// IsProcessed tells whether the chunk of the target snapshot is received
+func (s *snapshotSync) IsProcessed(l snstream.Locator) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target == nil || s.target.Block != l.Block {
+		return true
+	}
+	return l.Chunk < uint32(len(s.received)) && s.received[l.Chunk]
+}
+
+// OnChunks verifies and writes the received chunks of the target snapshot.
+// Returns an error if a chunk doesn't match the manifest.
+func (s *snapshotSync) OnChunks(chunks []snstream.Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target == nil || s.applying {
+		return nil
+	}
+	for _, c := range chunks {
+		if c.Locator.Block != s.target.Block || c.Locator.Chunk >= uint32(len(s.received)) || s.received[c.Locator.Chunk] {
+			continue
+		}
+		if err := s.target.VerifyChunk(c.Locator.Chunk, c.Data); err != nil {
+			return err
+		}
+		if _, err := s.file.WriteAt(c.Data, int64(c.Locator.Chunk)*snstream.ChunkSize); err != nil {
+			s.Log.Error("Failed to write snapshot chunk", "chunk", c.Locator.Chunk, "err", err)
+			return nil
+		}
+		s.received[c.Locator.Chunk] = true
+		s.left--
+		for s.lowest < uint32(len(s.received)) && s.received[s.lowest] {
+			s.lowest++
+		}
+	}
+	if s.left == 0 {
+		s.applying = true
+		m, file := s.target, s.file
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.complete(m, file)
+		}()
+	}
+	return nil
+}
+
+// complete applies the downloaded snapshot, it's rejected if the file or the state doesn't match the manifest
+func (s *snapshotSync) complete(m *snstream.Manifest, file *os.File) {
+	err := file.Sync()
+	if err == nil {
+		// re-read the whole file, as the chunks are only verified in memory before being written
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = m.VerifyData(bufio.NewReader(file))
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		s.Log.Info("Snapshot is downloaded, applying", "epoch", m.Epoch, "block", m.Block)
+		err = s.apply(m.Epoch, s.path())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file = nil
+	_ = os.Remove(s.path())
+	s.reset()
+	if err != nil {
+		s.Log.Error("Failed to apply snapshot", "epoch", m.Epoch, "block", m.Block, "err", err)
+		s.rejected[m.Hash()] = true
+		return
+	}
+	s.Log.Info("Snapshot is applied", "epoch", m.Epoch, "block", m.Block, "root", m.StateRoot)
+	atomic.StoreUint32(&s.finished, 1)
+}
@@ -0,0 +1,283 @@
+package gossip
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/mrmikeo/Xpense/gossip/protocols/snapshots/snstream"
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+const (
+	snapshotStateExt    = ".state"
+	snapshotManifestExt = ".manifest"
+)
+
+// StateSnapshots periodically exports the EVM state of LLR-finalized epochs into chunked files,
+// which are served to the peers bootstrapping via the snapshot sync.
+type StateSnapshots struct {
+	cfg   SnapshotsConfig
+	store *Store
+
+	mu        sync.RWMutex
+	snapshots []servedSnapshot // ordered by block
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	logger.Instance
+}
+
+type servedSnapshot struct {
+	manifest *snstream.Manifest
+	file     *os.File
+}
+
+// makeStateSnapshots returns nil if serving of the snapshots is disabled
+func (s *Service) makeStateSnapshots() *StateSnapshots {
+	if !s.config.Snapshots.Serve {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StateSnapshots{
+		cfg:      s.config.Snapshots,
+		store:    s.store,
+		ctx:      ctx,
+		cancel:   cancel,
+		Instance: logger.New("state-snapshots"),
+	}
+}
+
+// Start loads the previously created snapshots and starts creating new ones
+func (s *StateSnapshots) Start() {
+	if err := os.MkdirAll(s.cfg.Dir, 0700); err != nil {
+		s.Log.Error("Failed to create snapshots dir", "dir", s.cfg.Dir, "err", err)
+		return
+	}
+	s.load()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.cfg.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.mayCreate()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop interrupts the snapshot creation and closes the served snapshots
+func (s *StateSnapshots) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sn := range s.snapshots {
+		_ = sn.file.Close()
+	}
+	s.snapshots = nil
+}
+
+// Latest returns the manifest of the latest served snapshot, or nil if there's none
+func (s *StateSnapshots) Latest() *snstream.Manifest {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.snapshots) == 0 {
+		return nil
+	}
+	return s.snapshots[len(s.snapshots)-1].manifest
+}
+
+// ReadChunk returns the chunk of the served snapshot of the block
+func (s *StateSnapshots) ReadChunk(block idx.Block, chunk uint32) ([]byte, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sn := range s.snapshots {
+		if sn.manifest.Block != block {
+			continue
+		}
+		if chunk >= uint32(len(sn.manifest.Chunks)) {
+			return nil, false
+		}
+		data := make([]byte, sn.manifest.ChunkLen(chunk))
+		if _, err := sn.file.ReadAt(data, int64(chunk)*snstream.ChunkSize); err != nil {
+			s.Log.Error("Failed to read snapshot chunk", "block", block, "chunk", chunk, "err", err)
+			return nil, false
+		}
+		return data, true
+	}
+	return nil, false
+}
+
+func (s *StateSnapshots) statePath(block idx.Block) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%d%s", block, snapshotStateExt))
+}
+
+func (s *StateSnapshots) manifestPath(block idx.Block) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%d%s", block, snapshotManifestExt))
+}
+
+// load opens the snapshots created before the restart
+func (s *StateSnapshots) load() {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		s.Log.Error("Failed to read snapshots dir", "dir", s.cfg.Dir, "err", err)
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, snapshotManifestExt) {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSuffix(name, snapshotManifestExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		block := idx.Block(n)
+		raw, err := os.ReadFile(s.manifestPath(block))
+		if err != nil {
+			s.Log.Warn("Failed to read snapshot manifest", "block", block, "err", err)
+			continue
+		}
+		manifest := &snstream.Manifest{}
+		if err := rlp.DecodeBytes(raw, manifest); err != nil || manifest.Validate() != nil {
+			s.Log.Warn("Skipping malformed snapshot manifest", "block", block)
+			continue
+		}
+		file, err := os.Open(s.statePath(block))
+		if err != nil {
+			s.Log.Warn("Failed to open snapshot", "block", block, "err", err)
+			continue
+		}
+		s.snapshots = append(s.snapshots, servedSnapshot{manifest, file})
+	}
+	sort.Slice(s.snapshots, func(i, j int) bool {
+		return s.snapshots[i].manifest.Block < s.snapshots[j].manifest.Block
+	})
+	s.prune()
+}
+
+// mayCreate creates a snapshot of the latest LLR-finalized epoch if the previous one is old enough
+func (s *StateSnapshots) mayCreate() {
+	epoch := s.store.GetLlrState().LowestEpochToDecide - 1
+	if epoch == 0 || s.store.GetLlrEpochResult(epoch) == nil {
+		return
+	}
+	if latest := s.Latest(); latest != nil && epoch < latest.Epoch+s.cfg.EpochsInterval {
+		return
+	}
+	bs, _ := s.store.GetHistoryBlockEpochState(epoch)
+	if bs == nil {
+		return
+	}
+	height, empty, err := s.store.evm.GetArchiveBlockHeight()
+	if err != nil || empty || height < uint64(bs.LastBlock.Idx) {
+		return
+	}
+
+	start := time.Now()
+	manifest, err := s.create(epoch, bs.LastBlock.Idx)
+	if err != nil {
+		s.Log.Error("Failed to create state snapshot", "epoch", epoch, "block", bs.LastBlock.Idx, "err", err)
+		return
+	}
+	if manifest.StateRoot != bs.FinalizedStateRoot {
+		s.Log.Error("State snapshot root mismatch", "epoch", epoch, "block", manifest.Block, "root", manifest.StateRoot, "expected", bs.FinalizedStateRoot)
+		_ = os.Remove(s.statePath(manifest.Block))
+		return
+	}
+	if err := s.add(manifest); err != nil {
+		s.Log.Error("Failed to save state snapshot", "epoch", epoch, "block", manifest.Block, "err", err)
+		return
+	}
+	s.Log.Info("New state snapshot is created", "epoch", epoch, "block", manifest.Block, "size", manifest.Size, "elapsed", time.Since(start))
+}
+
+// create exports the state of the block into a chunked file
+func (s *StateSnapshots) create(epoch idx.Epoch, block idx.Block) (*snstream.Manifest, error) {
+	path := s.statePath(block)
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	hasher := snstream.NewChunkHasher()
+	writer := bufio.NewWriterSize(io.MultiWriter(file, hasher), snstream.ChunkSize)
+	root, err := s.store.evm.ExportBlockWorldState(s.ctx, block, writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, err
+	}
+	return &snstream.Manifest{
+		Epoch:     epoch,
+		Block:     block,
+		StateRoot: root,
+		Size:      hasher.Size,
+		Chunks:    hasher.Finish(),
+	}, nil
+}
+
+// add writes the manifest and starts serving the snapshot
+func (s *StateSnapshots) add(manifest *snstream.Manifest) error {
+	raw, err := rlp.EncodeToBytes(manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.manifestPath(manifest.Block), raw, 0600); err != nil {
+		return err
+	}
+	file, err := os.Open(s.statePath(manifest.Block))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, servedSnapshot{manifest, file})
+	s.prune()
+	return nil
+}
+
+// prune removes the snapshots above the configured number
+func (s *StateSnapshots) prune() {
+	for len(s.snapshots) > s.cfg.Keep {
+		old := s.snapshots[0]
+		s.snapshots = s.snapshots[1:]
+		_ = old.file.Close()
+		_ = os.Remove(s.statePath(old.manifest.Block))
+		_ = os.Remove(s.manifestPath(old.manifest.Block))
+	}
+}
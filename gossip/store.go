@@ -57,6 +57,9 @@ type Store struct {
 		LlrEpochVoteIndex  kvdb.Store `table:"I"`
 		LlrLastBlockVotes  kvdb.Store `table:"G"`
 		LlrLastEpochVote   kvdb.Store `table:"F"`
+
+		// API-only
+		ValidatorEpochMetrics kvdb.Store `table:"m"`
 	}
 
 	prevFlushTime time.Time
@@ -190,7 +190,12 @@ func (s *Store) GetBlockTxs(n idx.Block, block *inter.Block) types.Transactions
 	if cached := s.evm.GetCachedEvmBlock(n); cached != nil {
 		return cached.Transactions
 	}
+	return inter.FilterSkippedTxs(s.GetBlockAllTxs(block), block.SkippedTxs)
+}
 
+// GetBlockAllTxs returns all the transactions of the block including the skipped ones,
+// positioned as indexed by block.SkippedTxs.
+func (s *Store) GetBlockAllTxs(block *inter.Block) types.Transactions {
 	transactions := make(types.Transactions, 0, len(block.Txs)+len(block.InternalTxs)+len(block.Events)*10)
 	for _, txid := range block.InternalTxs {
 		tx := s.evm.GetTx(txid)
@@ -216,8 +221,5 @@ func (s *Store) GetBlockTxs(n idx.Block, block *inter.Block) types.Transactions
 		}
 		transactions = append(transactions, e.Txs()...)
 	}
-
-	transactions = inter.FilterSkippedTxs(transactions, block.SkippedTxs)
-
 	return transactions
 }
@@ -0,0 +1,104 @@
+package gossip
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/mrmikeo/Xpense/gossip/evmstore"
+	"github.com/mrmikeo/Xpense/utils/signers/gsignercache"
+)
+
+// IsHistoryPruned tells whether the history of the block is pruned.
+// The genesis block is never pruned.
+func (s *Store) IsHistoryPruned(n idx.Block) bool {
+	if n == 0 || n >= s.evm.GetHistoryStart() {
+		return false
+	}
+	genesis := s.GetGenesisBlockIndex()
+	return genesis == nil || n != *genesis
+}
+
+// PrunedError returns the error of the pruned history of the block, nil if the history is available.
+func (s *Store) PrunedError(n idx.Block) error {
+	if !s.IsHistoryPruned(n) {
+		return nil
+	}
+	return &evmstore.PrunedError{
+		Block:        n,
+		HistoryStart: s.evm.GetHistoryStart(),
+	}
+}
+
+// PruneHistory deletes the history of at most limit blocks preceding the block to:
+// receipts, transactions positions, non-event transactions, indexed logs, traces and addresses of the blocks.
+// The blocks themselves are kept, but their full records aren't available anymore.
+// The genesis block is kept. It returns the number of pruned blocks.
+func (s *Store) PruneHistory(to idx.Block, limit idx.Block) (pruned idx.Block) {
+	start := s.evm.GetHistoryStart()
+	if start >= to {
+		return 0
+	}
+
+	genesis := s.GetGenesisBlockIndex()
+	signer := gsignercache.Wrap(types.LatestSignerForChainID(s.GetEvmChainConfig().ChainID))
+	n := s.firstBlockSince(start, to)
+	for ; n < to && pruned < limit; n++ {
+		if genesis != nil && n == *genesis {
+			continue
+		}
+		s.pruneBlock(n, signer)
+		pruned++
+	}
+	s.evm.SetHistoryStart(n)
+	return pruned
+}
+
+// firstBlockSince returns the first stored block which is not lower than start, or to if there is none.
+func (s *Store) firstBlockSince(start, to idx.Block) idx.Block {
+	if start == 0 {
+		// the genesis block 0 is fake
+		start = 1
+	}
+	it := s.table.Blocks.NewIterator(nil, start.Bytes())
+	defer it.Release()
+	if !it.Next() {
+		return to
+	}
+	if n := idx.BytesToBlock(it.Key()); n < to {
+		return n
+	}
+	return to
+}
+
+func (s *Store) pruneBlock(n idx.Block, signer types.Signer) {
+	block := s.GetBlock(n)
+	if block == nil {
+		return
+	}
+	txs := s.GetBlockTxs(n, block)
+
+	if raw, _ := s.evm.GetRawReceipts(n); raw != nil {
+		receipts, err := evmstore.UnwrapStorageReceipts(raw, n, signer, common.Hash(block.Atropos), txs)
+		if err != nil {
+			s.Log.Warn("Failed to derive receipts of pruned block", "block", n, "err", err)
+		} else {
+			for _, r := range receipts {
+				s.evm.DeleteLogs(r.Logs...)
+			}
+		}
+		s.evm.DeleteReceipts(n)
+	}
+	s.evm.DeleteSkippedTxs(n)
+	for _, tx := range txs {
+		s.evm.DeleteTxPosition(tx.Hash())
+	}
+	for _, txid := range block.InternalTxs {
+		s.evm.DeleteTx(txid)
+	}
+	for _, txid := range block.Txs {
+		s.evm.DeleteTx(txid)
+	}
+	s.evm.DeleteBlockTraces(n)
+	s.evm.DeleteBlockTxAddresses(n)
+}
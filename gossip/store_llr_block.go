@@ -117,6 +117,9 @@ func (s *Store) GetLlrBlockResult(block idx.Block) *hash.Hash {
 }
 
 func (s *Store) GetFullBlockRecord(n idx.Block) *ibr.LlrFullBlockRecord {
+	if s.IsHistoryPruned(n) {
+		return nil
+	}
 	block := s.GetBlock(n)
 	if block == nil {
 		return nil
@@ -185,12 +188,16 @@ func (s *Store) IterateFullBlockRecordsRLP(start idx.Block, f func(b idx.Block,
 	it := s.table.Blocks.NewIterator(nil, start.Bytes())
 	defer it.Release()
 	for it.Next() {
+		n := idx.BytesToBlock(it.Key())
+		if s.IsHistoryPruned(n) {
+			// full records of the pruned blocks aren't available
+			break
+		}
 		block := &inter.Block{}
 		err := rlp.DecodeBytes(it.Value(), block)
 		if err != nil {
 			s.Log.Crit("Failed to decode block", "err", err)
 		}
-		n := idx.BytesToBlock(it.Key())
 		txs := s.GetBlockTxs(n, block)
 		receiptsRLP := s.EvmStore().GetRawReceiptsRLP(n)
 		if receiptsRLP == nil {
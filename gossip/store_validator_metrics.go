@@ -0,0 +1,84 @@
+package gossip
+
+import (
+	"math/big"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/inter/pos"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/mrmikeo/Xpense/inter/iblockproc"
+	"github.com/mrmikeo/Xpense/opera/contracts/driver/drivercall"
+)
+
+// validatorEventsStats is the contribution of a validator's confirmed events into a block
+type validatorEventsStats struct {
+	Events       idx.Event
+	GasPowerUsed uint64
+}
+
+func validatorEpochMetricsKey(epoch idx.Epoch, vid idx.ValidatorID) []byte {
+	return append(epoch.Bytes(), vid.Bytes()...)
+}
+
+// GetValidatorEpochMetrics returns the metrics of the validator in the epoch, nil if they aren't recorded.
+// The metrics of the current epoch are incomplete until it's sealed.
+func (s *Store) GetValidatorEpochMetrics(epoch idx.Epoch, vid idx.ValidatorID) *iblockproc.ValidatorEpochMetrics {
+	m, _ := s.rlp.Get(s.table.ValidatorEpochMetrics, validatorEpochMetricsKey(epoch, vid), &iblockproc.ValidatorEpochMetrics{}).(*iblockproc.ValidatorEpochMetrics)
+	return m
+}
+
+func (s *Store) setValidatorEpochMetrics(epoch idx.Epoch, vid idx.ValidatorID, m *iblockproc.ValidatorEpochMetrics) {
+	s.rlp.Set(s.table.ValidatorEpochMetrics, validatorEpochMetricsKey(epoch, vid), m)
+}
+
+// GetEpochMetrics returns the metrics of all the validators in the epoch, nil if they aren't recorded.
+func (s *Store) GetEpochMetrics(epoch idx.Epoch) map[idx.ValidatorID]iblockproc.ValidatorEpochMetrics {
+	var res map[idx.ValidatorID]iblockproc.ValidatorEpochMetrics
+	it := s.table.ValidatorEpochMetrics.NewIterator(epoch.Bytes(), nil)
+	defer it.Release()
+	for it.Next() {
+		var m iblockproc.ValidatorEpochMetrics
+		if err := rlp.DecodeBytes(it.Value(), &m); err != nil {
+			s.Log.Crit("Failed to decode validator epoch metrics", "err", err)
+		}
+		if res == nil {
+			res = make(map[idx.ValidatorID]iblockproc.ValidatorEpochMetrics)
+		}
+		res[idx.BytesToValidatorID(it.Key()[4:])] = m
+	}
+	if it.Error() != nil {
+		s.Log.Crit("Failed to iterate validator epoch metrics", "err", it.Error())
+	}
+	return res
+}
+
+// addValidatorEpochEvents accumulates the confirmed events of a block into the epoch metrics
+func (s *Store) addValidatorEpochEvents(epoch idx.Epoch, stats map[idx.ValidatorID]validatorEventsStats) {
+	for vid, st := range stats {
+		m := s.GetValidatorEpochMetrics(epoch, vid)
+		if m == nil {
+			m = &iblockproc.ValidatorEpochMetrics{OriginatedFee: new(big.Int)}
+		}
+		m.Events += st.Events
+		m.GasPowerUsed += st.GasPowerUsed
+		s.setValidatorEpochMetrics(epoch, vid, m)
+	}
+}
+
+// sealValidatorEpochMetrics records the metrics passed to the driver contract on the epoch sealing,
+// metrics are indexed by validator index
+func (s *Store) sealValidatorEpochMetrics(epoch idx.Epoch, validators *pos.Validators, metrics []drivercall.ValidatorEpochMetric) {
+	for i := range metrics {
+		vid := validators.GetID(idx.Validator(i))
+		m := s.GetValidatorEpochMetrics(epoch, vid)
+		if m == nil {
+			m = &iblockproc.ValidatorEpochMetrics{}
+		}
+		m.MissedBlocks = metrics[i].Missed.BlocksNum
+		m.OfflineTime = metrics[i].Missed.Period
+		m.Uptime = metrics[i].Uptime
+		m.OriginatedFee = new(big.Int).Set(metrics[i].OriginatedTxFee)
+		s.setValidatorEpochMetrics(epoch, vid, m)
+	}
+}
@@ -0,0 +1,116 @@
+package gossip
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/opera"
+	"github.com/mrmikeo/Xpense/txtrace"
+)
+
+// blockTraceRecorder collects traces of the transactions executed in a block.
+// Transactions are positioned by the not skipped transactions of the block, as their receipts.
+type blockTraceRecorder struct {
+	current *txtrace.TraceStructLogger
+	txs     int
+	traces  []txtrace.ActionTrace
+}
+
+func newBlockTraceRecorder() *blockTraceRecorder {
+	return &blockTraceRecorder{
+		traces: make([]txtrace.ActionTrace, 0),
+	}
+}
+
+// TxStart creates the tracer of the transaction
+func (r *blockTraceRecorder) TxStart(block *evmcore.EvmBlock, tx *types.Transaction, msg types.Message, index int) vm.Tracer {
+	r.current = txtrace.NewTraceStructLogger(block, tx, msg, uint(r.txs), 0)
+	return r.current
+}
+
+// TxEnd collects traces of the transaction, skipped transactions are not traced
+func (r *blockTraceRecorder) TxEnd(tx *types.Transaction, receipt *types.Receipt) {
+	if r.current == nil {
+		return
+	}
+	if receipt != nil {
+		r.current.SetGasUsed(receipt.GasUsed)
+		r.traces = append(r.traces, *r.current.GetResult()...)
+		r.txs++
+	}
+	r.current = nil
+}
+
+// BackfillTraceIndex replays the blocks [from, to] on the archive state
+// and stores traces of their transactions into the trace index.
+func (s *Store) BackfillTraceIndex(ctx context.Context, from, to idx.Block, onBlock func(n idx.Block)) error {
+	if !s.evm.TraceIndexingEnabled() {
+		return fmt.Errorf("trace indexing is not enabled")
+	}
+	if from == 0 {
+		// genesis block is not traceable
+		from = 1
+	}
+
+	return s.replayBlocks(ctx, from, to, func() evmcore.TxTracer {
+		return newBlockTraceRecorder()
+	}, func(n idx.Block, tracer evmcore.TxTracer) {
+		s.evm.SetBlockTraces(n, tracer.(*blockTraceRecorder).traces)
+		s.evm.MarkTracedBlocks(from, n)
+		if onBlock != nil {
+			onBlock(n)
+		}
+	})
+}
+
+// replayBlocks executes the blocks [from, to] on the archive state with the txs tracer
+// created by newTracer for each block, and passes the tracer to onBlock after the block execution.
+func (s *Store) replayBlocks(ctx context.Context, from, to idx.Block, newTracer func() evmcore.TxTracer, onBlock func(n idx.Block, tracer evmcore.TxTracer)) error {
+	reader := &EvmStateReader{store: s}
+	chainCfg := s.GetEvmChainConfig()
+	for n := from; n <= to; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block := reader.GetBlock(common.Hash{}, uint64(n))
+		if block == nil {
+			return fmt.Errorf("block %d not found", n)
+		}
+		parent := reader.GetHeader(common.Hash{}, uint64(n-1))
+		if parent == nil {
+			return fmt.Errorf("block %d not found", n-1)
+		}
+
+		statedb, err := s.evm.GetRpcStateDb(parent.Number, parent.Root)
+		if err != nil {
+			return fmt.Errorf("cannot get state for block %d: %w", n, err)
+		}
+
+		tracer := newTracer()
+		processor := evmcore.NewStateProcessor(chainCfg, reader)
+		processor.SetTxTracer(tracer)
+		var gasUsed uint64
+		_, _, skipped, err := processor.Process(block, statedb, opera.DefaultVMConfig, &gasUsed, func(*types.Log) {})
+		statedb.Release()
+		if err != nil {
+			return fmt.Errorf("cannot replay block %d: %w", n, err)
+		}
+		if len(skipped) != 0 {
+			return fmt.Errorf("invalid replay of block %d: %d txs skipped", n, len(skipped))
+		}
+
+		onBlock(n, tracer)
+		if s.IsCommitNeeded() {
+			if err := s.Commit(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Commit()
+}
@@ -0,0 +1,300 @@
+package gossip
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/mrmikeo/Xpense/ethapi"
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+var errTxStatusSubOverflow = errors.New("transaction status subscriber is too slow")
+
+// txStatusSub is a subscription to the lifecycle changes of a transaction
+type txStatusSub struct {
+	statuses *txStatuses
+	txid     common.Hash
+	ch       chan<- ethapi.TxLifecycle
+	err      chan error
+	once     sync.Once
+}
+
+func (s *txStatusSub) Err() <-chan error {
+	return s.err
+}
+
+func (s *txStatusSub) Unsubscribe() {
+	s.statuses.unsubscribe(s, nil)
+}
+
+// txStatuses tracks the lifecycles of the recent transactions, from the txpool admission
+// till the block confirmation. A nil *txStatuses is valid and tracks nothing.
+type txStatuses struct {
+	mu    sync.Mutex
+	cache *simplelru.LRU // tx hash -> *ethapi.TxLifecycle
+
+	subsMu sync.Mutex
+	subs   map[common.Hash]map[*txStatusSub]struct{}
+
+	txpool   TxPool
+	txsSub   notify.Subscription
+	dropsSub notify.Subscription
+	wg       sync.WaitGroup
+}
+
+func newTxStatuses(size int, txpool TxPool) *txStatuses {
+	if size <= 0 {
+		return nil
+	}
+	cache, _ := simplelru.NewLRU(size, nil)
+	return &txStatuses{
+		cache:  cache,
+		subs:   make(map[common.Hash]map[*txStatusSub]struct{}),
+		txpool: txpool,
+	}
+}
+
+// update applies the modification to the lifecycles of the transactions and notifies the subscribers about the changed ones.
+// The modification returns false if the lifecycle isn't changed.
+// It's called during the events and blocks processing, so the subscribers are never waited for.
+func (t *txStatuses) update(txids []common.Hash, modify func(l *ethapi.TxLifecycle) bool) {
+	if t == nil || len(txids) == 0 {
+		return
+	}
+	updated := make([]ethapi.TxLifecycle, 0, len(txids))
+	t.mu.Lock()
+	for _, txid := range txids {
+		var l *ethapi.TxLifecycle
+		if v, ok := t.cache.Get(txid); ok {
+			l = v.(*ethapi.TxLifecycle)
+		} else {
+			l = &ethapi.TxLifecycle{Hash: txid}
+		}
+		if !modify(l) {
+			continue
+		}
+		t.cache.Add(txid, l)
+		updated = append(updated, *l)
+	}
+	t.mu.Unlock()
+
+	t.notify(updated)
+}
+
+// notify sends the updates to the subscribers of the transactions without blocking,
+// the subscribers which can't keep up are unsubscribed
+func (t *txStatuses) notify(updated []ethapi.TxLifecycle) {
+	var overflowed []*txStatusSub
+	t.subsMu.Lock()
+	if len(t.subs) == 0 {
+		t.subsMu.Unlock()
+		return
+	}
+	for _, l := range updated {
+		for sub := range t.subs[l.Hash] {
+			select {
+			case sub.ch <- l:
+			default:
+				overflowed = append(overflowed, sub)
+			}
+		}
+	}
+	t.subsMu.Unlock()
+
+	for _, sub := range overflowed {
+		t.unsubscribe(sub, errTxStatusSubOverflow)
+	}
+}
+
+// Get returns the lifecycle of the transaction, nil if it isn't tracked
+func (t *txStatuses) Get(txid common.Hash) *ethapi.TxLifecycle {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.cache.Peek(txid)
+	if !ok {
+		return nil
+	}
+	l := *v.(*ethapi.TxLifecycle)
+	return &l
+}
+
+// Subscribe subscribes to the changes of the transaction lifecycle.
+// The updates are never waited for, so the subscription fails if ch is full.
+func (t *txStatuses) Subscribe(txid common.Hash, ch chan<- ethapi.TxLifecycle) notify.Subscription {
+	if t == nil {
+		return notify.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		})
+	}
+	sub := &txStatusSub{
+		statuses: t,
+		txid:     txid,
+		ch:       ch,
+		err:      make(chan error, 1),
+	}
+	t.subsMu.Lock()
+	defer t.subsMu.Unlock()
+	if t.subs[txid] == nil {
+		t.subs[txid] = make(map[*txStatusSub]struct{})
+	}
+	t.subs[txid][sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe removes the subscription, err is reported to the subscriber if it's not nil
+func (t *txStatuses) unsubscribe(sub *txStatusSub, err error) {
+	t.subsMu.Lock()
+	if subs := t.subs[sub.txid]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(t.subs, sub.txid)
+		}
+	}
+	t.subsMu.Unlock()
+
+	sub.once.Do(func() {
+		if err != nil {
+			sub.err <- err
+		}
+		close(sub.err)
+	})
+}
+
+func txHashes(txs types.Transactions) []common.Hash {
+	txids := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		txids[i] = tx.Hash()
+	}
+	return txids
+}
+
+// OnPoolAdded marks the transactions as admitted into the txpool
+func (t *txStatuses) OnPoolAdded(txs types.Transactions) {
+	now := inter.Timestamp(time.Now().UnixNano())
+	t.update(txHashes(txs), func(l *ethapi.TxLifecycle) bool {
+		if l.Block != 0 || l.PoolTime != 0 && !l.Dropped {
+			return false
+		}
+		l.PoolTime = now
+		l.Dropped = false
+		l.Reason = ""
+		return true
+	})
+}
+
+// OnDropped marks the transactions as dropped from the txpool, the included transactions are ignored
+func (t *txStatuses) OnDropped(txids []common.Hash, reason string) {
+	t.update(txids, func(l *ethapi.TxLifecycle) bool {
+		if l.Block != 0 || l.Event != hash.ZeroEvent || l.Dropped {
+			return false
+		}
+		l.Dropped = true
+		l.Reason = reason
+		return true
+	})
+}
+
+// OnEvent marks the transactions as included into the event, only the first event is memorized
+func (t *txStatuses) OnEvent(e inter.EventPayloadI) {
+	if e.Txs().Len() == 0 {
+		return
+	}
+	t.update(txHashes(e.Txs()), func(l *ethapi.TxLifecycle) bool {
+		if l.Event != hash.ZeroEvent {
+			return false
+		}
+		l.Event = e.ID()
+		l.EventCreator = e.Creator()
+		l.EventTime = e.CreationTime()
+		return true
+	})
+}
+
+// OnBlock marks the transactions as confirmed by the block, including the skipped transactions
+func (t *txStatuses) OnBlock(block idx.Block, atropos hash.Event, blockTime inter.Timestamp, txs types.Transactions, skipped []evmcore.SkippedTx) {
+	if t == nil {
+		return
+	}
+	t.update(txHashes(txs), func(l *ethapi.TxLifecycle) bool {
+		l.Block = block
+		l.Atropos = atropos
+		l.BlockTime = blockTime
+		l.Skipped = false
+		l.Dropped = false
+		l.Reason = ""
+		return true
+	})
+	skippedTxids := make([]common.Hash, 0, len(skipped))
+	reasons := make(map[common.Hash]string, len(skipped))
+	for _, tx := range skipped {
+		// a transaction may be skipped several times, the first reason is memorized
+		if _, ok := reasons[tx.Hash]; ok {
+			continue
+		}
+		reasons[tx.Hash] = tx.Reason
+		skippedTxids = append(skippedTxids, tx.Hash)
+	}
+	t.update(skippedTxids, func(l *ethapi.TxLifecycle) bool {
+		// a transaction may be met in several events, it's skipped as a duplicate once executed
+		if l.Block != 0 && !l.Skipped {
+			return false
+		}
+		l.Block = block
+		l.Atropos = atropos
+		l.BlockTime = blockTime
+		l.Skipped = true
+		l.Reason = reasons[l.Hash]
+		return true
+	})
+}
+
+func (t *txStatuses) loop(txsCh chan evmcore.NewTxsNotify, dropsCh chan evmcore.DroppedTxsNotify) {
+	defer t.wg.Done()
+	for {
+		select {
+		case ev := <-txsCh:
+			t.OnPoolAdded(ev.Txs)
+		case ev := <-dropsCh:
+			t.OnDropped(txHashes(ev.Txs), ev.Reason)
+		// Err() channel will be closed when unsubscribing.
+		case <-t.txsSub.Err():
+			return
+		case <-t.dropsSub.Err():
+			return
+		}
+	}
+}
+
+func (t *txStatuses) Start() {
+	if t == nil {
+		return
+	}
+	txsCh := make(chan evmcore.NewTxsNotify, 256)
+	dropsCh := make(chan evmcore.DroppedTxsNotify, 256)
+	t.txsSub = t.txpool.SubscribeNewTxsNotify(txsCh)
+	t.dropsSub = t.txpool.SubscribeDroppedTxsNotify(dropsCh)
+	t.wg.Add(1)
+	go t.loop(txsCh, dropsCh)
+}
+
+func (t *txStatuses) Stop() {
+	if t == nil {
+		return
+	}
+	t.txsSub.Unsubscribe()
+	t.dropsSub.Unsubscribe()
+	t.wg.Wait()
+}
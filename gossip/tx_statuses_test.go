@@ -0,0 +1,94 @@
+package gossip
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/ethapi"
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+func TestTxStatuses(t *testing.T) {
+	require := require.New(t)
+
+	newTx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	included := newTx(1)
+	skipped := newTx(2)
+	dropped := newTx(3)
+
+	statuses := newTxStatuses(10, nil)
+	require.Nil(statuses.Get(included.Hash()))
+
+	updates := make(chan ethapi.TxLifecycle, 100)
+	sub := statuses.Subscribe(included.Hash(), updates)
+	defer sub.Unsubscribe()
+	// the subscriber which can't keep up is unsubscribed rather than waited for
+	slowSub := statuses.Subscribe(included.Hash(), make(chan ethapi.TxLifecycle))
+
+	statuses.OnPoolAdded(types.Transactions{included, skipped, dropped})
+	require.Equal(ethapi.TxStatusPending, statuses.Get(included.Hash()).Status())
+	require.Len(updates, 1)
+	require.Equal(included.Hash(), (<-updates).Hash)
+	require.Equal(errTxStatusSubOverflow, <-slowSub.Err())
+	_, ok := <-slowSub.Err()
+	require.False(ok)
+	slowSub.Unsubscribe()
+
+	statuses.OnDropped([]common.Hash{dropped.Hash()}, evmcore.DropReasonReplaced)
+	require.Equal(ethapi.TxStatusDropped, statuses.Get(dropped.Hash()).Status())
+	require.Equal(evmcore.DropReasonReplaced, statuses.Get(dropped.Hash()).Reason)
+
+	e := &inter.MutableEventPayload{}
+	e.SetCreator(5)
+	e.SetTxs(types.Transactions{included, skipped})
+	event := e.Build()
+	statuses.OnEvent(event)
+	l := statuses.Get(included.Hash())
+	require.Equal(ethapi.TxStatusInEvent, l.Status())
+	require.Equal(event.ID(), l.Event)
+	require.Equal(event.Creator(), l.EventCreator)
+
+	// included transactions are never reported as dropped
+	statuses.OnDropped([]common.Hash{included.Hash()}, evmcore.DropReasonNonceTooLow)
+	require.Equal(ethapi.TxStatusInEvent, statuses.Get(included.Hash()).Status())
+
+	recorder := newBlockSkippedTxsRecorder()
+	recorder.OnSkip(skipped, errors.New("nonce too high"))
+	atropos := hash.FakeEvent()
+	skippedTxs, err := recorder.result([]uint32{1})
+	require.NoError(err)
+	statuses.OnBlock(7, atropos, 100, types.Transactions{included}, skippedTxs)
+
+	l = statuses.Get(included.Hash())
+	require.Equal(ethapi.TxStatusInBlock, l.Status())
+	require.Equal(uint64(7), uint64(l.Block))
+	require.Equal(atropos, l.Atropos)
+
+	require.Len(updates, 2)
+
+	l = statuses.Get(skipped.Hash())
+	require.Equal(ethapi.TxStatusSkipped, l.Status())
+	require.Equal("nonce too high", l.Reason)
+
+	// a duplicate of an executed transaction doesn't make it skipped
+	statuses.OnBlock(8, hash.FakeEvent(), 101, nil, []evmcore.SkippedTx{{Index: 0, Hash: included.Hash(), Reason: "nonce too low"}})
+	require.Equal(ethapi.TxStatusInBlock, statuses.Get(included.Hash()).Status())
+	require.Equal(uint64(7), uint64(statuses.Get(included.Hash()).Block))
+
+	sub.Unsubscribe()
+	require.Empty(statuses.subs)
+
+	// disabled tracking
+	var disabled *txStatuses
+	disabled.OnPoolAdded(types.Transactions{included})
+	require.Nil(disabled.Get(included.Hash()))
+}
@@ -0,0 +1,1214 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql provides a GraphQL interface (EIP-1767) to the node data,
+// extended with the Lachesis epochs, events, validators and LLR votes.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/mrmikeo/Xpense/ethapi"
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/gossip/filters"
+	"github.com/mrmikeo/Xpense/inter/state"
+	"github.com/mrmikeo/Xpense/utils/signers/gsignercache"
+)
+
+// maxBlocksRange is the max number of blocks queried at once, the same as the blocks count of eth_feeHistory
+const maxBlocksRange = 1024
+
+var (
+	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+)
+
+// Backend is the node API the resolvers are built on
+type Backend interface {
+	ethapi.Backend
+	filters.Backend
+}
+
+type Long int64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL type.
+func (b Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (b *Long) UnmarshalGraphQL(input interface{}) error {
+	var err error
+	switch input := input.(type) {
+	case string:
+		value, err := strconv.ParseInt(input, 10, 64)
+		*b = Long(value)
+		return err
+	case int32:
+		*b = Long(input)
+	case int64:
+		*b = Long(input)
+	default:
+		err = fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return err
+}
+
+// Account represents an Ethereum account at a particular block.
+type Account struct {
+	r             *Resolver
+	address       common.Address
+	blockNrOrHash rpc.BlockNumberOrHash
+}
+
+// withState calls f on the StateDB object of the account block.
+func (a *Account) withState(ctx context.Context, f func(statedb state.StateDB)) error {
+	statedb, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	if err != nil {
+		return err
+	}
+	if statedb == nil {
+		return errors.New("state is not found")
+	}
+	defer statedb.Release()
+	f(statedb)
+	return statedb.Error()
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	var balance *big.Int
+	err := a.withState(ctx, func(statedb state.StateDB) {
+		balance = statedb.GetBalance(a.address)
+	})
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	if balance == nil {
+		return hexutil.Big{}, fmt.Errorf("failed to load balance %x", a.address)
+	}
+	return hexutil.Big(*balance), nil
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
+	var nonce uint64
+	err := a.withState(ctx, func(statedb state.StateDB) {
+		nonce = statedb.GetNonce(a.address)
+	})
+	return hexutil.Uint64(nonce), err
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	var code []byte
+	err := a.withState(ctx, func(statedb state.StateDB) {
+		code = statedb.GetCode(a.address)
+	})
+	return code, err
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	var value common.Hash
+	err := a.withState(ctx, func(statedb state.StateDB) {
+		value = statedb.GetState(a.address, args.Slot)
+	})
+	return value, err
+}
+
+// Log represents an individual log message. All arguments are mandatory.
+type Log struct {
+	r           *Resolver
+	transaction *Transaction
+	log         *types.Log
+}
+
+func (l *Log) Transaction(ctx context.Context) *Transaction {
+	return l.transaction
+}
+
+func (l *Log) Account(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		r:             l.r,
+		address:       l.log.Address,
+		blockNrOrHash: args.NumberOrLatest(),
+	}
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return l.log.Data
+}
+
+// AccessTuple represents EIP-2930
+type AccessTuple struct {
+	address     common.Address
+	storageKeys *[]common.Hash
+}
+
+func (at *AccessTuple) Address(ctx context.Context) common.Address {
+	return at.address
+}
+
+func (at *AccessTuple) StorageKeys(ctx context.Context) *[]common.Hash {
+	return at.storageKeys
+}
+
+// Transaction represents an Ethereum transaction.
+// r and hash are mandatory; all others will be fetched when required.
+type Transaction struct {
+	r     *Resolver
+	hash  common.Hash
+	tx    *types.Transaction
+	block *Block
+	index uint64
+	// lookedUp is true if the tx position was looked up in the index
+	lookedUp bool
+}
+
+// resolve returns the internal transaction object, fetching it if needed.
+// The block of a transaction known without a block (e.g. from an event) is looked up as well.
+func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
+	if t.block == nil && !t.lookedUp {
+		t.lookedUp = true
+		// Try to return an already finalized transaction
+		tx, blockNumber, index, err := t.r.backend.GetTransaction(ctx, t.hash)
+		if err == nil && tx != nil {
+			t.tx = tx
+			blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber))
+			t.block = &Block{
+				r:            t.r,
+				numberOrHash: &blockNrOrHash,
+			}
+			t.index = index
+			return t.tx, nil
+		}
+	}
+	if t.tx == nil {
+		// No finalized transaction, try to retrieve it from the pool
+		t.tx = t.r.backend.GetPoolTransaction(t.hash)
+	}
+	return t.tx, nil
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.hash
+}
+
+func (t *Transaction) InputData(ctx context.Context) (hexutil.Bytes, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Bytes{}, err
+	}
+	return tx.Data(), nil
+}
+
+func (t *Transaction) Gas(ctx context.Context) (hexutil.Uint64, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return hexutil.Uint64(tx.Gas()), nil
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	if tx.Type() == types.DynamicFeeTxType && t.block != nil {
+		if baseFee, _ := t.block.BaseFeePerGas(ctx); baseFee != nil {
+			// price = min(tip, gasFeeCap - baseFee) + baseFee
+			return (hexutil.Big)(*math.BigMin(new(big.Int).Add(tx.GasTipCap(), baseFee.ToInt()), tx.GasFeeCap())), nil
+		}
+	}
+	return hexutil.Big(*tx.GasPrice()), nil
+}
+
+func (t *Transaction) EffectiveGasPrice(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	header, err := t.block.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return (*hexutil.Big)(tx.GasPrice()), nil
+	}
+	return (*hexutil.Big)(math.BigMin(new(big.Int).Add(tx.GasTipCap(), header.BaseFee), tx.GasFeeCap())), nil
+}
+
+func (t *Transaction) MaxFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, nil
+	}
+	return (*hexutil.Big)(tx.GasFeeCap()), nil
+}
+
+func (t *Transaction) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, nil
+	}
+	return (*hexutil.Big)(tx.GasTipCap()), nil
+}
+
+func (t *Transaction) Value(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	if tx.Value() == nil {
+		return hexutil.Big{}, fmt.Errorf("invalid transaction value %x", t.hash)
+	}
+	return hexutil.Big(*tx.Value()), nil
+}
+
+func (t *Transaction) Nonce(ctx context.Context) (hexutil.Uint64, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return hexutil.Uint64(tx.Nonce()), nil
+}
+
+func (t *Transaction) To(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	to := tx.To()
+	if to == nil {
+		return nil, nil
+	}
+	return &Account{
+		r:             t.r,
+		address:       *to,
+		blockNrOrHash: args.NumberOrLatest(),
+	}, nil
+}
+
+func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	signer := gsignercache.Wrap(types.LatestSigner(t.r.backend.ChainConfig()))
+	from, _ := types.Sender(signer, tx)
+	return &Account{
+		r:             t.r,
+		address:       from,
+		blockNrOrHash: args.NumberOrLatest(),
+	}, nil
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	return t.block, nil
+}
+
+func (t *Transaction) Index(ctx context.Context) (*int32, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	if t.block == nil {
+		return nil, nil
+	}
+	index := int32(t.index)
+	return &index, nil
+}
+
+// getReceipt returns the receipt associated with this transaction, if any.
+func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
+	if _, err := t.resolve(ctx); err != nil {
+		return nil, err
+	}
+	if t.block == nil {
+		return nil, nil
+	}
+	receipts, err := t.block.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= t.index {
+		return nil, nil
+	}
+	return receipts[t.index], nil
+}
+
+func (t *Transaction) Status(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := Long(receipt.Status)
+	return &ret, nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := Long(receipt.GasUsed)
+	return &ret, nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := Long(receipt.CumulativeGasUsed)
+	return &ret, nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil || receipt.ContractAddress == (common.Address{}) {
+		return nil, err
+	}
+	return &Account{
+		r:             t.r,
+		address:       receipt.ContractAddress,
+		blockNrOrHash: args.NumberOrLatest(),
+	}, nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		ret = append(ret, &Log{
+			r:           t.r,
+			transaction: t,
+			log:         log,
+		})
+	}
+	return &ret, nil
+}
+
+func (t *Transaction) Type(ctx context.Context) (*int32, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	txType := int32(tx.Type())
+	return &txType, nil
+}
+
+func (t *Transaction) AccessList(ctx context.Context) (*[]*AccessTuple, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	accessList := tx.AccessList()
+	ret := make([]*AccessTuple, 0, len(accessList))
+	for _, al := range accessList {
+		al := al
+		ret = append(ret, &AccessTuple{
+			address:     al.Address,
+			storageKeys: &al.StorageKeys,
+		})
+	}
+	return &ret, nil
+}
+
+func (t *Transaction) R(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	_, r, _ := tx.RawSignatureValues()
+	return hexutil.Big(*r), nil
+}
+
+func (t *Transaction) S(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	_, _, s := tx.RawSignatureValues()
+	return hexutil.Big(*s), nil
+}
+
+func (t *Transaction) V(ctx context.Context) (hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return hexutil.Big{}, err
+	}
+	v, _, _ := tx.RawSignatureValues()
+	return hexutil.Big(*v), nil
+}
+
+// Block represents an Ethereum block.
+// r, and numberOrHash are mandatory. All other fields are lazily fetched
+// when required.
+type Block struct {
+	r            *Resolver
+	numberOrHash *rpc.BlockNumberOrHash
+	hash         common.Hash
+	header       *evmcore.EvmHeader
+	block        *evmcore.EvmBlock
+	receipts     []*types.Receipt
+}
+
+// resolve returns the internal Block object representing this block, fetching
+// it if necessary.
+func (b *Block) resolve(ctx context.Context) (*evmcore.EvmBlock, error) {
+	if b.block != nil {
+		return b.block, nil
+	}
+	if b.numberOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		b.numberOrHash = &latest
+	}
+	var err error
+	if hash, ok := b.numberOrHash.Hash(); ok {
+		b.block, err = b.r.backend.BlockByHash(ctx, hash)
+	} else {
+		number, _ := b.numberOrHash.Number()
+		b.block, err = b.r.backend.BlockByNumber(ctx, number)
+	}
+	if b.block != nil && b.header == nil {
+		b.header = b.block.Header()
+		b.hash = b.header.Hash
+	}
+	return b.block, err
+}
+
+// resolveHeader returns the internal Header object for this block, fetching it
+// if necessary. Call this function instead of `resolve` unless you need the
+// additional data (transactions).
+func (b *Block) resolveHeader(ctx context.Context) (*evmcore.EvmHeader, error) {
+	if b.numberOrHash == nil && b.hash == (common.Hash{}) {
+		return nil, errBlockInvariant
+	}
+	var err error
+	if b.header == nil {
+		if b.hash != (common.Hash{}) {
+			b.header, err = b.r.backend.HeaderByHash(ctx, b.hash)
+		} else if hash, ok := b.numberOrHash.Hash(); ok {
+			b.header, err = b.r.backend.HeaderByHash(ctx, hash)
+		} else {
+			number, _ := b.numberOrHash.Number()
+			b.header, err = b.r.backend.HeaderByNumber(ctx, number)
+		}
+		if err == nil && b.header == nil {
+			err = errors.New("block is not found")
+		}
+	}
+	return b.header, err
+}
+
+// resolveReceipts returns the list of receipts for this block, fetching them
+// if necessary.
+func (b *Block) resolveReceipts(ctx context.Context) ([]*types.Receipt, error) {
+	if b.receipts == nil {
+		header, err := b.resolveHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		receipts, err := b.r.backend.GetReceiptsByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return nil, err
+		}
+		b.receipts = receipts
+	}
+	return b.receipts, nil
+}
+
+func (b *Block) Number(ctx context.Context) (Long, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(header.Number.Uint64()), nil
+}
+
+func (b *Block) Hash(ctx context.Context) (common.Hash, error) {
+	if b.hash == (common.Hash{}) {
+		header, err := b.resolveHeader(ctx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		b.hash = header.Hash
+	}
+	return b.hash, nil
+}
+
+func (b *Block) GasLimit(ctx context.Context) (Long, error) {
+	_, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// don't use header.GasLimit (too much bits) here to avoid parsing issues, same as in JSON-RPC
+	return Long(0xffffffffffff), nil
+}
+
+func (b *Block) GasUsed(ctx context.Context) (Long, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(header.GasUsed), nil
+}
+
+func (b *Block) BaseFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, nil
+	}
+	return (*hexutil.Big)(header.BaseFee), nil
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if header.Number.Uint64() == 0 {
+		return nil, nil
+	}
+	num := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(header.Number.Uint64() - 1))
+	return &Block{
+		r:            b.r,
+		numberOrHash: &num,
+		hash:         header.ParentHash,
+	}, nil
+}
+
+func (b *Block) Difficulty(ctx context.Context) (hexutil.Big, error) {
+	return hexutil.Big{}, nil
+}
+
+func (b *Block) Timestamp(ctx context.Context) (hexutil.Uint64, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(header.Time.Unix()), nil
+}
+
+func (b *Block) Nonce(ctx context.Context) (hexutil.Bytes, error) {
+	return make(hexutil.Bytes, len(types.BlockNonce{})), nil
+}
+
+func (b *Block) MixHash(ctx context.Context) (common.Hash, error) {
+	return common.Hash{}, nil
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) (common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.TxHash, nil
+}
+
+func (b *Block) StateRoot(ctx context.Context) (common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Root, nil
+}
+
+func (b *Block) ReceiptsRoot(ctx context.Context) (common.Hash, error) {
+	if !b.r.backend.CalcBlockExtApi() {
+		return common.Hash{}, nil
+	}
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(receipts) == 0 {
+		return types.EmptyRootHash, nil
+	}
+	return types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil)), nil
+}
+
+func (b *Block) OmmerHash(ctx context.Context) (common.Hash, error) {
+	return types.EmptyUncleHash, nil
+}
+
+func (b *Block) OmmerCount(ctx context.Context) (*int32, error) {
+	count := int32(0)
+	return &count, nil
+}
+
+func (b *Block) Ommers(ctx context.Context) (*[]*Block, error) {
+	ret := []*Block{}
+	return &ret, nil
+}
+
+func (b *Block) ExtraData(ctx context.Context) (hexutil.Bytes, error) {
+	return hexutil.Bytes{}, nil
+}
+
+func (b *Block) LogsBloom(ctx context.Context) (hexutil.Bytes, error) {
+	var bloom types.Bloom
+	if !b.r.backend.CalcBlockExtApi() {
+		return bloom.Bytes(), nil
+	}
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	if len(receipts) != 0 {
+		bloom = types.CreateBloom(receipts)
+	}
+	return bloom.Bytes(), nil
+}
+
+func (b *Block) TotalDifficulty(ctx context.Context) (hexutil.Big, error) {
+	h, err := b.Hash(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	td := b.r.backend.GetTd(h)
+	if td == nil {
+		return hexutil.Big{}, fmt.Errorf("total difficulty not found %x", h)
+	}
+	return hexutil.Big(*td), nil
+}
+
+// BlockNumberArgs encapsulates arguments to accessors that specify a block number.
+type BlockNumberArgs struct {
+	Block *hexutil.Uint64
+}
+
+// NumberOr returns the provided block number argument, or the "current" block number or hash if none
+// was provided.
+func (a BlockNumberArgs) NumberOr(current rpc.BlockNumberOrHash) rpc.BlockNumberOrHash {
+	if a.Block != nil {
+		blockNr := rpc.BlockNumber(*a.Block)
+		return rpc.BlockNumberOrHashWithNumber(blockNr)
+	}
+	return current
+}
+
+// NumberOrLatest returns the provided block number argument, or the "latest" block number if none
+// was provided.
+func (a BlockNumberArgs) NumberOrLatest() rpc.BlockNumberOrHash {
+	return a.NumberOr(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+}
+
+func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		r:             b.r,
+		address:       header.Coinbase,
+		blockNrOrHash: args.NumberOrLatest(),
+	}, nil
+}
+
+func (b *Block) TransactionCount(ctx context.Context) (*int32, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	count := int32(len(block.Transactions))
+	return &count, err
+}
+
+func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	ret := make([]*Transaction, 0, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		ret = append(ret, &Transaction{
+			r:     b.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			block: b,
+			index: uint64(i),
+		})
+	}
+	return &ret, nil
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (*Transaction, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	txs := block.Transactions
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil, nil
+	}
+	tx := txs[args.Index]
+	return &Transaction{
+		r:     b.r,
+		hash:  tx.Hash(),
+		tx:    tx,
+		block: b,
+		index: uint64(args.Index),
+	}, nil
+}
+
+func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block, error) {
+	return nil, nil
+}
+
+// BlockFilterCriteria encapsulates criteria passed to a `logs` accessor inside
+// a block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	//
+	// Examples:
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position, B in second position
+	// {{A}, {B}}         matches topic A in first position, B in second position
+	// {{A, B}}, {C, D}}  matches topic (A OR B) in first position, (C OR D) in second position
+	Topics *[][]common.Hash
+}
+
+// runFilter accepts a filter and executes it, returning all its results as
+// `Log` objects.
+func runFilter(ctx context.Context, r *Resolver, filter *filters.Filter) ([]*Log, error) {
+	logs, err := filter.Logs(ctx)
+	if err != nil || logs == nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(logs))
+	for _, log := range logs {
+		ret = append(ret, &Log{
+			r:           r,
+			transaction: &Transaction{r: r, hash: log.TxHash},
+			log:         log,
+		})
+	}
+	return ret, nil
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	hash, err := b.Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Construct the range filter
+	filter := filters.NewBlockFilter(b.r.backend, b.r.filterCfg, hash, addresses, topics)
+
+	// Run the filter and return all the logs
+	return runFilter(ctx, b.r, filter)
+}
+
+func (b *Block) Account(ctx context.Context, args struct {
+	Address common.Address
+}) (*Account, error) {
+	if b.numberOrHash == nil {
+		_, err := b.resolveHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Account{
+		r:             b.r,
+		address:       args.Address,
+		blockNrOrHash: *b.numberOrHash,
+	}, nil
+}
+
+// CallData encapsulates arguments to `call` or `estimateGas`.
+// All arguments are optional.
+type CallData struct {
+	From                 *common.Address // The Ethereum address the call is from.
+	To                   *common.Address // The Ethereum address the call is to.
+	Gas                  *hexutil.Uint64 // The amount of gas provided for the call.
+	GasPrice             *hexutil.Big    // The price of each unit of gas, in wei.
+	MaxFeePerGas         *hexutil.Big    // The max price of each unit of gas, in wei (1559).
+	MaxPriorityFeePerGas *hexutil.Big    // The max tip of each unit of gas, in wei (1559).
+	Value                *hexutil.Big    // The value sent along with the call.
+	Data                 *hexutil.Bytes  // Any data sent with the call.
+}
+
+// CallResult encapsulates the result of an invocation of the `call` accessor.
+type CallResult struct {
+	data    hexutil.Bytes // The return data from the call
+	gasUsed Long          // The amount of gas used
+	status  Long          // The return status of the call - 0 for failure or 1 for success.
+}
+
+func (c *CallResult) Data() hexutil.Bytes {
+	return c.data
+}
+
+func (c *CallResult) GasUsed() Long {
+	return c.gasUsed
+}
+
+func (c *CallResult) Status() Long {
+	return c.status
+}
+
+func doCall(ctx context.Context, backend Backend, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) (*CallResult, error) {
+	result, err := ethapi.DoCall(ctx, backend, args, blockNrOrHash, nil, backend.RPCEVMTimeout(), backend.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	status := Long(1)
+	if result.Failed() {
+		status = 0
+	}
+	return &CallResult{
+		data:    result.ReturnData,
+		gasUsed: Long(result.UsedGas),
+		status:  status,
+	}, nil
+}
+
+func (b *Block) Call(ctx context.Context, args struct {
+	Data ethapi.TransactionArgs
+}) (*CallResult, error) {
+	if b.numberOrHash == nil {
+		_, err := b.resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doCall(ctx, b.r.backend, args.Data, *b.numberOrHash)
+}
+
+func (b *Block) EstimateGas(ctx context.Context, args struct {
+	Data ethapi.TransactionArgs
+}) (Long, error) {
+	if b.numberOrHash == nil {
+		_, err := b.resolveHeader(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+	gas, err := ethapi.DoEstimateGas(ctx, b.r.backend, args.Data, *b.numberOrHash, b.r.backend.RPCGasCap())
+	return Long(gas), err
+}
+
+type Pending struct {
+	r *Resolver
+}
+
+func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
+	txs, err := p.r.backend.GetPoolTransactions()
+	return int32(len(txs)), err
+}
+
+func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
+	txs, err := p.r.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Transaction, 0, len(txs))
+	for i, tx := range txs {
+		ret = append(ret, &Transaction{
+			r:     p.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			index: uint64(i),
+		})
+	}
+	return &ret, nil
+}
+
+func (p *Pending) Account(ctx context.Context, args struct {
+	Address common.Address
+}) *Account {
+	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	return &Account{
+		r:             p.r,
+		address:       args.Address,
+		blockNrOrHash: pendingBlockNr,
+	}
+}
+
+func (p *Pending) Call(ctx context.Context, args struct {
+	Data ethapi.TransactionArgs
+}) (*CallResult, error) {
+	return doCall(ctx, p.r.backend, args.Data, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+}
+
+func (p *Pending) EstimateGas(ctx context.Context, args struct {
+	Data ethapi.TransactionArgs
+}) (Long, error) {
+	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	gas, err := ethapi.DoEstimateGas(ctx, p.r.backend, args.Data, pendingBlockNr, p.r.backend.RPCGasCap())
+	return Long(gas), err
+}
+
+// Resolver is the top-level object in the GraphQL hierarchy.
+type Resolver struct {
+	backend   Backend
+	filterCfg filters.Config
+	eth       *ethapi.PublicEthereumAPI
+	abft      *ethapi.PublicAbftAPI
+}
+
+// NewResolver creates the top-level resolver
+func NewResolver(backend Backend, filterCfg filters.Config) *Resolver {
+	return &Resolver{
+		backend:   backend,
+		filterCfg: filterCfg,
+		eth:       ethapi.NewPublicEthereumAPI(backend),
+		abft:      ethapi.NewPublicAbftAPI(backend),
+	}
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	var numberOrHash rpc.BlockNumberOrHash
+	if args.Number != nil {
+		if *args.Number < 0 {
+			return nil, nil
+		}
+		numberOrHash = rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(*args.Number))
+	} else if args.Hash != nil {
+		numberOrHash = rpc.BlockNumberOrHashWithHash(*args.Hash, false)
+	} else {
+		numberOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	block := &Block{
+		r:            r,
+		numberOrHash: &numberOrHash,
+	}
+	// Resolve the header, return nil if it doesn't exist.
+	h, err := block.resolveHeader(ctx)
+	if err != nil || h == nil {
+		return nil, nil
+	}
+	return block, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From *Long
+	To   *Long
+}) ([]*Block, error) {
+	var from rpc.BlockNumber
+	if args.From != nil {
+		from = rpc.BlockNumber(*args.From)
+	}
+
+	var to rpc.BlockNumber
+	if args.To != nil {
+		to = rpc.BlockNumber(*args.To)
+	} else {
+		to = rpc.BlockNumber(r.backend.CurrentBlock().Number.Int64())
+	}
+	if to < from {
+		return []*Block{}, nil
+	}
+	if to-from >= maxBlocksRange {
+		return nil, fmt.Errorf("too wide blocks range, the limit is %d", maxBlocksRange)
+	}
+	ret := make([]*Block, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		numberOrHash := rpc.BlockNumberOrHashWithNumber(i)
+		ret = append(ret, &Block{
+			r:            r,
+			numberOrHash: &numberOrHash,
+		})
+	}
+	return ret, nil
+}
+
+func (r *Resolver) Pending(ctx context.Context) *Pending {
+	return &Pending{r}
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx := &Transaction{
+		r:    r,
+		hash: args.Hash,
+	}
+	// Resolve the transaction; if it doesn't exist, return nil.
+	t, err := tx.resolve(ctx)
+	if err != nil {
+		return nil, err
+	} else if t == nil {
+		return nil, nil
+	}
+	return tx, nil
+}
+
+func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
+		return common.Hash{}, err
+	}
+	return ethapi.SubmitTransaction(ctx, r.backend, tx)
+}
+
+// FilterCriteria encapsulates the arguments to `logs` on the root resolver object.
+type FilterCriteria struct {
+	FromBlock *hexutil.Uint64   // beginning of the queried range, nil means genesis block
+	ToBlock   *hexutil.Uint64   // end of the range, nil means latest block
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	//
+	// Examples:
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position, B in second position
+	// {{A}, {B}}         matches topic A in first position, B in second position
+	// {{A, B}}, {C, D}}  matches topic (A OR B) in first position, (C OR D) in second position
+	Topics *[][]common.Hash
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if args.Filter.FromBlock != nil {
+		begin = int64(*args.Filter.FromBlock)
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if args.Filter.ToBlock != nil {
+		end = int64(*args.Filter.ToBlock)
+	}
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	// Construct the range filter
+	filter := filters.NewRangeFilter(r.backend, r.filterCfg, begin, end, addresses, topics)
+	return runFilter(ctx, r, filter)
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := r.eth.GasPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return *price, nil
+}
+
+func (r *Resolver) MaxPriorityFeePerGas(ctx context.Context) (hexutil.Big, error) {
+	tipcap, err := r.eth.MaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return *tipcap, nil
+}
+
+func (r *Resolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	return hexutil.Big(*r.backend.ChainConfig().ChainID), nil
+}
+
+// SyncState represents the synchronisation status returned from the `syncing` accessor.
+type SyncState struct {
+	progress ethapi.PeerProgress
+}
+
+func (s *SyncState) StartingBlock() hexutil.Uint64 {
+	return 0
+}
+
+func (s *SyncState) CurrentBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.CurrentBlock)
+}
+
+func (s *SyncState) HighestBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.HighestBlock)
+}
+
+func (s *SyncState) CurrentEpoch() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.CurrentEpoch)
+}
+
+func (s *SyncState) HighestEpoch() hexutil.Uint64 {
+	return hexutil.Uint64(s.progress.HighestEpoch)
+}
+
+func (s *SyncState) PulledStates() *hexutil.Uint64 {
+	return nil
+}
+
+func (s *SyncState) KnownStates() *hexutil.Uint64 {
+	return nil
+}
+
+// Syncing returns false in case the node is currently not syncing with the network,
+// using the same criteria as eth_syncing.
+func (r *Resolver) Syncing() (*SyncState, error) {
+	progress := r.backend.Progress()
+
+	// Return not syncing if the synchronisation already completed
+	if time.Since(progress.CurrentBlockTime.Time()) <= 90*time.Minute {
+		return nil, nil
+	}
+	// Otherwise gather the block sync stats
+	return &SyncState{progress}, nil
+}
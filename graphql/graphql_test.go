@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/evmcore"
+	"github.com/mrmikeo/Xpense/gossip/filters"
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/iblockproc"
+)
+
+// testBackend serves the blocks and the sealed epochs the resolvers query,
+// the methods not overridden panic as the embedded Backend is nil
+type testBackend struct {
+	Backend
+	blocks []*evmcore.EvmBlock
+	epochs map[idx.Epoch]*iblockproc.EpochState
+}
+
+func newTestBackend(blocks int) *testBackend {
+	b := &testBackend{
+		epochs: make(map[idx.Epoch]*iblockproc.EpochState),
+	}
+	for n := 0; n < blocks; n++ {
+		b.blocks = append(b.blocks, evmcore.NewEvmBlock(&evmcore.EvmHeader{
+			Number: big.NewInt(int64(n)),
+			Hash:   common.BigToHash(big.NewInt(int64(n + 1))),
+			Time:   inter.FromUnix(int64(n)),
+		}, nil))
+	}
+	return b
+}
+
+func (b *testBackend) CurrentBlock() *evmcore.EvmBlock {
+	return b.blocks[len(b.blocks)-1]
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*evmcore.EvmBlock, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.CurrentBlock(), nil
+	}
+	if number < 0 || int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*evmcore.EvmHeader, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	return block.Header(), err
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmBlock, error) {
+	for _, block := range b.blocks {
+		if block.Hash == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmHeader, error) {
+	block, err := b.BlockByHash(ctx, hash)
+	return block.Header(), err
+}
+
+func (b *testBackend) GetEpochBlockState(ctx context.Context, epoch rpc.BlockNumber) (*iblockproc.BlockState, *iblockproc.EpochState, error) {
+	es := b.epochs[idx.Epoch(epoch)]
+	if es == nil {
+		return nil, nil, nil
+	}
+	return &iblockproc.BlockState{}, es, nil
+}
+
+func execQuery(t *testing.T, backend Backend, query string) (map[string]interface{}, []string) {
+	s, err := graphql.ParseSchema(schema, NewResolver(backend, filters.DefaultConfig()))
+	require.NoError(t, err)
+	response := s.Exec(context.Background(), query, "", nil)
+	var errs []string
+	for _, err := range response.Errors {
+		errs = append(errs, err.Message)
+	}
+	var data map[string]interface{}
+	if len(response.Data) != 0 {
+		require.NoError(t, json.Unmarshal(response.Data, &data))
+	}
+	return data, errs
+}
+
+func blockNumbers(t *testing.T, v interface{}) []float64 {
+	var numbers []float64
+	for _, block := range v.([]interface{}) {
+		numbers = append(numbers, block.(map[string]interface{})["number"].(float64))
+	}
+	return numbers
+}
+
+// TestSchemaResolvers checks that every field of the schema has a matching resolver.
+func TestSchemaResolvers(t *testing.T) {
+	_, err := graphql.ParseSchema(schema, NewResolver(nil, filters.DefaultConfig()))
+	require.NoError(t, err)
+}
+
+func TestResolverBlock(t *testing.T) {
+	require := require.New(t)
+	backend := newTestBackend(10)
+
+	data, errs := execQuery(t, backend, `{ block(number: 3) { number hash } }`)
+	require.Empty(errs)
+	require.Equal(map[string]interface{}{
+		"number": 3.0,
+		"hash":   common.BigToHash(big.NewInt(4)).Hex(),
+	}, data["block"])
+
+	data, errs = execQuery(t, backend, `{ block(hash: "`+common.BigToHash(big.NewInt(8)).Hex()+`") { number } }`)
+	require.Empty(errs)
+	require.Equal(map[string]interface{}{"number": 7.0}, data["block"])
+
+	data, errs = execQuery(t, backend, `{ block { number } }`)
+	require.Empty(errs)
+	require.Equal(map[string]interface{}{"number": 9.0}, data["block"])
+
+	data, errs = execQuery(t, backend, `{ block(number: 10) { number } }`)
+	require.Empty(errs)
+	require.Nil(data["block"])
+}
+
+func TestResolverBlocks(t *testing.T) {
+	require := require.New(t)
+	backend := newTestBackend(10)
+
+	data, errs := execQuery(t, backend, `{ blocks(from: 2, to: 4) { number } }`)
+	require.Empty(errs)
+	require.Equal([]float64{2, 3, 4}, blockNumbers(t, data["blocks"]))
+
+	// to defaults to the latest block
+	data, errs = execQuery(t, backend, `{ blocks(from: 8) { number } }`)
+	require.Empty(errs)
+	require.Equal([]float64{8, 9}, blockNumbers(t, data["blocks"]))
+
+	data, errs = execQuery(t, backend, `{ blocks(from: 4, to: 2) { number } }`)
+	require.Empty(errs)
+	require.Empty(data["blocks"])
+
+	// the range is limited regardless of the existing blocks
+	data, errs = execQuery(t, backend, `{ blocks(from: 0, to: 1023) { __typename } }`)
+	require.Empty(errs)
+	require.Len(data["blocks"], 1024)
+	_, errs = execQuery(t, backend, `{ blocks(from: 0, to: 1024) { number } }`)
+	require.Equal([]string{"too wide blocks range, the limit is 1024"}, errs)
+	_, errs = execQuery(t, backend, `{ blocks(from: 0, to: 2147483647) { number } }`)
+	require.Equal([]string{"too wide blocks range, the limit is 1024"}, errs)
+}
+
+func TestResolverEpoch(t *testing.T) {
+	require := require.New(t)
+	backend := newTestBackend(1)
+	backend.epochs[5] = &iblockproc.EpochState{
+		Epoch:          5,
+		EpochStart:     inter.FromUnix(100),
+		PrevEpochStart: inter.FromUnix(50),
+	}
+
+	data, errs := execQuery(t, backend, `{ epoch(number: 5) { number start prevEpochStart } }`)
+	require.Empty(errs)
+	require.Equal(map[string]interface{}{
+		"number":         5.0,
+		"start":          float64(inter.FromUnix(100)),
+		"prevEpochStart": float64(inter.FromUnix(50)),
+	}, data["epoch"])
+
+	data, errs = execQuery(t, backend, `{ epoch(number: 6) { number } }`)
+	require.Empty(errs)
+	require.Nil(data["epoch"])
+}
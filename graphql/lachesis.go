@@ -0,0 +1,520 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/drivertype"
+	"github.com/mrmikeo/Xpense/inter/iblockproc"
+)
+
+var errEpochNotFound = errors.New("epoch is not found")
+
+// Epoch represents a Lachesis epoch at its start.
+// r and number are mandatory, the states are fetched when required.
+type Epoch struct {
+	r      *Resolver
+	number rpc.BlockNumber
+	bs     *iblockproc.BlockState
+	es     *iblockproc.EpochState
+}
+
+func (e *Epoch) resolve(ctx context.Context) (*iblockproc.BlockState, *iblockproc.EpochState, error) {
+	if e.es == nil {
+		bs, es, err := e.r.backend.GetEpochBlockState(ctx, e.number)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bs == nil || es == nil {
+			return nil, nil, errEpochNotFound
+		}
+		e.bs, e.es = bs, es
+	}
+	return e.bs, e.es, nil
+}
+
+func (e *Epoch) Number(ctx context.Context) (Long, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(es.Epoch), nil
+}
+
+func (e *Epoch) Start(ctx context.Context) (Long, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(es.EpochStart), nil
+}
+
+func (e *Epoch) PrevEpochStart(ctx context.Context) (Long, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(es.PrevEpochStart), nil
+}
+
+func (e *Epoch) StateRoot(ctx context.Context) (common.Hash, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(es.EpochStateRoot), nil
+}
+
+func (e *Epoch) LastBlock(ctx context.Context) (*Block, error) {
+	bs, _, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(bs.LastBlock.Idx))
+	return &Block{
+		r:            e.r,
+		numberOrHash: &numberOrHash,
+		hash:         common.Hash(bs.LastBlock.Atropos),
+	}, nil
+}
+
+func (e *Epoch) TotalWeight(ctx context.Context) (Long, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(es.Validators.TotalWeight()), nil
+}
+
+func (e *Epoch) Validators(ctx context.Context) ([]*Validator, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := es.Epoch == e.r.backend.CurrentEpoch(ctx)
+	ret := make([]*Validator, 0, es.Validators.Len())
+	for _, id := range es.Validators.SortedIDs() {
+		ret = append(ret, &Validator{
+			r:       e.r,
+			id:      id,
+			weight:  Long(es.Validators.Get(id)),
+			profile: es.ValidatorProfiles[id],
+			current: current,
+		})
+	}
+	return ret, nil
+}
+
+func (e *Epoch) LlrResult(ctx context.Context) (*common.Hash, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ev := e.r.backend.GetLlrEpochResult(ctx, es.Epoch)
+	if ev == nil {
+		return nil, nil
+	}
+	res := common.Hash(*ev)
+	return &res, nil
+}
+
+func (e *Epoch) Heads(ctx context.Context) ([]*Event, error) {
+	_, es, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := e.r.backend.GetHeads(ctx, rpc.BlockNumber(es.Epoch))
+	if err != nil {
+		return nil, err
+	}
+	return e.r.events(heads), nil
+}
+
+// Validator represents a validator of an epoch.
+type Validator struct {
+	r       *Resolver
+	id      idx.ValidatorID
+	weight  Long
+	profile drivertype.Validator
+	current bool // if the validator belongs to the current epoch
+}
+
+func (v *Validator) Id(ctx context.Context) Long {
+	return Long(v.id)
+}
+
+func (v *Validator) Weight(ctx context.Context) Long {
+	return v.weight
+}
+
+func (v *Validator) Stake(ctx context.Context) (hexutil.Big, error) {
+	if v.profile.Weight == nil {
+		return hexutil.Big{}, nil
+	}
+	return hexutil.Big(*v.profile.Weight), nil
+}
+
+func (v *Validator) Pubkey(ctx context.Context) hexutil.Bytes {
+	return v.profile.PubKey.Bytes()
+}
+
+// Downtime represents the validator downtime in the current epoch.
+type Downtime struct {
+	blocks Long
+	time   Long
+}
+
+func (d *Downtime) OfflineBlocks() Long {
+	return d.blocks
+}
+
+func (d *Downtime) OfflineTime() Long {
+	return d.time
+}
+
+func (v *Validator) Downtime(ctx context.Context) (*Downtime, error) {
+	if !v.current {
+		return nil, nil
+	}
+	res, err := v.r.abft.GetDowntime(ctx, hexutil.Uint(v.id))
+	if err != nil {
+		return nil, err
+	}
+	return &Downtime{
+		blocks: Long(res["offlineBlocks"].(hexutil.Uint64)),
+		time:   Long(res["offlineTime"].(hexutil.Uint64)),
+	}, nil
+}
+
+func (v *Validator) Uptime(ctx context.Context) (*Long, error) {
+	if !v.current {
+		return nil, nil
+	}
+	uptime, err := v.r.abft.GetEpochUptime(ctx, hexutil.Uint(v.id))
+	if err != nil {
+		return nil, err
+	}
+	res := Long(uptime)
+	return &res, nil
+}
+
+func (v *Validator) OriginatedFee(ctx context.Context) (*hexutil.Big, error) {
+	if !v.current {
+		return nil, nil
+	}
+	return v.r.abft.GetOriginatedEpochFee(ctx, hexutil.Uint(v.id))
+}
+
+// Event represents a Lachesis DAG event.
+// r and id are mandatory, the event and its payload are fetched when required.
+type Event struct {
+	r       *Resolver
+	id      hash.Event
+	event   *inter.Event
+	payload *inter.EventPayload
+}
+
+func (e *Event) resolve(ctx context.Context) (*inter.Event, error) {
+	if e.event == nil {
+		event, err := e.r.backend.GetEvent(ctx, e.id.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return nil, errors.New("event is not found")
+		}
+		e.event = event
+	}
+	return e.event, nil
+}
+
+func (e *Event) resolvePayload(ctx context.Context) (*inter.EventPayload, error) {
+	if e.payload == nil {
+		payload, err := e.r.backend.GetEventPayload(ctx, e.id.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if payload == nil {
+			return nil, errors.New("event is not found")
+		}
+		e.payload = payload
+		e.event = &payload.Event
+	}
+	return e.payload, nil
+}
+
+func (e *Event) Id(ctx context.Context) common.Hash {
+	return common.Hash(e.id)
+}
+
+func (e *Event) Epoch(ctx context.Context) Long {
+	return Long(e.id.Epoch())
+}
+
+func (e *Event) Lamport(ctx context.Context) Long {
+	return Long(e.id.Lamport())
+}
+
+func (e *Event) Seq(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.Seq()), nil
+}
+
+func (e *Event) Frame(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.Frame()), nil
+}
+
+func (e *Event) Creator(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.Creator()), nil
+}
+
+func (e *Event) CreationTime(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.CreationTime()), nil
+}
+
+func (e *Event) MedianTime(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.MedianTime()), nil
+}
+
+func (e *Event) Parents(ctx context.Context) ([]*Event, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.r.events(event.Parents()), nil
+}
+
+func (e *Event) PrevEpochHash(ctx context.Context) (*common.Hash, error) {
+	event, err := e.resolve(ctx)
+	if err != nil || event.PrevEpochHash() == nil {
+		return nil, err
+	}
+	res := common.Hash(*event.PrevEpochHash())
+	return &res, nil
+}
+
+func (e *Event) ExtraData(ctx context.Context) (hexutil.Bytes, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return event.Extra(), nil
+}
+
+func (e *Event) PayloadHash(ctx context.Context) (common.Hash, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(event.PayloadHash()), nil
+}
+
+func (e *Event) GasPowerUsed(ctx context.Context) (Long, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(event.GasPowerUsed()), nil
+}
+
+// GasPowerLeft represents the gas power of the event creator left after the event.
+type GasPowerLeft struct {
+	gas inter.GasPowerLeft
+}
+
+func (g *GasPowerLeft) ShortTerm() Long {
+	return Long(g.gas.Gas[inter.ShortTermGas])
+}
+
+func (g *GasPowerLeft) LongTerm() Long {
+	return Long(g.gas.Gas[inter.LongTermGas])
+}
+
+func (e *Event) GasPowerLeft(ctx context.Context) (*GasPowerLeft, error) {
+	event, err := e.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GasPowerLeft{event.GasPowerLeft()}, nil
+}
+
+func (e *Event) Transactions(ctx context.Context) ([]*Transaction, error) {
+	payload, err := e.resolvePayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txs := payload.Txs()
+	ret := make([]*Transaction, 0, len(txs))
+	for _, tx := range txs {
+		ret = append(ret, &Transaction{
+			r:    e.r,
+			hash: tx.Hash(),
+			tx:   tx,
+		})
+	}
+	return ret, nil
+}
+
+// BlockVotes represents the LLR block votes of an event.
+type BlockVotes struct {
+	votes inter.LlrBlockVotes
+}
+
+func (bv *BlockVotes) Start() Long {
+	return Long(bv.votes.Start)
+}
+
+func (bv *BlockVotes) Epoch() Long {
+	return Long(bv.votes.Epoch)
+}
+
+func (bv *BlockVotes) Votes() []common.Hash {
+	res := make([]common.Hash, len(bv.votes.Votes))
+	for i, v := range bv.votes.Votes {
+		res[i] = common.Hash(v)
+	}
+	return res
+}
+
+func (e *Event) BlockVotes(ctx context.Context) (*BlockVotes, error) {
+	event, err := e.resolve(ctx)
+	if err != nil || !event.AnyBlockVotes() {
+		return nil, err
+	}
+	payload, err := e.resolvePayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockVotes{payload.BlockVotes()}, nil
+}
+
+// EpochVote represents the LLR epoch vote of an event.
+type EpochVote struct {
+	vote inter.LlrEpochVote
+}
+
+func (ev *EpochVote) Epoch() Long {
+	return Long(ev.vote.Epoch)
+}
+
+func (ev *EpochVote) Vote() common.Hash {
+	return common.Hash(ev.vote.Vote)
+}
+
+func (e *Event) EpochVote(ctx context.Context) (*EpochVote, error) {
+	event, err := e.resolve(ctx)
+	if err != nil || !event.AnyEpochVote() {
+		return nil, err
+	}
+	payload, err := e.resolvePayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &EpochVote{payload.EpochVote()}, nil
+}
+
+func (b *Block) Epoch(ctx context.Context) (*Epoch, error) {
+	h, err := b.Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Epoch{
+		r:      b.r,
+		number: rpc.BlockNumber(hash.Event(h).Epoch()),
+	}, nil
+}
+
+func (b *Block) Atropos(ctx context.Context) (*Event, error) {
+	h, err := b.Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		r:  b.r,
+		id: hash.Event(h),
+	}, nil
+}
+
+func (b *Block) LlrResult(ctx context.Context) (*common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bv := b.r.backend.GetLlrBlockResult(ctx, idx.Block(header.Number.Uint64()))
+	if bv == nil {
+		return nil, nil
+	}
+	res := common.Hash(*bv)
+	return &res, nil
+}
+
+func (r *Resolver) events(ids hash.Events) []*Event {
+	ret := make([]*Event, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, &Event{
+			r:  r,
+			id: id,
+		})
+	}
+	return ret
+}
+
+func (r *Resolver) Epoch(ctx context.Context, args struct{ Number *Long }) (*Epoch, error) {
+	number := rpc.LatestBlockNumber
+	if args.Number != nil {
+		if *args.Number < 0 {
+			return nil, nil
+		}
+		number = rpc.BlockNumber(*args.Number)
+	}
+	epoch := &Epoch{
+		r:      r,
+		number: number,
+	}
+	// Resolve the states, return nil if the epoch doesn't exist.
+	if _, _, err := epoch.resolve(ctx); err != nil {
+		if err == errEpochNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return epoch, nil
+}
+
+func (r *Resolver) Event(ctx context.Context, args struct{ Id string }) (*Event, error) {
+	event, err := r.backend.GetEvent(ctx, args.Id)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	return &Event{
+		r:     r,
+		id:    event.ID(),
+		event: event,
+	}, nil
+}
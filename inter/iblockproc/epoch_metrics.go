@@ -0,0 +1,20 @@
+package iblockproc
+
+import (
+	"math/big"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+// ValidatorEpochMetrics is the performance of a validator in an epoch.
+// Downtime, uptime and originated fee are the values passed to the driver contract on the epoch sealing.
+type ValidatorEpochMetrics struct {
+	MissedBlocks  idx.Block
+	OfflineTime   inter.Timestamp
+	Uptime        inter.Timestamp
+	OriginatedFee *big.Int
+	Events        idx.Event // confirmed events
+	GasPowerUsed  uint64    // gas power used by the confirmed events
+}
@@ -0,0 +1,71 @@
+package lease
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is the lease held in the lease service
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient creates the client of the named lease in the service at the URL
+func NewClient(serviceURL, name string, timeout time.Duration) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(serviceURL, "/") + Path + url.PathEscape(name),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Acquire takes the lease for the holder or renews it
+func (c *Client) Acquire(holder string, ttl time.Duration) (Record, error) {
+	resp, err := c.request(http.MethodPost, Request{Holder: holder, TTL: ttl.Milliseconds()})
+	if err != nil {
+		return Record{}, err
+	}
+	return resp.Record, nil
+}
+
+// Release gives up the lease if it's held by the holder
+func (c *Client) Release(holder string) error {
+	_, err := c.request(http.MethodDelete, Request{Holder: holder})
+	return err
+}
+
+func (c *Client) request(method string, req Request) (Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq, err := http.NewRequest(method, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxRequestSize)).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode the lease service response (status %d): %w", httpResp.StatusCode, err)
+	}
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusConflict:
+		return resp, &HeldError{resp.Record}
+	default:
+		return resp, fmt.Errorf("lease service error (status %d): %s", httpResp.StatusCode, resp.Error)
+	}
+}
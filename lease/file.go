@@ -0,0 +1,95 @@
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File is the lease stored in a file on the storage shared by the nodes.
+// The updates of the file are serialized by an exclusive lock of the adjacent lock file.
+type File struct {
+	path string
+}
+
+// NewFile creates the lease stored in the file
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Acquire takes the lease for the holder or renews it
+func (f *File) Acquire(holder string, ttl time.Duration) (Record, error) {
+	var res Record
+	err := f.update(func(cur Record) (Record, bool, error) {
+		var err error
+		res, err = grant(cur, holder, ttl, time.Now())
+		return res, err == nil, err
+	})
+	return res, err
+}
+
+// Release gives up the lease if it's held by the holder
+func (f *File) Release(holder string) error {
+	return f.update(func(cur Record) (Record, bool, error) {
+		if cur.Holder != holder {
+			return cur, false, nil
+		}
+		return Record{}, true, nil
+	})
+}
+
+// update reads the lease and writes its new state under the lock
+func (f *File) update(fn func(cur Record) (Record, bool, error)) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	unlock, err := lockFile(f.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock the lease file: %w", err)
+	}
+	defer unlock()
+
+	var cur Record
+	data, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, &cur); err != nil {
+			return fmt.Errorf("failed to decode the lease file %s: %w", f.path, err)
+		}
+	}
+	next, changed, err := fn(cur)
+	if err != nil || !changed {
+		return err
+	}
+	return f.write(next)
+}
+
+// write replaces the lease file atomically
+func (f *File) write(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	tmpPath := f.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, f.path)
+}
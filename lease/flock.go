@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package lease
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock of the file, blocks until the lock is released by another process
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package lease
+
+import "errors"
+
+// lockFile isn't supported on Windows, the lease service has to be used instead
+func lockFile(path string) (func(), error) {
+	return nil, errors.New("lease files aren't supported on Windows")
+}
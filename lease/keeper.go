@@ -0,0 +1,169 @@
+package lease
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/mrmikeo/Xpense/logger"
+)
+
+var leaderGauge = metrics.GetOrRegisterGauge("lease/leader", nil)
+
+// Keeper competes for the lease and keeps renewing it while the node is running.
+//
+// The node considers itself the leader only until the local deadline, which passes a quarter of TTL
+// before the lease expires for the other nodes, so that a stalled or partitioned leader stops emitting
+// before a standby may take over. The quarter also covers the drift of the clocks between the nodes
+// sharing a lease file.
+//
+// The callbacks registered by OnLost are run once the leadership is lost, including at the deadline
+// if the lease isn't renewed in time, so that the resources shared with the standby are released
+// even if the node is stalled.
+type Keeper struct {
+	backend Backend
+	holder  string
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	deadline time.Time
+	leading  bool
+	expiry   *time.Timer
+	onLost   []func()
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	logger.Periodic
+}
+
+// NewKeeper creates the keeper of the lease for the holder
+func NewKeeper(backend Backend, holder string, ttl time.Duration) *Keeper {
+	return &Keeper{
+		backend:  backend,
+		holder:   holder,
+		ttl:      ttl,
+		quit:     make(chan struct{}),
+		Periodic: logger.Periodic{Instance: logger.New("lease")},
+	}
+}
+
+// IsLeader tells whether the node holds the lease
+func (k *Keeper) IsLeader() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return time.Now().Before(k.deadline)
+}
+
+// OnLost registers the callback which is run in a separate goroutine each time the leadership is lost
+func (k *Keeper) OnLost(fn func()) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.onLost = append(k.onLost, fn)
+}
+
+// Start starts competing for the lease
+func (k *Keeper) Start() error {
+	k.Log.Info("Competing for the validator lease", "holder", k.holder, "ttl", k.ttl)
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		ticker := time.NewTicker(k.ttl / 4)
+		defer ticker.Stop()
+		for {
+			k.renew()
+			select {
+			case <-ticker.C:
+			case <-k.quit:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops renewing the lease and releases it, so that a standby may take over without waiting for the expiration.
+// The emitter must be stopped before.
+func (k *Keeper) Stop() error {
+	close(k.quit)
+	k.wg.Wait()
+
+	wasLeader := k.IsLeader()
+	k.setDeadline(time.Time{})
+	if wasLeader {
+		if err := k.backend.Release(k.holder); err != nil {
+			k.Log.Warn("Failed to release the validator lease", "err", err)
+			return nil
+		}
+		k.Log.Info("Validator lease is released")
+	}
+	return nil
+}
+
+func (k *Keeper) renew() {
+	wasLeader := k.IsLeader()
+	start := time.Now()
+	rec, err := k.backend.Acquire(k.holder, k.ttl)
+	if err == nil {
+		k.setDeadline(start.Add(k.ttl - k.ttl/4))
+		if !wasLeader {
+			k.Log.Info("Validator lease is acquired", "holder", k.holder, "expires", rec.Expires)
+		}
+		return
+	}
+
+	var held *HeldError
+	if errors.As(err, &held) {
+		k.setDeadline(time.Time{})
+		if wasLeader {
+			k.Log.Warn("Validator lease is taken over", "holder", held.Holder)
+		} else {
+			k.Periodic.Info(time.Minute, "Standing by, the validator lease is held by another node", "holder", held.Holder, "expires", held.Expires)
+		}
+		return
+	}
+	// the leadership expires at the deadline if the lease cannot be renewed
+	k.Periodic.Warn(5*time.Second, "Failed to renew the validator lease", "leader", wasLeader, "err", err)
+}
+
+func (k *Keeper) setDeadline(deadline time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.deadline = deadline
+	if k.expiry != nil {
+		k.expiry.Stop()
+		k.expiry = nil
+	}
+	if deadline.IsZero() {
+		k.lose()
+		return
+	}
+	k.leading = true
+	k.expiry = time.AfterFunc(time.Until(deadline), k.expire)
+	leaderGauge.Update(1)
+}
+
+// expire ends the leadership at the deadline unless the lease is renewed
+func (k *Keeper) expire() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.leading || time.Now().Before(k.deadline) {
+		return
+	}
+	k.Log.Warn("Validator lease isn't renewed in time, the leadership is lost")
+	k.lose()
+}
+
+// lose ends the leadership and runs the callbacks, the lock must be held
+func (k *Keeper) lose() {
+	leaderGauge.Update(0)
+	if !k.leading {
+		return
+	}
+	k.leading = false
+	for _, fn := range k.onLost {
+		go fn()
+	}
+}
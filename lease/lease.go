@@ -0,0 +1,49 @@
+package lease
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Record is the state of a lease
+type Record struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// HeldError is returned if the lease is held by another holder
+type HeldError struct {
+	Record
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("lease is held by %s until %s", e.Holder, e.Expires.Format(time.RFC3339Nano))
+}
+
+// Backend is the storage of the lease shared by the nodes which compete for it
+type Backend interface {
+	// Acquire takes the lease for the holder or renews it, fails with HeldError if another holder has the lease
+	Acquire(holder string, ttl time.Duration) (Record, error)
+	// Release gives up the lease if it's held by the holder
+	Release(holder string) error
+}
+
+// NewBackend creates the lease service client if the location is an HTTP URL, otherwise the lease file at the path
+func NewBackend(location, name string, timeout time.Duration) (Backend, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return NewClient(location, name, timeout), nil
+	}
+	return NewFile(location), nil
+}
+
+// grant applies the lease request to the current state of the lease
+func grant(cur Record, holder string, ttl time.Duration, now time.Time) (Record, error) {
+	if cur.Holder != "" && cur.Holder != holder && now.Before(cur.Expires) {
+		return cur, &HeldError{cur}
+	}
+	return Record{
+		Holder:  holder,
+		Expires: now.Add(ttl),
+	}, nil
+}
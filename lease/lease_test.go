@@ -0,0 +1,126 @@
+package lease
+
+import (
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testBackend(t *testing.T, backend Backend) {
+	require := require.New(t)
+
+	rec, err := backend.Acquire("a", time.Hour)
+	require.NoError(err)
+	require.Equal("a", rec.Holder)
+	require.True(rec.Expires.After(time.Now().Add(59 * time.Minute)))
+
+	// the holder renews the lease
+	_, err = backend.Acquire("a", 50*time.Millisecond)
+	require.NoError(err)
+
+	// another holder waits for the expiration
+	_, err = backend.Acquire("b", time.Hour)
+	var held *HeldError
+	require.True(errors.As(err, &held))
+	require.Equal("a", held.Holder)
+
+	time.Sleep(60 * time.Millisecond)
+	rec, err = backend.Acquire("b", time.Hour)
+	require.NoError(err)
+	require.Equal("b", rec.Holder)
+
+	// only the holder releases the lease
+	require.NoError(backend.Release("a"))
+	_, err = backend.Acquire("a", time.Hour)
+	require.Error(err)
+	require.NoError(backend.Release("b"))
+	_, err = backend.Acquire("a", time.Hour)
+	require.NoError(err)
+}
+
+func TestFile(t *testing.T) {
+	testBackend(t, NewFile(filepath.Join(t.TempDir(), "leases", "validator")))
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(NewServer())
+	t.Cleanup(srv.Close)
+	testBackend(t, NewClient(srv.URL, "validator-1", time.Second))
+
+	// the leases are independent
+	_, err := NewClient(srv.URL, "validator-2", time.Second).Acquire("b", time.Hour)
+	require.NoError(t, err)
+}
+
+func TestKeeper(t *testing.T) {
+	require := require.New(t)
+	backend := NewFile(filepath.Join(t.TempDir(), "lease"))
+	ttl := 200 * time.Millisecond
+
+	active := NewKeeper(backend, "active", ttl)
+	require.NoError(active.Start())
+	require.Eventually(active.IsLeader, time.Second, 10*time.Millisecond)
+
+	standby := NewKeeper(backend, "standby", ttl)
+	require.NoError(standby.Start())
+	time.Sleep(2 * ttl)
+	require.True(active.IsLeader())
+	require.False(standby.IsLeader())
+
+	// the standby takes over once the lease is released
+	require.NoError(active.Stop())
+	require.False(active.IsLeader())
+	require.Eventually(standby.IsLeader, time.Second, 10*time.Millisecond)
+	require.NoError(standby.Stop())
+}
+
+// unreachableBackend fails to renew the lease once it's unreachable
+type unreachableBackend struct {
+	Backend
+	unreachable atomic.Bool
+}
+
+func (b *unreachableBackend) Acquire(holder string, ttl time.Duration) (Record, error) {
+	if b.unreachable.Load() {
+		return Record{}, errors.New("unreachable")
+	}
+	return b.Backend.Acquire(holder, ttl)
+}
+
+func TestKeeperOnLost(t *testing.T) {
+	require := require.New(t)
+	backend := &unreachableBackend{Backend: NewFile(filepath.Join(t.TempDir(), "lease"))}
+	ttl := 200 * time.Millisecond
+
+	lost := make(chan struct{}, 1)
+	keeper := NewKeeper(backend, "active", ttl)
+	keeper.OnLost(func() {
+		lost <- struct{}{}
+	})
+	require.NoError(keeper.Start())
+	require.Eventually(keeper.IsLeader, time.Second, 10*time.Millisecond)
+
+	// the leadership is lost at the deadline without waiting for the renewal to fail
+	backend.unreachable.Store(true)
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("leadership isn't lost at the deadline")
+	}
+	require.False(keeper.IsLeader())
+
+	// the lease is reacquired and released
+	backend.unreachable.Store(false)
+	require.Eventually(keeper.IsLeader, time.Second, 10*time.Millisecond)
+	require.NoError(keeper.Stop())
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("leadership isn't lost on release")
+	}
+}
@@ -0,0 +1,105 @@
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Path is the HTTP path prefix of the leases, followed by the name of a lease
+const Path = "/v1/lease/"
+
+// maxRequestSize is the max size of a lease request
+const maxRequestSize = 4096
+
+// Request is a request to acquire or release a lease
+type Request struct {
+	Holder string `json:"holder"`
+	// TTL is the requested time to live of the lease in milliseconds, ignored by the release
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// Response is the state of the lease, or the reason of a failure
+type Response struct {
+	Record
+	Error string `json:"error,omitempty"`
+}
+
+// Server is the stand-in lease service which keeps the leases in memory.
+// The expiration is decided by the clock of the service, so the clocks of the nodes don't affect it.
+type Server struct {
+	mu     sync.Mutex
+	leases map[string]Record
+}
+
+// NewServer creates the lease service
+func NewServer() *Server {
+	return &Server{
+		leases: make(map[string]Record),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, Path)
+	if !strings.HasPrefix(r.URL.Path, Path) || len(name) == 0 || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, Response{Error: "failed to decode the request: " + err.Error()})
+		return
+	}
+	if len(req.Holder) == 0 {
+		writeResponse(w, http.StatusBadRequest, Response{Error: "holder is required"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		if req.TTL <= 0 {
+			writeResponse(w, http.StatusBadRequest, Response{Error: "ttl must be positive"})
+			return
+		}
+		rec, err := s.acquire(name, req.Holder, time.Duration(req.TTL)*time.Millisecond)
+		var held *HeldError
+		if errors.As(err, &held) {
+			writeResponse(w, http.StatusConflict, Response{Record: rec, Error: err.Error()})
+			return
+		}
+		writeResponse(w, http.StatusOK, Response{Record: rec})
+	case http.MethodDelete:
+		s.release(name, req.Holder)
+		writeResponse(w, http.StatusOK, Response{})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) acquire(name, holder string, ttl time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := grant(s.leases[name], holder, ttl, time.Now())
+	if err == nil {
+		s.leases[name] = rec
+	}
+	return rec, err
+}
+
+func (s *Server) release(name, holder string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[name].Holder == holder {
+		delete(s.leases, name)
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,88 @@
+package rpclimit
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultClass is a cost class of the methods not listed in the config costs
+const defaultClass = "default"
+
+// bucket is a token bucket of a client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(q Quota, now time.Time) *bucket {
+	return &bucket{
+		tokens: q.Burst,
+		last:   now,
+	}
+}
+
+// take takes the cost from the bucket refilled according to the quota.
+// If the bucket has not enough tokens, nothing is taken and the time to wait for the tokens is returned.
+func (b *bucket) take(q Quota, cost float64, now time.Time) (time.Duration, bool) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(q.Burst, b.tokens+elapsed.Seconds()*q.Rate)
+		b.last = now
+	}
+	// a call costlier than the burst is allowed once the bucket is full
+	if cost > q.Burst {
+		cost = q.Burst
+	}
+	if b.tokens < cost {
+		return time.Duration((cost - b.tokens) / q.Rate * float64(time.Second)), false
+	}
+	b.tokens -= cost
+	return 0, true
+}
+
+// costTable resolves the costs of the methods
+type costTable struct {
+	exact map[string]float64
+	// prefixes are ordered from the longest to the shortest one
+	prefixes    []string
+	prefixCosts map[string]float64
+	def         float64
+}
+
+func newCostTable(costs map[string]float64, def float64) *costTable {
+	t := &costTable{
+		exact:       make(map[string]float64),
+		prefixCosts: make(map[string]float64),
+		def:         def,
+	}
+	for pattern, cost := range costs {
+		if strings.HasSuffix(pattern, "*") {
+			prefix := strings.TrimSuffix(pattern, "*")
+			t.prefixes = append(t.prefixes, prefix)
+			t.prefixCosts[prefix] = cost
+		} else {
+			t.exact[pattern] = cost
+		}
+	}
+	sort.Slice(t.prefixes, func(i, j int) bool {
+		if len(t.prefixes[i]) != len(t.prefixes[j]) {
+			return len(t.prefixes[i]) > len(t.prefixes[j])
+		}
+		return t.prefixes[i] < t.prefixes[j]
+	})
+	return t
+}
+
+// cost returns the cost of the method and its cost class, which is the matched pattern of the config
+func (t *costTable) cost(method string) (float64, string) {
+	if cost, ok := t.exact[method]; ok {
+		return cost, method
+	}
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return t.prefixCosts[prefix], prefix + "*"
+		}
+	}
+	return t.def, defaultClass
+}
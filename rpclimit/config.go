@@ -0,0 +1,106 @@
+package rpclimit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Quota is a token bucket of a client: the bucket is refilled with Rate cost units per second up to Burst units,
+// and each call takes its cost from the bucket.
+type Quota struct {
+	Rate  float64
+	Burst float64
+}
+
+// Config is a config of the rate limits of the HTTP-RPC, WebSocket-RPC and GraphQL calls
+type Config struct {
+	// Quota is a quota of each client identified by its IP address, zero rate disables the limits
+	Quota Quota
+	// APIKeys are quotas of the clients identified by the API keys, passed in the X-API-Key header
+	APIKeys map[string]Quota `toml:",omitempty"`
+
+	// Costs are costs of the methods in the quota units.
+	// Keys are the method names or the prefixes ending with "*", the longest matching prefix is used.
+	// GraphQL queries are charged as the "graphql" method.
+	Costs map[string]float64
+	// DefaultCost is a cost of the methods not listed in Costs
+	DefaultCost float64
+
+	// TrustedProxy makes the client IP address taken from the X-Forwarded-For header set by a reverse proxy
+	TrustedProxy bool
+	// MaxClients is a maximum number of the tracked clients, the least recently seen ones are forgotten
+	MaxClients int
+}
+
+// DefaultCosts are the costs of the expensive methods, the cheap methods cost DefaultCost
+func DefaultCosts() map[string]float64 {
+	return map[string]float64{
+		"debug_trace*":                 100,
+		"trace_filter":                 100,
+		"trace_*":                      50,
+		"eth_getLogs":                  20,
+		"graphql":                      20,
+		"ftm_getLogsPage":              20,
+		"ftm_getTransactionsByAddress": 20,
+		"eth_simulateV1":               20,
+		"eth_call":                     5,
+		"eth_estimateGas":              5,
+		"eth_createAccessList":         5,
+		"eth_feeHistory":               5,
+	}
+}
+
+// DefaultConfig returns the default config, the limits are disabled
+func DefaultConfig() Config {
+	return Config{
+		Quota: Quota{
+			Rate:  0,
+			Burst: 1000,
+		},
+		Costs:       DefaultCosts(),
+		DefaultCost: 1,
+		MaxClients:  100000,
+	}
+}
+
+// Enabled tells whether the rate limits are applied
+func (c Config) Enabled() bool {
+	return c.Quota.Rate > 0
+}
+
+func (q Quota) validate() error {
+	if q.Rate <= 0 || q.Burst <= 0 {
+		return errors.New("rate and burst have to be positive")
+	}
+	return nil
+}
+
+// Validate checks the rate limits config
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := c.Quota.validate(); err != nil {
+		return fmt.Errorf("RPC rate limit: %v", err)
+	}
+	for key, quota := range c.APIKeys {
+		if key == "" {
+			return errors.New("RPC rate limit: empty API key")
+		}
+		if err := quota.validate(); err != nil {
+			return fmt.Errorf("RPC rate limit of an API key: %v", err)
+		}
+	}
+	for method, cost := range c.Costs {
+		if cost < 0 {
+			return fmt.Errorf("RPC rate limit: negative cost of %s", method)
+		}
+	}
+	if c.DefaultCost < 0 {
+		return errors.New("RPC rate limit: negative default cost")
+	}
+	if c.MaxClients <= 0 {
+		return errors.New("RPC rate limit: max number of clients has to be positive")
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package rpclimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+const (
+	// maxRequestContentLength is the max size of the HTTP-RPC request, the same as in the RPC server
+	maxRequestContentLength = 1024 * 1024 * 5
+
+	// errcodeLimitExceeded is the JSON-RPC error code of the rejected calls, as defined by EIP-1474
+	errcodeLimitExceeded = -32005
+	// errcodeMethodNotFound is the JSON-RPC error code of the unavailable methods
+	errcodeMethodNotFound = -32601
+
+	apiKeyHeader = "X-API-Key"
+)
+
+var (
+	acceptedMeter = metrics.GetOrRegisterMeter("rpc/limit/accepted", nil)
+	rejectedMeter = metrics.GetOrRegisterMeter("rpc/limit/rejected", nil)
+	clientsGauge  = metrics.GetOrRegisterGauge("rpc/limit/clients", nil)
+)
+
+// jsonrpcCall is a JSON-RPC request decoded enough to identify the called method
+type jsonrpcCall struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+
+	raw json.RawMessage
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *jsonrpcError   `json:"error"`
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{
+		Version: "2.0",
+		ID:      id,
+		Error:   &jsonrpcError{Code: code, Message: message},
+	}
+}
+
+// parseCalls decodes the single call or the batch of calls, ok is false if the body isn't valid JSON
+func parseCalls(body []byte) (calls []jsonrpcCall, batch bool, ok bool) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, true, false
+		}
+		calls = make([]jsonrpcCall, len(raws))
+		for i, raw := range raws {
+			// invalid calls are left for the RPC server to answer
+			_ = json.Unmarshal(raw, &calls[i])
+			calls[i].raw = raw
+		}
+		return calls, true, true
+	}
+	var call jsonrpcCall
+	if err := json.Unmarshal(trimmed, &call); err != nil {
+		return nil, false, false
+	}
+	call.raw = trimmed
+	return []jsonrpcCall{call}, false, true
+}
+
+// Handler is an HTTP-RPC middleware which applies the per-client rate limits according to the methods costs.
+// The calls of the unavailable modules and methods are answered without being passed to the RPC server.
+// Only the availability is checked if the limits are disabled.
+type Handler struct {
+	cfg     Config
+	costs   *costTable
+	modules map[string]bool
+	next    http.Handler
+	// restricted are the namespaces served only by the methods of their public APIs, listed in publicMethods
+	restricted    map[string]bool
+	publicMethods map[string]bool
+
+	mu      sync.Mutex
+	clients *simplelru.LRU // client -> *bucket
+
+	now func() time.Time
+}
+
+// NewHandler creates the rate-limiting middleware over the RPC server.
+// The methods are available only if their namespaces are in modules.
+func NewHandler(cfg Config, modules map[string]bool, next http.Handler) *Handler {
+	clients, _ := simplelru.NewLRU(cfg.MaxClients, nil)
+	return &Handler{
+		cfg:     cfg,
+		costs:   newCostTable(cfg.Costs, cfg.DefaultCost),
+		modules: modules,
+		next:    next,
+		clients: clients,
+		now:     time.Now,
+	}
+}
+
+// restrict makes the namespaces served only by the methods of their public APIs,
+// e.g. the privileged methods of a namespace are served only by the authenticated endpoint
+func (h *Handler) restrict(namespaces []string, apis []rpc.API) {
+	h.restricted = make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		h.restricted[namespace] = true
+	}
+	h.publicMethods = make(map[string]bool)
+	for _, api := range apis {
+		if !api.Public || !h.restricted[api.Namespace] {
+			continue
+		}
+		t := reflect.TypeOf(api.Service)
+		for i := 0; i < t.NumMethod(); i++ {
+			// the method names are formatted as by the RPC server
+			name := []rune(t.Method(i).Name)
+			name[0] = unicode.ToLower(name[0])
+			h.publicMethods[api.Namespace+"_"+string(name)] = true
+		}
+	}
+}
+
+// available tells whether the method is served by the namespaces of modules
+func (h *Handler) available(modules map[string]bool, method string) bool {
+	namespace := method
+	if i := strings.IndexByte(method, '_'); i >= 0 {
+		namespace = method[:i]
+	}
+	// rpc_modules is served by every RPC server
+	if namespace == "rpc" {
+		return true
+	}
+	if !modules[namespace] {
+		return false
+	}
+	return !h.restricted[namespace] || h.publicMethods[method]
+}
+
+// client identifies the client of the request and returns its quota
+func (h *Handler) client(r *http.Request) (string, Quota) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		if quota, ok := h.cfg.APIKeys[key]; ok {
+			return "key:" + key, quota
+		}
+	}
+	if h.cfg.TrustedProxy {
+		// the last address is appended by the trusted proxy, the preceding ones may be forged by the client
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) != 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+				return "ip:" + ip, h.cfg.Quota
+			}
+		}
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip, h.cfg.Quota
+}
+
+// take takes the cost from the bucket of the client
+func (h *Handler) take(client string, quota Quota, cost float64) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	var b *bucket
+	if v, ok := h.clients.Get(client); ok {
+		b = v.(*bucket)
+	} else {
+		b = newBucket(quota, now)
+		h.clients.Add(client, b)
+		clientsGauge.Update(int64(h.clients.Len()))
+	}
+	return b.take(quota, cost, now)
+}
+
+// accept takes the cost of the calls from the bucket of the client and marks the metrics of the cost classes
+func (h *Handler) accept(client string, quota Quota, cost float64, classes []string, maxClass string) (time.Duration, bool) {
+	if !h.cfg.Enabled() {
+		return 0, true
+	}
+	wait, allowed := h.take(client, quota, cost)
+	if !allowed {
+		rejectedMeter.Mark(1)
+		metrics.GetOrRegisterMeter("rpc/limit/rejected/"+maxClass, nil).Mark(1)
+		return wait, false
+	}
+	acceptedMeter.Mark(1)
+	for _, class := range classes {
+		metrics.GetOrRegisterMeter("rpc/limit/accepted/"+class, nil).Mark(1)
+	}
+	return 0, true
+}
+
+// check answers the calls of the unavailable methods and returns the calls to forward to the RPC server,
+// the total cost of the calls, their cost classes and the class of the most expensive call.
+// ok is false if the calls aren't parsed, then they are forwarded to the RPC server to answer with the parse error.
+func (h *Handler) check(modules map[string]bool, calls []jsonrpcCall, ok bool) (forward []jsonrpcCall, answers []*jsonrpcResponse, cost float64, classes []string, maxClass string) {
+	if !ok {
+		return nil, nil, h.cfg.DefaultCost, []string{defaultClass}, defaultClass
+	}
+	maxCost := -1.0
+	for _, call := range calls {
+		if call.Method != "" && !h.available(modules, call.Method) {
+			answers = append(answers, errorResponse(call.ID, errcodeMethodNotFound,
+				fmt.Sprintf("the method %s does not exist/is not available", call.Method)))
+			continue
+		}
+		forward = append(forward, call)
+		c, class := h.costs.cost(call.Method)
+		cost += c
+		classes = append(classes, class)
+		if c > maxCost {
+			maxCost, maxClass = c, class
+		}
+	}
+	return forward, answers, cost, classes, maxClass
+}
+
+// Limit returns the HTTP middleware which charges each request with the cost of the method,
+// e.g. the GraphQL queries are charged as the "graphql" method.
+func (h *Handler) Limit(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			// CORS preflights are free
+			next.ServeHTTP(w, r)
+			return
+		}
+		cost, class := h.costs.cost(method)
+		client, quota := h.client(r)
+		if wait, allowed := h.accept(client, quota, cost, []string{class}, class); !allowed {
+			retryAfter := retryAfterSeconds(wait)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, limitExceededMessage(retryAfter), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		// health checks and CORS preflights are free
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestContentLength+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestContentLength {
+		http.Error(w, fmt.Sprintf("content length too large (>%d)", maxRequestContentLength), http.StatusRequestEntityTooLarge)
+		return
+	}
+	calls, batch, ok := parseCalls(body)
+	forward, answers, cost, classes, maxClass := h.check(h.modules, calls, ok)
+
+	client, quota := h.client(r)
+	if wait, allowed := h.accept(client, quota, cost, classes, maxClass); !allowed {
+		h.reject(w, calls, batch, wait)
+		return
+	}
+
+	switch {
+	case len(answers) == 0:
+		h.forward(w, r, body)
+	case len(forward) == 0:
+		if batch {
+			writeJSON(w, http.StatusOK, answers)
+		} else {
+			writeJSON(w, http.StatusOK, answers[0])
+		}
+	default:
+		h.forwardBatch(w, r, forward, answers)
+	}
+}
+
+func (h *Handler) forward(w http.ResponseWriter, r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	h.next.ServeHTTP(w, r)
+}
+
+// forwardBatch passes the available calls of the batch to the RPC server and merges its responses with the answers
+func (h *Handler) forwardBatch(w http.ResponseWriter, r *http.Request, forward []jsonrpcCall, answers []*jsonrpcResponse) {
+	raws := make([]json.RawMessage, len(forward))
+	for i, call := range forward {
+		raws[i] = call.raw
+	}
+	body, _ := json.Marshal(raws)
+
+	rec := newResponseRecorder()
+	h.forward(rec, r, body)
+
+	var responses []json.RawMessage
+	if rec.status != http.StatusOK || json.Unmarshal(rec.body.Bytes(), &responses) != nil {
+		rec.flush(w)
+		return
+	}
+	for _, answer := range answers {
+		raw, _ := json.Marshal(answer)
+		responses = append(responses, raw)
+	}
+	writeJSON(w, http.StatusOK, responses)
+}
+
+// reject answers all the calls with the limit exceeded error
+func (h *Handler) reject(w http.ResponseWriter, calls []jsonrpcCall, batch bool, wait time.Duration) {
+	retryAfter := retryAfterSeconds(wait)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeJSON(w, http.StatusTooManyRequests, rejection(calls, batch, retryAfter))
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return retryAfter
+}
+
+func limitExceededMessage(retryAfter int) string {
+	return fmt.Sprintf("request rate limit exceeded, retry in %ds", retryAfter)
+}
+
+// rejection returns the limit exceeded errors of all the calls
+func rejection(calls []jsonrpcCall, batch bool, retryAfter int) interface{} {
+	message := limitExceededMessage(retryAfter)
+	if !batch {
+		var id json.RawMessage
+		if len(calls) != 0 {
+			id = calls[0].ID
+		}
+		return errorResponse(id, errcodeLimitExceeded, message)
+	}
+	responses := make([]*jsonrpcResponse, len(calls))
+	for i, call := range calls {
+		responses[i] = errorResponse(call.ID, errcodeLimitExceeded, message)
+	}
+	return responses
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// responseRecorder buffers the response of the RPC server
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: make(http.Header),
+		status: http.StatusOK,
+	}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+// flush writes the buffered response as is
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -0,0 +1,331 @@
+package rpclimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type testService struct{}
+
+func (testService) BlockNumber() uint64 { return 1 }
+
+func (testService) TraceFilter() string { return "trace" }
+
+func testHandler(t *testing.T, cfg Config) (*Handler, *time.Time) {
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("eth", testService{}))
+	require.NoError(t, srv.RegisterName("trace", testService{}))
+	require.NoError(t, srv.RegisterName("personal", testService{}))
+	t.Cleanup(srv.Stop)
+
+	h := NewHandler(cfg, map[string]bool{"eth": true, "trace": true}, srv)
+	now := time.Unix(1000, 0)
+	h.now = func() time.Time {
+		return now
+	}
+	return h, &now
+}
+
+func call(h http.Handler, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.RemoteAddr = "10.0.0.1:1234"
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Quota = Quota{Rate: 10, Burst: 100}
+	cfg.Costs = map[string]float64{
+		"trace_*":           50,
+		"trace_traceFilter": 80,
+	}
+	return cfg
+}
+
+func TestCostTable(t *testing.T) {
+	require := require.New(t)
+
+	costs := newCostTable(map[string]float64{
+		"debug_*":      10,
+		"debug_trace*": 100,
+		"eth_call":     5,
+	}, 1)
+	for method, expect := range map[string]struct {
+		cost  float64
+		class string
+	}{
+		"eth_call":               {5, "eth_call"},
+		"eth_callMany":           {1, defaultClass},
+		"debug_traceTransaction": {100, "debug_trace*"},
+		"debug_getBadBlocks":     {10, "debug_*"},
+		"eth_blockNumber":        {1, defaultClass},
+	} {
+		cost, class := costs.cost(method)
+		require.Equal(expect.cost, cost, method)
+		require.Equal(expect.class, class, method)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	require := require.New(t)
+
+	q := Quota{Rate: 10, Burst: 100}
+	now := time.Unix(1000, 0)
+	b := newBucket(q, now)
+
+	_, ok := b.take(q, 60, now)
+	require.True(ok)
+	wait, ok := b.take(q, 60, now)
+	require.False(ok)
+	require.Equal(2*time.Second, wait)
+
+	now = now.Add(2 * time.Second)
+	_, ok = b.take(q, 60, now)
+	require.True(ok)
+
+	// a call costlier than the burst needs the full bucket
+	now = now.Add(time.Hour)
+	_, ok = b.take(q, 1000, now)
+	require.True(ok)
+	_, ok = b.take(q, 1, now)
+	require.False(ok)
+}
+
+func TestHandlerLimits(t *testing.T) {
+	require := require.New(t)
+
+	h, now := testHandler(t, testConfig())
+
+	// cheap calls
+	w := call(h, `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	require.Contains(w.Body.String(), `"result":1`)
+
+	// expensive call exhausts the quota
+	w = call(h, `{"jsonrpc":"2.0","id":2,"method":"trace_traceFilter","params":[]}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	require.Contains(w.Body.String(), `"result":"trace"`)
+	w = call(h, `{"jsonrpc":"2.0","id":3,"method":"trace_traceFilter","params":[]}`, nil)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	require.Equal("7", w.Header().Get("Retry-After"))
+	var resp jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(json.RawMessage("3"), resp.ID)
+	require.Equal(errcodeLimitExceeded, resp.Error.Code)
+
+	// other clients have their own quotas
+	w = call(h, `{"jsonrpc":"2.0","id":4,"method":"trace_traceFilter","params":[]}`, map[string]string{"X-Forwarded-For": "10.0.0.2"})
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	h.cfg.TrustedProxy = true
+	w = call(h, `{"jsonrpc":"2.0","id":4,"method":"trace_traceFilter","params":[]}`, map[string]string{"X-Forwarded-For": "1.1.1.1, 10.0.0.2"})
+	require.Equal(http.StatusOK, w.Code)
+	h.cfg.TrustedProxy = false
+
+	// the quota is refilled
+	*now = now.Add(7 * time.Second)
+	w = call(h, `{"jsonrpc":"2.0","id":5,"method":"trace_traceFilter","params":[]}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+
+	// batches are charged for all the calls
+	*now = now.Add(time.Hour)
+	w = call(h, `{"jsonrpc":"2.0","id":5,"method":"trace_filter","params":[]}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	w = call(h, `[{"jsonrpc":"2.0","id":6,"method":"trace_traceFilter","params":[]},{"jsonrpc":"2.0","id":7,"method":"trace_traceFilter","params":[]}]`, nil)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	var batch []jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(batch, 2)
+	require.Equal(errcodeLimitExceeded, batch[1].Error.Code)
+}
+
+func TestHandlerAPIKeys(t *testing.T) {
+	require := require.New(t)
+
+	cfg := testConfig()
+	cfg.APIKeys = map[string]Quota{
+		"secret": {Rate: 100, Burst: 1000},
+	}
+	h, _ := testHandler(t, cfg)
+
+	for i := 0; i < 10; i++ {
+		w := call(h, `{"jsonrpc":"2.0","id":1,"method":"trace_traceFilter","params":[]}`, map[string]string{apiKeyHeader: "secret"})
+		require.Equal(http.StatusOK, w.Code)
+	}
+	// unknown keys are limited by IP
+	w := call(h, `{"jsonrpc":"2.0","id":1,"method":"trace_traceFilter","params":[]}`, map[string]string{apiKeyHeader: "unknown"})
+	require.Equal(http.StatusOK, w.Code)
+	w = call(h, `{"jsonrpc":"2.0","id":1,"method":"trace_traceFilter","params":[]}`, map[string]string{apiKeyHeader: "unknown"})
+	require.Equal(http.StatusTooManyRequests, w.Code)
+}
+
+func TestHandlerModules(t *testing.T) {
+	require := require.New(t)
+
+	h, _ := testHandler(t, testConfig())
+
+	w := call(h, `{"jsonrpc":"2.0","id":1,"method":"personal_blockNumber","params":[]}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	var resp jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(errcodeMethodNotFound, resp.Error.Code)
+
+	// the available calls of a mixed batch are served
+	w = call(h, `[{"jsonrpc":"2.0","id":1,"method":"personal_blockNumber","params":[]},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber","params":[]}]`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	var batch []map[string]interface{}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(batch, 2)
+	results := map[float64]bool{}
+	for _, r := range batch {
+		_, ok := r["result"]
+		results[r["id"].(float64)] = ok
+	}
+	require.Equal(map[float64]bool{1: false, 2: true}, results)
+}
+
+type testPrivateService struct{}
+
+func (testPrivateService) SetHead() string { return "set" }
+
+func TestHandlerRestricted(t *testing.T) {
+	require := require.New(t)
+
+	srv := rpc.NewServer()
+	require.NoError(srv.RegisterName("debug", testService{}))
+	require.NoError(srv.RegisterName("debug", testPrivateService{}))
+	t.Cleanup(srv.Stop)
+	apis := []rpc.API{
+		{Namespace: "debug", Service: testService{}, Public: true},
+		{Namespace: "debug", Service: testPrivateService{}},
+	}
+
+	h := NewHandler(DefaultConfig(), map[string]bool{"debug": true}, srv)
+	w := call(h, `{"jsonrpc":"2.0","id":1,"method":"debug_setHead","params":[]}`, nil)
+	require.Contains(w.Body.String(), `"result":"set"`)
+
+	// only the methods of the public APIs are served in the restricted namespace
+	h.restrict([]string{"debug"}, apis)
+	w = call(h, `{"jsonrpc":"2.0","id":2,"method":"debug_traceFilter","params":[]}`, nil)
+	require.Contains(w.Body.String(), `"result":"trace"`)
+	w = call(h, `{"jsonrpc":"2.0","id":3,"method":"debug_setHead","params":[]}`, nil)
+	var resp jsonrpcResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(errcodeMethodNotFound, resp.Error.Code)
+}
+
+func TestHandlerLimit(t *testing.T) {
+	require := require.New(t)
+
+	cfg := testConfig()
+	cfg.Costs["graphql"] = 60
+	h, _ := testHandler(t, cfg)
+	limited := h.Limit("graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := call(limited, `{"query":"{ block { number } }"}`, nil)
+	require.Equal(http.StatusOK, w.Code)
+	w = call(limited, `{"query":"{ block { number } }"}`, nil)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+	require.Equal("2", w.Header().Get("Retry-After"))
+
+	// the quota is shared with the RPC calls
+	w = call(h, `{"jsonrpc":"2.0","id":1,"method":"trace_filter","params":[]}`, nil)
+	require.Equal(http.StatusTooManyRequests, w.Code)
+}
+
+func TestWSHandler(t *testing.T) {
+	require := require.New(t)
+
+	h, _ := testHandler(t, testConfig())
+	server := httptest.NewServer(NewWSHandler(h, map[string]bool{"eth": true, "trace": true}, nil, h.next.(*rpc.Server)))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(err)
+	defer conn.Close()
+
+	send := func(msg string) {
+		require.NoError(conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+	}
+	read := func() string {
+		_, data, err := conn.ReadMessage()
+		require.NoError(err)
+		return string(data)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"trace_traceFilter","params":[]}`)
+	require.Contains(read(), `"result":"trace"`)
+
+	// the unavailable calls are answered, the rest of the batch is served
+	send(`{"jsonrpc":"2.0","id":2,"method":"personal_blockNumber","params":[]}`)
+	var resp jsonrpcResponse
+	require.NoError(json.Unmarshal([]byte(read()), &resp))
+	require.Equal(errcodeMethodNotFound, resp.Error.Code)
+	send(`[{"jsonrpc":"2.0","id":3,"method":"personal_blockNumber","params":[]},{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber","params":[]}]`)
+	require.Contains(read(), `"id":3`)
+	require.Contains(read(), `"result":1`)
+
+	// the quota is exhausted, the messages are rejected without closing the connection
+	send(`{"jsonrpc":"2.0","id":5,"method":"trace_traceFilter","params":[]}`)
+	require.NoError(json.Unmarshal([]byte(read()), &resp))
+	require.Equal(json.RawMessage("5"), resp.ID)
+	require.Equal(errcodeLimitExceeded, resp.Error.Code)
+	send(`{"jsonrpc":"2.0","id":6,"method":"eth_blockNumber","params":[]}`)
+	require.Contains(read(), `"result":1`)
+}
+
+func TestAvailableModules(t *testing.T) {
+	require := require.New(t)
+
+	apis := []rpc.API{
+		{Namespace: "eth", Public: true},
+		{Namespace: "debug", Public: true},
+		{Namespace: "debug", Public: false},
+		{Namespace: "personal", Public: false},
+	}
+	require.Equal(map[string]bool{"eth": true, "web3": true}, availableModules(nil, apis))
+	require.Equal(map[string]bool{"eth": true, "debug": true}, availableModules([]string{"eth", "debug"}, apis))
+}
+
+func TestPathHandler(t *testing.T) {
+	require := require.New(t)
+
+	h, _ := testHandler(t, testConfig())
+	// the quota is exhausted by a single call
+	h.cfg.Quota = Quota{Rate: 0.001, Burst: 1}
+
+	post := func(handler http.Handler, path string) int {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// every path served by the node HTTP-RPC under the prefix is limited
+	prefixed := pathHandler{"/rpc", h}
+	require.Equal(http.StatusOK, post(prefixed, "/rpc"))
+	for _, path := range []string{"/rpc", "/rpc/", "/rpc/anything", "/rpcx"} {
+		require.Equal(http.StatusTooManyRequests, post(prefixed, path), path)
+	}
+	require.Equal(http.StatusNotFound, post(prefixed, "/other"))
+
+	root := pathHandler{"", h}
+	require.Equal(http.StatusTooManyRequests, post(root, "/"))
+	require.Equal(http.StatusNotFound, post(root, "/other"))
+}
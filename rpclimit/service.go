@@ -0,0 +1,185 @@
+package rpclimit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// nodePublicModules are the namespaces of the node built-in public APIs
+var nodePublicModules = []string{"web3"}
+
+// availableModules returns the namespaces served over HTTP-RPC.
+// As the node, only the public APIs are served if no modules are configured,
+// so the namespaces mixing the public and the private APIs are not served.
+func availableModules(modules []string, apis []rpc.API) map[string]bool {
+	available := make(map[string]bool)
+	if len(modules) != 0 {
+		for _, module := range modules {
+			available[module] = true
+		}
+		return available
+	}
+	private := make(map[string]bool)
+	for _, api := range apis {
+		if api.Public {
+			available[api.Namespace] = true
+		} else {
+			private[api.Namespace] = true
+		}
+	}
+	for namespace := range private {
+		delete(available, namespace)
+	}
+	for _, namespace := range nodePublicModules {
+		available[namespace] = true
+	}
+	return available
+}
+
+// pathHandler serves the requests of the paths served by the node HTTP-RPC server:
+// the root path only if the prefix is empty, or any path starting with the prefix otherwise
+type pathHandler struct {
+	prefix string
+	next   http.Handler
+}
+
+func (h pathHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.prefix == "" && r.URL.Path != "" && r.URL.Path != "/" || !strings.HasPrefix(r.URL.Path, h.prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// upgradeHandler passes the WebSocket upgrade requests to the WebSocket-RPC server and the other requests to next
+type upgradeHandler struct {
+	ws   http.Handler
+	next http.Handler
+}
+
+func (h upgradeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebsocket(r) {
+		h.ws.ServeHTTP(w, r)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// WSConfig is a config of the WebSocket-RPC endpoint taken over from the node
+type WSConfig struct {
+	Host       string
+	Port       int
+	PathPrefix string
+	Origins    []string
+	Modules    []string
+}
+
+// TakeWS takes the WebSocket-RPC endpoint over from the node config, so that the node doesn't serve it unchecked.
+// The endpoint is served by Register with the rate limits and the methods restrictions applied.
+func TakeWS(nodeCfg *node.Config) WSConfig {
+	ws := WSConfig{
+		Host:       nodeCfg.WSHost,
+		Port:       nodeCfg.WSPort,
+		PathPrefix: nodeCfg.WSPathPrefix,
+		Origins:    nodeCfg.WSOrigins,
+		Modules:    nodeCfg.WSModules,
+	}
+	nodeCfg.WSHost = ""
+	return ws
+}
+
+// sharesHTTP tells whether the WebSocket-RPC endpoint is on the port of the HTTP-RPC endpoint
+func (ws WSConfig) sharesHTTP(nodeCfg *node.Config) bool {
+	return nodeCfg.HTTPHost != "" && ws.Host == nodeCfg.HTTPHost && ws.Port == nodeCfg.HTTPPort
+}
+
+// Register registers the rate-limited HTTP-RPC handler on the node HTTP server in place of the default one,
+// and the rate-limited WebSocket-RPC endpoint taken over from the node by TakeWS, if any.
+// The handlers serve the APIs of the node modules, apis are the APIs registered on the node.
+// The restricted namespaces are served only by the methods of their public APIs.
+// The returned limiter applies the same limits to the other HTTP handlers, e.g. GraphQL.
+func Register(stack *node.Node, nodeCfg *node.Config, ws WSConfig, cfg Config, apis []rpc.API, restricted []string) (*Handler, error) {
+	srv, err := stack.RPCHandler()
+	if err != nil {
+		return nil, err
+	}
+	limiter := NewHandler(cfg, availableModules(nodeCfg.HTTPModules, apis), srv)
+	limiter.restrict(restricted, apis)
+	var wsHandler http.Handler
+	if ws.Host != "" {
+		wsHandler = NewWSHandler(limiter, availableModules(ws.Modules, apis), ws.Origins, srv)
+		if !ws.sharesHTTP(nodeCfg) {
+			stack.RegisterLifecycle(newWSService(ws, wsHandler))
+			wsHandler = nil
+		} else if ws.PathPrefix != nodeCfg.HTTPPathPrefix {
+			return nil, errors.New("rate-limited WebSocket-RPC on the HTTP-RPC port has to have the same path prefix")
+		}
+	}
+	if nodeCfg.HTTPHost == "" {
+		return limiter, nil
+	}
+	var handler http.Handler = node.NewHTTPHandlerStack(limiter, nodeCfg.HTTPCors, nodeCfg.HTTPVirtualHosts)
+	if wsHandler != nil {
+		// the WebSocket-RPC shares the endpoint, as the node serves it
+		handler = upgradeHandler{wsHandler, handler}
+	}
+
+	// the mux root pattern catches all the paths not served by the other handlers,
+	// so the requests never reach the unlimited node HTTP-RPC handler whatever the prefix is
+	prefix := nodeCfg.HTTPPathPrefix
+	if prefix == "/" {
+		prefix = ""
+	}
+	stack.RegisterHandler("Public RPC", "/", pathHandler{prefix, handler})
+	return limiter, nil
+}
+
+// wsService is the rate-limited WebSocket-RPC endpoint served on its own listener
+type wsService struct {
+	cfg     WSConfig
+	handler http.Handler
+
+	server   *http.Server
+	listener net.Listener
+}
+
+func newWSService(cfg WSConfig, handler http.Handler) *wsService {
+	path := strings.TrimSuffix(cfg.PathPrefix, "/")
+	return &wsService{
+		cfg:     cfg,
+		handler: pathHandler{path, handler},
+	}
+}
+
+// Start opens the listener of the endpoint
+func (s *wsService) Start() error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to open the public WebSocket-RPC endpoint: %w", err)
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s.handler}
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	log.Info("Public WebSocket-RPC endpoint opened", "url", "ws://"+listener.Addr().String()+s.cfg.PathPrefix)
+	return nil
+}
+
+// Stop closes the listener and the connections of the endpoint
+func (s *wsService) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	log.Info("Public WebSocket-RPC endpoint closed", "url", "ws://"+s.listener.Addr().String()+s.cfg.PathPrefix)
+	return err
+}
@@ -0,0 +1,195 @@
+package rpclimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsMessageSizeLimit, wsPingInterval and wsWriteTimeout are the same as in the RPC server
+	wsMessageSizeLimit = 15 * 1024 * 1024
+	wsPingInterval     = 30 * time.Second
+	wsWriteTimeout     = 10 * time.Second
+)
+
+// WSHandler serves the WebSocket-RPC connections, the limits of the Handler are applied to each message.
+// The rejected calls and the calls of the unavailable modules are answered without being passed to the RPC server.
+// If a batch mixes the available and the unavailable calls, the latter are answered by a separate batch.
+type WSHandler struct {
+	limiter  *Handler
+	modules  map[string]bool
+	srv      *rpc.Server
+	upgrader websocket.Upgrader
+}
+
+// NewWSHandler creates the rate-limited WebSocket-RPC server, the clients quotas are shared with the limiter.
+// The methods are available only if their namespaces are in modules.
+func NewWSHandler(limiter *Handler, modules map[string]bool, origins []string, srv *rpc.Server) *WSHandler {
+	return &WSHandler{
+		limiter: limiter,
+		modules: modules,
+		srv:     srv,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     originValidator(origins),
+		},
+	}
+}
+
+// originValidator allows the connections from the allowed origins, as the RPC server.
+// The localhost origins are allowed if no origins are configured, "*" allows any origin.
+func originValidator(allowed []string) func(r *http.Request) bool {
+	origins := make(map[string]bool)
+	for _, origin := range allowed {
+		origins[strings.ToLower(origin)] = true
+	}
+	if len(origins) == 0 {
+		origins["http://localhost"] = true
+	}
+	return func(r *http.Request) bool {
+		if _, ok := r.Header["Origin"]; !ok {
+			// non-browser clients
+			return true
+		}
+		origin := strings.ToLower(r.Header.Get("Origin"))
+		if origins["*"] || origins[origin] {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && (origins[u.Host] || origins[u.Hostname()] || origins[u.Scheme+"://"+u.Hostname()]) {
+			return true
+		}
+		log.Warn("Rejected WebSocket connection", "origin", origin)
+		return false
+	}
+}
+
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+func (h *WSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("WebSocket upgrade failed", "err", err)
+		return
+	}
+	client, quota := h.limiter.client(r)
+	c := newWSConn(h, conn, client, quota)
+	h.srv.ServeCodec(rpc.NewFuncCodec(c, c.writeJSON, c.readJSON), 0)
+}
+
+// wsConn is a WebSocket connection of a client which checks the incoming messages before they reach the RPC server
+type wsConn struct {
+	h      *WSHandler
+	conn   *websocket.Conn
+	client string
+	quota  Quota
+
+	writeMu sync.Mutex
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newWSConn(h *WSHandler, conn *websocket.Conn, client string, quota Quota) *wsConn {
+	conn.SetReadLimit(wsMessageSizeLimit)
+	c := &wsConn{
+		h:      h,
+		conn:   conn,
+		client: client,
+		quota:  quota,
+		closed: make(chan struct{}),
+	}
+	go c.pingLoop()
+	return c
+}
+
+// readJSON reads the next message to pass to the RPC server.
+// The rejected messages are answered and skipped, the unavailable calls are answered and removed from the batches.
+func (c *wsConn) readJSON(v interface{}) error {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		calls, batch, ok := parseCalls(data)
+		forward, answers, cost, classes, maxClass := c.h.limiter.check(c.h.modules, calls, ok)
+		if wait, allowed := c.h.limiter.accept(c.client, c.quota, cost, classes, maxClass); !allowed {
+			if err := c.writeJSON(rejection(calls, batch, retryAfterSeconds(wait))); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(answers) == 0 {
+			// the invalid messages are left for the RPC server to answer
+			return json.Unmarshal(data, v)
+		}
+		var answer interface{} = answers
+		if !batch {
+			answer = answers[0]
+		}
+		if err := c.writeJSON(answer); err != nil {
+			return err
+		}
+		if len(forward) == 0 {
+			continue
+		}
+		raws := make([]json.RawMessage, len(forward))
+		for i, call := range forward {
+			raws[i] = call.raw
+		}
+		data, _ = json.Marshal(raws)
+		return json.Unmarshal(data, v)
+	}
+}
+
+// writeJSON writes the message, the writes of the RPC server and of the answers are serialized
+func (c *wsConn) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(v)
+}
+
+// pingLoop sends the periodic pings to keep the connection alive
+func (c *wsConn) pingLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			_ = c.conn.WriteMessage(websocket.PingMessage, nil)
+			c.writeMu.Unlock()
+		}
+	}
+}
+
+// SetWriteDeadline does nothing, the deadline is set by every write under the write lock
+func (c *wsConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// RemoteAddr returns the client, it's logged by the RPC server
+func (c *wsConn) RemoteAddr() string {
+	return c.client
+}
+
+func (c *wsConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return c.conn.Close()
+}
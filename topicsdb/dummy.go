@@ -14,10 +14,18 @@ func (n dummyIndex) FindInBlocks(ctx context.Context, from, to idx.Block, patter
 	return nil, ErrLogsNotRecorded
 }
 
+func (n dummyIndex) ForEachInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash, onLog func(*types.Log) (gonext bool)) error {
+	return ErrLogsNotRecorded
+}
+
 func (n dummyIndex) Push(recs ...*types.Log) error {
 	return nil
 }
 
+func (n dummyIndex) Delete(recs ...*types.Log) error {
+	return nil
+}
+
 func (n dummyIndex) Close() {}
 
 func (n dummyIndex) WrapTablesAsBatched() (unwrap func()) {
@@ -130,6 +130,30 @@ func (tt *index) Push(recs ...*types.Log) error {
 	return nil
 }
 
+// Delete log record from database batch
+func (tt *index) Delete(recs ...*types.Log) error {
+	for _, rec := range recs {
+		id := NewID(rec.BlockNumber, rec.TxHash, rec.Index)
+
+		// delete index
+		if err := tt.table.Topic.Delete(topicKey(rec.Address.Hash(), 0, id)); err != nil {
+			return err
+		}
+		for i, topic := range rec.Topics {
+			if err := tt.table.Topic.Delete(topicKey(topic, uint8(i+1), id)); err != nil {
+				return err
+			}
+		}
+
+		// delete data
+		if err := tt.table.Logrec.Delete(id.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (tt *index) Close() {
 	_ = tt.table.Topic.Close()
 	_ = tt.table.Logrec.Close()
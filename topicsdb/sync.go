@@ -12,8 +12,9 @@ type (
 	}
 
 	blockCounter struct {
-		wait  chan struct{}
-		count int
+		wait     chan struct{}
+		count    int
+		released bool
 	}
 
 	synchronizator struct {
@@ -43,10 +44,8 @@ func (s *synchronizator) Halt() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for n := range s.blocks {
-		if n != s.minBlock {
-			close(s.blocks[n].wait)
-		}
+	for _, b := range s.blocks {
+		b.release()
 	}
 }
 
@@ -60,6 +59,10 @@ func (s *synchronizator) GoNext(n uint64) (prev uint64, gonext bool) {
 		prev = s.minBlock
 		s.enqueueBlock(n)
 		s.dequeueBlock()
+		if !s.goNext {
+			// halted while the block was enqueued
+			s.blocks[n].release()
+		}
 		wait := s.blocks[n].wait
 		s.mu.Unlock()
 		// wait for other threads
@@ -128,7 +131,15 @@ func (s *synchronizator) dequeueBlock() {
 				s.minBlock = b
 			}
 		}
-		close(s.blocks[s.minBlock].wait)
+		s.blocks[s.minBlock].release()
+	}
+}
+
+// release lets the threads waiting for the block to go on, it may be called several times.
+func (b *blockCounter) release() {
+	if !b.released {
+		b.released = true
+		close(b.wait)
 	}
 }
 
@@ -20,7 +20,9 @@ var (
 
 type Index interface {
 	FindInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash) (logs []*types.Log, err error)
+	ForEachInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash, onLog func(*types.Log) (gonext bool)) error
 	Push(recs ...*types.Log) error
+	Delete(recs ...*types.Log) error
 	Close()
 
 	WrapTablesAsBatched() (unwrap func())
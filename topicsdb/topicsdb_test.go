@@ -10,6 +10,7 @@ import (
 
 	"github.com/Fantom-foundation/lachesis-base/hash"
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -553,3 +554,101 @@ func randAddress() (addr common.Address) {
 	}
 	return
 }
+
+func TestIndexSearchStop(t *testing.T) {
+	logger.SetTestMode(t)
+	var (
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+		addr  = randAddress()
+	)
+
+	index := newTestIndex()
+	for n := uint64(1); n <= 100; n++ {
+		for i := uint(0); i < 3; i++ {
+			err := index.Push(&types.Log{
+				BlockNumber: n,
+				Address:     addr,
+				Topics:      []common.Hash{hash1, hash2},
+				TxHash:      common.Hash{byte(n), byte(i)},
+				Index:       i,
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	pooled := withThreadPool{index}
+
+	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash, func(*types.Log) bool) error{
+		"index":  index.ForEachInBlocks,
+		"pooled": pooled.ForEachInBlocks,
+	} {
+		t.Run(dsc, func(t *testing.T) {
+			for _, stopAt := range []uint64{1, 2, 50, 100} {
+				require := require.New(t)
+				var got []*types.Log
+				err := method(nil, 0, 1000, [][]common.Hash{
+					{addr.Hash()},
+					{hash1, hash2},
+					{hash1, hash2},
+				}, func(l *types.Log) bool {
+					if l.BlockNumber > stopAt {
+						return false
+					}
+					got = append(got, l)
+					return true
+				})
+				require.NoError(err)
+				for _, l := range got {
+					require.LessOrEqual(l.BlockNumber, stopAt)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexDelete(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	var (
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+		addr  = randAddress()
+	)
+
+	index := newTestIndex()
+	var logs []*types.Log
+	for n := uint64(1); n <= 3; n++ {
+		l := &types.Log{
+			BlockNumber: n,
+			Address:     addr,
+			Topics:      []common.Hash{hash1, hash2},
+			TxHash:      common.Hash{byte(n)},
+		}
+		require.NoError(index.Push(l))
+		logs = append(logs, l)
+	}
+
+	require.NoError(index.Delete(logs[0], logs[1]))
+
+	for _, pattern := range [][][]common.Hash{
+		{{addr.Hash()}},
+		{{}, {hash1}},
+		{{}, {}, {hash2}},
+	} {
+		got, err := index.FindInBlocks(nil, 0, 10, pattern)
+		require.NoError(err)
+		require.Len(got, 1)
+		require.Equal(uint64(3), got[0].BlockNumber)
+	}
+
+	// nothing is left of the deleted records
+	for _, tab := range []kvdb.Store{index.table.Topic, index.table.Logrec} {
+		it := tab.NewIterator(nil, nil)
+		for it.Next() {
+			id := it.Key()[len(it.Key())-logrecKeySize:]
+			require.Equal(uint64(3), bytesToUint(id[:uint64Size]))
+		}
+		it.Release()
+	}
+}
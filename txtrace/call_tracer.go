@@ -0,0 +1,244 @@
+package txtrace
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// nativeInterrupt implements stopping of a native tracer
+type nativeInterrupt struct {
+	interrupt atomic.Bool
+	reason    atomic.Pointer[error]
+}
+
+// Stop terminates the tracing, the reason is returned by GetResult.
+// It may be called concurrently with the tracing, e.g. by a timeout.
+func (n *nativeInterrupt) Stop(err error) {
+	n.reason.Store(&err)
+	n.interrupt.Store(true)
+}
+
+// stopReason returns the reason passed to Stop, nil if the tracing wasn't terminated
+func (n *nativeInterrupt) stopReason() error {
+	if reason := n.reason.Load(); reason != nil {
+		return *reason
+	}
+	return nil
+}
+
+// stopped tells whether the tracing was terminated and cancels the EVM if so
+func (n *nativeInterrupt) stopped(env *vm.EVM) bool {
+	if !n.interrupt.Load() {
+		return false
+	}
+	if env != nil {
+		env.Cancel()
+	}
+	return true
+}
+
+// callLog is a log emitted by a call
+type callLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// callFrame is a single call of the callTracer result
+type callFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Logs         []callLog       `json:"logs,omitempty"`
+	Calls        []callFrame     `json:"calls,omitempty"`
+}
+
+// processOutput sets output or error of the finished call
+func (f *callFrame) processOutput(output []byte, err error) {
+	if err == nil {
+		f.Output = common.CopyBytes(output)
+		return
+	}
+	f.Error = err.Error()
+	if f.Type == vm.CREATE.String() || f.Type == vm.CREATE2.String() {
+		f.To = nil
+	}
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = common.CopyBytes(output)
+	if reason, errUnpack := abi.UnpackRevert(output); errUnpack == nil {
+		f.RevertReason = reason
+	}
+}
+
+// clearFailedLogs removes logs of the failed calls, as they are reverted
+func (f *callFrame) clearFailedLogs(parentFailed bool) {
+	failed := f.Error != "" || parentFailed
+	if failed {
+		f.Logs = nil
+	}
+	for i := range f.Calls {
+		f.Calls[i].clearFailedLogs(failed)
+	}
+}
+
+type callTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // if true, call tracer won't collect any subcalls
+	WithLog     bool `json:"withLog"`     // if true, call tracer will collect event logs
+}
+
+// callTracer is the native implementation of the callTracer
+type callTracer struct {
+	nativeInterrupt
+	config    callTracerConfig
+	callstack []callFrame
+	depth     int
+}
+
+func newCallTracer(cfg json.RawMessage) (NativeTracer, error) {
+	t := &callTracer{}
+	if err := parseTracerConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CaptureTxStart is not used by the callTracer
+func (t *callTracer) CaptureTxStart(gasLimit uint64) {
+}
+
+// CaptureTxEnd is not used by the callTracer
+func (t *callTracer) CaptureTxEnd(restGas uint64) {
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	callType := vm.CALL
+	if create {
+		callType = vm.CREATE
+	}
+	t.callstack = []callFrame{{
+		Type:  callType.String(),
+		From:  from,
+		To:    &to,
+		Value: toHexBig(value),
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}}
+}
+
+// CaptureState collects logs emitted by the executed instruction
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.stopped(env) || !t.config.WithLog || err != nil {
+		return
+	}
+	if t.config.OnlyTopCall && t.depth > 0 {
+		return
+	}
+	if op < vm.LOG0 || op > vm.LOG4 || len(t.callstack) == 0 {
+		return
+	}
+	stack := scope.Stack
+	topicCount := int(op - vm.LOG0)
+	if stack.Len() < 2+topicCount {
+		return
+	}
+	mStart, mSize := stack.Back(0), stack.Back(1)
+	if !mStart.IsUint64() || !mSize.IsUint64() || mStart.Uint64()+mSize.Uint64() > uint64(scope.Memory.Len()) {
+		return
+	}
+	log := callLog{
+		Address: scope.Contract.Address(),
+		Topics:  make([]common.Hash, topicCount),
+		Data:    scope.Memory.GetCopy(int64(mStart.Uint64()), int64(mSize.Uint64())),
+	}
+	for i := 0; i < topicCount; i++ {
+		log.Topics[i] = common.Hash(stack.Back(2 + i).Bytes32())
+	}
+	top := &t.callstack[len(t.callstack)-1]
+	top.Logs = append(top.Logs, log)
+}
+
+// CaptureEnter implements the tracer interface to trace entering of a call frame.
+func (t *callTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.depth++
+	if t.config.OnlyTopCall {
+		return
+	}
+	t.callstack = append(t.callstack, callFrame{
+		Type:  op.String(),
+		From:  from,
+		To:    &to,
+		Value: toHexBig(value),
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	})
+}
+
+// CaptureExit implements the tracer interface to trace exiting of a call frame.
+func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.depth--
+	if t.config.OnlyTopCall || len(t.callstack) <= 1 {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.GasUsed = hexutil.Uint64(gasUsed)
+	call.processOutput(output, err)
+
+	parent := &t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	root := &t.callstack[0]
+	root.GasUsed = hexutil.Uint64(gasUsed)
+	root.processOutput(output, err)
+}
+
+// CaptureFault is not used by the callTracer, as errors are contained in CaptureExit or CaptureEnd
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// GetResult returns the json-encoded nested list of call traces
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	if t.config.WithLog {
+		t.callstack[0].clearFailedLogs(false)
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return res, t.stopReason()
+}
+
+// toHexBig converts value into its json representation, nil is kept as nil
+func toHexBig(value *big.Int) *hexutil.Big {
+	if value == nil {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).Set(value))
+}
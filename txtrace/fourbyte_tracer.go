@@ -0,0 +1,98 @@
+package txtrace
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// fourByteTracer is the native implementation of the 4byteTracer.
+// It counts the 4-byte function selectors of the calls together with
+// the size of the call data following the selector:
+//
+//	{"0x27dc297e-128": 1, "0x38cc4831-0": 2}
+type fourByteTracer struct {
+	nativeInterrupt
+	ids         map[string]int
+	precompiles map[common.Address]struct{}
+}
+
+func newFourByteTracer(cfg json.RawMessage) (NativeTracer, error) {
+	return &fourByteTracer{
+		ids: make(map[string]int),
+	}, nil
+}
+
+// store records the selector and the size of the call data
+func (t *fourByteTracer) store(id []byte, size int) {
+	key := hexutil.Encode(id) + "-" + strconv.Itoa(size)
+	t.ids[key]++
+}
+
+// CaptureTxStart is not used by the 4byteTracer
+func (t *fourByteTracer) CaptureTxStart(gasLimit uint64) {
+}
+
+// CaptureTxEnd is not used by the 4byteTracer
+func (t *fourByteTracer) CaptureTxEnd(restGas uint64) {
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (t *fourByteTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.precompiles = make(map[common.Address]struct{})
+	for _, addr := range vm.ActivePrecompiles(env.ChainConfig().Rules(env.Context.BlockNumber)) {
+		t.precompiles[addr] = struct{}{}
+	}
+	if !create && len(input) >= 4 {
+		t.store(input[0:4], len(input)-4)
+	}
+}
+
+// CaptureState only checks whether the tracing was stopped
+func (t *fourByteTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	t.stopped(env)
+}
+
+// CaptureEnter records the selector of the inner call
+func (t *fourByteTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	if len(input) < 4 {
+		return
+	}
+	// primarily we want to avoid CREATE/CREATE2/SELFDESTRUCT
+	if op != vm.DELEGATECALL && op != vm.STATICCALL && op != vm.CALL && op != vm.CALLCODE {
+		return
+	}
+	if _, ok := t.precompiles[to]; ok {
+		return
+	}
+	t.store(input[0:4], len(input)-4)
+}
+
+// CaptureExit is not used by the 4byteTracer
+func (t *fourByteTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureEnd is not used by the 4byteTracer
+func (t *fourByteTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+}
+
+// CaptureFault is not used by the 4byteTracer
+func (t *fourByteTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// GetResult returns the json-encoded map of the selectors and their counts
+func (t *fourByteTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.ids)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.stopReason()
+}
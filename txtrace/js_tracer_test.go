@@ -185,7 +185,7 @@ func TestCustomTracerCodeComplexCall(t *testing.T) {
 }
 
 // executeCallbacks Simulate EVM callbacks to tracer for complex inner call
-func executeCallbacks(tracer *tracers.Tracer) {
+func executeCallbacks(tracer vm.Tracer) {
 	tracer.CaptureStart(getEVMEnv(), from, to, false, inputData, 1000, value)
 
 	tracer.CaptureEnter(vm.CREATE2, to, toInner, inputDataInner, 600, value)
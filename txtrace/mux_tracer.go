@@ -0,0 +1,59 @@
+package txtrace
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// MuxTracer dispatches tracer events to several tracers at once
+type MuxTracer []vm.Tracer
+
+// NewMuxTracer creates a tracer dispatching events to all given tracers
+func NewMuxTracer(tracers ...vm.Tracer) MuxTracer {
+	return tracers
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (t MuxTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	for _, tracer := range t {
+		tracer.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+// CaptureState implements the tracer interface to trace a single step of VM execution.
+func (t MuxTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for _, tracer := range t {
+		tracer.CaptureState(env, pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+// CaptureEnter implements the tracer interface to trace entering of a call frame.
+func (t MuxTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	for _, tracer := range t {
+		tracer.CaptureEnter(op, from, to, input, gas, value)
+	}
+}
+
+// CaptureExit implements the tracer interface to trace exiting of a call frame.
+func (t MuxTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	for _, tracer := range t {
+		tracer.CaptureExit(output, gasUsed, err)
+	}
+}
+
+// CaptureEnd implements the tracer interface to finish the tracing operation.
+func (t MuxTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	for _, tracer := range t {
+		tracer.CaptureEnd(output, gasUsed, d, err)
+	}
+}
+
+// CaptureFault implements the tracer interface to trace an execution fault.
+func (t MuxTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	for _, tracer := range t {
+		tracer.CaptureFault(env, pc, op, gas, cost, scope, depth, err)
+	}
+}
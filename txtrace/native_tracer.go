@@ -0,0 +1,55 @@
+package txtrace
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// NativeTracer is a built-in tracer implemented in Go, which can be used
+// by debug_trace* calls instead of the JavaScript tracer of the same name
+type NativeTracer interface {
+	vm.Tracer
+	// CaptureTxStart is called before the execution of the transaction
+	CaptureTxStart(gasLimit uint64)
+	// CaptureTxEnd is called after the execution of the transaction
+	// when the state already contains all its changes
+	CaptureTxEnd(restGas uint64)
+	// GetResult returns the result of the tracer in JSON format
+	GetResult() (json.RawMessage, error)
+	// Stop terminates the tracing, the reason is returned by GetResult
+	Stop(err error)
+}
+
+// nativeTracerCtor creates a native tracer with the tracer specific configuration
+type nativeTracerCtor func(cfg json.RawMessage) (NativeTracer, error)
+
+// nativeTracers contains all the native tracers by name
+var nativeTracers = map[string]nativeTracerCtor{
+	"callTracer":     newCallTracer,
+	"prestateTracer": newPrestateTracer,
+	"4byteTracer":    newFourByteTracer,
+}
+
+// NewNativeTracer creates the native tracer of the given name. It returns false
+// if there is no native tracer of such name, so the JavaScript one should be used.
+func NewNativeTracer(name string, cfg json.RawMessage) (NativeTracer, bool, error) {
+	ctor, ok := nativeTracers[name]
+	if !ok {
+		return nil, false, nil
+	}
+	tracer, err := ctor(cfg)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid config of %s: %w", name, err)
+	}
+	return tracer, true, nil
+}
+
+// parseTracerConfig decodes the tracer config, empty config leaves the defaults
+func parseTracerConfig(cfg json.RawMessage, v interface{}) error {
+	if len(cfg) == 0 || string(cfg) == "null" {
+		return nil
+	}
+	return json.Unmarshal(cfg, v)
+}
@@ -0,0 +1,111 @@
+package txtrace
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNativeCallTracerComplexCall Compare native callTracer with the JavaScript one on a complex inner calls
+func TestNativeCallTracerComplexCall(t *testing.T) {
+
+	jsTracer := getJSTracer("callTracer", t)
+	defer jsTracer.Destroy()
+	executeCallbacks(jsTracer)
+	want, err := jsTracer.GetResult()
+	if err != nil {
+		t.Fatalf("callTracer GetResult must not fail, error: %v", err.Error())
+	}
+	wantIndented, _ := json.MarshalIndent(want, "", "    ")
+
+	tracer := getNativeTracer("callTracer", "", t)
+	executeCallbacks(tracer)
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("native callTracer GetResult must not fail, error: %v", err.Error())
+	}
+	checkTracerResult(t, result, string(wantIndented))
+}
+
+// TestNativeCallTracerOnlyTopCall Create native callTracer ignoring inner calls
+func TestNativeCallTracerOnlyTopCall(t *testing.T) {
+
+	tracer := getNativeTracer("callTracer", `{"onlyTopCall": true}`, t)
+	executeCallbacks(tracer)
+
+	want := `{
+    "type": "CALL",
+    "from": "0x0000000000000000000000000000000000000001",
+    "to": "0x0000000000000000000000000000000000000002",
+    "value": "0x5",
+    "gas": "0x3e8",
+    "gasUsed": "0x64",
+    "input": "0x2f7468610000000000000000000000000000000000000000000000000000000000000008",
+    "output": "0x45"
+}`
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("native callTracer GetResult must not fail, error: %v", err.Error())
+	}
+	checkTracerResult(t, result, want)
+}
+
+// TestNativeFourByteTracer Create native 4byteTracer and run it on a complex inner calls
+func TestNativeFourByteTracer(t *testing.T) {
+
+	tracer := getNativeTracer("4byteTracer", "", t)
+	executeCallbacks(tracer)
+
+	// inner calls are made to the precompiled contract 0x3, which is ignored
+	want := `{
+    "0x2f746861-32": 1
+}`
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("native 4byteTracer GetResult must not fail, error: %v", err.Error())
+	}
+	checkTracerResult(t, result, want)
+}
+
+// TestNativeTracerStop Stopped native tracer returns the reason
+func TestNativeTracerStop(t *testing.T) {
+
+	tracer := getNativeTracer("callTracer", "", t)
+	tracer.CaptureStart(getEVMEnv(), from, to, false, inputData, 1000, value)
+	tracer.CaptureEnd(outputData, 100, time.Since(time.Now()), nil)
+
+	// the tracer is stopped by the timeout goroutine while the result is read
+	go tracer.Stop(errTimeout)
+	for {
+		_, err := tracer.GetResult()
+		if err == nil {
+			continue
+		}
+		if err != errTimeout {
+			t.Errorf("stopped tracer must return the stop reason, got: %v", err)
+		}
+		break
+	}
+}
+
+// TestNativeTracerUnknown Unknown tracer name is not a native tracer
+func TestNativeTracerUnknown(t *testing.T) {
+	if _, isNative, _ := NewNativeTracer("unknownTracer", nil); isNative {
+		t.Errorf("unknown tracer must not be native")
+	}
+	if _, isNative, err := NewNativeTracer("callTracer", json.RawMessage(`{"onlyTopCall": 1}`)); !isNative || err == nil {
+		t.Errorf("invalid tracer config must fail")
+	}
+}
+
+var errTimeout = errors.New("execution timeout")
+
+// getNativeTracer Creates new native tracer with the given config
+func getNativeTracer(name string, cfg string, t *testing.T) NativeTracer {
+	tracer, isNative, err := NewNativeTracer(name, json.RawMessage(cfg))
+	if !isNative || err != nil {
+		t.Fatalf("native tracer %s creation must not fail but did fail with error: %v", name, err)
+	}
+	return tracer
+}
@@ -0,0 +1,247 @@
+package txtrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// prestateAccount is the state of an account as reported by the prestateTracer
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// empty tells whether the account has no balance, nonce, code nor storage
+func (a *prestateAccount) empty() bool {
+	return (a.Balance == nil || a.Balance.ToInt().Sign() == 0) && a.Nonce == 0 && len(a.Code) == 0 && len(a.Storage) == 0
+}
+
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // if true, the tracer returns both the pre and the post state
+}
+
+// prestateTracer is the native implementation of the prestateTracer.
+// It collects the state of all the accounts touched by the transaction before its execution
+// and, in the diff mode, the changed parts of the state after the execution.
+type prestateTracer struct {
+	nativeInterrupt
+	config      prestateTracerConfig
+	env         *vm.EVM
+	precompiles map[common.Address]struct{}
+	gasLimit    uint64
+	pre         map[common.Address]*prestateAccount
+	post        map[common.Address]*prestateAccount
+	created     map[common.Address]struct{}
+	deleted     map[common.Address]struct{}
+}
+
+func newPrestateTracer(cfg json.RawMessage) (NativeTracer, error) {
+	t := &prestateTracer{
+		pre:     make(map[common.Address]*prestateAccount),
+		post:    make(map[common.Address]*prestateAccount),
+		created: make(map[common.Address]struct{}),
+		deleted: make(map[common.Address]struct{}),
+	}
+	if err := parseTracerConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CaptureTxStart records the gas limit of the transaction, which was already
+// bought from the sender balance when the execution starts
+func (t *prestateTracer) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.precompiles = make(map[common.Address]struct{})
+	for _, addr := range vm.ActivePrecompiles(env.ChainConfig().Rules(env.Context.BlockNumber)) {
+		t.precompiles[addr] = struct{}{}
+	}
+
+	t.lookupAccount(from)
+	t.lookupAccount(to)
+	t.lookupAccount(env.Context.Coinbase)
+
+	// The sender balance is already reduced by the value and the bought gas
+	// and the nonce is already increased, so restore the pre-tx values
+	fromBalance := new(big.Int).Set(t.pre[from].Balance.ToInt())
+	if value != nil {
+		fromBalance.Add(fromBalance, value)
+	}
+	if env.TxContext.GasPrice != nil {
+		fromBalance.Add(fromBalance, new(big.Int).Mul(env.TxContext.GasPrice, new(big.Int).SetUint64(t.gasLimit)))
+	}
+	t.pre[from].Balance = (*hexutil.Big)(fromBalance)
+	if t.pre[from].Nonce > 0 {
+		t.pre[from].Nonce--
+	}
+
+	if create {
+		t.created[to] = struct{}{}
+	}
+}
+
+// CaptureState looks up accounts and storage slots accessed by the executed instruction
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.stopped(env) || err != nil {
+		return
+	}
+	stack := scope.Stack
+	caller := scope.Contract.Address()
+	switch {
+	case stack.Len() >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
+		t.lookupStorage(caller, common.Hash(stack.Back(0).Bytes32()))
+	case stack.Len() >= 1 && (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SELFDESTRUCT):
+		t.lookupAccount(common.Address(stack.Back(0).Bytes20()))
+		if op == vm.SELFDESTRUCT {
+			t.deleted[caller] = struct{}{}
+		}
+	case stack.Len() >= 5 && (op == vm.DELEGATECALL || op == vm.CALL || op == vm.STATICCALL || op == vm.CALLCODE):
+		addr := common.Address(stack.Back(1).Bytes20())
+		if _, ok := t.precompiles[addr]; !ok {
+			t.lookupAccount(addr)
+		}
+	case op == vm.CREATE:
+		addr := crypto.CreateAddress(caller, env.StateDB.GetNonce(caller))
+		t.lookupAccount(addr)
+		t.created[addr] = struct{}{}
+	case stack.Len() >= 4 && op == vm.CREATE2:
+		offset, size := stack.Back(1), stack.Back(2)
+		if !offset.IsUint64() || !size.IsUint64() || offset.Uint64()+size.Uint64() > uint64(scope.Memory.Len()) {
+			return
+		}
+		initCode := scope.Memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+		addr := crypto.CreateAddress2(caller, stack.Back(3).Bytes32(), crypto.Keccak256(initCode))
+		t.lookupAccount(addr)
+		t.created[addr] = struct{}{}
+	}
+}
+
+// CaptureEnter is not used by the prestateTracer, as accounts are looked up by the calling instruction
+func (t *prestateTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit is not used by the prestateTracer
+func (t *prestateTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureEnd is not used by the prestateTracer
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+}
+
+// CaptureFault is not used by the prestateTracer
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureTxEnd collects the post state in the diff mode. The state used by the EVM
+// contains all changes of the transaction at this point.
+func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
+	if !t.config.DiffMode || t.env == nil {
+		return
+	}
+	statedb := t.env.StateDB
+
+	for addr, pre := range t.pre {
+		// deleted accounts are reported in the pre state only
+		if _, ok := t.deleted[addr]; ok {
+			continue
+		}
+
+		modified := false
+		post := &prestateAccount{Storage: make(map[common.Hash]common.Hash)}
+		if balance := statedb.GetBalance(addr); pre.Balance.ToInt().Cmp(balance) != 0 {
+			modified = true
+			post.Balance = (*hexutil.Big)(balance)
+		}
+		if nonce := statedb.GetNonce(addr); nonce != pre.Nonce {
+			modified = true
+			post.Nonce = nonce
+		}
+		if code := statedb.GetCode(addr); !bytes.Equal(code, pre.Code) {
+			modified = true
+			post.Code = code
+		}
+		for key, value := range pre.Storage {
+			newValue := statedb.GetState(addr, key)
+			if newValue == value {
+				// unchanged slots are omitted from both states
+				delete(pre.Storage, key)
+				continue
+			}
+			modified = true
+			if newValue != (common.Hash{}) {
+				post.Storage[key] = newValue
+			}
+		}
+
+		if modified {
+			t.post[addr] = post
+		} else {
+			// unmodified accounts are omitted from both states
+			delete(t.pre, addr)
+		}
+	}
+
+	// the pre state of the created accounts is empty
+	for addr := range t.created {
+		if pre, ok := t.pre[addr]; ok && pre.empty() {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+// GetResult returns the json-encoded pre state, or both the pre and the post state in the diff mode
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	var (
+		res []byte
+		err error
+	)
+	if t.config.DiffMode {
+		res, err = json.Marshal(struct {
+			Post map[common.Address]*prestateAccount `json:"post"`
+			Pre  map[common.Address]*prestateAccount `json:"pre"`
+		}{t.post, t.pre})
+	} else {
+		res, err = json.Marshal(t.pre)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, t.stopReason()
+}
+
+// lookupAccount records the state of the account when it is touched first
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+	statedb := t.env.StateDB
+	t.pre[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(statedb.GetBalance(addr))),
+		Nonce:   statedb.GetNonce(addr),
+		Code:    statedb.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage records the value of the storage slot when it is touched first
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.pre[addr].Storage[key]; ok {
+		return
+	}
+	t.pre[addr].Storage[key] = t.env.StateDB.GetState(addr, key)
+}
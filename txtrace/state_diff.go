@@ -0,0 +1,216 @@
+package txtrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// StateReader is the part of the state needed to compute the state difference
+type StateReader interface {
+	Exist(addr common.Address) bool
+	GetBalance(addr common.Address) *big.Int
+	GetNonce(addr common.Address) uint64
+	GetCode(addr common.Address) []byte
+	GetState(addr common.Address, key common.Hash) common.Hash
+}
+
+// StateDiff is the parity-style difference of the state made by a transaction
+type StateDiff map[common.Address]*AccountDiff
+
+// AccountDiff represents changes of a single account
+type AccountDiff struct {
+	Balance *Diff                 `json:"balance"`
+	Nonce   *Diff                 `json:"nonce"`
+	Code    *Diff                 `json:"code"`
+	Storage map[common.Hash]*Diff `json:"storage"`
+}
+
+// Diff represents a change of a single value. It is marshalled as:
+//   - "=" if the value is unchanged
+//   - {"+": to} if the value was born
+//   - {"-": from} if the value died
+//   - {"*": {"from": from, "to": to}} if the value was changed
+type Diff struct {
+	From interface{}
+	To   interface{}
+	Kind DiffKind
+}
+
+// DiffKind is a kind of value change
+type DiffKind byte
+
+const (
+	DiffSame DiffKind = iota
+	DiffBorn
+	DiffDied
+	DiffChanged
+)
+
+type diffFromTo struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (d *Diff) MarshalJSON() ([]byte, error) {
+	switch d.Kind {
+	case DiffBorn:
+		return json.Marshal(map[string]interface{}{"+": d.To})
+	case DiffDied:
+		return json.Marshal(map[string]interface{}{"-": d.From})
+	case DiffChanged:
+		return json.Marshal(map[string]interface{}{"*": diffFromTo{d.From, d.To}})
+	default:
+		return json.Marshal("=")
+	}
+}
+
+// StateDiffLogger is a tracer collecting accounts and storage slots
+// which may be modified by a transaction
+type StateDiffLogger struct {
+	touched map[common.Address]map[common.Hash]struct{}
+}
+
+// NewStateDiffLogger creates new instance of state diff collector
+func NewStateDiffLogger() *StateDiffLogger {
+	return &StateDiffLogger{
+		touched: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (l *StateDiffLogger) touchAccount(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := l.touched[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		l.touched[addr] = slots
+	}
+	return slots
+}
+
+func (l *StateDiffLogger) touchSlot(addr common.Address, key common.Hash) {
+	l.touchAccount(addr)[key] = struct{}{}
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (l *StateDiffLogger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.touchAccount(from)
+	l.touchAccount(to)
+	if env != nil {
+		l.touchAccount(env.Context.Coinbase)
+	}
+}
+
+// CaptureState records storage slots accessed by the executed instruction
+func (l *StateDiffLogger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	stack := scope.Stack
+	switch {
+	case (op == vm.SLOAD || op == vm.SSTORE) && stack.Len() >= 1:
+		l.touchSlot(scope.Contract.Address(), common.Hash(stack.Back(0).Bytes32()))
+	case op == vm.SELFDESTRUCT && stack.Len() >= 1:
+		l.touchAccount(scope.Contract.Address())
+		l.touchAccount(common.Address(stack.Back(0).Bytes20()))
+	}
+}
+
+// CaptureEnter records accounts entered by an inner call
+func (l *StateDiffLogger) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	l.touchAccount(from)
+	l.touchAccount(to)
+}
+
+// CaptureExit is not used by the state diff collector
+func (l *StateDiffLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureEnd is not used by the state diff collector
+func (l *StateDiffLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+}
+
+// CaptureFault is not used by the state diff collector
+func (l *StateDiffLogger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// GetResult compares the touched accounts in the states before and after
+// the transaction and returns the changed ones
+func (l *StateDiffLogger) GetResult(pre, post StateReader) StateDiff {
+	res := make(StateDiff)
+	for addr, slots := range l.touched {
+		if diff := diffAccount(pre, post, addr, slots); diff != nil {
+			res[addr] = diff
+		}
+	}
+	return res
+}
+
+// diffAccount returns the changes of the account, or nil if the account is unchanged
+func diffAccount(pre, post StateReader, addr common.Address, slots map[common.Hash]struct{}) *AccountDiff {
+	existedBefore, existsAfter := pre.Exist(addr), post.Exist(addr)
+	if !existedBefore && !existsAfter {
+		return nil
+	}
+
+	var (
+		preBalance, postBalance = new(big.Int), new(big.Int)
+		preNonce, postNonce     uint64
+		preCode, postCode       []byte
+	)
+	if existedBefore {
+		preBalance, preNonce, preCode = pre.GetBalance(addr), pre.GetNonce(addr), pre.GetCode(addr)
+	}
+	if existsAfter {
+		postBalance, postNonce, postCode = post.GetBalance(addr), post.GetNonce(addr), post.GetCode(addr)
+	}
+
+	diff := &AccountDiff{
+		Balance: newDiff(existedBefore, existsAfter, preBalance.Cmp(postBalance) == 0, (*hexutil.Big)(preBalance), (*hexutil.Big)(postBalance)),
+		Nonce:   newDiff(existedBefore, existsAfter, preNonce == postNonce, hexutil.Uint64(preNonce), hexutil.Uint64(postNonce)),
+		Code:    newDiff(existedBefore, existsAfter, bytes.Equal(preCode, postCode), hexutil.Bytes(preCode), hexutil.Bytes(postCode)),
+		Storage: make(map[common.Hash]*Diff),
+	}
+	for key := range slots {
+		var preValue, postValue common.Hash
+		if existedBefore {
+			preValue = pre.GetState(addr, key)
+		}
+		if existsAfter {
+			postValue = post.GetState(addr, key)
+		}
+		if preValue == postValue {
+			continue
+		}
+		switch {
+		case preValue == (common.Hash{}):
+			diff.Storage[key] = &Diff{To: postValue, Kind: DiffBorn}
+		case postValue == (common.Hash{}):
+			diff.Storage[key] = &Diff{From: preValue, Kind: DiffDied}
+		default:
+			diff.Storage[key] = &Diff{From: preValue, To: postValue, Kind: DiffChanged}
+		}
+	}
+
+	if existedBefore && existsAfter && diff.Balance.Kind == DiffSame && diff.Nonce.Kind == DiffSame &&
+		diff.Code.Kind == DiffSame && len(diff.Storage) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// newDiff creates a diff of an account field
+func newDiff(existedBefore, existsAfter, same bool, from, to interface{}) *Diff {
+	switch {
+	case !existedBefore:
+		return &Diff{To: to, Kind: DiffBorn}
+	case !existsAfter:
+		return &Diff{From: from, Kind: DiffDied}
+	case same:
+		return &Diff{Kind: DiffSame}
+	default:
+		return &Diff{From: from, To: to, Kind: DiffChanged}
+	}
+}
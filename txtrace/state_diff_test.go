@@ -0,0 +1,105 @@
+package txtrace
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[common.Hash]common.Hash
+}
+
+type testState map[common.Address]*testAccount
+
+func (s testState) Exist(addr common.Address) bool {
+	_, ok := s[addr]
+	return ok
+}
+
+func (s testState) GetBalance(addr common.Address) *big.Int {
+	return s[addr].balance
+}
+
+func (s testState) GetNonce(addr common.Address) uint64 {
+	return s[addr].nonce
+}
+
+func (s testState) GetCode(addr common.Address) []byte {
+	return s[addr].code
+}
+
+func (s testState) GetState(addr common.Address, key common.Hash) common.Hash {
+	return s[addr].storage[key]
+}
+
+func TestStateDiff(t *testing.T) {
+	slot := common.HexToHash("0x1")
+	unchanged := common.HexToAddress("4")
+
+	pre := testState{
+		from:      {balance: big.NewInt(100), nonce: 1},
+		to:        {balance: big.NewInt(0), storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x5")}},
+		unchanged: {balance: big.NewInt(7)},
+	}
+	post := testState{
+		from:      {balance: big.NewInt(95), nonce: 2},
+		to:        {balance: big.NewInt(5), storage: map[common.Hash]common.Hash{}},
+		toInner:   {balance: big.NewInt(0), nonce: 1, code: []byte{0x60}},
+		unchanged: {balance: big.NewInt(7)},
+	}
+
+	logger := NewStateDiffLogger()
+	logger.CaptureStart(nil, from, to, false, inputData, 1000, value)
+	logger.CaptureEnter(0, to, toInner, nil, 100, big.NewInt(0))
+	logger.CaptureEnter(0, to, unchanged, nil, 100, big.NewInt(0))
+	logger.touchSlot(to, slot)
+	logger.CaptureExit(nil, 0, nil)
+	logger.CaptureEnd(outputData, 100, time.Since(time.Now()), nil)
+
+	want := `{
+		"0x0000000000000000000000000000000000000001": {
+			"balance": {"*": {"from": "0x64", "to": "0x5f"}},
+			"nonce": {"*": {"from": "0x1", "to": "0x2"}},
+			"code": "=",
+			"storage": {}
+		},
+		"0x0000000000000000000000000000000000000002": {
+			"balance": {"*": {"from": "0x0", "to": "0x5"}},
+			"nonce": "=",
+			"code": "=",
+			"storage": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": {"-": "0x0000000000000000000000000000000000000000000000000000000000000005"}
+			}
+		},
+		"0x0000000000000000000000000000000000000003": {
+			"balance": {"+": "0x0"},
+			"nonce": {"+": "0x1"},
+			"code": {"+": "0x60"},
+			"storage": {}
+		}
+	}`
+
+	got, err := json.Marshal(logger.GetResult(pre, post))
+	if err != nil {
+		t.Fatalf("cannot marshal state diff: %v", err)
+	}
+	var gotObj, wantObj interface{}
+	if err := json.Unmarshal(got, &gotObj); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantObj); err != nil {
+		t.Fatal(err)
+	}
+	gotJson, _ := json.Marshal(gotObj)
+	wantJson, _ := json.Marshal(wantObj)
+	if string(gotJson) != string(wantJson) {
+		t.Errorf("unexpected state diff\ngot:  %s\nwant: %s", gotJson, wantJson)
+	}
+}
@@ -100,6 +100,31 @@ func NewTraceStructLogger(block *evmcore.EvmBlock, tx *types.Transaction, msg ty
 	return &traceStructLogger
 }
 
+// NewTraceStructLoggerForCall creates new instance of trace creator for a call
+// which is not included in any block. Gas used by the call is known only after
+// its execution, so it has to be provided by SetGasUsed.
+func NewTraceStructLoggerForCall(header *evmcore.EvmHeader, msg types.Message, index uint) *TraceStructLogger {
+	return &TraceStructLogger{
+		from:        msg.From(),
+		to:          msg.To(),
+		value:       *msg.Value(),
+		blockHash:   header.Hash,
+		blockNumber: *header.Number,
+		txIndex:     index,
+		gasLimit:    msg.Gas(),
+	}
+}
+
+// SetGasUsed sets gas used by the whole traced call into the root trace
+func (tr *TraceStructLogger) SetGasUsed(gasUsed uint64) {
+	tr.gasUsed = gasUsed
+	if tr.rootTrace != nil && len(tr.rootTrace.Actions) > 0 {
+		if result := tr.rootTrace.Actions[0].Result; result != nil {
+			result.GasUsed = hexutil.Uint64(gasUsed)
+		}
+	}
+}
+
 // NewActionTrace creates new instance of type ActionTrace
 func NewActionTrace(bHash common.Hash, bNumber big.Int, tHash common.Hash, tPos uint64, tType string) *ActionTrace {
 	return &ActionTrace{
@@ -0,0 +1,253 @@
+package txtrace
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// VMTrace is the parity-style trace of the executed instructions of a single call frame
+type VMTrace struct {
+	Code hexutil.Bytes  `json:"code"`
+	Ops  []*VMOperation `json:"ops"`
+}
+
+// VMOperation represents a single executed instruction
+type VMOperation struct {
+	Cost uint64      `json:"cost"`
+	Ex   *VMExecuted `json:"ex"`
+	Pc   uint64      `json:"pc"`
+	Sub  *VMTrace    `json:"sub"`
+}
+
+// VMExecuted holds the effects of an executed instruction
+type VMExecuted struct {
+	Mem   *VMMem         `json:"mem"`
+	Push  []*hexutil.Big `json:"push"`
+	Store *VMStore       `json:"store"`
+	Used  uint64         `json:"used"`
+}
+
+// VMMem represents a memory write
+type VMMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// VMStore represents a storage write
+type VMStore struct {
+	Key *hexutil.Big `json:"key"`
+	Val *hexutil.Big `json:"val"`
+}
+
+// vmFrame is the trace of a call frame in progress
+type vmFrame struct {
+	trace *VMTrace
+
+	// instruction waiting for its effects, which are known
+	// only when the next instruction of the frame is reached
+	pending      *VMOperation
+	pendingOp    vm.OpCode
+	pendingGas   uint64
+	memOff       uint64
+	memSize      uint64
+	pendingStore *VMStore
+}
+
+// VMTraceLogger is a tracer collecting the parity-style vmTrace
+type VMTraceLogger struct {
+	env    *vm.EVM
+	root   *VMTrace
+	frames []*vmFrame
+}
+
+// NewVMTraceLogger creates new instance of vmTrace collector
+func NewVMTraceLogger() *VMTraceLogger {
+	return &VMTraceLogger{}
+}
+
+func (l *VMTraceLogger) current() *vmFrame {
+	if len(l.frames) == 0 {
+		return nil
+	}
+	return l.frames[len(l.frames)-1]
+}
+
+// CaptureStart implements the tracer interface to initialize the tracing operation.
+func (l *VMTraceLogger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.env = env
+	l.root = &VMTrace{Code: l.frameCode(to, create, input), Ops: []*VMOperation{}}
+	l.frames = []*vmFrame{{trace: l.root}}
+}
+
+// CaptureState records the executed instruction and completes the previous one
+func (l *VMTraceLogger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	frame := l.current()
+	if frame == nil {
+		return
+	}
+	frame.finish(gas, scope)
+
+	operation := &VMOperation{Cost: cost, Pc: pc}
+	frame.trace.Ops = append(frame.trace.Ops, operation)
+	if err != nil {
+		// instruction was not executed
+		return
+	}
+	frame.pending = operation
+	frame.pendingOp = op
+	frame.pendingGas = gas
+	frame.memOff, frame.memSize = memoryWrite(op, scope.Stack)
+	frame.pendingStore = nil
+	if op == vm.SSTORE && scope.Stack.Len() >= 2 {
+		frame.pendingStore = &VMStore{
+			Key: (*hexutil.Big)(scope.Stack.Back(0).ToBig()),
+			Val: (*hexutil.Big)(scope.Stack.Back(1).ToBig()),
+		}
+	}
+}
+
+// CaptureEnter attaches the trace of the inner call to the calling instruction
+func (l *VMTraceLogger) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	frame := l.current()
+	if frame == nil {
+		return
+	}
+	sub := &VMTrace{Code: l.frameCode(to, op == vm.CREATE || op == vm.CREATE2, input), Ops: []*VMOperation{}}
+	if frame.pending != nil {
+		frame.pending.Sub = sub
+	}
+	l.frames = append(l.frames, &vmFrame{trace: sub})
+}
+
+// CaptureExit completes the inner call frame
+func (l *VMTraceLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	frame := l.current()
+	if frame == nil {
+		return
+	}
+	frame.finishLast()
+	l.frames = l.frames[:len(l.frames)-1]
+}
+
+// CaptureEnd completes the top call frame
+func (l *VMTraceLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	for frame := l.current(); frame != nil; frame = l.current() {
+		frame.finishLast()
+		l.frames = l.frames[:len(l.frames)-1]
+	}
+}
+
+// CaptureFault marks the failed instruction as not executed
+func (l *VMTraceLogger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if frame := l.current(); frame != nil {
+		frame.pending = nil
+	}
+}
+
+// frameCode returns the code executed by a call frame
+func (l *VMTraceLogger) frameCode(to common.Address, create bool, input []byte) hexutil.Bytes {
+	if create {
+		return common.CopyBytes(input)
+	}
+	if l.env == nil {
+		return nil
+	}
+	return l.env.StateDB.GetCode(to)
+}
+
+// GetResult returns the collected vmTrace
+func (l *VMTraceLogger) GetResult() *VMTrace {
+	return l.root
+}
+
+// finish completes the pending instruction using the state
+// reached before the next instruction of the frame
+func (f *vmFrame) finish(gas uint64, scope *vm.ScopeContext) {
+	if f.pending == nil {
+		return
+	}
+	ex := &VMExecuted{Used: gas, Push: []*hexutil.Big{}, Store: f.pendingStore}
+	stack := scope.Stack
+	for i := pushCount(f.pendingOp) - 1; i >= 0; i-- {
+		if i < stack.Len() {
+			ex.Push = append(ex.Push, (*hexutil.Big)(stack.Back(i).ToBig()))
+		}
+	}
+	if f.memSize > 0 && f.memOff+f.memSize <= uint64(scope.Memory.Len()) {
+		ex.Mem = &VMMem{
+			Data: scope.Memory.GetCopy(int64(f.memOff), int64(f.memSize)),
+			Off:  f.memOff,
+		}
+	}
+	f.pending.Ex = ex
+	f.pending = nil
+}
+
+// finishLast completes the last instruction of the frame, which has no successor
+func (f *vmFrame) finishLast() {
+	if f.pending == nil {
+		return
+	}
+	used := uint64(0)
+	if f.pendingGas > f.pending.Cost {
+		used = f.pendingGas - f.pending.Cost
+	}
+	f.pending.Ex = &VMExecuted{Used: used, Push: []*hexutil.Big{}, Store: f.pendingStore}
+	f.pending = nil
+}
+
+// pushCount returns the number of stack items pushed by the instruction
+func pushCount(op vm.OpCode) int {
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.INVALID:
+		return 0
+	}
+	return 1
+}
+
+// memoryWrite returns the memory region written by the instruction
+func memoryWrite(op vm.OpCode, stack *vm.Stack) (off, size uint64) {
+	region := func(offPos, sizePos int) (uint64, uint64) {
+		if stack.Len() <= offPos || stack.Len() <= sizePos {
+			return 0, 0
+		}
+		o, s := stack.Back(offPos), stack.Back(sizePos)
+		if !o.IsUint64() || !s.IsUint64() {
+			return 0, 0
+		}
+		return o.Uint64(), s.Uint64()
+	}
+	switch op {
+	case vm.MSTORE:
+		if off, _ = region(0, 0); stack.Len() >= 1 {
+			return off, 32
+		}
+	case vm.MSTORE8:
+		if off, _ = region(0, 0); stack.Len() >= 1 {
+			return off, 1
+		}
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		return region(0, 2)
+	case vm.EXTCODECOPY:
+		return region(1, 3)
+	case vm.CALL, vm.CALLCODE:
+		return region(5, 6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		return region(4, 5)
+	}
+	return 0, 0
+}
@@ -0,0 +1,21 @@
+package valkeystore
+
+import (
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+)
+
+// EventSignerI is a signer which needs the signed event itself rather than its digest,
+// e.g. to protect the validator against the double signing.
+type EventSignerI interface {
+	SignerI
+	SignEvent(pubkey validatorpk.PubKey, e inter.EventPayloadI) ([]byte, error)
+}
+
+// SignEvent signs the event with the signer, passing the event to the signer if it supports it
+func SignEvent(signer SignerI, pubkey validatorpk.PubKey, e inter.EventPayloadI) ([]byte, error) {
+	if eventSigner, ok := signer.(EventSignerI); ok {
+		return eventSigner.SignEvent(pubkey, e)
+	}
+	return signer.Sign(pubkey, e.HashToSign().Bytes())
+}
@@ -0,0 +1,251 @@
+package protection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+)
+
+// Key prefixes of the tables, each key continues with the length-prefixed public key of the validator
+const (
+	keysPrefix       = 'k' // pubkey -> nil
+	eventsPrefix     = 'e' // pubkey, epoch, seq -> eventRecord
+	blockVotesPrefix = 'b' // pubkey, block -> blockVoteRecord
+	epochVotesPrefix = 'v' // pubkey, epoch -> vote
+)
+
+var errClosed = errors.New("slashing protection database is closed")
+
+// SignedEvent is the part of the event which the slashing protection checks before signing
+type SignedEvent struct {
+	Epoch      idx.Epoch
+	Seq        idx.Event
+	Lamport    idx.Lamport
+	Digest     hash.Hash
+	BlockVotes inter.LlrBlockVotes
+	EpochVote  inter.LlrEpochVote
+}
+
+// NewSignedEvent extracts the signed part of the event
+func NewSignedEvent(e inter.EventPayloadI) SignedEvent {
+	return SignedEvent{
+		Epoch:      e.Epoch(),
+		Seq:        e.Seq(),
+		Lamport:    e.Lamport(),
+		Digest:     e.HashToSign(),
+		BlockVotes: e.BlockVotes(),
+		EpochVote:  e.EpochVote(),
+	}
+}
+
+// HasEpochVote tells whether the event votes for an epoch
+func (e SignedEvent) HasEpochVote() bool {
+	return e.EpochVote.Epoch != 0 && e.EpochVote.Vote != hash.Zero
+}
+
+type eventRecord struct {
+	Lamport idx.Lamport
+	Digest  hash.Hash
+}
+
+type blockVoteRecord struct {
+	Epoch idx.Epoch
+	Vote  hash.Hash
+}
+
+// DB is the slashing protection database.
+// It records every event and vote signed by the validator keys and refuses to approve the conflicting ones.
+// Each approval is written synchronously before the signing, so a crash may only make the protection stricter.
+type DB struct {
+	mu sync.Mutex
+	db *leveldb.DB
+}
+
+// Open opens the database in the directory, the database is created if it doesn't exist
+func Open(path string) (*DB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the slashing protection database %s: %w", path, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database
+func (p *DB) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return nil
+	}
+	err := p.db.Close()
+	p.db = nil
+	return err
+}
+
+// Approve checks that the event is safe to sign by the key and records it.
+// Signing the same event again is allowed.
+func (p *DB) Approve(pubkey validatorpk.PubKey, e SignedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return errClosed
+	}
+
+	batch := new(leveldb.Batch)
+	if err := p.checkEvent(batch, pubkey, e); err != nil {
+		return err
+	}
+	for i, vote := range e.BlockVotes.Votes {
+		block := e.BlockVotes.Start + idx.Block(i)
+		if err := p.checkBlockVote(batch, pubkey, block, blockVoteRecord{e.BlockVotes.Epoch, vote}); err != nil {
+			return err
+		}
+	}
+	if e.HasEpochVote() {
+		if err := p.checkEpochVote(batch, pubkey, e.EpochVote); err != nil {
+			return err
+		}
+	}
+	batch.Put(keyOf(keysPrefix, pubkey), []byte{})
+	return p.write(batch)
+}
+
+// checkEvent refuses the event if another one with the same epoch and seq is signed,
+// or if it precedes the last signed event
+func (p *DB) checkEvent(batch *leveldb.Batch, pubkey validatorpk.PubKey, e SignedEvent) error {
+	key := keyOf(eventsPrefix, pubkey, e.Epoch.Bytes(), e.Seq.Bytes())
+	var prev eventRecord
+	found, err := p.get(key, &prev)
+	if err != nil {
+		return err
+	}
+	if found {
+		if prev.Digest != e.Digest {
+			return fmt.Errorf("another event with epoch %d and seq %d is already signed", e.Epoch, e.Seq)
+		}
+		return nil
+	}
+
+	lastEpoch, lastSeq, err := p.lastEvent(pubkey)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.Epoch < lastEpoch:
+		return fmt.Errorf("event epoch %d is below the last signed epoch %d", e.Epoch, lastEpoch)
+	case e.Epoch == lastEpoch && e.Seq < lastSeq:
+		return fmt.Errorf("event seq %d is below the last signed seq %d", e.Seq, lastSeq)
+	}
+	return putRLP(batch, key, eventRecord{e.Lamport, e.Digest})
+}
+
+// LastEvent returns the epoch and seq of the last event signed by the key, zeros if there are none
+func (p *DB) LastEvent(pubkey validatorpk.PubKey) (idx.Epoch, idx.Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return 0, 0, errClosed
+	}
+	return p.lastEvent(pubkey)
+}
+
+func (p *DB) lastEvent(pubkey validatorpk.PubKey) (idx.Epoch, idx.Event, error) {
+	it := p.db.NewIterator(util.BytesPrefix(keyOf(eventsPrefix, pubkey)), nil)
+	defer it.Release()
+	if !it.Last() {
+		return 0, 0, it.Error()
+	}
+	suffix := it.Key()[len(it.Key())-8:]
+	return idx.BytesToEpoch(suffix[:4]), idx.BytesToEvent(suffix[4:]), nil
+}
+
+// checkBlockVote refuses the vote if another vote for the block is signed
+func (p *DB) checkBlockVote(batch *leveldb.Batch, pubkey validatorpk.PubKey, block idx.Block, vote blockVoteRecord) error {
+	key := keyOf(blockVotesPrefix, pubkey, block.Bytes())
+	var prev blockVoteRecord
+	found, err := p.get(key, &prev)
+	if err != nil {
+		return err
+	}
+	if found {
+		if prev != vote {
+			return fmt.Errorf("another vote for block %d is already signed", block)
+		}
+		return nil
+	}
+	return putRLP(batch, key, vote)
+}
+
+// checkEpochVote refuses the vote if another vote for the epoch is signed
+func (p *DB) checkEpochVote(batch *leveldb.Batch, pubkey validatorpk.PubKey, vote inter.LlrEpochVote) error {
+	key := keyOf(epochVotesPrefix, pubkey, vote.Epoch.Bytes())
+	prev, err := p.db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		batch.Put(key, vote.Vote.Bytes())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(prev, vote.Vote.Bytes()) {
+		return fmt.Errorf("another vote for epoch %d is already signed", vote.Epoch)
+	}
+	return nil
+}
+
+func (p *DB) get(key []byte, val interface{}) (bool, error) {
+	buf, err := p.db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := rlp.DecodeBytes(buf, val); err != nil {
+		return false, fmt.Errorf("failed to decode the slashing protection record: %w", err)
+	}
+	return true, nil
+}
+
+// write writes the batch synchronously, the record must be on disk before the signature is released
+func (p *DB) write(batch *leveldb.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	if err := p.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("failed to write the slashing protection record: %w", err)
+	}
+	return nil
+}
+
+func putRLP(batch *leveldb.Batch, key []byte, val interface{}) error {
+	buf, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	batch.Put(key, buf)
+	return nil
+}
+
+// keyOf builds the key of a table record, the public key is length-prefixed to keep the prefixes of the keys distinct
+func keyOf(prefix byte, pubkey validatorpk.PubKey, suffix ...[]byte) []byte {
+	pk := pubkey.Bytes()
+	key := make([]byte, 0, 2+len(pk)+12)
+	key = append(key, prefix, byte(len(pk)))
+	key = append(key, pk...)
+	for _, s := range suffix {
+		key = append(key, s...)
+	}
+	return key
+}
@@ -0,0 +1,263 @@
+package protection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+)
+
+// InterchangeVersion is the version of the interchange format written by Export
+const InterchangeVersion = "1"
+
+// Interchange is the portable form of the slashing protection history,
+// which is exported on the old host of a validator and imported on the new one
+type Interchange struct {
+	Metadata InterchangeMetadata `json:"metadata"`
+	Data     []KeyHistory        `json:"data"`
+}
+
+// InterchangeMetadata describes the interchange file
+type InterchangeMetadata struct {
+	Version string `json:"interchange_format_version"`
+}
+
+// KeyHistory is the signing history of a validator key
+type KeyHistory struct {
+	PubKey       validatorpk.PubKey  `json:"pubkey"`
+	SignedEvents []SignedEventRecord `json:"signed_events"`
+	BlockVotes   []BlockVotesRecord  `json:"block_votes"`
+	EpochVotes   []EpochVoteRecord   `json:"epoch_votes"`
+}
+
+// SignedEventRecord is a signed event in the interchange file
+type SignedEventRecord struct {
+	Epoch   idx.Epoch   `json:"epoch"`
+	Seq     idx.Event   `json:"seq"`
+	Lamport idx.Lamport `json:"lamport"`
+	Digest  hash.Hash   `json:"digest"`
+}
+
+// BlockVotesRecord is a range of the signed block votes in the interchange file
+type BlockVotesRecord struct {
+	Start idx.Block   `json:"start"`
+	Epoch idx.Epoch   `json:"epoch"`
+	Votes []hash.Hash `json:"votes"`
+}
+
+// LastBlock returns the last block of the range
+func (r BlockVotesRecord) LastBlock() idx.Block {
+	return r.Start + idx.Block(len(r.Votes)) - 1
+}
+
+// EpochVoteRecord is a signed epoch vote in the interchange file
+type EpochVoteRecord struct {
+	Epoch idx.Epoch `json:"epoch"`
+	Vote  hash.Hash `json:"vote"`
+}
+
+// Export writes the whole history into the interchange file
+func (p *DB) Export(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return errClosed
+	}
+
+	snap, err := p.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	interchange := Interchange{
+		Metadata: InterchangeMetadata{Version: InterchangeVersion},
+		Data:     []KeyHistory{},
+	}
+	it := snap.NewIterator(util.BytesPrefix([]byte{keysPrefix}), nil)
+	defer it.Release()
+	for it.Next() {
+		pubkey, err := validatorpk.FromBytes(it.Key()[2:])
+		if err != nil {
+			return err
+		}
+		history, err := exportKey(snap, pubkey)
+		if err != nil {
+			return fmt.Errorf("failed to export the history of %s: %w", pubkey.String(), err)
+		}
+		interchange.Data = append(interchange.Data, history)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(interchange)
+}
+
+// exportKey reads the history of the key, the consecutive block votes of the same epoch are joined into ranges
+func exportKey(snap *leveldb.Snapshot, pubkey validatorpk.PubKey) (KeyHistory, error) {
+	history := KeyHistory{
+		PubKey:       pubkey,
+		SignedEvents: []SignedEventRecord{},
+		BlockVotes:   []BlockVotesRecord{},
+		EpochVotes:   []EpochVoteRecord{},
+	}
+
+	err := forEach(snap, keyOf(eventsPrefix, pubkey), func(suffix, val []byte) error {
+		var r eventRecord
+		if err := rlp.DecodeBytes(val, &r); err != nil {
+			return err
+		}
+		history.SignedEvents = append(history.SignedEvents, SignedEventRecord{
+			Epoch:   idx.BytesToEpoch(suffix[:4]),
+			Seq:     idx.BytesToEvent(suffix[4:]),
+			Lamport: r.Lamport,
+			Digest:  r.Digest,
+		})
+		return nil
+	})
+	if err != nil {
+		return history, err
+	}
+
+	err = forEach(snap, keyOf(blockVotesPrefix, pubkey), func(suffix, val []byte) error {
+		var r blockVoteRecord
+		if err := rlp.DecodeBytes(val, &r); err != nil {
+			return err
+		}
+		block := idx.BytesToBlock(suffix)
+		if n := len(history.BlockVotes); n != 0 {
+			last := &history.BlockVotes[n-1]
+			if last.Epoch == r.Epoch && last.LastBlock()+1 == block {
+				last.Votes = append(last.Votes, r.Vote)
+				return nil
+			}
+		}
+		history.BlockVotes = append(history.BlockVotes, BlockVotesRecord{
+			Start: block,
+			Epoch: r.Epoch,
+			Votes: []hash.Hash{r.Vote},
+		})
+		return nil
+	})
+	if err != nil {
+		return history, err
+	}
+
+	err = forEach(snap, keyOf(epochVotesPrefix, pubkey), func(suffix, val []byte) error {
+		history.EpochVotes = append(history.EpochVotes, EpochVoteRecord{
+			Epoch: idx.BytesToEpoch(suffix),
+			Vote:  hash.BytesToHash(val),
+		})
+		return nil
+	})
+	return history, err
+}
+
+func forEach(snap *leveldb.Snapshot, prefix []byte, fn func(suffix, val []byte) error) error {
+	it := snap.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	for it.Next() {
+		if err := fn(it.Key()[len(prefix):], it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// Import merges the interchange file into the history.
+// Nothing is imported if any of the records conflicts with the history or with another record.
+func (p *DB) Import(r io.Reader) error {
+	var interchange Interchange
+	if err := json.NewDecoder(r).Decode(&interchange); err != nil {
+		return fmt.Errorf("failed to decode the interchange file: %w", err)
+	}
+	if interchange.Metadata.Version != InterchangeVersion {
+		return fmt.Errorf("unsupported interchange format version %q", interchange.Metadata.Version)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return errClosed
+	}
+
+	batch := new(leveldb.Batch)
+	// the records of the file are checked against each other too
+	pending := make(map[string][]byte)
+	for _, history := range interchange.Data {
+		if history.PubKey.Empty() {
+			return errors.New("empty public key in the interchange file")
+		}
+		if err := p.importKey(batch, pending, history); err != nil {
+			return fmt.Errorf("failed to import the history of %s: %w", history.PubKey.String(), err)
+		}
+		batch.Put(keyOf(keysPrefix, history.PubKey), []byte{})
+	}
+	return p.write(batch)
+}
+
+// importKey adds the records of the key into the batch, unlike Approve it accepts the events which precede the recorded ones
+func (p *DB) importKey(batch *leveldb.Batch, pending map[string][]byte, history KeyHistory) error {
+	pubkey := history.PubKey
+	put := func(key []byte, val []byte, conflict error) error {
+		prev, ok := pending[string(key)]
+		if !ok {
+			var err error
+			prev, err = p.db.Get(key, nil)
+			if errors.Is(err, leveldb.ErrNotFound) {
+				prev = nil
+			} else if err != nil {
+				return err
+			}
+		}
+		if prev != nil {
+			if !bytes.Equal(prev, val) {
+				return conflict
+			}
+			return nil
+		}
+		pending[string(key)] = val
+		batch.Put(key, val)
+		return nil
+	}
+
+	for _, e := range history.SignedEvents {
+		val, err := rlp.EncodeToBytes(eventRecord{e.Lamport, e.Digest})
+		if err != nil {
+			return err
+		}
+		if err := put(keyOf(eventsPrefix, pubkey, e.Epoch.Bytes(), e.Seq.Bytes()), val, fmt.Errorf("another event with epoch %d and seq %d is already signed", e.Epoch, e.Seq)); err != nil {
+			return err
+		}
+	}
+	for _, bvs := range history.BlockVotes {
+		for i, vote := range bvs.Votes {
+			block := bvs.Start + idx.Block(i)
+			val, err := rlp.EncodeToBytes(blockVoteRecord{bvs.Epoch, vote})
+			if err != nil {
+				return err
+			}
+			if err := put(keyOf(blockVotesPrefix, pubkey, block.Bytes()), val, fmt.Errorf("another vote for block %d is already signed", block)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ev := range history.EpochVotes {
+		if err := put(keyOf(epochVotesPrefix, pubkey, ev.Epoch.Bytes()), ev.Vote.Bytes(), fmt.Errorf("another vote for epoch %d is already signed", ev.Epoch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package protection
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore"
+)
+
+var (
+	testPubkey, _ = validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	testKey       = common.FromHex("e77b3e0e1bfb52a1e22b73dd7941336443363c4942c5c70869302f66940eefc2")
+	otherPubkey   = validatorpk.PubKey{Type: validatorpk.Types.Secp256k1, Raw: []byte{1, 2, 3}}
+)
+
+func testEvent(epoch idx.Epoch, seq idx.Event, lamport idx.Lamport, bvs inter.LlrBlockVotes, ev inter.LlrEpochVote) SignedEvent {
+	return SignedEvent{
+		Epoch:      epoch,
+		Seq:        seq,
+		Lamport:    lamport,
+		Digest:     hash.Of(epoch.Bytes(), seq.Bytes(), lamport.Bytes(), bvs.Hash().Bytes(), ev.Hash().Bytes()),
+		BlockVotes: bvs,
+		EpochVote:  ev,
+	}
+}
+
+func openTestDB(t *testing.T, path string) *DB {
+	db, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestApprove(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "protection")
+	db := openTestDB(t, path)
+
+	e1 := testEvent(2, 1, 1, inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}, {2}}}, inter.LlrEpochVote{})
+	require.NoError(db.Approve(testPubkey, e1))
+	// the same event may be signed again
+	require.NoError(db.Approve(testPubkey, e1))
+	// other keys have their own history
+	require.NoError(db.Approve(otherPubkey, testEvent(2, 1, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{})))
+
+	// event double sign
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 1, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "another event with epoch 2 and seq 1")
+	// preceding events
+	require.ErrorContains(db.Approve(testPubkey, testEvent(1, 5, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "below the last signed epoch")
+	require.NoError(db.Approve(testPubkey, testEvent(2, 3, 3, inter.LlrBlockVotes{}, inter.LlrEpochVote{})))
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 2, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "below the last signed seq")
+
+	// block vote double sign, nothing is recorded for the refused event
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 4, 4, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{{3}}}, inter.LlrEpochVote{})), "another vote for block 11")
+	require.NoError(db.Approve(testPubkey, testEvent(2, 4, 4, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{{2}, {4}}}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{5}})))
+
+	// epoch vote double sign
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 5, 5, inter.LlrBlockVotes{}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{6}})), "another vote for epoch 2")
+
+	epoch, seq, err := db.LastEvent(testPubkey)
+	require.NoError(err)
+	require.Equal(idx.Epoch(2), epoch)
+	require.Equal(idx.Event(4), seq)
+
+	// the history survives restarts
+	require.NoError(db.Close())
+	require.Equal(errClosed, db.Approve(testPubkey, e1))
+	db = openTestDB(t, path)
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 4, 5, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "another event with epoch 2 and seq 4")
+	require.ErrorContains(db.Approve(testPubkey, testEvent(2, 6, 6, inter.LlrBlockVotes{Start: 12, Epoch: 2, Votes: []hash.Hash{{7}}}, inter.LlrEpochVote{})), "another vote for block 12")
+	require.NoError(db.Approve(testPubkey, e1))
+}
+
+func TestInterchange(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+	db := openTestDB(t, filepath.Join(dir, "old"))
+
+	require.NoError(db.Approve(testPubkey, testEvent(2, 1, 1, inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}, {2}}}, inter.LlrEpochVote{})))
+	require.NoError(db.Approve(testPubkey, testEvent(2, 2, 2, inter.LlrBlockVotes{Start: 12, Epoch: 2, Votes: []hash.Hash{{3}}}, inter.LlrEpochVote{Epoch: 1, Vote: hash.Hash{4}})))
+	require.NoError(db.Approve(testPubkey, testEvent(3, 1, 3, inter.LlrBlockVotes{Start: 20, Epoch: 3, Votes: []hash.Hash{{5}}}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{6}})))
+	require.NoError(db.Approve(otherPubkey, testEvent(3, 1, 3, inter.LlrBlockVotes{}, inter.LlrEpochVote{})))
+
+	exported := new(bytes.Buffer)
+	require.NoError(db.Export(exported))
+
+	var interchange Interchange
+	require.NoError(json.Unmarshal(exported.Bytes(), &interchange))
+	require.Equal(InterchangeVersion, interchange.Metadata.Version)
+	require.Len(interchange.Data, 2)
+	var history KeyHistory
+	for _, h := range interchange.Data {
+		if h.PubKey.String() == testPubkey.String() {
+			history = h
+		}
+	}
+	require.Len(history.SignedEvents, 3)
+	require.Equal(SignedEventRecord{Epoch: 3, Seq: 1, Lamport: 3, Digest: testEvent(3, 1, 3, inter.LlrBlockVotes{Start: 20, Epoch: 3, Votes: []hash.Hash{{5}}}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{6}}).Digest}, history.SignedEvents[2])
+	// the consecutive block votes are joined into ranges
+	require.Equal([]BlockVotesRecord{
+		{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}, {2}, {3}}},
+		{Start: 20, Epoch: 3, Votes: []hash.Hash{{5}}},
+	}, history.BlockVotes)
+	require.Equal([]EpochVoteRecord{{1, hash.Hash{4}}, {2, hash.Hash{6}}}, history.EpochVotes)
+
+	// the new host refuses to sign the conflicting events after the import
+	moved := openTestDB(t, filepath.Join(dir, "new"))
+	require.NoError(moved.Approve(testPubkey, testEvent(1, 1, 1, inter.LlrBlockVotes{}, inter.LlrEpochVote{})))
+	require.NoError(moved.Import(bytes.NewReader(exported.Bytes())))
+	require.ErrorContains(moved.Approve(testPubkey, testEvent(3, 1, 4, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "another event with epoch 3 and seq 1")
+	require.ErrorContains(moved.Approve(testPubkey, testEvent(3, 2, 4, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{{9}}}, inter.LlrEpochVote{})), "another vote for block 11")
+	require.ErrorContains(moved.Approve(testPubkey, testEvent(3, 2, 4, inter.LlrBlockVotes{}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{9}})), "another vote for epoch 2")
+	require.ErrorContains(moved.Approve(otherPubkey, testEvent(3, 1, 4, inter.LlrBlockVotes{}, inter.LlrEpochVote{})), "another event with epoch 3 and seq 1")
+
+	// the import is idempotent, and its result is exported along with the own history
+	require.NoError(moved.Import(bytes.NewReader(exported.Bytes())))
+	reexported := new(bytes.Buffer)
+	require.NoError(moved.Export(reexported))
+	var merged Interchange
+	require.NoError(json.Unmarshal(reexported.Bytes(), &merged))
+	for _, h := range merged.Data {
+		if h.PubKey.String() == testPubkey.String() {
+			require.Len(h.SignedEvents, 4)
+		}
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	require := require.New(t)
+	db := openTestDB(t, filepath.Join(t.TempDir(), "protection"))
+	require.NoError(db.Approve(testPubkey, testEvent(2, 1, 1, inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}}}, inter.LlrEpochVote{})))
+
+	importJSON := func(interchange Interchange) error {
+		data, err := json.Marshal(interchange)
+		require.NoError(err)
+		return db.Import(bytes.NewReader(data))
+	}
+	v1 := InterchangeMetadata{Version: InterchangeVersion}
+
+	// conflicts with the history
+	err := importJSON(Interchange{v1, []KeyHistory{{
+		PubKey:     testPubkey,
+		EpochVotes: []EpochVoteRecord{{1, hash.Hash{1}}},
+		BlockVotes: []BlockVotesRecord{{Start: 9, Epoch: 2, Votes: []hash.Hash{{2}, {2}}}},
+	}}})
+	require.ErrorContains(err, "another vote for block 10")
+	// nothing is imported if the file is refused
+	require.NoError(db.Approve(testPubkey, testEvent(2, 2, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{Epoch: 1, Vote: hash.Hash{3}})))
+
+	// conflicts within the file
+	err = importJSON(Interchange{v1, []KeyHistory{
+		{PubKey: otherPubkey, SignedEvents: []SignedEventRecord{{Epoch: 1, Seq: 1, Digest: hash.Hash{1}}}},
+		{PubKey: otherPubkey, SignedEvents: []SignedEventRecord{{Epoch: 1, Seq: 1, Digest: hash.Hash{2}}}},
+	}})
+	require.ErrorContains(err, "another event with epoch 1 and seq 1")
+
+	require.ErrorContains(importJSON(Interchange{Metadata: InterchangeMetadata{Version: "0"}}), "unsupported interchange format version")
+	require.ErrorContains(importJSON(Interchange{v1, []KeyHistory{{}}}), "empty public key")
+}
+
+func TestSigner(t *testing.T) {
+	require := require.New(t)
+	keystore := valkeystore.NewDefaultMemKeystore()
+	require.NoError(keystore.Add(testPubkey, testKey, "auth"))
+	require.NoError(keystore.Unlock(testPubkey, "auth"))
+	db := openTestDB(t, filepath.Join(t.TempDir(), "protection"))
+	signer := NewSigner(valkeystore.NewSigner(keystore), db)
+
+	newEvent := func(lamport idx.Lamport) *inter.MutableEventPayload {
+		e := &inter.MutableEventPayload{}
+		e.SetVersion(1)
+		e.SetEpoch(2)
+		e.SetSeq(1)
+		e.SetLamport(lamport)
+		e.SetCreator(1)
+		e.SetPayloadHash(inter.CalcPayloadHash(e))
+		return e
+	}
+	e := newEvent(1)
+	sig, err := valkeystore.SignEvent(signer, testPubkey, e)
+	require.NoError(err)
+	expected, err := valkeystore.NewSigner(keystore).Sign(testPubkey, e.HashToSign().Bytes())
+	require.NoError(err)
+	require.Equal(expected, sig)
+
+	_, err = valkeystore.SignEvent(signer, testPubkey, newEvent(2))
+	require.ErrorContains(err, "another event with epoch 2 and seq 1")
+
+	_, err = signer.Sign(testPubkey, e.HashToSign().Bytes())
+	require.Equal(errEventRequired, err)
+}
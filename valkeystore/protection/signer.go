@@ -0,0 +1,41 @@
+package protection
+
+import (
+	"errors"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore"
+)
+
+var errEventRequired = errors.New("slashing protection signs only the events")
+
+// Signer is the signer which approves each event by the slashing protection database before signing it
+type Signer struct {
+	signer valkeystore.SignerI
+	db     *DB
+}
+
+// NewSigner wraps the signer with the slashing protection, a protected signer is rewrapped with the new database
+func NewSigner(signer valkeystore.SignerI, db *DB) *Signer {
+	if protected, ok := signer.(*Signer); ok {
+		signer = protected.signer
+	}
+	return &Signer{
+		signer: signer,
+		db:     db,
+	}
+}
+
+// Sign refuses to sign an opaque digest, because it cannot be checked against the history
+func (s *Signer) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	return nil, errEventRequired
+}
+
+// SignEvent records the event in the history and signs it if it doesn't conflict with the history
+func (s *Signer) SignEvent(pubkey validatorpk.PubKey, e inter.EventPayloadI) ([]byte, error) {
+	if err := s.db.Approve(pubkey, NewSignedEvent(e)); err != nil {
+		return nil, err
+	}
+	return valkeystore.SignEvent(s.signer, pubkey, e)
+}
@@ -0,0 +1,74 @@
+package remote
+
+import (
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/mrmikeo/Xpense/inter"
+)
+
+const (
+	// SignPath is the HTTP path of the signing endpoint
+	SignPath = "/v1/sign/event"
+	// StatusPath is the HTTP path of the health-check endpoint
+	StatusPath = "/v1/status"
+)
+
+var (
+	errUnsupportedVersion = errors.New("events of version 0 cannot be signed remotely")
+	errPayloadMismatch    = errors.New("payload hash doesn't match the event votes")
+	errDigestMismatch     = errors.New("digest doesn't match the event")
+)
+
+// EventToSign is the part of the event which the signer needs to recalculate the signed digest,
+// so that the slashing protection checks the actual event and its votes rather than an opaque digest.
+type EventToSign struct {
+	Version uint8              `json:"version"`
+	Locator inter.EventLocator `json:"locator"`
+	// TxsAndMisbehaviourProofsHash is the hash of the event payload, besides the votes
+	TxsAndMisbehaviourProofsHash hash.Hash           `json:"txsAndMisbehaviourProofsHash"`
+	BlockVotes                   inter.LlrBlockVotes `json:"blockVotes"`
+	EpochVote                    inter.LlrEpochVote  `json:"epochVote"`
+}
+
+// NewEventToSign extracts the signed part of the event
+func NewEventToSign(e inter.EventPayloadI) EventToSign {
+	return EventToSign{
+		Version:                      e.Version(),
+		Locator:                      e.Locator(),
+		TxsAndMisbehaviourProofsHash: hash.Of(inter.CalcTxHash(e.Txs()).Bytes(), inter.CalcMisbehaviourProofsHash(e.MisbehaviourProofs()).Bytes()),
+		BlockVotes:                   e.BlockVotes(),
+		EpochVote:                    e.EpochVote(),
+	}
+}
+
+// Digest checks that the votes are a part of the event payload and returns the digest to sign
+func (e EventToSign) Digest() (hash.Hash, error) {
+	if e.Version == 0 {
+		return hash.Zero, errUnsupportedVersion
+	}
+	payloadHash := inter.LlrSignedBlockVotes{
+		TxsAndMisbehaviourProofsHash: e.TxsAndMisbehaviourProofsHash,
+		EpochVoteHash:                e.EpochVote.Hash(),
+		Val:                          e.BlockVotes,
+	}.CalcPayloadHash()
+	if payloadHash != e.Locator.PayloadHash {
+		return hash.Zero, errPayloadMismatch
+	}
+	return e.Locator.HashToSign(), nil
+}
+
+// SignRequest is a request to sign an event by the validator key
+type SignRequest struct {
+	PubKey string        `json:"pubkey"`
+	Digest hexutil.Bytes `json:"digest"`
+	Event  EventToSign   `json:"event"`
+}
+
+// SignResponse is a response of the signer, either the signature or the reason of the refusal
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
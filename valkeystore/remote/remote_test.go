@@ -0,0 +1,228 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
+)
+
+var (
+	testPubkey, _ = validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	testKey       = common.FromHex("e77b3e0e1bfb52a1e22b73dd7941336443363c4942c5c70869302f66940eefc2")
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert, key}
+}
+
+// write writes the PEM-encoded certificate and key, returns their paths
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certPath, keyPath
+}
+
+func testEvent(epoch idx.Epoch, seq idx.Event, lamport idx.Lamport, bvs inter.LlrBlockVotes, ev inter.LlrEpochVote) *inter.MutableEventPayload {
+	e := &inter.MutableEventPayload{}
+	e.SetVersion(1)
+	e.SetEpoch(epoch)
+	e.SetSeq(seq)
+	e.SetLamport(lamport)
+	e.SetCreator(1)
+	e.SetBlockVotes(bvs)
+	e.SetEpochVote(ev)
+	e.SetPayloadHash(inter.CalcPayloadHash(e))
+	return e
+}
+
+type testSetup struct {
+	signer     *Signer
+	audit      *bytes.Buffer
+	protection *protection.DB
+	dir        string
+	ca         *testCert
+	caPath     string
+	url        string
+}
+
+func newTestSetup(t *testing.T) *testSetup {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	ca := newTestCert(t, "ca", nil, true)
+	caPath, _ := ca.write(t, dir, "ca")
+	serverCertPath, serverKeyPath := newTestCert(t, "signer", ca, false).write(t, dir, "signer")
+	clientCertPath, clientKeyPath := newTestCert(t, "validator-1", ca, false).write(t, dir, "client")
+
+	keystore := valkeystore.NewDefaultMemKeystore()
+	require.NoError(keystore.Add(testPubkey, testKey, "auth"))
+	require.NoError(keystore.Unlock(testPubkey, "auth"))
+
+	db, err := protection.Open(filepath.Join(dir, "protection"))
+	require.NoError(err)
+	t.Cleanup(func() { _ = db.Close() })
+	audit := new(bytes.Buffer)
+
+	srv := httptest.NewUnstartedServer(NewServer(valkeystore.NewSigner(keystore), db, audit))
+	srv.TLS, err = ServerTLSConfig(serverCertPath, serverKeyPath, caPath)
+	require.NoError(err)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	signer, err := NewSigner(Config{
+		URL:      srv.URL,
+		CertFile: clientCertPath,
+		KeyFile:  clientKeyPath,
+		CAFile:   caPath,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(err)
+	return &testSetup{signer, audit, db, dir, ca, caPath, srv.URL}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	require := require.New(t)
+	s := newTestSetup(t)
+	require.NoError(s.signer.Status())
+
+	e1 := testEvent(2, 1, 1, inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}, {2}}}, inter.LlrEpochVote{})
+	sig, err := valkeystore.SignEvent(s.signer, testPubkey, e1)
+	require.NoError(err)
+	require.Len(sig, 64)
+
+	// the same event may be signed again
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, e1)
+	require.NoError(err)
+
+	// opaque digests aren't signed
+	_, err = s.signer.Sign(testPubkey, e1.HashToSign().Bytes())
+	require.Equal(errEventRequired, err)
+
+	// event double sign
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, testEvent(2, 1, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{}))
+	require.ErrorContains(err, "another event with epoch 2 and seq 1")
+	// preceding event
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, testEvent(1, 5, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{}))
+	require.ErrorContains(err, "below the last signed epoch")
+
+	// block vote double sign
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, testEvent(2, 2, 2, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{{3}}}, inter.LlrEpochVote{}))
+	require.ErrorContains(err, "another vote for block 11")
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, testEvent(2, 2, 2, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{{2}, {4}}}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{5}}))
+	require.NoError(err)
+
+	// epoch vote double sign
+	_, err = valkeystore.SignEvent(s.signer, testPubkey, testEvent(2, 3, 3, inter.LlrBlockVotes{}, inter.LlrEpochVote{Epoch: 2, Vote: hash.Hash{6}}))
+	require.ErrorContains(err, "another vote for epoch 2")
+
+	// the signed events are recorded in the database
+	e := testEvent(2, 2, 3, inter.LlrBlockVotes{}, inter.LlrEpochVote{})
+	require.ErrorContains(s.protection.Approve(testPubkey, protection.NewSignedEvent(e)), "another event with epoch 2 and seq 2")
+
+	// every request is audited
+	var records []auditRecord
+	dec := json.NewDecoder(s.audit)
+	for dec.More() {
+		var r auditRecord
+		require.NoError(dec.Decode(&r))
+		records = append(records, r)
+	}
+	require.Len(records, 7)
+	require.Equal("validator-1", records[0].Client)
+	require.True(records[0].Signed)
+	require.False(records[2].Signed)
+	require.NotEmpty(records[2].Error)
+}
+
+func TestRemoteSignerDigestMismatch(t *testing.T) {
+	require := require.New(t)
+	s := newTestSetup(t)
+
+	e := testEvent(2, 1, 1, inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{{1}}}, inter.LlrEpochVote{})
+	// votes which aren't a part of the signed payload
+	toSign := NewEventToSign(e)
+	toSign.BlockVotes.Votes = []hash.Hash{{2}}
+	_, err := s.signer.request(SignRequest{PubKey: testPubkey.String(), Digest: e.HashToSign().Bytes(), Event: toSign})
+	require.ErrorContains(err, errPayloadMismatch.Error())
+
+	// digest of another event
+	other := testEvent(2, 1, 2, inter.LlrBlockVotes{}, inter.LlrEpochVote{})
+	_, err = s.signer.request(SignRequest{PubKey: testPubkey.String(), Digest: other.HashToSign().Bytes(), Event: NewEventToSign(e)})
+	require.ErrorContains(err, errDigestMismatch.Error())
+}
+
+func TestRemoteSignerClientAuth(t *testing.T) {
+	require := require.New(t)
+	s := newTestSetup(t)
+
+	// certificate of another CA
+	otherCA := newTestCert(t, "other", nil, true)
+	certPath, keyPath := newTestCert(t, "intruder", otherCA, false).write(t, s.dir, "intruder")
+	intruder, err := NewSigner(Config{
+		URL:      s.url,
+		CertFile: certPath,
+		KeyFile:  keyPath,
+		CAFile:   s.caPath,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(err)
+	require.Error(intruder.Status())
+	_, err = valkeystore.SignEvent(intruder, testPubkey, testEvent(2, 1, 1, inter.LlrBlockVotes{}, inter.LlrEpochVote{}))
+	require.Error(err)
+
+	// plain HTTP isn't allowed
+	_, err = NewSigner(Config{URL: "http://127.0.0.1:1"})
+	require.Error(err)
+}
@@ -0,0 +1,185 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore"
+	"github.com/mrmikeo/Xpense/valkeystore/protection"
+)
+
+// maxRequestSize is the max size of a sign request, an event votes for a limited number of blocks
+const maxRequestSize = 1024 * 1024
+
+// auditRecord is a line of the audit log, written for each sign request
+type auditRecord struct {
+	Time    time.Time   `json:"time"`
+	Client  string      `json:"client"`
+	PubKey  string      `json:"pubkey,omitempty"`
+	Epoch   idx.Epoch   `json:"epoch,omitempty"`
+	Seq     idx.Event   `json:"seq,omitempty"`
+	Lamport idx.Lamport `json:"lamport,omitempty"`
+	Digest  string      `json:"digest,omitempty"`
+	Signed  bool        `json:"signed"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// Server is the HTTP endpoint of the signer.
+// It signs the events by the local keys after checking them against the slashing protection history,
+// and writes each request into the audit log.
+type Server struct {
+	signer     valkeystore.SignerI
+	protection *protection.DB
+
+	auditMu sync.Mutex
+	audit   *json.Encoder
+}
+
+// NewServer creates the signer endpoint over the unlocked keys
+func NewServer(signer valkeystore.SignerI, protection *protection.DB, audit io.Writer) *Server {
+	return &Server{
+		signer:     signer,
+		protection: protection,
+		audit:      json.NewEncoder(audit),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == StatusPath && r.Method == http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == SignPath && r.Method == http.MethodPost:
+		s.serveSign(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveSign(w http.ResponseWriter, r *http.Request) {
+	record := auditRecord{
+		Time:   time.Now().UTC(),
+		Client: clientName(r),
+	}
+	status, sig, err := s.sign(r, &record)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	record.Signed = err == nil
+	s.writeAudit(record)
+
+	var resp SignResponse
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Signature = sig
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) sign(r *http.Request, record *auditRecord) (int, []byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if len(body) > maxRequestSize {
+		return http.StatusRequestEntityTooLarge, nil, errors.New("request is too large")
+	}
+	var req SignRequest
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&req); err != nil {
+		return http.StatusBadRequest, nil, fmt.Errorf("failed to decode the request: %v", err)
+	}
+	record.PubKey = req.PubKey
+	record.Epoch = req.Event.Locator.Epoch
+	record.Seq = req.Event.Locator.Seq
+	record.Lamport = req.Event.Locator.Lamport
+	record.Digest = req.Digest.String()
+
+	pubkey, err := validatorpk.FromString(req.PubKey)
+	if err != nil {
+		return http.StatusBadRequest, nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	digest, err := req.Event.Digest()
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if len(req.Digest) != len(digest) || hash.BytesToHash(req.Digest) != digest {
+		return http.StatusBadRequest, nil, errDigestMismatch
+	}
+	err = s.protection.Approve(pubkey, protection.SignedEvent{
+		Epoch:      req.Event.Locator.Epoch,
+		Seq:        req.Event.Locator.Seq,
+		Lamport:    req.Event.Locator.Lamport,
+		Digest:     digest,
+		BlockVotes: req.Event.BlockVotes,
+		EpochVote:  req.Event.EpochVote,
+	})
+	if err != nil {
+		return http.StatusConflict, nil, err
+	}
+	sig, err := s.signer.Sign(pubkey, digest.Bytes())
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, sig, nil
+}
+
+func (s *Server) writeAudit(record auditRecord) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	_ = s.audit.Encode(record)
+}
+
+// clientName identifies the client by the common name of its certificate
+func clientName(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) != 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return r.RemoteAddr
+}
+
+// loadCertPool reads the PEM-encoded CA certificates from the file
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// ServerTLSConfig returns the TLS config of the signer endpoint which requires the clients
+// to present the certificates issued by the client CA
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
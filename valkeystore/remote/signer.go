@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/mrmikeo/Xpense/inter"
+	"github.com/mrmikeo/Xpense/inter/validatorpk"
+	"github.com/mrmikeo/Xpense/valkeystore/encryption"
+)
+
+var errEventRequired = errors.New("remote signer signs only the events")
+
+// Config is a config of the remote signer client
+type Config struct {
+	// URL is the base URL of the signer, e.g. https://signer:9000
+	URL string
+	// CertFile and KeyFile are the client certificate and key presented to the signer
+	CertFile string
+	KeyFile  string
+	// CAFile is the CA certificate which the signer certificate is verified against
+	CAFile string
+	// Timeout is the timeout of a sign request
+	Timeout time.Duration
+}
+
+// Signer is a validator signer which delegates the signing to a remote signer over mutually authenticated TLS.
+// The private keys aren't loaded into the node, and the signer checks the events against its slashing protection.
+type Signer struct {
+	url    string
+	client *http.Client
+}
+
+// NewSigner creates the remote signer client
+func NewSigner(cfg Config) (*Signer, error) {
+	if !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.New("remote signer URL must be https")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the remote signer client certificate: %v", err)
+	}
+	rootCAs, err := loadCertPool(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the remote signer CA: %v", err)
+	}
+	return &Signer{
+		url: strings.TrimSuffix(cfg.URL, "/"),
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					Certificates: []tls.Certificate{cert},
+					RootCAs:      rootCAs,
+					MinVersion:   tls.VersionTLS12,
+				},
+			},
+		},
+	}, nil
+}
+
+// Sign refuses to sign the opaque digests, as the signer cannot protect them against the double signing
+func (s *Signer) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	return nil, errEventRequired
+}
+
+// SignEvent requests the remote signer to sign the event
+func (s *Signer) SignEvent(pubkey validatorpk.PubKey, e inter.EventPayloadI) ([]byte, error) {
+	if pubkey.Type != validatorpk.Types.Secp256k1 {
+		return nil, encryption.ErrNotSupportedType
+	}
+	digest := e.HashToSign()
+	req := SignRequest{
+		PubKey: pubkey.String(),
+		Digest: digest.Bytes(),
+		Event:  NewEventToSign(e),
+	}
+	start := time.Now()
+	sig, err := s.request(req)
+	log.Debug("Remote signer request", "epoch", e.Epoch(), "seq", e.Seq(), "digest", digest, "err", err, "t", time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 64 || !crypto.VerifySignature(pubkey.Raw, digest.Bytes(), sig) {
+		return nil, errors.New("remote signer returned an invalid signature")
+	}
+	return sig, nil
+}
+
+func (s *Signer) request(req SignRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.Post(s.url+SignPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("remote signer request failed: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp SignResponse
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxRequestSize)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the remote signer response (status %d): %v", httpResp.StatusCode, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote signer refused: %s", resp.Error)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer responded with status %d", httpResp.StatusCode)
+	}
+	return resp.Signature, nil
+}
+
+// Status checks that the remote signer is reachable and accepts the client certificate
+func (s *Signer) Status() error {
+	resp, err := s.client.Get(s.url + StatusPath)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer responded with status %d", resp.StatusCode)
+	}
+	return nil
+}